@@ -0,0 +1,243 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/qbandev/kaddons/internal/addon"
+	"github.com/qbandev/kaddons/internal/extract"
+	"github.com/qbandev/kaddons/internal/validate"
+)
+
+// driftReport is the machine-readable result of a --drift run, embedded in the
+// db-sync pull request body.
+type driftReport struct {
+	GeneratedAt   string             `json:"generated_at"`
+	Checked       int                `json:"checked"`
+	Addons        []driftRecord      `json:"addons"`
+	Unextractable []string           `json:"unextractable,omitempty"`
+	FetchFailures []driftFetchFailed `json:"fetch_failures,omitempty"`
+}
+
+type driftRecord struct {
+	Name       string `json:"name"`
+	SourceURL  string `json:"source_url"`
+	Confidence string `json:"confidence"`
+	extract.Drift
+	// Merged is true when the additive changes were written to the database.
+	Merged bool `json:"merged"`
+	// MergeSkipped explains why additive changes were not written.
+	MergeSkipped string `json:"merge_skipped,omitempty"`
+}
+
+type driftFetchFailed struct {
+	URL   string `json:"url"`
+	Error string `json:"error"`
+}
+
+// runDrift re-extracts compatibility pages for addons that already have stored
+// data and diffs the result against the database. With merge, additive drift
+// (new addon versions, new K8s versions for known ones) is written back after
+// validation; removals and contradictions are only reported.
+//
+// Exit codes: 0 = no drift, 1 = drift found (with --merge, the database was
+// updated), 2 = runtime error, 3 = with --merge, drift that needs review
+// (removals, contradictions or skipped merges) and nothing was merged.
+func runDrift(client *http.Client, dbPath string, workerCount int, filters []string, merge bool, outputPath string) int {
+	dbPath = filepath.Clean(dbPath)
+	if _, err := os.Stat(dbPath); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: database file not found: %s\n", dbPath)
+		return 2
+	}
+
+	addons, err := addon.LoadAddonsFromDisk(dbPath)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %s\n", err)
+		return 2
+	}
+
+	candidates, uniqueURLs, urlSourceTypes := selectCandidates(addons, filters, func(a *addon.Addon) bool {
+		return a.HasStoredCompatibility()
+	})
+	if len(candidates) == 0 {
+		fmt.Fprintln(os.Stderr, "No addons with stored data to check.")
+		return 0
+	}
+
+	fmt.Fprintf(os.Stderr, "Candidates: %d addons with stored data\n", len(candidates))
+	fmt.Fprintf(os.Stderr, "Fetching %d unique URLs with %d workers...\n", len(uniqueURLs), workerCount)
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	pageResults := fetchAndExtractPages(ctx, client, uniqueURLs, urlSourceTypes, workerCount)
+
+	report := driftReport{
+		GeneratedAt: time.Now().UTC().Format(time.RFC3339),
+		Checked:     len(candidates),
+		Addons:      []driftRecord{},
+	}
+	for _, r := range pageResults {
+		if r.err != nil {
+			report.FetchFailures = append(report.FetchFailures, driftFetchFailed{URL: r.url, Error: r.err.Error()})
+		}
+	}
+	sort.Slice(report.FetchFailures, func(i, j int) bool { return report.FetchFailures[i].URL < report.FetchFailures[j].URL })
+
+	merged := 0
+	for _, c := range candidates {
+		r, ok := pageResults[c.url]
+		if !ok || r.err != nil {
+			continue
+		}
+		if r.extracted == nil {
+			report.Unextractable = append(report.Unextractable, addons[c.index].Name)
+			continue
+		}
+
+		d := extract.DiffCompatibility(addons[c.index], r.extracted)
+		if d.IsEmpty() {
+			continue
+		}
+		record := driftRecord{
+			Name:       addons[c.index].Name,
+			SourceURL:  c.url,
+			Confidence: r.provenance.Confidence,
+			Drift:      d,
+		}
+
+		if merge && d.IsAdditive() {
+			original := addons[c.index]
+			original.KubernetesCompatibility = cloneMatrix(original.KubernetesCompatibility)
+			// Only cells are added, so the existing provenance, or its
+			// absence for curated data, still describes most of the entry;
+			// the drift report records where the merged cells came from.
+			extract.MergeAdditive(&addons[c.index], d)
+
+			if problems := validate.ValidateStoredData([]addon.Addon{addons[c.index]}); len(problems) > 0 {
+				addons[c.index] = original
+				record.MergeSkipped = fmt.Sprintf("%s: %s", problems[0].Field, problems[0].Reason)
+			} else {
+				record.Merged = true
+				merged++
+			}
+		} else if merge {
+			record.MergeSkipped = "not additive"
+		}
+		report.Addons = append(report.Addons, record)
+	}
+	sort.Strings(report.Unextractable)
+	sort.Slice(report.Addons, func(i, j int) bool { return report.Addons[i].Name < report.Addons[j].Name })
+
+	if merged > 0 {
+		if err := addon.SaveAddonsToDisk(dbPath, addons); err != nil {
+			fmt.Fprintf(os.Stderr, "Error writing database: %s\n", err)
+			return 2
+		}
+	}
+
+	printDriftReport(os.Stderr, dbPath, report, merge, merged)
+	if outputPath != "" {
+		if err := writeDriftReport(outputPath, report); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %s\n", err)
+			return 2
+		}
+	}
+
+	switch {
+	case len(report.Addons) == 0:
+		return 0
+	case merge && merged == 0:
+		return 3
+	default:
+		return 1
+	}
+}
+
+func cloneMatrix(matrix map[string][]string) map[string][]string {
+	if matrix == nil {
+		return nil
+	}
+	clone := make(map[string][]string, len(matrix))
+	for key, versions := range matrix {
+		clone[key] = append([]string(nil), versions...)
+	}
+	return clone
+}
+
+// writeDriftReport writes the JSON report to path, or to stdout when path is "-".
+func writeDriftReport(path string, report driftReport) error {
+	data, err := json.MarshalIndent(report, "", "  ")
+	if err != nil {
+		return fmt.Errorf("marshaling drift report: %w", err)
+	}
+	data = append(data, '\n')
+	if path == "-" {
+		_, err = os.Stdout.Write(data)
+		return err
+	}
+	if err := os.WriteFile(path, data, 0o600); err != nil {
+		return fmt.Errorf("writing drift report: %w", err)
+	}
+	return nil
+}
+
+func printDriftReport(w io.Writer, dbPath string, report driftReport, merge bool, merged int) {
+	_, _ = fmt.Fprintln(w, "DB Drift Summary")
+	_, _ = fmt.Fprintf(w, "  Checked:     %d addons with stored data\n", report.Checked)
+	_, _ = fmt.Fprintf(w, "  Failed:      %d pages could not be fetched\n", len(report.FetchFailures))
+	_, _ = fmt.Fprintf(w, "  No data:     %d addons (page yielded no matrix or bounds)\n", len(report.Unextractable))
+	_, _ = fmt.Fprintf(w, "  Drifted:     %d addons\n", len(report.Addons))
+	if merge {
+		_, _ = fmt.Fprintf(w, "  Merged:      %d addons written to %s\n", merged, dbPath)
+	}
+
+	if len(report.Addons) > 0 {
+		_, _ = fmt.Fprintln(w)
+		_, _ = fmt.Fprintln(w, "Drifted addons:")
+		for _, r := range report.Addons {
+			status := ""
+			switch {
+			case r.Merged:
+				status = ", merged"
+			case r.MergeSkipped != "":
+				status = ", not merged: " + r.MergeSkipped
+			}
+			_, _ = fmt.Fprintf(w, "  - %s (%s confidence%s)\n", r.Name, r.Confidence, status)
+			printDriftEntries(w, "added version", r.AddedVersions)
+			printDriftEntries(w, "added K8s", r.AddedK8sVersions)
+			printDriftEntries(w, "removed K8s", r.RemovedK8sVersions)
+			for _, c := range r.Contradictions {
+				_, _ = fmt.Fprintf(w, "      contradiction: %s\n", c)
+			}
+		}
+	}
+
+	if len(report.FetchFailures) > 0 {
+		_, _ = fmt.Fprintln(w)
+		_, _ = fmt.Fprintln(w, "Fetch failures:")
+		for _, f := range report.FetchFailures {
+			_, _ = fmt.Fprintf(w, "  - %s: %s\n", f.URL, f.Error)
+		}
+	}
+}
+
+func printDriftEntries(w io.Writer, label string, entries map[string][]string) {
+	keys := make([]string, 0, len(entries))
+	for key := range entries {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		_, _ = fmt.Fprintf(w, "      %s %s: %s\n", label, key, strings.Join(entries[key], ", "))
+	}
+}
@@ -28,6 +28,8 @@ const defaultWorkerCount = 10
 type syncUpdateRecord struct {
 	name       string
 	entryCount int
+	bounds     string // ">= 1.25", "1.24–1.31"; set when prose bounds were written instead of a matrix
+	confidence string // provenance confidence of the written data
 }
 
 type syncSkipRecord struct {
@@ -54,16 +56,25 @@ func main() {
 	filterFlag := flag.String("filter", "", "Comma-separated addon names to process (case-insensitive substring match)")
 	syncMode := flag.Bool("sync", false, "Extract matrices and write back to addon database JSON")
 	dbPath := flag.String("db-path", "internal/addon/k8s_universal_addons.json", "Path to addon database JSON file")
+	driftMode := flag.Bool("drift", false, "Re-extract pages for addons with stored data and report differences")
+	mergeDrift := flag.Bool("merge", false, "With --drift, write additive changes back to the addon database")
+	driftOutput := flag.String("drift-output", "", "With --drift, write the JSON drift report to this path (\"-\" for stdout)")
+	recordDir := flag.String("record", "", "Record every HTTP response into this fixture directory")
+	replayDir := flag.String("replay", "", "Serve HTTP responses from this fixture directory instead of the network")
 	flag.Usage = func() {
 		fmt.Fprintf(os.Stderr, "Usage: kaddons-extract [--cache-root PATH] [--workers N] [--filter NAMES]\n")
-		fmt.Fprintf(os.Stderr, "       kaddons-extract --sync [--db-path PATH] [--workers N] [--filter NAMES]\n\n")
+		fmt.Fprintf(os.Stderr, "       kaddons-extract --sync [--db-path PATH] [--workers N] [--filter NAMES]\n")
+		fmt.Fprintf(os.Stderr, "       kaddons-extract --drift [--merge] [--drift-output PATH] [--db-path PATH] [--workers N] [--filter NAMES]\n\n")
 		fmt.Fprintf(os.Stderr, "Fetches compatibility pages for addon matrix URLs,\n")
 		fmt.Fprintf(os.Stderr, "classifies matrix quality, and writes a manifest for extraction subagents.\n\n")
-		fmt.Fprintf(os.Stderr, "With --sync, extracts matrices and writes them back to the addon database.\n\n")
+		fmt.Fprintf(os.Stderr, "With --sync, extracts matrices and writes them back to the addon database.\n")
+		fmt.Fprintf(os.Stderr, "With --drift, diffs live pages against stored matrices; --merge writes additive changes.\n\n")
 		fmt.Fprintf(os.Stderr, "Examples:\n")
 		fmt.Fprintf(os.Stderr, "  kaddons-extract --filter cert-manager\n")
 		fmt.Fprintf(os.Stderr, "  kaddons-extract --sync\n")
-		fmt.Fprintf(os.Stderr, "  kaddons-extract --sync --db-path path/to/addons.json\n\n")
+		fmt.Fprintf(os.Stderr, "  kaddons-extract --sync --db-path path/to/addons.json\n")
+		fmt.Fprintf(os.Stderr, "  kaddons-extract --drift --merge --drift-output drift.json\n")
+		fmt.Fprintf(os.Stderr, "  kaddons-extract --record internal/extract/testdata/fixtures --filter keda\n\n")
 		fmt.Fprintf(os.Stderr, "Flags:\n")
 		flag.PrintDefaults()
 	}
@@ -84,18 +95,45 @@ func main() {
 		}
 	}
 
+	if *syncMode && *driftMode {
+		fmt.Fprintln(os.Stderr, "Error: --sync and --drift are mutually exclusive")
+		os.Exit(2)
+	}
+	if (*mergeDrift || *driftOutput != "") && !*driftMode {
+		fmt.Fprintln(os.Stderr, "Error: --merge and --drift-output require --drift")
+		os.Exit(2)
+	}
+
+	if *recordDir != "" && *replayDir != "" {
+		fmt.Fprintln(os.Stderr, "Error: --record and --replay are mutually exclusive")
+		os.Exit(2)
+	}
+	var transport http.RoundTripper
+	switch {
+	case *recordDir != "":
+		transport = &fetch.RecordingTransport{Dir: *recordDir}
+	case *replayDir != "":
+		transport = &fetch.ReplayTransport{Dir: *replayDir}
+	}
+	client := newFetchClient(transport)
+
+	if *driftMode {
+		exitCode := runDrift(client, *dbPath, *workerCount, filters, *mergeDrift, *driftOutput)
+		os.Exit(exitCode)
+	}
+
 	if *syncMode {
-		exitCode := runSync(*dbPath, *workerCount, filters)
+		exitCode := runSync(client, *dbPath, *workerCount, filters)
 		os.Exit(exitCode)
 	}
 
-	if err := run(*cacheRootPath, *workerCount, filters); err != nil {
+	if err := run(client, *cacheRootPath, *workerCount, filters); err != nil {
 		fmt.Fprintf(os.Stderr, "Error: %s\n", err)
 		os.Exit(1)
 	}
 }
 
-func run(cacheRootPath string, workerCount int, filters []string) error {
+func run(client *http.Client, cacheRootPath string, workerCount int, filters []string) error {
 	allAddons, err := addon.LoadAddons()
 	if err != nil {
 		return fmt.Errorf("failed to load addon database: %w", err)
@@ -127,16 +165,6 @@ func run(cacheRootPath string, workerCount int, filters []string) error {
 	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
 	defer stop()
 
-	client := &http.Client{
-		Timeout: 15 * time.Second,
-		CheckRedirect: func(req *http.Request, via []*http.Request) error {
-			if len(via) >= 10 {
-				return fmt.Errorf("too many redirects")
-			}
-			return nil
-		},
-	}
-
 	resultsByURL := fetchAndClassifyAll(
 		ctx,
 		client,
@@ -240,17 +268,24 @@ func fetchAndClassifyOne(
 	pageURL string,
 	pagesCacheDirectory string,
 ) fetchResult {
-	content, err := fetch.CompatibilityPageWithClient(ctx, client, pageURL)
+	page, err := fetch.CompatibilityPageFullWithClient(ctx, client, pageURL)
 	if err != nil {
 		return fetchResult{
 			classifiedTier: "fetch-error",
 			fetchError:     err.Error(),
 		}
 	}
+	content := page.Text
 
+	// The full page also goes into the shared page cache that `kaddons serve
+	// --cache-dir` reads.
 	cacheFileName := sha256Hex(pageURL) + ".txt"
 	cacheFilePath := filepath.Join(pagesCacheDirectory, cacheFileName)
-	if writeErr := os.WriteFile(cacheFilePath, []byte(content), 0o600); writeErr != nil {
+	writeErr := os.WriteFile(cacheFilePath, []byte(content), 0o600)
+	if writeErr == nil {
+		writeErr = fetch.SavePage(pagesCacheDirectory, pageURL, page)
+	}
+	if writeErr != nil {
 		return fetchResult{
 			classifiedTier: "cache-write-error",
 			fetchError:     writeErr.Error(),
@@ -313,7 +348,7 @@ func sha256Hex(value string) string {
 	return hex.EncodeToString(sum[:])
 }
 
-func runSync(dbPath string, workerCount int, filters []string) int {
+func runSync(client *http.Client, dbPath string, workerCount int, filters []string) int {
 	dbPath = filepath.Clean(dbPath)
 	if _, err := os.Stat(dbPath); err != nil {
 		fmt.Fprintf(os.Stderr, "Error: database file not found: %s\n", dbPath)
@@ -327,53 +362,127 @@ func runSync(dbPath string, workerCount int, filters []string) int {
 	}
 
 	// Filter to candidates: no stored compatibility data and has a compatibility URL
-	type candidate struct {
-		index int
-		url   string
+	candidates, uniqueURLs, urlSourceTypes := selectCandidates(addons, filters, func(a *addon.Addon) bool {
+		return !a.HasStoredCompatibility()
+	})
+	if len(candidates) == 0 {
+		fmt.Fprintln(os.Stderr, "No addons without stored data to process.")
+		return 0
 	}
+
+	fmt.Fprintf(os.Stderr, "Candidates: %d addons without stored data\n", len(candidates))
+	fmt.Fprintf(os.Stderr, "Fetching %d unique URLs with %d workers...\n", len(uniqueURLs), workerCount)
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	pageResults := fetchAndExtractPages(ctx, client, uniqueURLs, urlSourceTypes, workerCount)
+	fetchSuccess, fetchFail, fetchFailures := summarizePageResults(pageResults)
+
+	// Extract matrices and apply to addons
+	var updated []syncUpdateRecord
+	var skipped []syncSkipRecord
+
+	for _, c := range candidates {
+		r, ok := pageResults[c.url]
+		if !ok || r.err != nil || r.extracted == nil {
+			continue
+		}
+
+		// Tentatively apply and validate
+		original := addons[c.index]
+		extracted := r.extracted
+		record := syncUpdateRecord{name: addons[c.index].Name, entryCount: len(extracted.Matrix), confidence: r.provenance.Confidence}
+		addons[c.index].CompatibilityProvenance = r.provenance
+		if len(extracted.Matrix) > 0 {
+			addons[c.index].KubernetesCompatibility = extracted.Matrix
+		} else {
+			addons[c.index].KubernetesMinVersion = extracted.MinVersion
+			addons[c.index].KubernetesMaxVersion = extracted.MaxVersion
+			record.bounds = formatK8sBounds(extracted.MinVersion, extracted.MaxVersion)
+		}
+
+		problems := validate.ValidateStoredData([]addon.Addon{addons[c.index]})
+		if len(problems) > 0 {
+			addons[c.index] = original
+			skipped = append(skipped, syncSkipRecord{
+				name:   addons[c.index].Name,
+				reason: fmt.Sprintf("%s: %s", problems[0].Field, problems[0].Reason),
+			})
+			continue
+		}
+
+		updated = append(updated, record)
+	}
+
+	if len(updated) == 0 {
+		fmt.Fprintln(os.Stderr, "No new data extracted.")
+		printSyncReport(os.Stderr, dbPath, len(candidates), fetchSuccess, fetchFail, updated, skipped, fetchFailures)
+		return 0
+	}
+
+	// Write back
+	if err := addon.SaveAddonsToDisk(dbPath, addons); err != nil {
+		fmt.Fprintf(os.Stderr, "Error writing database: %s\n", err)
+		return 2
+	}
+
+	printSyncReport(os.Stderr, dbPath, len(candidates), fetchSuccess, fetchFail, updated, skipped, fetchFailures)
+	return 1
+}
+
+// candidate is an addon (by index into the loaded database) whose
+// compatibility URL will be fetched.
+type candidate struct {
+	index int
+	url   string
+}
+
+// selectCandidates returns addons with a compatibility URL that match the name
+// filters and the include predicate, plus the deduplicated, sorted URLs to fetch
+// and the structured source type declared for each URL.
+func selectCandidates(addons []addon.Addon, filters []string, include func(a *addon.Addon) bool) ([]candidate, []string, map[string]string) {
 	var candidates []candidate
 	urlSet := make(map[string]bool)
+	// Addons sharing a URL normally agree on its format; the first declared type wins.
+	urlSourceTypes := make(map[string]string)
 	for i := range addons {
-		if addons[i].HasStoredCompatibility() || addons[i].CompatibilityMatrixURL == "" {
+		if addons[i].CompatibilityMatrixURL == "" || !include(&addons[i]) {
 			continue
 		}
-		if len(filters) > 0 {
-			nameLower := strings.ToLower(addons[i].Name)
-			matched := false
-			for _, f := range filters {
-				if strings.Contains(nameLower, f) {
-					matched = true
-					break
-				}
-			}
-			if !matched {
-				continue
-			}
+		if len(filters) > 0 && len(filterAddons(addons[i:i+1], filters)) == 0 {
+			continue
 		}
 		candidates = append(candidates, candidate{index: i, url: addons[i].CompatibilityMatrixURL})
 		urlSet[addons[i].CompatibilityMatrixURL] = true
+		if sourceType := addons[i].CompatibilitySourceType; sourceType != "" && urlSourceTypes[addons[i].CompatibilityMatrixURL] == "" {
+			urlSourceTypes[addons[i].CompatibilityMatrixURL] = sourceType
+		}
 	}
 
-	if len(candidates) == 0 {
-		fmt.Fprintln(os.Stderr, "No addons without stored data to process.")
-		return 0
-	}
-
-	// Deduplicate URLs for fetching
 	uniqueURLs := make([]string, 0, len(urlSet))
 	for u := range urlSet {
 		uniqueURLs = append(uniqueURLs, u)
 	}
 	sort.Strings(uniqueURLs)
+	return candidates, uniqueURLs, urlSourceTypes
+}
 
-	fmt.Fprintf(os.Stderr, "Candidates: %d addons without stored data\n", len(candidates))
-	fmt.Fprintf(os.Stderr, "Fetching %d unique URLs with %d workers...\n", len(uniqueURLs), workerCount)
-
-	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
-	defer stop()
+// pageResult is the outcome of fetching and extracting one compatibility URL.
+type pageResult struct {
+	url        string
+	extracted  *extract.Compatibility // nil if the page yielded neither a matrix nor bounds
+	provenance *addon.Provenance      // set whenever extracted is
+	err        error                  // fetch error
+}
 
-	client := &http.Client{
-		Timeout: 15 * time.Second,
+// newFetchClient returns the page-fetch client. A nil transport uses the
+// network; --record and --replay substitute fetch.RecordingTransport and
+// fetch.ReplayTransport.
+func newFetchClient(transport http.RoundTripper) *http.Client {
+	return &http.Client{
+		Transport: transport,
+		Timeout:   15 * time.Second,
 		CheckRedirect: func(req *http.Request, via []*http.Request) error {
 			if len(via) >= 10 {
 				return fmt.Errorf("too many redirects")
@@ -381,17 +490,16 @@ func runSync(dbPath string, workerCount int, filters []string) int {
 			return nil
 		},
 	}
+}
 
-	// Fetch pages and extract matrices with worker pool.
-	// Extraction happens inside the worker so the raw page content (up to 2 MB
-	// per URL) is discarded immediately, and each URL is extracted only once
-	// even when multiple addons share the same compatibility page.
-	type pageResult struct {
-		url    string
-		matrix map[string][]string // extracted matrix (nil if extraction failed)
-		err    error               // fetch error
-	}
+// fetchAndExtractPages fetches pages and extracts compatibility data with a
+// worker pool. Extraction happens inside the worker so the raw page content
+// (up to 2 MB per URL) is discarded immediately, and each URL is extracted
+// only once even when multiple addons share the same compatibility page.
+func fetchAndExtractPages(ctx context.Context, client *http.Client, uniqueURLs []string, urlSourceTypes map[string]string, workerCount int) map[string]pageResult {
 	pageResults := make(map[string]pageResult, len(uniqueURLs))
+	// One timestamp per run keeps provenance of a single db-sync PR consistent.
+	extractedAt := time.Now()
 	var (
 		mu sync.Mutex
 		wg sync.WaitGroup
@@ -414,23 +522,24 @@ func runSync(dbPath string, workerCount int, filters []string) int {
 					mu.Unlock()
 					continue
 				}
-				var matrix map[string][]string
-				if page.IsRaw {
-					matrix, _ = extract.ExtractMarkdownMatrix(page.Raw)
-				} else {
-					matrix, _ = extract.ExtractHTMLMatrix(page.Raw)
+				result := pageResult{url: pageURL}
+				if extracted := extract.ExtractPage(pageURL, page.Raw, page.IsRaw, urlSourceTypes[pageURL]); extracted != nil {
+					result.extracted = extracted
+					result.provenance = extracted.Provenance(pageURL, page.Raw, extractedAt)
 				}
 				mu.Lock()
-				pageResults[pageURL] = pageResult{url: pageURL, matrix: matrix}
+				pageResults[pageURL] = result
 				mu.Unlock()
 			}
 		}()
 	}
 	wg.Wait()
+	return pageResults
+}
 
-	// Count fetch results
-	fetchSuccess, fetchFail := 0, 0
-	var fetchFailures []string
+// summarizePageResults counts fetch outcomes and lists failures, sorted, in
+// report format.
+func summarizePageResults(pageResults map[string]pageResult) (fetchSuccess int, fetchFail int, fetchFailures []string) {
 	for _, r := range pageResults {
 		if r.err != nil {
 			fetchFail++
@@ -440,58 +549,14 @@ func runSync(dbPath string, workerCount int, filters []string) int {
 		}
 	}
 	sort.Strings(fetchFailures)
-
-	// Extract matrices and apply to addons
-	var updated []syncUpdateRecord
-	var skipped []syncSkipRecord
-
-	for _, c := range candidates {
-		r, ok := pageResults[c.url]
-		if !ok || r.err != nil || len(r.matrix) == 0 {
-			continue
-		}
-
-		// Tentatively apply and validate
-		original := addons[c.index].KubernetesCompatibility
-		addons[c.index].KubernetesCompatibility = r.matrix
-
-		problems := validate.ValidateStoredData([]addon.Addon{addons[c.index]})
-		if len(problems) > 0 {
-			addons[c.index].KubernetesCompatibility = original
-			skipped = append(skipped, syncSkipRecord{
-				name:   addons[c.index].Name,
-				reason: fmt.Sprintf("%s: %s", problems[0].Field, problems[0].Reason),
-			})
-			continue
-		}
-
-		updated = append(updated, syncUpdateRecord{
-			name:       addons[c.index].Name,
-			entryCount: len(r.matrix),
-		})
-	}
-
-	if len(updated) == 0 {
-		fmt.Fprintln(os.Stderr, "No new data extracted.")
-		printSyncReport(os.Stderr, dbPath, len(candidates), fetchSuccess, fetchFail, updated, skipped, fetchFailures)
-		return 0
-	}
-
-	// Write back
-	if err := addon.SaveAddonsToDisk(dbPath, addons); err != nil {
-		fmt.Fprintf(os.Stderr, "Error writing database: %s\n", err)
-		return 2
-	}
-
-	printSyncReport(os.Stderr, dbPath, len(candidates), fetchSuccess, fetchFail, updated, skipped, fetchFailures)
-	return 1
+	return fetchSuccess, fetchFail, fetchFailures
 }
 
 func printSyncReport(w io.Writer, dbPath string, candidateCount, fetchSuccess, fetchFail int, updated []syncUpdateRecord, skipped []syncSkipRecord, fetchFailures []string) {
 	_, _ = fmt.Fprintln(w, "DB Sync Summary")
 	_, _ = fmt.Fprintf(w, "  Candidates:  %d addons without stored data\n", candidateCount)
 	_, _ = fmt.Fprintf(w, "  Fetched:     %d pages (%d failed)\n", fetchSuccess, fetchFail)
-	_, _ = fmt.Fprintf(w, "  Extracted:   %d new compatibility matrices\n", len(updated)+len(skipped))
+	_, _ = fmt.Fprintf(w, "  Extracted:   %d new compatibility matrices or bounds\n", len(updated)+len(skipped))
 	_, _ = fmt.Fprintf(w, "  Validated:   %d passed, %d skipped (validation errors)\n", len(updated), len(skipped))
 	if len(updated) > 0 {
 		_, _ = fmt.Fprintf(w, "  Updated:     %d addons written to %s\n", len(updated), dbPath)
@@ -501,7 +566,11 @@ func printSyncReport(w io.Writer, dbPath string, candidateCount, fetchSuccess, f
 		_, _ = fmt.Fprintln(w)
 		_, _ = fmt.Fprintln(w, "Updated addons:")
 		for _, u := range updated {
-			_, _ = fmt.Fprintf(w, "  - %s: %d version entries\n", u.name, u.entryCount)
+			if u.bounds != "" {
+				_, _ = fmt.Fprintf(w, "  - %s: K8s %s (%s confidence)\n", u.name, u.bounds, u.confidence)
+				continue
+			}
+			_, _ = fmt.Fprintf(w, "  - %s: %d version entries (%s confidence)\n", u.name, u.entryCount, u.confidence)
 		}
 	}
 
@@ -521,3 +590,15 @@ func printSyncReport(w io.Writer, dbPath string, candidateCount, fetchSuccess, f
 		}
 	}
 }
+
+// formatK8sBounds renders prose-extracted min/max bounds for the sync report.
+func formatK8sBounds(minVersion, maxVersion string) string {
+	switch {
+	case minVersion != "" && maxVersion != "":
+		return minVersion + "–" + maxVersion
+	case minVersion != "":
+		return ">= " + minVersion
+	default:
+		return "<= " + maxVersion
+	}
+}
@@ -0,0 +1,66 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"strings"
+
+	"github.com/qbandev/kaddons/internal/agent"
+	"github.com/qbandev/kaddons/internal/inventory"
+	"github.com/spf13/cobra"
+)
+
+func newCheckCmd() *cobra.Command {
+	var (
+		k8sVersion    string
+		inventoryPath string
+		apiKey        string
+		model         string
+		output        string
+		outputPath    string
+		skipUpstream  bool
+	)
+
+	cmd := &cobra.Command{
+		Use:   "check [name@version ...]",
+		Short: "Check addon versions against a Kubernetes version without a cluster",
+		Long: "Checks the named addon versions, or those listed in a JSON or CSV inventory file, against the Kubernetes version given with --cluster. " +
+			"Cluster discovery is skipped; matching, stored data, extraction and optional Gemini analysis run as in a cluster scan.",
+		Example: "  kaddons check -c 1.31 cert-manager@1.13 karpenter@0.37.0\n" +
+			"  kaddons check -c 1.31 --file inventory.csv -o html",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := validateOutput(output, outputPath); err != nil {
+				return err
+			}
+			if strings.TrimSpace(k8sVersion) == "" {
+				return errors.New("--cluster is required (e.g. --cluster 1.31)")
+			}
+			if len(args) == 0 && inventoryPath == "" {
+				return errors.New("name addons as name@version arguments or pass --file")
+			}
+
+			addons, err := inventory.ParseArgs(args)
+			if err != nil {
+				return err
+			}
+			if inventoryPath != "" {
+				fromFile, err := inventory.Load(inventoryPath)
+				if err != nil {
+					return err
+				}
+				addons = append(addons, fromFile...)
+			}
+
+			return agent.RunCheck(context.Background(), agent.Options{APIKey: resolveAPIKey(apiKey), Model: model, SkipUpstream: skipUpstream, Tool: toolInfo()}, k8sVersion, addons, output, outputPath)
+		},
+	}
+
+	cmd.Flags().StringVarP(&k8sVersion, "cluster", "c", "", "Kubernetes version to check against (required, e.g. 1.31)")
+	cmd.Flags().StringVarP(&inventoryPath, "file", "f", "", "JSON or CSV inventory of addons (name, version, optional namespace)")
+	cmd.Flags().StringVarP(&apiKey, "key", "k", "", "Gemini API key (optional; overrides GEMINI_API_KEY env var)")
+	cmd.Flags().StringVarP(&model, "model", "m", "gemini-3-flash-preview", "Gemini model to use")
+	cmd.Flags().StringVarP(&output, "output", "o", "json", "Output format (json, html, table, markdown, sarif, csv, xlsx, prometheus), or comma-separated format=path sinks, e.g. json=report.json,html=report.html,sarif=- (- is stdout)")
+	cmd.Flags().StringVar(&outputPath, "output-path", "./kaddons-report.html", "Output file path for an html format given without a path")
+	cmd.Flags().BoolVar(&skipUpstream, "skip-upstream", false, "Skip GitHub lookups of each addon's latest upstream release (for offline or rate-limited runs)")
+	return cmd
+}
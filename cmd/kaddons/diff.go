@@ -0,0 +1,55 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/qbandev/kaddons/internal/output"
+	"github.com/spf13/cobra"
+)
+
+func newDiffCmd() *cobra.Command {
+	var (
+		format     string
+		outputPath string
+	)
+
+	cmd := &cobra.Command{
+		Use:   "diff OLD.json NEW.json",
+		Short: "Compare two JSON reports",
+		Long: "Compares two kaddons JSON reports and lists addons added and removed, version bumps, " +
+			"compatibility transitions (true→false is flagged as a regression), data source changes and a Kubernetes version change.",
+		Example: "  kaddons diff yesterday.json today.json -o markdown",
+		Args:    cobra.ExactArgs(2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			oldReport, err := output.LoadReport(args[0])
+			if err != nil {
+				return err
+			}
+			newReport, err := output.LoadReport(args[1])
+			if err != nil {
+				return err
+			}
+
+			diff := output.DiffReports(oldReport, newReport)
+			rendered, err := output.FormatDiff(diff, format)
+			if err != nil {
+				return err
+			}
+			if outputPath == "" {
+				_, err = os.Stdout.Write(rendered)
+				return err
+			}
+			if err := os.WriteFile(filepath.Clean(outputPath), rendered, 0o600); err != nil {
+				return fmt.Errorf("writing diff: %w", err)
+			}
+			fmt.Fprintf(os.Stderr, "Diff written to %s\n", outputPath)
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVarP(&format, "output", "o", "json", "Output format: json, markdown or html")
+	cmd.Flags().StringVar(&outputPath, "output-path", "", "Write the diff to this file instead of stdout")
+	return cmd
+}
@@ -6,6 +6,8 @@ import (
 	"os"
 
 	"github.com/qbandev/kaddons/internal/agent"
+	"github.com/qbandev/kaddons/internal/cluster"
+	"github.com/qbandev/kaddons/internal/output"
 	"github.com/spf13/cobra"
 )
 
@@ -24,6 +26,11 @@ func main() {
 		model        string
 		output       string
 		outputPath   string
+		store        string
+		storeName    string
+		storeNS      string
+		chunkSize    int
+		skipUpstream bool
 	)
 
 	rootCmd := &cobra.Command{
@@ -34,17 +41,24 @@ func main() {
 		SilenceUsage:  true,
 		SilenceErrors: true,
 		RunE: func(cmd *cobra.Command, args []string) error {
-			if output != "json" && output != "html" {
-				return fmt.Errorf("invalid output format %q: must be json or html", output)
+			if err := validateOutput(output, outputPath); err != nil {
+				return err
 			}
 
-			key := apiKey
-			if key == "" {
-				key = os.Getenv("GEMINI_API_KEY")
+			if err := cluster.ValidateStoreKind(store); err != nil {
+				return fmt.Errorf("invalid --store: %w", err)
+			}
+			if chunkSize < 1 {
+				return fmt.Errorf("invalid --chunk-size %d: must be at least 1", chunkSize)
+			}
+
+			opts := agent.Options{APIKey: resolveAPIKey(apiKey), Model: model, ChunkSize: chunkSize, SkipUpstream: skipUpstream, Tool: toolInfo()}
+			if store != "" {
+				opts.Store = &cluster.ReportStore{Kind: store, Name: storeName, Namespace: storeNS}
 			}
 
 			ctx := context.Background()
-			return agent.Run(ctx, key, model, namespace, k8sVersion, addonsFilter, output, outputPath)
+			return agent.Run(ctx, opts, namespace, k8sVersion, addonsFilter, output, outputPath)
 		},
 	}
 
@@ -53,11 +67,42 @@ func main() {
 	rootCmd.Flags().StringVarP(&addonsFilter, "addons", "a", "", "Comma-separated addon name filter")
 	rootCmd.Flags().StringVarP(&apiKey, "key", "k", "", "Gemini API key (optional; overrides GEMINI_API_KEY env var)")
 	rootCmd.Flags().StringVarP(&model, "model", "m", "gemini-3-flash-preview", "Gemini model to use")
-	rootCmd.Flags().StringVarP(&output, "output", "o", "json", "Output format: json or html")
-	rootCmd.Flags().StringVar(&outputPath, "output-path", "./kaddons-report.html", "Output file path when --output=html")
+	rootCmd.Flags().StringVarP(&output, "output", "o", "json", "Output format (json, html, table, markdown, sarif, csv, xlsx, prometheus), or comma-separated format=path sinks, e.g. json=report.json,html=report.html,sarif=- (- is stdout)")
+	rootCmd.Flags().StringVar(&outputPath, "output-path", "./kaddons-report.html", "Output file path for an html format given without a path")
+	rootCmd.Flags().StringVar(&store, "store", "", "Also save the report in the cluster: configmap, or crd for an AddonCompatibilityReport (see deploy/)")
+	rootCmd.Flags().StringVar(&storeName, "store-name", cluster.DefaultReportName, "Name of the ConfigMap or AddonCompatibilityReport written by --store")
+	rootCmd.Flags().StringVar(&storeNS, "store-namespace", "", "Namespace for --store (default: kubectl's namespace, the service account's namespace in a pod)")
+	rootCmd.Flags().IntVar(&chunkSize, "chunk-size", cluster.DefaultChunkSize, "Objects per list request during discovery; lower it on very large clusters")
+	rootCmd.Flags().BoolVar(&skipUpstream, "skip-upstream", false, "Skip GitHub lookups of each addon's latest upstream release (for offline or rate-limited runs)")
+	rootCmd.AddCommand(newCheckCmd())
+	rootCmd.AddCommand(newDiffCmd())
+	rootCmd.AddCommand(newSchemaCmd())
+	rootCmd.AddCommand(newServeCmd())
 
 	if err := rootCmd.Execute(); err != nil {
 		fmt.Fprintf(os.Stderr, "Error: %s\n", err)
 		os.Exit(1)
 	}
 }
+
+// validateOutput checks the --output spec before any discovery or LLM work,
+// so a typo does not cost a full run.
+func validateOutput(spec, htmlPath string) error {
+	if _, err := output.ParseSinks(spec, htmlPath); err != nil {
+		return fmt.Errorf("invalid --output: %w", err)
+	}
+	return nil
+}
+
+// toolInfo identifies this build in report metadata.
+func toolInfo() output.ToolInfo {
+	return output.ToolInfo{Name: "kaddons", Version: version, Commit: commit}
+}
+
+// resolveAPIKey returns the --key flag value, falling back to GEMINI_API_KEY.
+func resolveAPIKey(flagValue string) string {
+	if flagValue != "" {
+		return flagValue
+	}
+	return os.Getenv("GEMINI_API_KEY")
+}
@@ -0,0 +1,23 @@
+package main
+
+import (
+	"os"
+
+	"github.com/qbandev/kaddons/internal/output"
+	"github.com/spf13/cobra"
+)
+
+func newSchemaCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "schema",
+		Short: "Print the JSON Schema for JSON reports",
+		Long: "Prints the versioned JSON Schema (draft 2020-12) that kaddons -o json reports follow. " +
+			"The report's metadata.schema_version names the schema version.",
+		Example: "  kaddons schema > compatibility-report.v" + output.ReportSchemaVersion + ".json",
+		Args:    cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			_, err := os.Stdout.Write(output.ReportSchema)
+			return err
+		},
+	}
+}
@@ -0,0 +1,96 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/qbandev/kaddons/internal/addon"
+	"github.com/qbandev/kaddons/internal/agent"
+	"github.com/qbandev/kaddons/internal/fetch"
+	"github.com/qbandev/kaddons/internal/server"
+	"github.com/spf13/cobra"
+)
+
+func newServeCmd() *cobra.Command {
+	var (
+		listenAddr string
+		cacheTTL   time.Duration
+		cacheDir   string
+	)
+
+	cmd := &cobra.Command{
+		Use:   "serve",
+		Short: "Serve compatibility checks over a REST API",
+		Long: "Runs an HTTP server answering compatibility checks for addon versions posted to /v1/check. " +
+			"Verdicts come from stored data and deterministic extraction; the LLM is never called. " +
+			"Fetched compatibility pages and upstream releases are cached for --cache-ttl; pages also on disk in --cache-dir. " +
+			"Prometheus metrics are served on /metrics.",
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			addons, err := addon.LoadAddons()
+			if err != nil {
+				return fmt.Errorf("loading addon database: %w", err)
+			}
+			pages := fetch.NewPageCache(cacheTTL)
+			if cacheDir != "" {
+				// A directory the server cannot create, as for the image's
+				// nonroot user, costs the disk cache rather than the server.
+				if err := os.MkdirAll(cacheDir, 0o750); err != nil {
+					fmt.Fprintf(os.Stderr, "Warning: keeping pages in memory only: creating --cache-dir: %v\n", err)
+				} else {
+					pages.Dir = cacheDir
+				}
+			}
+			handler := server.New(addons, agent.Options{
+				Pages:    pages,
+				Upstream: agent.NewUpstreamReleaseCache(fetch.NewGitHubClient(), cacheTTL),
+				Tool:     toolInfo(),
+			})
+
+			httpServer := &http.Server{
+				Addr:              listenAddr,
+				Handler:           handler,
+				ReadHeaderTimeout: 10 * time.Second,
+				ReadTimeout:       30 * time.Second,
+				// Checks that fetch several pages can take a while; each fetch
+				// is bounded by its own retry budget.
+				WriteTimeout: 5 * time.Minute,
+				IdleTimeout:  2 * time.Minute,
+			}
+
+			ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+			defer stop()
+
+			errCh := make(chan error, 1)
+			go func() {
+				fmt.Fprintf(os.Stderr, "Serving %d addons on %s\n", len(addons), listenAddr)
+				errCh <- httpServer.ListenAndServe()
+			}()
+
+			select {
+			case err := <-errCh:
+				if errors.Is(err, http.ErrServerClosed) {
+					return nil
+				}
+				return err
+			case <-ctx.Done():
+			}
+
+			fmt.Fprintln(os.Stderr, "Shutting down...")
+			shutdownCtx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+			defer cancel()
+			return httpServer.Shutdown(shutdownCtx)
+		},
+	}
+
+	cmd.Flags().StringVar(&listenAddr, "listen", ":8080", "Address to listen on")
+	cmd.Flags().DurationVar(&cacheTTL, "cache-ttl", time.Hour, "How long fetched pages and upstream releases are reused")
+	cmd.Flags().StringVar(&cacheDir, "cache-dir", "", "Directory of an on-disk page cache, e.g. kaddons-extract's .cache/matrix-extract/pages (empty keeps pages in memory only)")
+	return cmd
+}
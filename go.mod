@@ -5,6 +5,7 @@ go 1.25.11
 require (
 	github.com/spf13/cobra v1.10.2
 	google.golang.org/genai v1.60.0
+	gopkg.in/yaml.v3 v3.0.1
 )
 
 require (
@@ -1,7 +1,9 @@
 package addon
 
 import (
+	"crypto/sha256"
 	_ "embed"
+	"encoding/hex"
 	"encoding/json"
 	"fmt"
 	"os"
@@ -23,6 +25,35 @@ type Addon struct {
 	KubernetesCompatibility map[string][]string `json:"kubernetes_compatibility,omitempty"`
 	KubernetesMinVersion    string              `json:"kubernetes_min_version,omitempty"`
 	KubernetesMaxVersion    string              `json:"kubernetes_max_version,omitempty"`
+	// CompatibilitySourceType names the structured format behind
+	// CompatibilityMatrixURL ("yaml", "json", "helm-chart"). Empty means a
+	// Markdown or HTML page handled by table and prose extraction.
+	CompatibilitySourceType string `json:"compatibility_source_type,omitempty"`
+	// CompatibilityProvenance records how kaddons-extract --sync produced the
+	// stored matrix or bounds. Absent for hand-curated entries.
+	CompatibilityProvenance *Provenance `json:"compatibility_provenance,omitempty"`
+	// CRDGroups lists the API groups of the CustomResourceDefinitions the
+	// addon installs, e.g. "cert-manager.io". Discovery uses them to find
+	// addons whose workloads it cannot see or name.
+	CRDGroups []string `json:"crd_groups,omitempty"`
+}
+
+// Confidence levels for extracted compatibility data.
+const (
+	ConfidenceHigh   = "high"
+	ConfidenceMedium = "medium"
+	ConfidenceLow    = "low"
+)
+
+// Provenance describes where deterministically extracted compatibility data
+// came from, so reviewers and report readers can judge how much to trust it.
+type Provenance struct {
+	SourceURL    string `json:"source_url"`
+	ContentHash  string `json:"content_hash,omitempty"`  // "sha256:<hex>" of the fetched raw content
+	TableLocator string `json:"table_locator,omitempty"` // e.g. "markdown table 2", "kubeVersion", "text"
+	Strategy     string `json:"strategy"`                // e.g. "version-header", "labeled-column", "prose", "structured"
+	ExtractedAt  string `json:"extracted_at"`            // RFC 3339, UTC
+	Confidence   string `json:"confidence"`              // ConfidenceHigh, ConfidenceMedium or ConfidenceLow
 }
 
 // HasStoredCompatibility returns true if the addon has pre-populated
@@ -43,6 +74,12 @@ type EOLCycle struct {
 	Latest            string `json:"latest"`
 	LatestReleaseDate string `json:"latestReleaseDate"`
 	LTS               any    `json:"lts"`
+	// Support is the end of active support, a date or a bool, for products
+	// that distinguish it from EOL, such as upstream Kubernetes.
+	Support any `json:"support,omitempty"`
+	// ExtendedSupport is the end of paid or extended support, a date or a
+	// bool, for managed services such as Amazon EKS.
+	ExtendedSupport any `json:"extendedSupport,omitempty"`
 }
 
 // EOLProductCatalogEntry represents a single product from endoflife.date v1.
@@ -61,6 +98,13 @@ func LoadAddons() ([]Addon, error) {
 	return f.Addons, nil
 }
 
+// DatabaseHash returns "sha256:<hex>" of the embedded addon database, which
+// identifies the database revision a report was produced with.
+func DatabaseHash() string {
+	sum := sha256.Sum256(addonsJSON)
+	return "sha256:" + hex.EncodeToString(sum[:])
+}
+
 // LoadAddonsFromDisk reads and parses the addon database from a file on disk,
 // as opposed to LoadAddons which reads from the embedded go:embed data.
 func LoadAddonsFromDisk(path string) ([]Addon, error) {
@@ -99,6 +143,7 @@ func SaveAddonsToDisk(path string, addons []Addon) error {
 var addonAliases = map[string]string{
 	"nodelocaldns":   "nodelocal dnscache",
 	"node-local-dns": "nodelocal dnscache",
+	"aws-node":       "aws vpc cni", // the EKS VPC CNI DaemonSet
 }
 
 // componentRoleSuffixes are common K8s workload role suffixes that indicate a
@@ -289,12 +334,14 @@ type addonEntry struct {
 type Matcher struct {
 	entries      []addonEntry
 	firstByLower map[string]Addon
+	byCRDGroup   map[string]Addon
 }
 
 // NewMatcher builds a reusable matcher for repeated addon name lookups.
 func NewMatcher(addons []Addon) *Matcher {
 	entries := make([]addonEntry, len(addons))
 	firstByLower := make(map[string]Addon, len(addons))
+	byCRDGroup := make(map[string]Addon)
 	for i, addon := range addons {
 		lowerName := strings.ToLower(addon.Name)
 		entries[i] = addonEntry{
@@ -304,11 +351,51 @@ func NewMatcher(addons []Addon) *Matcher {
 		if _, exists := firstByLower[lowerName]; !exists {
 			firstByLower[lowerName] = addon
 		}
+		for _, group := range addon.CRDGroups {
+			group = strings.ToLower(group)
+			if _, exists := byCRDGroup[group]; !exists {
+				byCRDGroup[group] = addon
+			}
+		}
 	}
 	return &Matcher{
 		entries:      entries,
 		firstByLower: firstByLower,
+		byCRDGroup:   byCRDGroup,
+	}
+}
+
+// MatchCRDGroup resolves the API group of an installed CRD to the addon that
+// owns it. An exact group wins; otherwise the longest registered group the
+// CRD group is a subdomain of, so acme.cert-manager.io maps to cert-manager
+// while trust.cert-manager.io maps to trust-manager.
+func (matcher *Matcher) MatchCRDGroup(group string) (Addon, bool) {
+	group = strings.ToLower(strings.TrimSpace(group))
+	if group == "" {
+		return Addon{}, false
+	}
+	if addon, ok := matcher.byCRDGroup[group]; ok {
+		return addon, true
+	}
+	var best Addon
+	bestLen := 0
+	for registered, addon := range matcher.byCRDGroup {
+		if len(registered) > bestLen && strings.HasSuffix(group, "."+registered) {
+			best, bestLen = addon, len(registered)
+		}
+	}
+	return best, bestLen > 0
+}
+
+// Lookup returns the addon named name, case-insensitively, or by one of the
+// addonAliases. Unlike Match it never guesses from prefixes or word overlap.
+func (matcher *Matcher) Lookup(name string) (Addon, bool) {
+	lower := strings.ToLower(strings.TrimSpace(name))
+	if canonical, ok := addonAliases[lower]; ok {
+		lower = canonical
 	}
+	addon, ok := matcher.firstByLower[lower]
+	return addon, ok
 }
 
 // Match resolves a detected addon name to known addon definitions.
@@ -314,6 +314,18 @@ func TestLookupAddon_AliasNodeLocalDNS(t *testing.T) {
 	}
 }
 
+func TestLookupAddon_AliasAWSNode(t *testing.T) {
+	addons := []Addon{
+		{Name: "AWS VPC CNI"},
+		{Name: "AWS Node Termination Handler"},
+	}
+
+	matches := LookupAddon("aws-node", addons)
+	if len(matches) != 1 || matches[0].Name != "AWS VPC CNI" {
+		t.Fatalf("LookupAddon(%q) = %+v, want AWS VPC CNI", "aws-node", matches)
+	}
+}
+
 func TestLookupAddon_ReversePrefixMatch(t *testing.T) {
 	addons := []Addon{
 		{Name: "Prometheus"},
@@ -370,6 +382,89 @@ func TestMatcher_Match(t *testing.T) {
 	}
 }
 
+func TestMatcher_Lookup(t *testing.T) {
+	matcher := NewMatcher([]Addon{
+		{Name: "AWS EBS CSI Driver"},
+		{Name: "NodeLocal DNSCache"},
+		{Name: "cert-manager"},
+	})
+
+	tests := []struct {
+		input  string
+		want   string
+		wantOK bool
+	}{
+		{input: "cert-manager", want: "cert-manager", wantOK: true},
+		{input: " AWS ebs csi driver ", want: "AWS EBS CSI Driver", wantOK: true},
+		{input: "node-local-dns", want: "NodeLocal DNSCache", wantOK: true},
+		{input: "ebs-csi-node"},
+		{input: "cert-manager-webhook"},
+		{input: "cert"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			got, ok := matcher.Lookup(tt.input)
+			if ok != tt.wantOK || got.Name != tt.want {
+				t.Errorf("Lookup(%q) = %q, %v; want %q, %v", tt.input, got.Name, ok, tt.want, tt.wantOK)
+			}
+		})
+	}
+}
+
+func TestMatcher_MatchCRDGroup(t *testing.T) {
+	addons := []Addon{
+		{Name: "cert-manager", CRDGroups: []string{"cert-manager.io"}},
+		{Name: "Cert-Manager Trust Manager", CRDGroups: []string{"trust.cert-manager.io"}},
+		{Name: "Karpenter", CRDGroups: []string{"karpenter.sh", "karpenter.k8s.aws"}},
+		{Name: "Goldilocks"},
+	}
+	matcher := NewMatcher(addons)
+
+	tests := []struct {
+		group string
+		want  string
+	}{
+		{group: "cert-manager.io", want: "cert-manager"},
+		{group: "acme.cert-manager.io", want: "cert-manager"},
+		{group: "trust.cert-manager.io", want: "Cert-Manager Trust Manager"},
+		{group: "Karpenter.K8s.AWS", want: "Karpenter"},
+		{group: "notcert-manager.io", want: ""},
+		{group: "apps", want: ""},
+		{group: "", want: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.group, func(t *testing.T) {
+			got, ok := matcher.MatchCRDGroup(tt.group)
+			if ok != (tt.want != "") || got.Name != tt.want {
+				t.Fatalf("MatchCRDGroup(%q) = %q, %v, want %q", tt.group, got.Name, ok, tt.want)
+			}
+		})
+	}
+}
+
+func TestEmbeddedDatabase_CRDGroupsAreUnique(t *testing.T) {
+	addons, err := LoadAddons()
+	if err != nil {
+		t.Fatalf("LoadAddons() error: %v", err)
+	}
+	owners := make(map[string]string)
+	for _, addon := range addons {
+		for _, group := range addon.CRDGroups {
+			if group != strings.ToLower(group) || strings.TrimSpace(group) != group || !strings.Contains(group, ".") {
+				t.Errorf("%s: crd_groups entry %q is not a lowercase API group", addon.Name, group)
+			}
+			if owner, exists := owners[group]; exists {
+				t.Errorf("crd group %q is listed by both %s and %s", group, owner, addon.Name)
+			}
+			owners[group] = addon.Name
+		}
+	}
+	if owner := owners["cert-manager.io"]; owner != "cert-manager" {
+		t.Errorf("cert-manager.io owner = %q, want cert-manager", owner)
+	}
+}
+
 func TestNormalizeName(t *testing.T) {
 	tests := []struct {
 		input string
@@ -910,3 +1005,13 @@ func TestLoadAddonsFromDisk_InvalidJSON(t *testing.T) {
 		t.Fatal("LoadAddonsFromDisk() should return error for invalid JSON")
 	}
 }
+
+func TestDatabaseHash(t *testing.T) {
+	hash := DatabaseHash()
+	if !strings.HasPrefix(hash, "sha256:") || len(hash) != len("sha256:")+64 {
+		t.Errorf("DatabaseHash() = %q, want sha256:<64 hex chars>", hash)
+	}
+	if DatabaseHash() != hash {
+		t.Error("DatabaseHash() is not stable")
+	}
+}
@@ -3,12 +3,14 @@ package agent
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"os"
 	"regexp"
 	"sort"
 	"strconv"
 	"strings"
+	"sync"
 	"time"
 	"unicode/utf8"
 
@@ -16,6 +18,8 @@ import (
 	"github.com/qbandev/kaddons/internal/cluster"
 	"github.com/qbandev/kaddons/internal/extract"
 	"github.com/qbandev/kaddons/internal/fetch"
+	"github.com/qbandev/kaddons/internal/lifecycle"
+	"github.com/qbandev/kaddons/internal/metrics"
 	"github.com/qbandev/kaddons/internal/output"
 	"github.com/qbandev/kaddons/internal/resilience"
 	"google.golang.org/genai"
@@ -23,46 +27,101 @@ import (
 
 type addonWithInfo struct {
 	cluster.DetectedAddon
-	DBMatch              *addon.Addon     `json:"db_match,omitempty"`
-	CompatibilityContent string           `json:"compatibility_content,omitempty"`
-	RawContent           string           `json:"-"`
-	IsRawContent         bool             `json:"-"`
-	CompatibilityURL     string           `json:"compatibility_url,omitempty"`
-	FetchError           string           `json:"fetch_error,omitempty"`
-	EOLData              []addon.EOLCycle `json:"eol_data,omitempty"`
+	DBMatch              *addon.Addon         `json:"db_match,omitempty"`
+	CompatibilityContent string               `json:"compatibility_content,omitempty"`
+	RawContent           string               `json:"-"`
+	IsRawContent         bool                 `json:"-"`
+	CompatibilityURL     string               `json:"compatibility_url,omitempty"`
+	FetchError           string               `json:"fetch_error,omitempty"`
+	EOLData              []addon.EOLCycle     `json:"eol_data,omitempty"`
+	Upstream             *fetch.GitHubRelease `json:"-"`
 }
 
 // Run executes the Plan-and-Execute pipeline.
-func Run(ctx context.Context, apiKey, model, namespace, k8sVersionOverride, addonsFilter, outputFormat, outputPath string) error {
+func Run(ctx context.Context, opts Options, namespace, k8sVersionOverride, addonsFilter, outputFormat, outputPath string) error {
 	addonDB, err := addon.LoadAddons()
 	if err != nil {
 		return fmt.Errorf("loading addon database: %w", err)
 	}
 	addonMatcher := addon.NewMatcher(addonDB)
+	metadata := newReportMetadata(opts, len(addonDB))
 
 	// Phase 1: Deterministic data collection (no LLM involved)
 	k8sVersion := k8sVersionOverride
 	if k8sVersion == "" {
 		fmt.Fprintln(os.Stderr, "Detecting cluster version...")
-		v, err := cluster.GetClusterVersion(ctx)
-		if err != nil {
-			return fmt.Errorf("getting cluster version: %w", err)
+	}
+	start := time.Now()
+	serverVersion, versionErr := cluster.GetServerVersion(ctx)
+	if k8sVersion == "" {
+		if versionErr != nil {
+			return fmt.Errorf("getting cluster version: %w", versionErr)
 		}
-		k8sVersion = v
+		k8sVersion = serverVersion.MajorMinor()
+		recordPhase(metadata, PhaseClusterVersion, start)
 	}
 	fmt.Fprintf(os.Stderr, "Cluster version: %s\n", k8sVersion)
 
-	detected, err := cluster.ListInstalledAddons(ctx, namespace)
+	if current, err := cluster.CurrentContext(ctx); err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: could not read kubeconfig context for report metadata: %v\n", err)
+	} else {
+		metadata.Cluster = &output.ClusterInfo{Context: current.Name, Server: current.Server}
+	}
+
+	start = time.Now()
+	// Without a preflight, discovery lists everything and records what fails.
+	access, err := cluster.Preflight(ctx, namespace)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: RBAC preflight failed, scanning without it: %v\n", err)
+	}
+	skipped := access.SkippedScopes()
+	listOptions := cluster.ListOptions{ChunkSize: opts.ChunkSize}
+	var distribution cluster.Distribution
+	if versionErr != nil {
+		fmt.Fprintf(os.Stderr, "Warning: could not detect the Kubernetes distribution: %v\n", versionErr)
+	} else {
+		var nodesSkipped []output.SkippedScope
+		distribution, nodesSkipped = cluster.DetectDistribution(ctx, serverVersion, access, listOptions)
+		skipped = append(skipped, nodesSkipped...)
+		if metadata.Cluster == nil {
+			metadata.Cluster = &output.ClusterInfo{}
+		}
+		metadata.Cluster.Distribution = distribution.Name
+		metadata.Cluster.ServerVersion = distribution.ServerVersion
+		if distribution.Name != "" {
+			fmt.Fprintf(os.Stderr, "Distribution: %s\n", distribution.Name)
+		}
+	}
+
+	detected, listSkipped, err := cluster.ListInstalledAddons(ctx, namespace, access, listOptions)
 	if err != nil {
 		return fmt.Errorf("listing installed addons: %w", err)
 	}
+	skipped = append(skipped, listSkipped...)
+	// CRDs are cluster-scoped and say nothing about namespaces, so they are
+	// only merged when the whole cluster is scanned.
+	if namespace == "" && access.CanList(cluster.ResourceCustomResourceDefinitions) {
+		crds, err := cluster.ListCustomResourceDefinitions(ctx, access, listOptions)
+		if err != nil {
+			skipped = append(skipped, output.SkippedScope{Resource: cluster.ResourceCustomResourceDefinitions, Reason: err.Error()})
+		} else {
+			detected = mergeCRDAddons(detected, crds, addonMatcher)
+		}
+	}
+	for _, scope := range skipped {
+		fmt.Fprintf(os.Stderr, "Warning: skipped %s\n", output.DescribeSkippedScope(scope))
+	}
 
 	// Apply addon filter if specified
+	if namespace != "" || addonsFilter != "" {
+		metadata.Filters = &output.Filters{Namespace: namespace}
+	}
 	if addonsFilter != "" {
 		filters := strings.Split(addonsFilter, ",")
 		filterSet := make(map[string]bool, len(filters))
 		for _, f := range filters {
 			filterSet[strings.TrimSpace(strings.ToLower(f))] = true
+			metadata.Filters.Addons = append(metadata.Filters.Addons, strings.TrimSpace(f))
 		}
 		var filtered []cluster.DetectedAddon
 		for _, a := range detected {
@@ -80,7 +139,182 @@ func Run(ctx context.Context, apiKey, model, namespace, k8sVersionOverride, addo
 		return leftKey < rightKey
 	})
 	fmt.Fprintf(os.Stderr, "Discovered %d workloads\n", len(detected))
+	recordPhase(metadata, PhaseDiscovery, start)
 
+	start = time.Now()
+	results, err := resolve(ctx, opts, addonMatcher, k8sVersion, detected)
+	if err != nil {
+		return err
+	}
+	recordPhase(metadata, PhaseResolution, start)
+	report := output.CompatibilityReport{
+		K8sVersion:    k8sVersion,
+		Metadata:      metadata,
+		Cluster:       clusterSupport(ctx, opts, k8sVersion, distribution.Name),
+		NodePools:     nodePools(ctx, opts, distribution, k8sVersion),
+		SkippedScopes: skipped,
+		Addons:        results,
+	}
+	return emitResults(ctx, opts, report, outputFormat, outputPath)
+}
+
+// nodePools checks the cluster's nodes against the kubelet skew policy for
+// the running API server and for k8sVersion, and their containerd releases
+// against endoflife.date. It returns nil when no nodes were listed; the
+// report's skipped scopes then say why.
+func nodePools(ctx context.Context, opts Options, distribution cluster.Distribution, k8sVersion string) []output.NodePool {
+	if len(distribution.Nodes) == 0 {
+		return nil
+	}
+	var containerdCycles []addon.EOLCycle
+	if slug, ok := addon.LookupEOLSlug("containerd"); ok && !opts.SkipEOL {
+		cycles, err := fetch.EOLData(ctx, slug)
+		if err != nil {
+			metrics.FetchFailures.Inc(metrics.FetchEOL)
+			fmt.Fprintf(os.Stderr, "Warning: containerd lifecycle fetch failed: %v\n", err)
+		} else {
+			containerdCycles = cycles
+		}
+	}
+	return lifecycle.NodePools(distribution.Nodes, distribution.ServerVersion, k8sVersion, containerdCycles, time.Now())
+}
+
+// clusterSupport evaluates the support lifecycle of k8sVersion on the given
+// distribution. Release cycles are fetched from endoflife.date, falling back
+// to the embedded snapshot when the fetch fails or SkipEOL is set; a provider
+// without data for the version falls back to the upstream lifecycle.
+func clusterSupport(ctx context.Context, opts Options, k8sVersion, distribution string) *output.ClusterSupport {
+	now := time.Now()
+	var support *output.ClusterSupport
+	for _, product := range lifecycle.Products(distribution) {
+		cycles := lifecycle.Snapshot(product)
+		if !opts.SkipEOL {
+			if fresh, err := fetch.EOLData(ctx, product); err != nil {
+				metrics.FetchFailures.Inc(metrics.FetchEOL)
+				fmt.Fprintf(os.Stderr, "Warning: %s lifecycle fetch failed, using embedded data: %v\n", product, err)
+			} else {
+				cycles = fresh
+			}
+		}
+		support = lifecycle.Evaluate(normalizeK8sVersion(k8sVersion), product, cycles, now)
+		if support.Status != output.SupportUnknown {
+			break
+		}
+	}
+	return support
+}
+
+// RunCheck resolves an explicit addon list against k8sVersion and writes the
+// report, skipping Phase 1 discovery. With an API key, addons left unresolved
+// by stored data and extraction go to the LLM exactly as in Run.
+func RunCheck(ctx context.Context, opts Options, k8sVersion string, addons []cluster.DetectedAddon, outputFormat, outputPath string) error {
+	addonDB, err := addon.LoadAddons()
+	if err != nil {
+		return fmt.Errorf("loading addon database: %w", err)
+	}
+	metadata := newReportMetadata(opts, len(addonDB))
+	fmt.Fprintf(os.Stderr, "Cluster version: %s\n", k8sVersion)
+	fmt.Fprintf(os.Stderr, "Checking %d addons\n", len(addons))
+	start := time.Now()
+	results, err := Check(ctx, opts, addon.NewMatcher(addonDB), k8sVersion, addons)
+	if err != nil {
+		return err
+	}
+	recordPhase(metadata, PhaseResolution, start)
+	report := output.CompatibilityReport{K8sVersion: k8sVersion, Metadata: metadata, Cluster: clusterSupport(ctx, opts, k8sVersion, ""), Addons: results}
+	return emitResults(ctx, opts, report, outputFormat, outputPath)
+}
+
+// upstreamParallelism caps the GitHub release lookups resolve runs at once.
+const upstreamParallelism = 4
+
+// Pipeline phases timed in report metadata.
+const (
+	PhaseClusterVersion = "cluster_version"
+	PhaseDiscovery      = "discovery"
+	PhaseResolution     = "resolution"
+)
+
+// newReportMetadata starts the metadata block for a report produced with opts
+// against a database of dbEntries addons.
+func newReportMetadata(opts Options, dbEntries int) *output.Metadata {
+	analysis := output.AnalysisInfo{Provider: output.AnalysisProviderNone}
+	if opts.APIKey != "" {
+		analysis = output.AnalysisInfo{Provider: output.AnalysisProviderGemini, Model: opts.Model}
+	}
+	tool := opts.Tool
+	if tool.Name == "" {
+		tool.Name = "kaddons"
+	}
+	return output.NewMetadata(tool, output.DatabaseInfo{Hash: addon.DatabaseHash(), Entries: dbEntries}, analysis)
+}
+
+// recordPhase times a finished phase in both the report metadata and the
+// process metrics.
+func recordPhase(metadata *output.Metadata, phase string, start time.Time) {
+	metadata.AddTiming(phase, start)
+	metrics.ObservePhase(phase, start)
+}
+
+// Options configure the matching, enrichment and analysis phases shared by
+// Run, Check and the API server.
+type Options struct {
+	// APIKey enables Gemini analysis of addons that stored data and
+	// extraction leave unresolved; without it they get local-only results.
+	APIKey string
+	Model  string
+	// Pages caches fetched compatibility pages. Nil caches for a single call.
+	Pages *fetch.PageCache
+	// Upstream caches latest GitHub releases. Nil caches for a single call.
+	Upstream *UpstreamReleaseCache
+	// SkipUpstream skips the GitHub lookups of each matched addon's latest
+	// release; results then carry no latest upstream version or lag.
+	SkipUpstream bool
+	// SkipEOL skips endoflife.date lookups, which feed LLM prompts, local-only
+	// notes and the cluster and node lifecycle checks; cluster support then
+	// uses the embedded snapshot.
+	SkipEOL bool
+	// ChunkSize is the number of objects each discovery list request
+	// returns. Zero uses cluster.DefaultChunkSize.
+	ChunkSize int
+	// Tool identifies the kaddons build in report metadata.
+	Tool output.ToolInfo
+	// Store, when set, saves each report into the cluster after it is
+	// written to the output sinks.
+	Store *cluster.ReportStore
+}
+
+// Check resolves the given addon versions against k8sVersion without cluster
+// discovery. Unlike Run, addons that are not in the database are reported as
+// unknown instead of being dropped, since the caller named them explicitly.
+func Check(ctx context.Context, opts Options, matcher *addon.Matcher, k8sVersion string, addons []cluster.DetectedAddon) ([]output.AddonCompatibility, error) {
+	var matched []cluster.DetectedAddon
+	var unmatched []output.AddonCompatibility
+	for _, a := range addons {
+		if len(matcher.Match(a.Name)) == 0 {
+			unmatched = append(unmatched, output.AddonCompatibility{
+				Name:             a.Name,
+				Namespace:        a.Namespace,
+				InstalledVersion: a.Version,
+				Compatible:       output.StatusUnknown,
+				DataSource:       output.DataSourceLocal,
+				Note:             "Addon not found in the kaddons database",
+			})
+			continue
+		}
+		matched = append(matched, a)
+	}
+	results, err := resolve(ctx, opts, matcher, k8sVersion, matched)
+	if err != nil {
+		return nil, err
+	}
+	return append(results, unmatched...), nil
+}
+
+// resolve runs Phases 2 and 3 on discovered addons: database matching, stored
+// resolution, page and EOL enrichment, deterministic extraction and, with an
+// API key, LLM analysis. Addons without a database match are skipped.
+func resolve(ctx context.Context, opts Options, addonMatcher *addon.Matcher, k8sVersion string, detected []cluster.DetectedAddon) ([]output.AddonCompatibility, error) {
 	// Phase 2: Match addons against DB, deduplicate by addon name (prefer entry with version)
 	type enrichedEntry struct {
 		info   addonWithInfo
@@ -98,6 +332,9 @@ func Run(ctx context.Context, apiKey, model, namespace, k8sVersionOverride, addo
 		if exists && existing.info.Version != "" && a.Version == "" {
 			continue // keep the one with a version
 		}
+		if exists && existing.info.Source == "olm" && existing.info.Version != "" && a.Source != "olm" {
+			continue // an OLM ClusterServiceVersion names the installed release
+		}
 
 		bestByName[dbName] = enrichedEntry{
 			info: addonWithInfo{
@@ -117,6 +354,36 @@ func Run(ctx context.Context, apiKey, model, namespace, k8sVersionOverride, addo
 	}
 	sort.Strings(orderedAddonNames)
 
+	// Latest upstream releases come from GitHub for every matched addon, stored
+	// or not: they are independent of the compatibility data source.
+	if !opts.SkipUpstream {
+		upstream := opts.Upstream
+		if upstream == nil {
+			upstream = NewUpstreamReleaseCache(fetch.NewGitHubClient(), 0)
+		}
+		releases := make([]*fetch.GitHubRelease, len(orderedAddonNames))
+		var (
+			wg  sync.WaitGroup
+			sem = make(chan struct{}, upstreamParallelism)
+		)
+		for i, addonName := range orderedAddonNames {
+			info := bestByName[addonName].info
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				sem <- struct{}{}
+				defer func() { <-sem }()
+				releases[i] = upstream.latest(ctx, info.Name, info.DBMatch.Repository)
+			}()
+		}
+		wg.Wait()
+		for i, addonName := range orderedAddonNames {
+			entry := bestByName[addonName]
+			entry.info.Upstream = releases[i]
+			bestByName[addonName] = entry
+		}
+	}
+
 	var storedResults []output.AddonCompatibility
 	var runtimeAddons []string // addon names that need runtime resolution
 
@@ -127,6 +394,10 @@ func Run(ctx context.Context, apiKey, model, namespace, k8sVersionOverride, addo
 			result := resolveFromStoredData(info, k8sVersion)
 			storedResults = append(storedResults, result)
 			fmt.Fprintf(os.Stderr, "Resolved %s from stored data -> %s\n", info.Name, result.Compatible)
+		} else if info.MinKubeVersion != "" {
+			result := resolveFromMinKubeVersion(info, k8sVersion)
+			storedResults = append(storedResults, result)
+			fmt.Fprintf(os.Stderr, "Resolved %s from OLM minKubeVersion -> %s\n", info.Name, result.Compatible)
 		} else {
 			runtimeAddons = append(runtimeAddons, addonName)
 		}
@@ -134,18 +405,22 @@ func Run(ctx context.Context, apiKey, model, namespace, k8sVersionOverride, addo
 
 	// Fetch compatibility pages and EOL data for addons without stored data
 	fmt.Fprintf(os.Stderr, "Enriching %d addons (runtime)...\n", len(runtimeAddons))
-	hasAPIKey := strings.TrimSpace(apiKey) != ""
+	hasAPIKey := strings.TrimSpace(opts.APIKey) != ""
 	runtimeEOLSlugLookup := make(map[string]string)
-	if len(runtimeAddons) > 0 {
+	if len(runtimeAddons) > 0 && !opts.SkipEOL {
 		products, err := fetch.EOLProducts(ctx)
 		if err != nil {
+			metrics.FetchFailures.Inc(metrics.FetchEOLCatalog)
 			fmt.Fprintf(os.Stderr, "Warning: EOL product catalog fetch failed, using static fallback aliases: %v\n", err)
 		} else {
 			runtimeEOLSlugLookup = addon.BuildRuntimeEOLSlugLookup(products)
 		}
 	}
 	enriched := make([]addonWithInfo, 0, len(runtimeAddons))
-	fetchedPages := make(map[string]fetch.FetchedPage) // cache: URL -> fetched page
+	pages := opts.Pages
+	if pages == nil {
+		pages = fetch.NewPageCache(0)
+	}
 	for _, addonName := range runtimeAddons {
 		entry := bestByName[addonName]
 		info := entry.info
@@ -153,25 +428,24 @@ func Run(ctx context.Context, apiKey, model, namespace, k8sVersionOverride, addo
 			info.CompatibilityURL = info.DBMatch.CompatibilityMatrixURL
 			// Always fetch: raw content feeds deterministic table extraction (Phase 2c)
 			// even when no LLM API key is configured.
-			if cached, ok := fetchedPages[info.CompatibilityURL]; ok {
-				info.CompatibilityContent = cached.Text
-				info.RawContent = cached.Raw
-				info.IsRawContent = cached.IsRaw
+			page, err := pages.Get(ctx, info.CompatibilityURL)
+			if err != nil {
+				metrics.FetchFailures.Inc(metrics.FetchCompatibilityPage)
+				info.FetchError = err.Error()
 			} else {
-				page, err := fetch.CompatibilityPageFull(ctx, info.DBMatch.CompatibilityMatrixURL)
-				if err != nil {
-					info.FetchError = err.Error()
-				} else {
-					info.CompatibilityContent = page.Text
-					info.RawContent = page.Raw
-					info.IsRawContent = page.IsRaw
-					fetchedPages[info.CompatibilityURL] = page
-				}
+				info.CompatibilityContent = page.Text
+				info.RawContent = page.Raw
+				info.IsRawContent = page.IsRaw
 			}
 		}
+		if opts.SkipEOL {
+			enriched = append(enriched, info)
+			continue
+		}
 		if slug, ok := addon.LookupEOLSlugWithRuntime(info.Name, runtimeEOLSlugLookup); ok {
 			cycles, err := fetch.EOLData(ctx, slug)
 			if err != nil {
+				metrics.FetchFailures.Inc(metrics.FetchEOL)
 				fmt.Fprintf(os.Stderr, "Warning: EOL data fetch failed for %s: %v\n", info.Name, err)
 			} else {
 				info.EOLData = cycles
@@ -181,10 +455,10 @@ func Run(ctx context.Context, apiKey, model, namespace, k8sVersionOverride, addo
 	}
 
 	if len(enriched) == 0 && len(storedResults) == 0 {
-		return emitResults(k8sVersion, []output.AddonCompatibility{}, outputFormat, outputPath)
+		return []output.AddonCompatibility{}, nil
 	}
 
-	// Phase 2c: Attempt deterministic table extraction before LLM
+	// Phase 2c: Attempt deterministic extraction (structured sources, tables, prose) before LLM
 	var extractedResults []output.AddonCompatibility
 	var remaining []addonWithInfo
 	k8sMajorMinor := normalizeK8sVersion(k8sVersion)
@@ -193,14 +467,31 @@ func Run(ctx context.Context, apiKey, model, namespace, k8sVersionOverride, addo
 			remaining = append(remaining, info)
 			continue
 		}
-		matrix := tryExtractMatrix(info)
-		if matrix == nil {
-			remaining = append(remaining, info)
-			continue
+		var result *output.AddonCompatibility
+		var evidence *extract.Compatibility
+		evidenceKind := "table"
+		if info.DBMatch != nil && extract.IsStructuredSourceType(info.DBMatch.CompatibilitySourceType) {
+			// Machine-readable sources are authoritative; skip the HTML heuristics.
+			evidenceKind = structuredEvidenceKind(info.DBMatch.CompatibilitySourceType)
+			if evidence = tryExtractStructured(info); evidence != nil {
+				result = resolveFromExtractedCompatibility(info, evidence, k8sMajorMinor, evidenceKind)
+			}
+		} else {
+			if evidence = tryExtractMatrix(info); evidence != nil {
+				result = resolveFromExtractedMatrix(info, evidence.Matrix, k8sMajorMinor)
+			}
+			if result == nil {
+				// No usable table: fall back to compatibility statements written as prose.
+				if evidence = tryExtractProse(info); evidence != nil {
+					result = resolveFromExtractedCompatibility(info, evidence, k8sMajorMinor, "text")
+					evidenceKind = "text"
+				}
+			}
 		}
-		result := resolveFromExtractedMatrix(info, matrix, k8sMajorMinor)
 		if result != nil {
-			fmt.Fprintf(os.Stderr, "Resolved %s from extracted table -> %s\n", info.Name, result.Compatible)
+			result.Provenance = evidence.Provenance(info.CompatibilityURL, info.RawContent, time.Now())
+			applyUpstreamRelease(result, info.Upstream, info.ChartVersion)
+			fmt.Fprintf(os.Stderr, "Resolved %s from extracted %s -> %s\n", info.Name, evidenceKind, result.Compatible)
 			extractedResults = append(extractedResults, *result)
 		} else {
 			remaining = append(remaining, info)
@@ -212,20 +503,21 @@ func Run(ctx context.Context, apiKey, model, namespace, k8sVersionOverride, addo
 	if len(remaining) > 0 && !hasAPIKey {
 		fmt.Fprintf(os.Stderr, "No Gemini API key configured. Producing local-only results for %d addons.\n", len(remaining))
 		localResults := resolveLocalOnly(remaining, k8sVersion)
-		return emitResults(k8sVersion, append(storedResults, localResults...), outputFormat, outputPath)
+		return append(storedResults, localResults...), nil
 	}
 	var client *genai.Client
 	if len(remaining) > 0 {
+		var err error
 		client, err = genai.NewClient(ctx, &genai.ClientConfig{
-			APIKey:  apiKey,
+			APIKey:  opts.APIKey,
 			Backend: genai.BackendGeminiAPI,
 		})
 		if err != nil {
-			return fmt.Errorf("creating Gemini client: %w", err)
+			return nil, fmt.Errorf("creating Gemini client: %w", err)
 		}
-		fmt.Fprintf(os.Stderr, "Analyzing with %s...\n", model)
+		fmt.Fprintf(os.Stderr, "Analyzing with %s...\n", opts.Model)
 	}
-	return analyzeCompatibility(ctx, client, model, k8sVersion, remaining, storedResults, outputFormat, outputPath)
+	return analyzeCompatibility(ctx, client, opts.Model, k8sVersion, remaining, storedResults), nil
 }
 
 // resolveFromStoredData produces a deterministic compatibility verdict from
@@ -242,7 +534,9 @@ func resolveFromStoredData(info addonWithInfo, k8sVersion string) output.AddonCo
 	finalizeResult := func() output.AddonCompatibility {
 		if info.DBMatch != nil {
 			result.Note = appendSourceReference(result.Note, info.DBMatch.CompatibilityMatrixURL)
+			result.Provenance = info.DBMatch.CompatibilityProvenance
 		}
+		applyUpstreamRelease(&result, info.Upstream, info.ChartVersion)
 		return result
 	}
 
@@ -319,6 +613,24 @@ func resolveFromStoredData(info addonWithInfo, k8sVersion string) output.AddonCo
 	return finalizeResult()
 }
 
+// resolveFromMinKubeVersion checks k8sVersion against the minKubeVersion an
+// OLM operator declares in its ClusterServiceVersion. It is only used when
+// the database has no stored data for the addon, as a floor alone cannot
+// rule out a cluster that is too new.
+func resolveFromMinKubeVersion(info addonWithInfo, k8sVersion string) output.AddonCompatibility {
+	result := output.AddonCompatibility{
+		Name:             info.Name,
+		Namespace:        info.Namespace,
+		InstalledVersion: info.Version,
+		DataSource:       output.DataSourceOLM,
+	}
+	floor := addon.Addon{KubernetesMinVersion: normalizeK8sVersion(info.MinKubeVersion)}
+	resolveFromMinMaxVersion(&floor, normalizeK8sVersion(k8sVersion), &result)
+	result.Note += " per the operator's ClusterServiceVersion spec.minKubeVersion " + info.MinKubeVersion
+	applyUpstreamRelease(&result, info.Upstream, info.ChartVersion)
+	return result
+}
+
 func matrixKeyMatchesInstalledVersion(matrixKey string, installedVersion string) bool {
 	keyNorm := strings.TrimPrefix(strings.ToLower(strings.TrimSpace(matrixKey)), "v")
 	installedNorm := strings.ToLower(strings.TrimPrefix(strings.TrimSpace(installedVersion), "v"))
@@ -686,29 +998,132 @@ func appendSourceReference(note string, sourceURL string) string {
 	return fmt.Sprintf("%s Source: %s", note, sourceURL)
 }
 
-// emitResults marshals results to JSON, formats output, and prints the summary line to stderr.
-func emitResults(k8sVersion string, results []output.AddonCompatibility, outputFormat string, outputPath string) error {
-	resultsJSON, err := json.Marshal(results)
-	if err != nil {
-		return fmt.Errorf("marshaling results: %w", err)
+// UpstreamReleaseCache resolves the latest GitHub release per repository.
+// Addons sharing a repository cost one lookup, and while the API rate limit
+// is exhausted lookups are skipped with a single warning. It is safe for
+// concurrent use, so a long-running server can share one across requests.
+type UpstreamReleaseCache struct {
+	client *fetch.GitHubClient
+	// ttl bounds how long a release is reused; zero keeps it for the life of
+	// the cache, which suits a single CLI run.
+	ttl time.Duration
+
+	mu                sync.Mutex
+	entries           map[string]upstreamEntry
+	rateLimitReported bool
+}
+
+type upstreamEntry struct {
+	release   *fetch.GitHubRelease
+	fetchedAt time.Time
+}
+
+// NewUpstreamReleaseCache returns a cache backed by client whose entries
+// expire after ttl (never when ttl is zero).
+func NewUpstreamReleaseCache(client *fetch.GitHubClient, ttl time.Duration) *UpstreamReleaseCache {
+	return &UpstreamReleaseCache{client: client, ttl: ttl, entries: make(map[string]upstreamEntry)}
+}
+
+// latest returns the newest stable upstream release, or nil when the
+// repository is not on GitHub or the lookup failed.
+func (c *UpstreamReleaseCache) latest(ctx context.Context, addonName string, repositoryURL string) *fetch.GitHubRelease {
+	repository, ok := fetch.GitHubRepository(repositoryURL)
+	if !ok {
+		return nil
 	}
-	formattedResults, err := output.FormatOutput(string(resultsJSON), k8sVersion, outputFormat, outputPath)
-	if err != nil {
-		return err
+	c.mu.Lock()
+	entry, seen := c.entries[repository]
+	c.mu.Unlock()
+	if seen && (c.ttl <= 0 || time.Since(entry.fetchedAt) < c.ttl) {
+		return entry.release
 	}
 
-	var compatible, incompatible, unknown int
-	for _, result := range formattedResults {
-		switch result.Compatible {
-		case output.StatusTrue:
-			compatible++
-		case output.StatusFalse:
-			incompatible++
-		default:
-			unknown++
+	release, err := c.client.LatestRelease(ctx, repositoryURL)
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	switch {
+	case err == nil:
+		c.entries[repository] = upstreamEntry{release: &release, fetchedAt: time.Now()}
+		return &release
+	case errors.Is(err, fetch.ErrGitHubRateLimited):
+		metrics.FetchFailures.Inc(metrics.FetchGitHubRelease)
+		// Not cached: the client refuses requests until the limit resets.
+		if !c.rateLimitReported {
+			c.rateLimitReported = true
+			fmt.Fprintf(os.Stderr, "Warning: %v; skipping upstream release lookups (set GITHUB_TOKEN for a higher limit)\n", err)
 		}
+		return nil
+	case errors.Is(err, fetch.ErrNoStableRelease):
+		// Nothing to report; not worth a warning.
+	default:
+		metrics.FetchFailures.Inc(metrics.FetchGitHubRelease)
+		fmt.Fprintf(os.Stderr, "Warning: upstream release lookup failed for %s: %v\n", addonName, err)
 	}
-	fmt.Fprintf(os.Stderr, "Done: %d compatible, %d incompatible, %d unknown\n", compatible, incompatible, unknown)
+	c.entries[repository] = upstreamEntry{fetchedAt: time.Now()}
+	return nil
+}
+
+// applyUpstreamRelease copies the latest upstream release into result and
+// computes how many minor versions the installed version trails it. A Helm
+// chart version is not comparable with the addon's release tags, so an
+// installed chartVersion leaves the lag unknown.
+func applyUpstreamRelease(result *output.AddonCompatibility, release *fetch.GitHubRelease, chartVersion bool) {
+	if release == nil {
+		return
+	}
+	result.LatestUpstreamVersion = release.Version
+	if !release.PublishedAt.IsZero() {
+		result.LatestUpstreamReleaseDate = release.PublishedAt.UTC().Format(time.DateOnly)
+	}
+	if chartVersion {
+		return
+	}
+	if behind, ok := minorVersionsBehind(result.InstalledVersion, release.Version); ok {
+		result.MinorVersionsBehind = &behind
+	}
+}
+
+// minorVersionsBehind returns the number of minor releases between installed
+// and latest. ok is false when either version lacks a major.minor or the
+// major versions differ, since minor counts do not carry across majors.
+// An installed version ahead of the latest stable release (a pre-release, say)
+// counts as zero behind.
+func minorVersionsBehind(installed string, latest string) (int, bool) {
+	installedParts, ok := parseAddonVersionFloor(installed)
+	if !ok || len(installedParts) < 2 {
+		return 0, false
+	}
+	latestParts, ok := parseAddonVersionFloor(latest)
+	if !ok || len(latestParts) < 2 || installedParts[0] != latestParts[0] {
+		return 0, false
+	}
+	if behind := latestParts[1] - installedParts[1]; behind > 0 {
+		return behind, true
+	}
+	return 0, true
+}
+
+// emitResults writes the report to every output sink and prints the summary line to stderr.
+func emitResults(ctx context.Context, opts Options, report output.CompatibilityReport, outputFormat string, outputPath string) error {
+	// Paths that leave Compatible unset report it as unknown; the schema only
+	// allows true, false and unknown.
+	for i := range report.Addons {
+		if report.Addons[i].Compatible != output.StatusTrue && report.Addons[i].Compatible != output.StatusFalse {
+			report.Addons[i].Compatible = output.StatusUnknown
+		}
+	}
+	if err := output.WriteReport(report, outputFormat, outputPath); err != nil {
+		return err
+	}
+	if opts.Store != nil {
+		if err := opts.Store.Save(ctx, report); err != nil {
+			return err
+		}
+		fmt.Fprintf(os.Stderr, "Report stored in %s\n", opts.Store)
+	}
+
+	summary := output.Summarize(report.Addons)
+	fmt.Fprintf(os.Stderr, "Done: %d compatible, %d incompatible, %d unknown\n", summary.Compatible, summary.Incompatible, summary.Unknown)
 	return nil
 }
 
@@ -722,45 +1137,117 @@ func resolveLocalOnly(addons []addonWithInfo, k8sVersion string) []output.AddonC
 		if len(info.EOLData) > 0 {
 			latest := info.EOLData[0]
 			note += fmt.Sprintf(". Latest release: %s (cycle %s)", latest.Latest, latest.Cycle)
+		} else if info.Upstream != nil {
+			note += fmt.Sprintf(". Latest upstream release: %s", info.Upstream.Tag)
 		}
 		if info.DBMatch != nil {
 			note = appendSourceReference(note, info.DBMatch.CompatibilityMatrixURL)
 		}
-		results = append(results, output.AddonCompatibility{
+		result := output.AddonCompatibility{
 			Name:             info.Name,
 			Namespace:        info.Namespace,
 			InstalledVersion: info.Version,
 			Compatible:       output.StatusUnknown,
 			DataSource:       output.DataSourceLocal,
 			Note:             note,
-		})
+		}
+		applyUpstreamRelease(&result, info.Upstream, info.ChartVersion)
+		results = append(results, result)
 	}
 	return results
 }
 
 // tryExtractMatrix attempts deterministic table extraction from the addon's raw content.
 // Returns nil if no valid matrix could be extracted.
-func tryExtractMatrix(info addonWithInfo) map[string][]string {
-	var matrix map[string][]string
+func tryExtractMatrix(info addonWithInfo) *extract.Compatibility {
+	var table *extract.Compatibility
 	var err error
 
 	if info.IsRawContent {
-		// GitHub raw content is Markdown
-		matrix, err = extract.ExtractMarkdownMatrix(info.RawContent)
+		// GitHub raw content is Markdown, AsciiDoc or reStructuredText
+		table, err = extract.ExtractRawTable(info.CompatibilityURL, info.RawContent)
 	} else {
 		// Non-GitHub content is HTML
-		matrix, err = extract.ExtractHTMLMatrix(info.RawContent)
+		table, err = extract.ExtractHTMLTable(info.RawContent)
 	}
 
 	if err != nil {
 		return nil
 	}
-	return matrix
+	return table
+}
+
+// tryExtractProse attempts deterministic extraction of compatibility statements
+// written as sentences or bullet lists. Returns nil if nothing was recognized.
+func tryExtractProse(info addonWithInfo) *extract.Compatibility {
+	if info.IsRawContent {
+		return extract.ExtractProseCompatibility(info.RawContent)
+	}
+	return extract.ExtractHTMLProseCompatibility(info.RawContent)
+}
+
+// tryExtractStructured parses the addon's raw content with the extractor named
+// by its compatibility_source_type. Returns nil if nothing was recognized.
+func tryExtractStructured(info addonWithInfo) *extract.Compatibility {
+	structured, err := extract.ExtractStructured(info.DBMatch.CompatibilitySourceType, info.RawContent)
+	if err != nil {
+		return nil
+	}
+	return structured
+}
+
+// structuredEvidenceKind names a structured source type in verdict notes.
+func structuredEvidenceKind(sourceType string) string {
+	switch sourceType {
+	case extract.SourceTypeHelmChart:
+		return "Chart.yaml kubeVersion"
+	case extract.SourceTypeJSON:
+		return "JSON"
+	default:
+		return "YAML"
+	}
+}
+
+// resolveFromExtractedCompatibility resolves compatibility from prose or
+// structured extraction: a per-version matrix when the source states one,
+// otherwise page-wide min/max bounds. Returns nil if neither yields a verdict
+// for the installed version.
+func resolveFromExtractedCompatibility(info addonWithInfo, extracted *extract.Compatibility, k8sMajorMinor string, evidenceKind string) *output.AddonCompatibility {
+	if len(extracted.Matrix) > 0 {
+		if result := resolveFromExtractedEvidence(info, extracted.Matrix, k8sMajorMinor, evidenceKind); result != nil {
+			return result
+		}
+	}
+	if !extracted.HasBounds() {
+		return nil
+	}
+
+	result := &output.AddonCompatibility{
+		Name:             info.Name,
+		Namespace:        info.Namespace,
+		InstalledVersion: info.Version,
+		DataSource:       output.DataSourceExtracted,
+	}
+	bounds := &addon.Addon{
+		KubernetesMinVersion: extracted.MinVersion,
+		KubernetesMaxVersion: extracted.MaxVersion,
+	}
+	if !resolveFromMinMaxVersion(bounds, k8sMajorMinor, result) {
+		return nil
+	}
+	result.Note = appendSourceReference(result.Note+" per extracted "+evidenceKind, info.CompatibilityURL)
+	return result
 }
 
 // resolveFromExtractedMatrix resolves compatibility from a deterministically extracted matrix.
 // Returns nil if the matrix doesn't contain enough data for a verdict.
 func resolveFromExtractedMatrix(info addonWithInfo, matrix map[string][]string, k8sMajorMinor string) *output.AddonCompatibility {
+	return resolveFromExtractedEvidence(info, matrix, k8sMajorMinor, "table")
+}
+
+// resolveFromExtractedEvidence is resolveFromExtractedMatrix with the evidence
+// kind ("table", "text", "YAML", ...) named in the verdict note.
+func resolveFromExtractedEvidence(info addonWithInfo, matrix map[string][]string, k8sMajorMinor string, evidenceKind string) *output.AddonCompatibility {
 	result := &output.AddonCompatibility{
 		Name:             info.Name,
 		Namespace:        info.Namespace,
@@ -777,8 +1264,8 @@ func resolveFromExtractedMatrix(info addonWithInfo, matrix map[string][]string,
 		if thresholdFound {
 			result.Compatible = output.StatusTrue
 			result.Note = fmt.Sprintf(
-				"Addon version %s satisfies threshold %s for K8s %s per extracted table",
-				info.Version, thresholdKey, k8sMajorMinor,
+				"Addon version %s satisfies threshold %s for K8s %s per extracted %s",
+				info.Version, thresholdKey, k8sMajorMinor, evidenceKind,
 			)
 			result.LatestCompatibleVersion = findLatestCompatibleVersion(matrix, k8sMajorMinor)
 			result.Note = appendSourceReference(result.Note, info.CompatibilityURL)
@@ -791,7 +1278,7 @@ func resolveFromExtractedMatrix(info addonWithInfo, matrix map[string][]string,
 	for _, v := range matchedK8sVersions {
 		if normalizeK8sVersion(v) == k8sMajorMinor {
 			result.Compatible = output.StatusTrue
-			result.Note = fmt.Sprintf("Addon version %s supports K8s %s per extracted table", matchedKey, k8sMajorMinor)
+			result.Note = fmt.Sprintf("Addon version %s supports K8s %s per extracted %s", matchedKey, k8sMajorMinor, evidenceKind)
 			result.Note = appendSourceReference(result.Note, info.CompatibilityURL)
 			return result
 		}
@@ -802,7 +1289,7 @@ func resolveFromExtractedMatrix(info addonWithInfo, matrix map[string][]string,
 	if latestKey != "" {
 		result.LatestCompatibleVersion = latestKey
 	}
-	result.Note = fmt.Sprintf("Addon version %s does not support K8s %s per extracted table (supports: %s)", matchedKey, k8sMajorMinor, strings.Join(matchedK8sVersions, ", "))
+	result.Note = fmt.Sprintf("Addon version %s does not support K8s %s per extracted %s (supports: %s)", matchedKey, k8sMajorMinor, evidenceKind, strings.Join(matchedK8sVersions, ", "))
 	result.Note = appendSourceReference(result.Note, info.CompatibilityURL)
 	return result
 }
@@ -903,7 +1390,7 @@ var evidenceVersionPattern = regexp.MustCompile(`(?i)\bv?\d+\.\d+(?:\.\d+)?\b`)
 var evidenceSupportPattern = regexp.MustCompile(`(?i)(compat|support|matrix|tested|require|recommended)`)
 var evidenceNegationPattern = regexp.MustCompile(`(?i)(non[- ]matrix|without (?:a )?(?:compatibility|support|version|matrix)|no (?:compatibility|support|version|matrix)|does not (?:contain|include)|lacks?)`)
 
-func analyzeCompatibility(ctx context.Context, client *genai.Client, model string, k8sVersion string, addons []addonWithInfo, storedResults []output.AddonCompatibility, outputFormat string, outputPath string) error {
+func analyzeCompatibility(ctx context.Context, client *genai.Client, model string, k8sVersion string, addons []addonWithInfo, storedResults []output.AddonCompatibility) []output.AddonCompatibility {
 	results := make([]output.AddonCompatibility, 0, len(storedResults)+len(addons))
 	results = append(results, storedResults...)
 	for addonIndex, addonInfo := range addons {
@@ -930,6 +1417,7 @@ func analyzeCompatibility(ctx context.Context, client *genai.Client, model strin
 			}
 		}
 		result.DataSource = output.DataSourceRuntime
+		applyUpstreamRelease(&result, addonInfo.Upstream, addonInfo.ChartVersion)
 		fmt.Fprintf(
 			os.Stderr,
 			"Completed addon %d/%d: %s -> %s\n",
@@ -941,7 +1429,7 @@ func analyzeCompatibility(ctx context.Context, client *genai.Client, model strin
 		results = append(results, result)
 	}
 
-	return emitResults(k8sVersion, results, outputFormat, outputPath)
+	return results
 }
 
 type singleAddonAnalysisInput struct {
@@ -1244,12 +1732,14 @@ func generateContentWithRetry(
 		response, err := client.Models.GenerateContent(attemptContext, model, contents, config)
 		cancelAttempt()
 		if err != nil {
+			metrics.LLMCalls.Inc(metrics.LLMError)
 			fmt.Fprintf(os.Stderr, "Warning: Gemini analysis attempt %d/%d failed: %v\n", attemptCounter, policy.Attempts, err)
 			if isTransientLLMError(err) && attemptCounter < policy.Attempts {
 				fmt.Fprintln(os.Stderr, "Retrying Gemini analysis...")
 			}
 			return nil, err
 		}
+		metrics.LLMCalls.Inc(metrics.LLMSuccess)
 		return response, nil
 	})
 }
@@ -0,0 +1,69 @@
+package agent
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/qbandev/kaddons/internal/addon"
+	"github.com/qbandev/kaddons/internal/cluster"
+)
+
+// crdSource is the DetectedAddon source of addons found only by their CRDs.
+const crdSource = "crd"
+
+// mergeCRDAddons folds installed CRDs into workload discovery. Each CRD group
+// is matched to the database addon that owns it. A detected workload of the
+// same addon that has no version takes the CRD's version hint; addons with no
+// detected workload, such as Gateway API, which ships only CRDs, or operators
+// running under an unrecognisable name, are added with source "crd" and no
+// namespace, as CRDs are cluster-scoped.
+func mergeCRDAddons(detected []cluster.DetectedAddon, crds []cluster.CustomResourceDefinition, matcher *addon.Matcher) []cluster.DetectedAddon {
+	hints := make(map[string]string)
+	dbNames := make(map[string]string)
+	for _, crd := range crds {
+		match, ok := matcher.MatchCRDGroup(crd.Group)
+		if !ok {
+			continue
+		}
+		key := strings.ToLower(match.Name)
+		dbNames[key] = match.Name
+		if hints[key] == "" {
+			hints[key] = crd.VersionHint
+		}
+	}
+	if len(dbNames) == 0 {
+		return detected
+	}
+
+	covered := make(map[string]bool)
+	for i, a := range detected {
+		matches := matcher.Match(a.Name)
+		if len(matches) == 0 {
+			continue
+		}
+		key := strings.ToLower(matches[0].Name)
+		if _, ok := dbNames[key]; !ok {
+			continue
+		}
+		covered[key] = true
+		if detected[i].Version == "" {
+			detected[i].Version = hints[key]
+		}
+	}
+
+	keys := make([]string, 0, len(dbNames))
+	for key := range dbNames {
+		if !covered[key] {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		detected = append(detected, cluster.DetectedAddon{
+			Name:    dbNames[key],
+			Version: hints[key],
+			Source:  crdSource,
+		})
+	}
+	return detected
+}
@@ -0,0 +1,47 @@
+package agent
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/qbandev/kaddons/internal/addon"
+	"github.com/qbandev/kaddons/internal/cluster"
+)
+
+func TestMergeCRDAddons(t *testing.T) {
+	matcher := addon.NewMatcher([]addon.Addon{
+		{Name: "cert-manager", CRDGroups: []string{"cert-manager.io"}},
+		{Name: "Gateway API", CRDGroups: []string{"gateway.networking.k8s.io"}},
+		{Name: "KEDA", CRDGroups: []string{"keda.sh"}},
+		{Name: "Karpenter", CRDGroups: []string{"karpenter.sh"}},
+	})
+	crds := []cluster.CustomResourceDefinition{
+		{Name: "certificates.cert-manager.io", Group: "cert-manager.io", VersionHint: "v1.14.2"},
+		{Name: "challenges.acme.cert-manager.io", Group: "acme.cert-manager.io"},
+		{Name: "gateways.gateway.networking.k8s.io", Group: "gateway.networking.k8s.io", VersionHint: "v1.2.1"},
+		{Name: "scaledobjects.keda.sh", Group: "keda.sh", VersionHint: "2.14.0"},
+		{Name: "nodepools.karpenter.sh", Group: "karpenter.sh"},
+		{Name: "widgets.example.com", Group: "example.com", VersionHint: "1.0.0"},
+	}
+	detected := []cluster.DetectedAddon{
+		{Name: "cert-manager", Namespace: "cert-manager", Source: "deployment"},
+		{Name: "keda-operator", Namespace: "keda", Version: "2.13.1", Source: "deployment"},
+		{Name: "coredns", Namespace: "kube-system", Version: "1.11.1", Source: "deployment"},
+	}
+
+	got := mergeCRDAddons(detected, crds, matcher)
+	want := []cluster.DetectedAddon{
+		{Name: "cert-manager", Namespace: "cert-manager", Version: "v1.14.2", Source: "deployment"},
+		{Name: "keda-operator", Namespace: "keda", Version: "2.13.1", Source: "deployment"},
+		{Name: "coredns", Namespace: "kube-system", Version: "1.11.1", Source: "deployment"},
+		{Name: "Gateway API", Version: "v1.2.1", Source: "crd"},
+		{Name: "Karpenter", Source: "crd"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("mergeCRDAddons() =\n%+v\nwant\n%+v", got, want)
+	}
+
+	if got := mergeCRDAddons(nil, []cluster.CustomResourceDefinition{{Group: "example.com"}}, matcher); got != nil {
+		t.Fatalf("mergeCRDAddons() with no known groups = %+v, want nil", got)
+	}
+}
@@ -1,13 +1,22 @@
 package agent
 
 import (
+	"context"
 	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
 	"strings"
+	"sync/atomic"
 	"testing"
+	"time"
 	"unicode/utf8"
 
 	"github.com/qbandev/kaddons/internal/addon"
 	"github.com/qbandev/kaddons/internal/cluster"
+	"github.com/qbandev/kaddons/internal/extract"
+	"github.com/qbandev/kaddons/internal/fetch"
 	"github.com/qbandev/kaddons/internal/output"
 )
 
@@ -199,6 +208,67 @@ func TestResolveFromStoredData_FullMatrix_Compatible(t *testing.T) {
 	}
 }
 
+func TestResolveFromStoredData_CarriesProvenance(t *testing.T) {
+	provenance := &addon.Provenance{
+		SourceURL:    "https://cert-manager.io/docs/releases/",
+		TableLocator: "html table 1",
+		Strategy:     "labeled-column",
+		ExtractedAt:  "2026-10-18T00:00:00Z",
+		Confidence:   addon.ConfidenceHigh,
+	}
+	info := addonWithInfo{
+		DetectedAddon: cluster.DetectedAddon{
+			Name:      "cert-manager",
+			Namespace: "cert-manager",
+			Version:   "v1.15.0",
+		},
+		DBMatch: &addon.Addon{
+			Name:                    "cert-manager",
+			KubernetesCompatibility: map[string][]string{"1.15": {"1.30"}},
+			CompatibilityProvenance: provenance,
+		},
+	}
+
+	result := resolveFromStoredData(info, "1.30")
+	if result.Provenance != provenance {
+		t.Errorf("expected stored provenance to be carried into the result, got %+v", result.Provenance)
+	}
+}
+
+func TestResolveFromMinKubeVersion(t *testing.T) {
+	tests := []struct {
+		k8sVersion     string
+		minKubeVersion string
+		want           output.Status
+	}{
+		{k8sVersion: "1.30", minKubeVersion: "1.25.0", want: output.StatusTrue},
+		{k8sVersion: "1.27", minKubeVersion: "1.27.0", want: output.StatusTrue},
+		{k8sVersion: "1.26", minKubeVersion: "1.27.0", want: output.StatusFalse},
+	}
+	for _, tt := range tests {
+		info := addonWithInfo{
+			DetectedAddon: cluster.DetectedAddon{
+				Name:           "openshift-cert-manager-operator",
+				Namespace:      "cert-manager-operator",
+				Version:        "1.13.1",
+				Source:         "olm",
+				MinKubeVersion: tt.minKubeVersion,
+			},
+			DBMatch: &addon.Addon{Name: "cert-manager"},
+		}
+		result := resolveFromMinKubeVersion(info, tt.k8sVersion)
+		if result.Compatible != tt.want {
+			t.Errorf("K8s %s, minKubeVersion %s: compatible = %q, want %q", tt.k8sVersion, tt.minKubeVersion, result.Compatible, tt.want)
+		}
+		if result.DataSource != output.DataSourceOLM {
+			t.Errorf("data_source = %q, want %q", result.DataSource, output.DataSourceOLM)
+		}
+		if !strings.Contains(result.Note, "minKubeVersion "+tt.minKubeVersion) {
+			t.Errorf("note %q does not cite the minKubeVersion", result.Note)
+		}
+	}
+}
+
 func TestResolveFromStoredData_FullMatrix_Incompatible(t *testing.T) {
 	info := addonWithInfo{
 		DetectedAddon: cluster.DetectedAddon{
@@ -767,11 +837,11 @@ func TestTryExtractMatrix_MarkdownWithValidTable(t *testing.T) {
 		IsRawContent: true,
 	}
 
-	matrix := tryExtractMatrix(info)
-	if matrix == nil {
+	table := tryExtractMatrix(info)
+	if table == nil {
 		t.Fatal("expected non-nil matrix from Markdown content")
 	}
-	got, ok := matrix["v1.15.0"]
+	got, ok := table.Matrix["v1.15.0"]
 	if !ok {
 		t.Fatal("expected key v1.15.0 in matrix")
 	}
@@ -780,6 +850,34 @@ func TestTryExtractMatrix_MarkdownWithValidTable(t *testing.T) {
 	}
 }
 
+func TestTryExtractMatrix_AsciiDocByExtension(t *testing.T) {
+	info := addonWithInfo{
+		DetectedAddon: cluster.DetectedAddon{
+			Name:      "strimzi",
+			Namespace: "kafka",
+			Version:   "0.41.0",
+		},
+		CompatibilityURL: "https://github.com/strimzi/strimzi-kafka-operator/blob/main/documentation/compat.adoc",
+		RawContent: `[cols="1,1",options="header"]
+|===
+|Operator version |Kubernetes versions
+
+|0.41.0
+|1.25, 1.26, 1.27, 1.28, 1.29
+|===
+`,
+		IsRawContent: true,
+	}
+
+	table := tryExtractMatrix(info)
+	if table == nil {
+		t.Fatal("expected non-nil matrix from AsciiDoc content")
+	}
+	if got := table.Matrix["0.41.0"]; len(got) != 5 {
+		t.Errorf("0.41.0 supports %d versions, want 5: %v", len(got), got)
+	}
+}
+
 func TestTryExtractMatrix_HTMLWithValidTable(t *testing.T) {
 	info := addonWithInfo{
 		DetectedAddon: cluster.DetectedAddon{
@@ -797,11 +895,11 @@ func TestTryExtractMatrix_HTMLWithValidTable(t *testing.T) {
 		IsRawContent: false,
 	}
 
-	matrix := tryExtractMatrix(info)
-	if matrix == nil {
+	table := tryExtractMatrix(info)
+	if table == nil {
 		t.Fatal("expected non-nil matrix from HTML content")
 	}
-	got, ok := matrix["v1.10.0"]
+	got, ok := table.Matrix["v1.10.0"]
 	if !ok {
 		t.Fatal("expected key v1.10.0 in matrix")
 	}
@@ -821,9 +919,9 @@ func TestTryExtractMatrix_NoTable(t *testing.T) {
 		IsRawContent: true,
 	}
 
-	matrix := tryExtractMatrix(info)
-	if matrix != nil {
-		t.Errorf("expected nil matrix for content without tables, got %v", matrix)
+	table := tryExtractMatrix(info)
+	if table != nil {
+		t.Errorf("expected nil matrix for content without tables, got %v", table.Matrix)
 	}
 }
 
@@ -838,9 +936,9 @@ func TestTryExtractMatrix_EmptyContent(t *testing.T) {
 		IsRawContent: true,
 	}
 
-	matrix := tryExtractMatrix(info)
-	if matrix != nil {
-		t.Errorf("expected nil matrix for empty content, got %v", matrix)
+	table := tryExtractMatrix(info)
+	if table != nil {
+		t.Errorf("expected nil matrix for empty content, got %v", table.Matrix)
 	}
 }
 
@@ -1001,3 +1099,364 @@ func TestResolveFromExtractedMatrix_LatestCompatibleVersion(t *testing.T) {
 		t.Errorf("expected LatestCompatibleVersion=1.15, got %q", result.LatestCompatibleVersion)
 	}
 }
+
+func TestTryExtractProse_MarkdownSentence(t *testing.T) {
+	info := addonWithInfo{
+		DetectedAddon: cluster.DetectedAddon{
+			Name:      "cert-manager",
+			Namespace: "cert-manager",
+			Version:   "v1.14.2",
+		},
+		RawContent:   "cert-manager v1.14 supports Kubernetes 1.24 through 1.31.",
+		IsRawContent: true,
+	}
+
+	prose := tryExtractProse(info)
+	if prose == nil {
+		t.Fatal("expected non-nil prose result from Markdown content")
+	}
+	if got := prose.Matrix["v1.14"]; len(got) != 8 {
+		t.Errorf("v1.14 supports %d versions, want 8: %v", len(got), got)
+	}
+}
+
+func TestResolveFromExtractedCompatibility_MatrixUsesTextEvidence(t *testing.T) {
+	info := addonWithInfo{
+		DetectedAddon: cluster.DetectedAddon{
+			Name:      "cert-manager",
+			Namespace: "cert-manager",
+			Version:   "v1.14.2",
+		},
+		DBMatch:          &addon.Addon{Name: "cert-manager"},
+		CompatibilityURL: "https://cert-manager.io/docs/releases",
+		RawContent:       "cert-manager v1.14 supports Kubernetes 1.24 through 1.31.",
+		IsRawContent:     true,
+	}
+
+	result := resolveFromExtractedCompatibility(info, tryExtractProse(info), "1.30", "text")
+	if result == nil {
+		t.Fatal("expected non-nil result")
+	}
+	if result.Compatible != output.StatusTrue {
+		t.Errorf("expected StatusTrue, got %q", result.Compatible)
+	}
+	if result.DataSource != output.DataSourceExtracted {
+		t.Errorf("expected data_source=extracted, got %q", result.DataSource)
+	}
+	if !strings.Contains(result.Note, "per extracted text") {
+		t.Errorf("expected note to cite extracted text, got %q", result.Note)
+	}
+}
+
+func TestResolveFromExtractedCompatibility_MinVersionBounds(t *testing.T) {
+	info := addonWithInfo{
+		DetectedAddon: cluster.DetectedAddon{
+			Name:      "karpenter",
+			Namespace: "kube-system",
+			Version:   "0.37.0",
+		},
+		DBMatch:          &addon.Addon{Name: "karpenter"},
+		CompatibilityURL: "https://example.com/compat",
+		RawContent:       "Minimum Kubernetes version: 1.29",
+		IsRawContent:     true,
+	}
+	prose := tryExtractProse(info)
+
+	result := resolveFromExtractedCompatibility(info, prose, "1.28", "text")
+	if result == nil {
+		t.Fatal("expected non-nil result")
+	}
+	if result.Compatible != output.StatusFalse {
+		t.Errorf("expected StatusFalse below minimum, got %q", result.Compatible)
+	}
+	if !strings.Contains(result.Note, "minimum required 1.29") {
+		t.Errorf("expected note to mention minimum, got %q", result.Note)
+	}
+	if !strings.Contains(result.Note, "Source: https://example.com/compat") {
+		t.Errorf("expected note to contain source URL, got %q", result.Note)
+	}
+
+	result = resolveFromExtractedCompatibility(info, prose, "1.31", "text")
+	if result == nil || result.Compatible != output.StatusTrue {
+		t.Fatalf("expected StatusTrue above minimum, got %+v", result)
+	}
+}
+
+func TestResolveFromExtractedCompatibility_NoVerdict(t *testing.T) {
+	info := addonWithInfo{
+		DetectedAddon: cluster.DetectedAddon{
+			Name:      "cert-manager",
+			Namespace: "cert-manager",
+			Version:   "v9.9.9",
+		},
+		DBMatch: &addon.Addon{Name: "cert-manager"},
+	}
+	prose := &extract.Compatibility{Matrix: map[string][]string{"v1.14": {"1.30"}}}
+
+	if result := resolveFromExtractedCompatibility(info, prose, "1.30", "text"); result != nil {
+		t.Errorf("expected nil result when installed version is not covered, got %+v", result)
+	}
+}
+
+func TestTryExtractStructured_HelmChartKubeVersion(t *testing.T) {
+	info := addonWithInfo{
+		DetectedAddon: cluster.DetectedAddon{
+			Name:      "cert-manager",
+			Namespace: "cert-manager",
+			Version:   "v1.14.2",
+		},
+		DBMatch: &addon.Addon{
+			Name:                    "cert-manager",
+			CompatibilitySourceType: extract.SourceTypeHelmChart,
+		},
+		CompatibilityURL: "https://github.com/cert-manager/cert-manager/blob/master/deploy/charts/cert-manager/Chart.template.yaml",
+		RawContent:       "apiVersion: v2\nname: cert-manager\nkubeVersion: \">=1.25.0-0 <1.32.0-0\"\n",
+		IsRawContent:     true,
+	}
+
+	structured := tryExtractStructured(info)
+	if structured == nil {
+		t.Fatal("expected non-nil structured result")
+	}
+
+	kind := structuredEvidenceKind(info.DBMatch.CompatibilitySourceType)
+	result := resolveFromExtractedCompatibility(info, structured, "1.32", kind)
+	if result == nil {
+		t.Fatal("expected non-nil result")
+	}
+	if result.Compatible != output.StatusFalse {
+		t.Errorf("expected StatusFalse above kubeVersion ceiling, got %q", result.Compatible)
+	}
+	if !strings.Contains(result.Note, "per extracted Chart.yaml kubeVersion") {
+		t.Errorf("expected note to cite Chart.yaml kubeVersion, got %q", result.Note)
+	}
+
+	result = resolveFromExtractedCompatibility(info, structured, "1.30", kind)
+	if result == nil || result.Compatible != output.StatusTrue {
+		t.Fatalf("expected StatusTrue inside kubeVersion range, got %+v", result)
+	}
+}
+
+func TestTryExtractStructured_MalformedContent(t *testing.T) {
+	info := addonWithInfo{
+		DBMatch:    &addon.Addon{CompatibilitySourceType: extract.SourceTypeYAML},
+		RawContent: "<html><body>not yaml: [</body></html>",
+	}
+	if structured := tryExtractStructured(info); structured != nil {
+		t.Errorf("expected nil for malformed structured content, got %+v", structured)
+	}
+}
+
+func TestMinorVersionsBehind(t *testing.T) {
+	tests := []struct {
+		name      string
+		installed string
+		latest    string
+		want      int
+		wantOK    bool
+	}{
+		{name: "same minor", installed: "v1.14.2", latest: "1.14.5", want: 0, wantOK: true},
+		{name: "three minors behind", installed: "2.12.0", latest: "2.15.1", want: 3, wantOK: true},
+		{name: "installed ahead of stable", installed: "v1.16.0-rc.1", latest: "1.15.3", want: 0, wantOK: true},
+		{name: "major version differs", installed: "v1.14.2", latest: "2.0.0", wantOK: false},
+		{name: "installed major only", installed: "8", latest: "8.3.0", wantOK: false},
+		{name: "unparseable installed", installed: "latest", latest: "1.2.0", wantOK: false},
+		{name: "empty installed", installed: "", latest: "1.2.0", wantOK: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := minorVersionsBehind(tt.installed, tt.latest)
+			if ok != tt.wantOK || got != tt.want {
+				t.Errorf("minorVersionsBehind(%q, %q) = %d, %v; want %d, %v", tt.installed, tt.latest, got, ok, tt.want, tt.wantOK)
+			}
+		})
+	}
+}
+
+func TestResolveLocalOnly_UpstreamRelease(t *testing.T) {
+	addons := []addonWithInfo{
+		{
+			DetectedAddon: cluster.DetectedAddon{Name: "keda", Namespace: "keda", Version: "2.12.0"},
+			DBMatch:       &addon.Addon{Name: "KEDA"},
+			Upstream: &fetch.GitHubRelease{
+				Repository:  "kedacore/keda",
+				Tag:         "v2.15.1",
+				Version:     "2.15.1",
+				PublishedAt: time.Date(2024, 8, 1, 12, 0, 0, 0, time.UTC),
+			},
+		},
+	}
+
+	r := resolveLocalOnly(addons, "1.30")[0]
+	if !strings.Contains(r.Note, "Latest upstream release: v2.15.1") {
+		t.Errorf("expected note to mention upstream release, got %q", r.Note)
+	}
+	if r.LatestUpstreamVersion != "2.15.1" || r.LatestUpstreamReleaseDate != "2024-08-01" {
+		t.Errorf("unexpected upstream fields: %q %q", r.LatestUpstreamVersion, r.LatestUpstreamReleaseDate)
+	}
+	if r.MinorVersionsBehind == nil || *r.MinorVersionsBehind != 3 {
+		t.Errorf("minor_versions_behind = %v, want 3", r.MinorVersionsBehind)
+	}
+}
+
+func TestResolveLocalOnly_ChartVersionHasNoUpstreamLag(t *testing.T) {
+	// A HelmRelease reports kube-prometheus-stack chart 56.6.2, while the
+	// repository's releases are app versions.
+	addons := []addonWithInfo{
+		{
+			DetectedAddon: cluster.DetectedAddon{Name: "kube-prometheus-stack", Namespace: "monitoring", Version: "56.6.2", Source: "helmrelease", ChartVersion: true},
+			DBMatch:       &addon.Addon{Name: "kube-prometheus-stack"},
+			Upstream:      &fetch.GitHubRelease{Repository: "prometheus-operator/kube-prometheus", Tag: "v0.14.0", Version: "0.14.0"},
+		},
+		{
+			DetectedAddon: cluster.DetectedAddon{Name: "keda", Namespace: "keda", Version: "2.12.0", Source: "helmrelease", ChartVersion: true},
+			DBMatch:       &addon.Addon{Name: "KEDA"},
+			Upstream:      &fetch.GitHubRelease{Repository: "kedacore/keda", Tag: "v2.15.1", Version: "2.15.1"},
+		},
+	}
+
+	for _, r := range resolveLocalOnly(addons, "1.30") {
+		if r.LatestUpstreamVersion == "" {
+			t.Errorf("%s: latest_upstream_version missing", r.Name)
+		}
+		if r.MinorVersionsBehind != nil {
+			t.Errorf("%s: minor_versions_behind = %d, want unknown for a chart version", r.Name, *r.MinorVersionsBehind)
+		}
+	}
+}
+
+func TestResolveFromStoredData_CarriesUpstreamRelease(t *testing.T) {
+	info := addonWithInfo{
+		DetectedAddon: cluster.DetectedAddon{Name: "cert-manager", Namespace: "cert-manager", Version: "v1.14.2"},
+		DBMatch: &addon.Addon{
+			Name:                    "cert-manager",
+			KubernetesCompatibility: map[string][]string{"1.14": {"1.29", "1.30"}},
+		},
+		Upstream: &fetch.GitHubRelease{Repository: "cert-manager/cert-manager", Tag: "v1.16.1", Version: "1.16.1"},
+	}
+
+	result := resolveFromStoredData(info, "1.30")
+	if result.LatestUpstreamVersion != "1.16.1" {
+		t.Errorf("latest_upstream_version = %q, want 1.16.1", result.LatestUpstreamVersion)
+	}
+	if result.LatestUpstreamReleaseDate != "" {
+		t.Errorf("tag-only release should have no date, got %q", result.LatestUpstreamReleaseDate)
+	}
+	if result.MinorVersionsBehind == nil || *result.MinorVersionsBehind != 2 {
+		t.Errorf("minor_versions_behind = %v, want 2", result.MinorVersionsBehind)
+	}
+}
+
+func TestUpstreamReleaseCache_CachesAndStopsWhenRateLimited(t *testing.T) {
+	var requests atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		switch r.URL.Path {
+		case "/repos/acme/widget/releases":
+			_, _ = io.WriteString(w, `[{"tag_name":"v1.2.0","published_at":"2024-01-01T00:00:00Z"}]`)
+		default:
+			w.Header().Set("X-RateLimit-Remaining", "0")
+			w.Header().Set("X-RateLimit-Reset", strconv.FormatInt(time.Now().Add(time.Hour).Unix(), 10))
+			w.WriteHeader(http.StatusForbidden)
+		}
+	}))
+	defer server.Close()
+
+	lookup := NewUpstreamReleaseCache(&fetch.GitHubClient{HTTPClient: server.Client(), BaseURL: server.URL}, 0)
+	ctx := context.Background()
+
+	if got := lookup.latest(ctx, "widget", "https://github.com/acme/widget"); got == nil || got.Version != "1.2.0" {
+		t.Fatalf("latest(widget) = %+v, want 1.2.0", got)
+	}
+	if got := lookup.latest(ctx, "widget-webhook", "https://github.com/acme/widget"); got == nil {
+		t.Fatal("second addon from the same repository should reuse the cached release")
+	}
+	if got := lookup.latest(ctx, "gadget", "https://github.com/acme/gadget"); got != nil {
+		t.Fatalf("rate-limited lookup = %+v, want nil", got)
+	}
+	if got := lookup.latest(ctx, "gizmo", "https://github.com/acme/gizmo"); got != nil {
+		t.Fatalf("lookup after rate limit = %+v, want nil", got)
+	}
+	if got := lookup.latest(ctx, "gitlab-runner", "https://gitlab.com/gitlab-org/gitlab-runner"); got != nil {
+		t.Fatalf("non-GitHub repository = %+v, want nil", got)
+	}
+	if got := requests.Load(); got != 2 {
+		t.Errorf("server saw %d requests, want 2", got)
+	}
+}
+
+func TestResolve_UpstreamLookups(t *testing.T) {
+	var requests atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		_, _ = io.WriteString(w, `[{"tag_name":"v2.0.0","published_at":"2024-01-01T00:00:00Z"}]`)
+	}))
+	defer server.Close()
+
+	var dbAddons []addon.Addon
+	var detected []cluster.DetectedAddon
+	for _, name := range []string{"widget", "gadget", "gizmo", "sprocket", "flange", "grommet"} {
+		dbAddons = append(dbAddons, addon.Addon{
+			Name:                    name,
+			Repository:              "https://github.com/acme/" + name,
+			KubernetesCompatibility: map[string][]string{"1.0": {"1.30"}},
+		})
+		detected = append(detected, cluster.DetectedAddon{Name: name, Namespace: "default", Version: "1.0.0"})
+	}
+	matcher := addon.NewMatcher(dbAddons)
+
+	tests := []struct {
+		name         string
+		skipUpstream bool
+		wantRequests int32
+		wantLatest   string
+	}{
+		{name: "lookups for every addon", wantRequests: 6, wantLatest: "2.0.0"},
+		{name: "skip upstream", skipUpstream: true, wantRequests: 0, wantLatest: ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			requests.Store(0)
+			opts := Options{
+				SkipUpstream: tt.skipUpstream,
+				Upstream:     NewUpstreamReleaseCache(&fetch.GitHubClient{HTTPClient: server.Client(), BaseURL: server.URL}, 0),
+			}
+			results, err := resolve(context.Background(), opts, matcher, "1.30", detected)
+			if err != nil {
+				t.Fatalf("resolve() error = %v", err)
+			}
+			if len(results) != len(detected) {
+				t.Fatalf("resolve() returned %d results, want %d", len(results), len(detected))
+			}
+			for _, r := range results {
+				if r.LatestUpstreamVersion != tt.wantLatest {
+					t.Errorf("%s latest_upstream_version = %q, want %q", r.Name, r.LatestUpstreamVersion, tt.wantLatest)
+				}
+			}
+			if got := requests.Load(); got != tt.wantRequests {
+				t.Errorf("server saw %d requests, want %d", got, tt.wantRequests)
+			}
+		})
+	}
+}
+
+func TestClusterSupport_OfflineUsesProviderSnapshot(t *testing.T) {
+	tests := []struct {
+		distribution string
+		want         string
+	}{
+		{cluster.DistributionGKE, "google-kubernetes-engine"},
+		{cluster.DistributionAKS, "azure-kubernetes-service"},
+		{cluster.DistributionEKS, "amazon-eks"},
+		{cluster.DistributionK3s, "kubernetes"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.distribution, func(t *testing.T) {
+			// SkipEOL makes no fetch, as when endoflife.date is unreachable.
+			support := clusterSupport(context.Background(), Options{SkipEOL: true}, "v1.31", tt.distribution)
+			if support.Product != tt.want || support.Status == output.SupportUnknown {
+				t.Errorf("clusterSupport(%s) = %s %s, want a verdict from %s", tt.distribution, support.Product, support.Status, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,486 @@
+package cluster
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+
+	"github.com/qbandev/kaddons/internal/output"
+)
+
+// Cluster-scoped resources Preflight checks besides those of discovery.
+const (
+	ResourceCustomResourceDefinitions = "customresourcedefinitions.apiextensions.k8s.io"
+	ResourceNodes                     = "nodes"
+)
+
+var (
+	crdResource  = discoveryResource{"apiextensions.k8s.io", "customresourcedefinitions"}
+	nodeResource = discoveryResource{"", "nodes"}
+
+	preflightClusterResources = []discoveryResource{crdResource, nodeResource}
+)
+
+var namespacesResource = discoveryResource{"", "namespaces"}
+
+// accessReview is a list permission: on a resource in a namespace, or across
+// all namespaces (and for cluster-scoped resources) when namespace is empty.
+type accessReview struct {
+	resource  discoveryResource
+	namespace string
+}
+
+// Access is what the current identity may list, as found by Preflight. A nil
+// *Access allows everything.
+type Access struct {
+	// served maps the listable resources the API server serves to their API
+	// version; nil when api-resources failed and every resource is assumed
+	// served.
+	served map[string]string
+	// allowed holds the result of every permission checked.
+	allowed map[accessReview]bool
+	// reasons holds the authorizer's reason for denied permissions, if any.
+	reasons map[accessReview]string
+	// namespaces are where resources denied across all namespaces were
+	// checked one namespace at a time.
+	namespaces []string
+	skipped    []output.SkippedScope
+}
+
+// Preflight checks which discovery resources the current identity may list,
+// in namespace or, when it is empty, across all namespaces. One batch of
+// SelfSubjectAccessReviews checks every resource; resources denied across
+// all namespaces are then checked per namespace with SelfSubjectRulesReviews.
+// Those namespaces are all namespaces when they can be listed, else the
+// kubeconfig context's namespace.
+func Preflight(ctx context.Context, namespace string) (*Access, error) {
+	access := &Access{allowed: make(map[accessReview]bool), reasons: make(map[accessReview]string)}
+	if out, err := runKubectlCommandWithRetry(ctx, apiResourcesArgs...); err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: could not list API resources for the RBAC preflight: %v\n", err)
+	} else {
+		access.served = parseAPIResources(out)
+	}
+
+	var reviews []accessReview
+	for _, r := range discoveryResources() {
+		if access.serves(r) {
+			reviews = append(reviews, accessReview{r, namespace})
+		}
+	}
+	for _, r := range preflightClusterResources {
+		reviews = append(reviews, accessReview{r, ""})
+	}
+	if namespace == "" {
+		reviews = append(reviews, accessReview{namespacesResource, ""})
+	}
+	out, err := runKubectlWithInput(ctx, accessReviewManifest(reviews), "create", "--filename=-", "--output=json")
+	if err != nil {
+		return nil, fmt.Errorf("creating SelfSubjectAccessReviews: %w", err)
+	}
+	results, err := parseAccessReviews(out)
+	if err != nil {
+		return nil, err
+	}
+	for review, result := range results {
+		access.allowed[review] = result.allowed
+		if !result.allowed && result.reason != "" {
+			access.reasons[review] = result.reason
+		}
+	}
+
+	if namespace == "" && len(access.deniedClusterWide()) > 0 {
+		access.namespaces = candidateNamespaces(ctx, access.allowed[accessReview{namespacesResource, ""}])
+		out, err := runKubectlWithInput(ctx, rulesReviewManifest(access.namespaces), "create", "--filename=-", "--output=json")
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: creating SelfSubjectRulesReviews failed: %v\n", err)
+		} else if rules, err := parseRulesReviews(out); err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: %v\n", err)
+		} else {
+			for _, r := range access.deniedClusterWide() {
+				for _, ns := range access.namespaces {
+					if review, ok := rules[ns]; ok {
+						access.allowed[accessReview{r, ns}] = review.allows(r)
+					}
+				}
+			}
+		}
+	}
+	access.skipped = access.skippedScopes(namespace)
+	return access, nil
+}
+
+// CanList reports whether a cluster-scoped resource, such as
+// ResourceCustomResourceDefinitions, may be listed.
+func (a *Access) CanList(resource string) bool {
+	if a == nil {
+		return true
+	}
+	for review, allowed := range a.allowed {
+		if review.namespace == "" && review.resource.String() == resource {
+			return allowed
+		}
+	}
+	return true
+}
+
+// SkippedScopes returns the scopes discovery will not read, with the reason.
+func (a *Access) SkippedScopes() []output.SkippedScope {
+	if a == nil {
+		return nil
+	}
+	return a.skipped
+}
+
+func (a *Access) serves(r discoveryResource) bool {
+	if a.served == nil {
+		return true
+	}
+	_, ok := a.served[r.String()]
+	return ok
+}
+
+// groupVersions returns the API version of each served resource, nil when
+// unknown.
+func (a *Access) groupVersions() map[string]string {
+	if a == nil {
+		return nil
+	}
+	return a.served
+}
+
+// may reports whether r may be listed in namespace, or across all namespaces
+// when namespace is empty. Access across all namespaces covers each of them.
+func (a *Access) may(r discoveryResource, namespace string) bool {
+	return a.allowed[accessReview{r, ""}] || (namespace != "" && a.allowed[accessReview{r, namespace}])
+}
+
+// deniedClusterWide returns the served discovery resources that may not be
+// listed across all namespaces.
+func (a *Access) deniedClusterWide() []discoveryResource {
+	var denied []discoveryResource
+	for _, r := range discoveryResources() {
+		if a.serves(r) && !a.may(r, "") {
+			denied = append(denied, r)
+		}
+	}
+	return denied
+}
+
+// scanTarget is one listing of discovery: resources listed in a namespace,
+// or across all namespaces when namespace is empty.
+type scanTarget struct {
+	resources []discoveryResource
+	namespace string
+}
+
+// String returns the comma-separated resource names, as kubectl takes them.
+func (t scanTarget) String() string {
+	names := make([]string, 0, len(t.resources))
+	for _, r := range t.resources {
+		names = append(names, r.String())
+	}
+	return strings.Join(names, ",")
+}
+
+// targets plans the listings of a query's resources. The first resource
+// must be listable; the others are included where they are. A resource that
+// cannot be listed across all namespaces is listed in each namespace it can.
+func (a *Access) targets(resources []discoveryResource, namespace string) []scanTarget {
+	if a == nil {
+		return []scanTarget{{resources: resources, namespace: namespace}}
+	}
+	primary := resources[0]
+	if !a.serves(primary) {
+		return nil
+	}
+	target := func(ns string) scanTarget {
+		listed := []discoveryResource{primary}
+		for _, r := range resources[1:] {
+			if a.serves(r) && a.may(r, ns) {
+				listed = append(listed, r)
+			}
+		}
+		return scanTarget{resources: listed, namespace: ns}
+	}
+	if a.may(primary, namespace) {
+		return []scanTarget{target(namespace)}
+	}
+	if namespace != "" {
+		return nil
+	}
+	var targets []scanTarget
+	for _, ns := range a.namespaces {
+		if a.may(primary, ns) {
+			targets = append(targets, target(ns))
+		}
+	}
+	return targets
+}
+
+// skippedScopes lists what discovery will not read: served discovery
+// resources that cannot be listed where asked, and denied cluster-scoped
+// resources. A resource readable in only some namespaces is one entry
+// naming them.
+func (a *Access) skippedScopes(namespace string) []output.SkippedScope {
+	var skipped []output.SkippedScope
+	for _, r := range discoveryResources() {
+		if !a.serves(r) || a.may(r, namespace) {
+			continue
+		}
+		reason := a.denial(accessReview{r, namespace})
+		if namespace == "" {
+			var readable []string
+			for _, ns := range a.namespaces {
+				if a.may(r, ns) {
+					readable = append(readable, ns)
+				}
+			}
+			if len(readable) > 0 {
+				reason += "; listed only in " + strings.Join(readable, ", ")
+			}
+		}
+		skipped = append(skipped, output.SkippedScope{Resource: r.String(), Namespace: namespace, Reason: reason})
+	}
+	for _, r := range preflightClusterResources {
+		review := accessReview{r, ""}
+		if allowed, checked := a.allowed[review]; checked && !allowed && (namespace == "" || r.String() != ResourceCustomResourceDefinitions) {
+			skipped = append(skipped, output.SkippedScope{Resource: r.String(), Reason: a.denial(review)})
+		}
+	}
+	return skipped
+}
+
+func (a *Access) denial(review accessReview) string {
+	reason := "list is forbidden"
+	if review.namespace == "" && slices.Contains(discoveryResources(), review.resource) {
+		reason = "list across all namespaces is forbidden"
+	}
+	if detail := a.reasons[review]; detail != "" {
+		reason += ": " + detail
+	}
+	return reason
+}
+
+// discoveryResources returns every resource discovery lists, in query order.
+func discoveryResources() []discoveryResource {
+	var resources []discoveryResource
+	for _, q := range discoveryQueries {
+		resources = append(resources, q.resources...)
+	}
+	return resources
+}
+
+// candidateNamespaces returns the namespaces to check one at a time: all of
+// them when they may be listed, else the kubeconfig context's namespace.
+func candidateNamespaces(ctx context.Context, canListNamespaces bool) []string {
+	if canListNamespaces {
+		out, err := runKubectlCommandWithRetry(ctx, "get", "namespaces", "--output=name")
+		if err == nil {
+			var namespaces []string
+			for _, name := range parseNames(out) {
+				namespaces = append(namespaces, strings.TrimPrefix(name, "namespace/"))
+			}
+			return namespaces
+		}
+		fmt.Fprintf(os.Stderr, "Warning: could not list namespaces for the RBAC preflight: %v\n", err)
+	}
+	if current, err := CurrentContext(ctx); err == nil && current.Namespace != "" {
+		return []string{current.Namespace}
+	}
+	return []string{"default"}
+}
+
+// apiResourcesArgs lists the served resources that support list, one per
+// line as NAME SHORTNAMES APIVERSION NAMESPACED KIND.
+var apiResourcesArgs = []string{"api-resources", "--verbs=list", "--no-headers"}
+
+// parseAPIResources maps each resource of kubectl api-resources output, named
+// resource.group, to its API version. Short names may be blank, so columns
+// are read from the end of the line.
+func parseAPIResources(data []byte) map[string]string {
+	served := make(map[string]string)
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) < 4 {
+			continue
+		}
+		groupVersion := fields[len(fields)-3]
+		group, _, found := strings.Cut(groupVersion, "/")
+		if !found {
+			group = ""
+		}
+		served[discoveryResource{group, fields[0]}.String()] = groupVersion
+	}
+	return served
+}
+
+// parseNames reads kubectl --output=name output, one name per line.
+func parseNames(data []byte) []string {
+	var names []string
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		if name := strings.TrimSpace(scanner.Text()); name != "" {
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+// accessReviewManifest is a List of SelfSubjectAccessReviews asking whether
+// each resource may be listed.
+func accessReviewManifest(reviews []accessReview) []byte {
+	items := make([]map[string]any, 0, len(reviews))
+	for _, review := range reviews {
+		items = append(items, map[string]any{
+			"apiVersion": "authorization.k8s.io/v1",
+			"kind":       "SelfSubjectAccessReview",
+			"spec": map[string]any{
+				"resourceAttributes": map[string]any{
+					"verb":      "list",
+					"group":     review.resource.Group,
+					"resource":  review.resource.Resource,
+					"namespace": review.namespace,
+				},
+			},
+		})
+	}
+	return listManifest(items)
+}
+
+// rulesReviewManifest is a List of SelfSubjectRulesReviews, one per namespace.
+func rulesReviewManifest(namespaces []string) []byte {
+	items := make([]map[string]any, 0, len(namespaces))
+	for _, ns := range namespaces {
+		items = append(items, map[string]any{
+			"apiVersion": "authorization.k8s.io/v1",
+			"kind":       "SelfSubjectRulesReview",
+			"spec":       map[string]any{"namespace": ns},
+		})
+	}
+	return listManifest(items)
+}
+
+func listManifest(items []map[string]any) []byte {
+	// Marshaling maps of strings cannot fail.
+	manifest, _ := json.Marshal(map[string]any{"apiVersion": "v1", "kind": "List", "items": items})
+	return manifest
+}
+
+// listItems returns the items of a kubectl List, or the object itself when
+// kubectl printed a single object.
+func listItems(data []byte) ([]json.RawMessage, error) {
+	var list struct {
+		Kind  string            `json:"kind"`
+		Items []json.RawMessage `json:"items"`
+	}
+	if err := json.Unmarshal(data, &list); err != nil {
+		return nil, err
+	}
+	if list.Kind == "List" {
+		return list.Items, nil
+	}
+	return []json.RawMessage{data}, nil
+}
+
+type accessResult struct {
+	allowed bool
+	reason  string
+}
+
+// parseAccessReviews reads created SelfSubjectAccessReviews, matching them
+// to the permissions asked by their spec rather than their order.
+func parseAccessReviews(data []byte) (map[accessReview]accessResult, error) {
+	items, err := listItems(data)
+	if err != nil {
+		return nil, fmt.Errorf("parsing SelfSubjectAccessReviews: %w", err)
+	}
+	results := make(map[accessReview]accessResult, len(items))
+	for _, item := range items {
+		var ssar struct {
+			Spec struct {
+				ResourceAttributes struct {
+					Namespace string `json:"namespace"`
+					Group     string `json:"group"`
+					Resource  string `json:"resource"`
+				} `json:"resourceAttributes"`
+			} `json:"spec"`
+			Status struct {
+				Allowed bool   `json:"allowed"`
+				Reason  string `json:"reason"`
+			} `json:"status"`
+		}
+		if err := json.Unmarshal(item, &ssar); err != nil {
+			return nil, fmt.Errorf("parsing SelfSubjectAccessReview: %w", err)
+		}
+		attributes := ssar.Spec.ResourceAttributes
+		review := accessReview{discoveryResource{attributes.Group, attributes.Resource}, attributes.Namespace}
+		results[review] = accessResult{allowed: ssar.Status.Allowed, reason: ssar.Status.Reason}
+	}
+	return results, nil
+}
+
+// resourceRule is a rule of a SelfSubjectRulesReview.
+type resourceRule struct {
+	Verbs         []string `json:"verbs"`
+	APIGroups     []string `json:"apiGroups"`
+	Resources     []string `json:"resources"`
+	ResourceNames []string `json:"resourceNames"`
+}
+
+// rulesReview is the rules a SelfSubjectRulesReview returned for a namespace.
+type rulesReview struct {
+	rules []resourceRule
+	// incomplete is set when an authorizer cannot enumerate its rules, as
+	// webhook authorizers may not.
+	incomplete bool
+}
+
+// allows reports whether the rules grant list on r. Rules an authorizer did
+// not enumerate may still grant it, so an incomplete review allows it and
+// the kubectl get decides.
+func (r rulesReview) allows(resource discoveryResource) bool {
+	if r.incomplete {
+		return true
+	}
+	matches := func(values []string, want string) bool {
+		return slices.Contains(values, "*") || slices.Contains(values, want)
+	}
+	for _, rule := range r.rules {
+		if len(rule.ResourceNames) == 0 && matches(rule.Verbs, "list") &&
+			matches(rule.APIGroups, resource.Group) && matches(rule.Resources, resource.Resource) {
+			return true
+		}
+	}
+	return false
+}
+
+// parseRulesReviews reads created SelfSubjectRulesReviews by namespace.
+func parseRulesReviews(data []byte) (map[string]rulesReview, error) {
+	items, err := listItems(data)
+	if err != nil {
+		return nil, fmt.Errorf("parsing SelfSubjectRulesReviews: %w", err)
+	}
+	reviews := make(map[string]rulesReview, len(items))
+	for _, item := range items {
+		var ssrr struct {
+			Spec struct {
+				Namespace string `json:"namespace"`
+			} `json:"spec"`
+			Status struct {
+				ResourceRules []resourceRule `json:"resourceRules"`
+				Incomplete    bool           `json:"incomplete"`
+			} `json:"status"`
+		}
+		if err := json.Unmarshal(item, &ssrr); err != nil {
+			return nil, fmt.Errorf("parsing SelfSubjectRulesReview: %w", err)
+		}
+		reviews[ssrr.Spec.Namespace] = rulesReview{rules: ssrr.Status.ResourceRules, incomplete: ssrr.Status.Incomplete}
+	}
+	return reviews, nil
+}
@@ -0,0 +1,193 @@
+package cluster
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/qbandev/kaddons/internal/output"
+)
+
+var (
+	csvResource          = discoveryResource{"operators.coreos.com", "clusterserviceversions"}
+	subscriptionResource = discoveryResource{"operators.coreos.com", "subscriptions"}
+	deploymentResource   = discoveryResource{"apps", "deployments"}
+	daemonSetResource    = discoveryResource{"apps", "daemonsets"}
+	statefulSetResource  = discoveryResource{"apps", "statefulsets"}
+)
+
+func TestParseAccessReviews(t *testing.T) {
+	list := `{"apiVersion": "v1", "kind": "List", "items": [
+		{
+			"kind": "SelfSubjectAccessReview",
+			"spec": {"resourceAttributes": {"verb": "list", "group": "apps", "resource": "deployments"}},
+			"status": {"allowed": true, "reason": "RBAC: allowed by ClusterRoleBinding \"viewers\""}
+		},
+		{
+			"kind": "SelfSubjectAccessReview",
+			"spec": {"resourceAttributes": {"verb": "list", "resource": "nodes"}},
+			"status": {"allowed": false}
+		}
+	]}`
+	got, err := parseAccessReviews([]byte(list))
+	if err != nil {
+		t.Fatalf("parseAccessReviews() error = %v", err)
+	}
+	want := map[accessReview]accessResult{
+		{deploymentResource, ""}: {allowed: true, reason: `RBAC: allowed by ClusterRoleBinding "viewers"`},
+		{nodeResource, ""}:       {allowed: false},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("parseAccessReviews() = %+v, want %+v", got, want)
+	}
+
+	single := `{"kind": "SelfSubjectAccessReview", "spec": {"resourceAttributes": {"group": "apps", "resource": "daemonsets", "namespace": "team-a"}}, "status": {"allowed": true}}`
+	got, err = parseAccessReviews([]byte(single))
+	if err != nil {
+		t.Fatalf("parseAccessReviews(single) error = %v", err)
+	}
+	if !got[accessReview{daemonSetResource, "team-a"}].allowed || len(got) != 1 {
+		t.Errorf("parseAccessReviews(single) = %+v", got)
+	}
+
+	if _, err := parseAccessReviews([]byte("{")); err == nil {
+		t.Error("parseAccessReviews() on invalid JSON: want error")
+	}
+}
+
+func TestAccessReviewManifest(t *testing.T) {
+	manifest := accessReviewManifest([]accessReview{{deploymentResource, "team-a"}, {nodeResource, ""}})
+	items, err := listItems(manifest)
+	if err != nil || len(items) != 2 {
+		t.Fatalf("listItems(manifest) = %d items, %v; want 2", len(items), err)
+	}
+	// A review echoes its spec, so the manifest parses back to the same keys.
+	got, err := parseAccessReviews(manifest)
+	if err != nil {
+		t.Fatalf("parseAccessReviews(manifest) error = %v", err)
+	}
+	for _, review := range []accessReview{{deploymentResource, "team-a"}, {nodeResource, ""}} {
+		if _, ok := got[review]; !ok {
+			t.Errorf("manifest is missing a review of %s in %q", review.resource, review.namespace)
+		}
+	}
+}
+
+func TestParseRulesReviews(t *testing.T) {
+	data := `{"kind": "List", "items": [
+		{
+			"kind": "SelfSubjectRulesReview",
+			"spec": {"namespace": "team-a"},
+			"status": {"resourceRules": [
+				{"verbs": ["get", "list", "watch"], "apiGroups": ["apps"], "resources": ["deployments", "statefulsets"]},
+				{"verbs": ["*"], "apiGroups": ["operators.coreos.com"], "resources": ["*"]},
+				{"verbs": ["list"], "apiGroups": ["apps"], "resources": ["daemonsets"], "resourceNames": ["kube-proxy"]}
+			], "incomplete": false}
+		},
+		{"kind": "SelfSubjectRulesReview", "spec": {"namespace": "team-b"}, "status": {"incomplete": true}}
+	]}`
+	reviews, err := parseRulesReviews([]byte(data))
+	if err != nil {
+		t.Fatalf("parseRulesReviews() error = %v", err)
+	}
+	tests := []struct {
+		namespace string
+		resource  discoveryResource
+		want      bool
+	}{
+		{"team-a", deploymentResource, true},
+		{"team-a", statefulSetResource, true},
+		{"team-a", subscriptionResource, true},
+		{"team-a", daemonSetResource, false},
+		{"team-a", nodeResource, false},
+		{"team-b", deploymentResource, true},
+	}
+	for _, tt := range tests {
+		if got := reviews[tt.namespace].allows(tt.resource); got != tt.want {
+			t.Errorf("allows(%s in %s) = %v, want %v", tt.resource, tt.namespace, got, tt.want)
+		}
+	}
+}
+
+func TestParseAPIResources(t *testing.T) {
+	data := `bindings                                       v1                                true         Binding
+namespaces                        ns           v1                                false        Namespace
+deployments                       deploy       apps/v1                           true         Deployment
+clusterserviceversions            csv,csvs     operators.coreos.com/v1alpha1     true         ClusterServiceVersion
+operatorconditions                condition    operators.coreos.com/v2           true         OperatorCondition
+`
+	want := map[string]string{
+		"bindings":           "v1",
+		"namespaces":         "v1",
+		"deployments.apps":   "apps/v1",
+		csvResource.String(): "operators.coreos.com/v1alpha1",
+		"operatorconditions.operators.coreos.com": "operators.coreos.com/v2",
+	}
+	if got := parseAPIResources([]byte(data)); !reflect.DeepEqual(got, want) {
+		t.Errorf("parseAPIResources() = %v, want %v", got, want)
+	}
+}
+
+func TestAccess_TargetsAndSkippedScopes(t *testing.T) {
+	access := &Access{
+		served: map[string]string{
+			csvResource.String(): "operators.coreos.com/v1alpha1", subscriptionResource.String(): "operators.coreos.com/v1alpha1",
+			deploymentResource.String(): "apps/v1", daemonSetResource.String(): "apps/v1", statefulSetResource.String(): "apps/v1",
+		},
+		allowed: map[accessReview]bool{
+			{csvResource, ""}:                true,
+			{subscriptionResource, ""}:       false,
+			{subscriptionResource, "team-a"}: true,
+			{deploymentResource, ""}:         false,
+			{deploymentResource, "team-a"}:   true,
+			{deploymentResource, "team-b"}:   false,
+			{daemonSetResource, ""}:          true,
+			{statefulSetResource, ""}:        false,
+			{crdResource, ""}:                true,
+			{nodeResource, ""}:               false,
+		},
+		reasons:    map[accessReview]string{{nodeResource, ""}: "no RBAC policy matched"},
+		namespaces: []string{"team-a", "team-b"},
+	}
+
+	targetTests := []struct {
+		name      string
+		resources []discoveryResource
+		namespace string
+		want      []scanTarget
+	}{
+		{"secondary resource dropped", []discoveryResource{csvResource, subscriptionResource}, "", []scanTarget{{[]discoveryResource{csvResource}, ""}}},
+		{"readable namespaces only", []discoveryResource{deploymentResource}, "", []scanTarget{{[]discoveryResource{deploymentResource}, "team-a"}}},
+		{"cluster-wide", []discoveryResource{daemonSetResource}, "", []scanTarget{{[]discoveryResource{daemonSetResource}, ""}}},
+		{"cluster-wide covers a namespace", []discoveryResource{daemonSetResource}, "team-b", []scanTarget{{[]discoveryResource{daemonSetResource}, "team-b"}}},
+		{"denied everywhere", []discoveryResource{statefulSetResource}, "", nil},
+		{"not served", []discoveryResource{{"argoproj.io", "applications"}}, "", nil},
+	}
+	for _, tt := range targetTests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := access.targets(tt.resources, tt.namespace); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("targets() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+
+	var none *Access
+	if got := none.targets([]discoveryResource{csvResource, subscriptionResource}, "team-a"); !reflect.DeepEqual(got, []scanTarget{{[]discoveryResource{csvResource, subscriptionResource}, "team-a"}}) {
+		t.Errorf("nil Access targets() = %+v, want both resources in team-a", got)
+	}
+	if !none.CanList(ResourceNodes) || none.SkippedScopes() != nil {
+		t.Error("a nil Access must allow everything and skip nothing")
+	}
+	if !access.CanList(ResourceCustomResourceDefinitions) || access.CanList(ResourceNodes) {
+		t.Error("CanList() does not follow the cluster-scoped reviews")
+	}
+
+	want := []output.SkippedScope{
+		{Resource: "subscriptions.operators.coreos.com", Reason: "list across all namespaces is forbidden; listed only in team-a"},
+		{Resource: "deployments.apps", Reason: "list across all namespaces is forbidden; listed only in team-a"},
+		{Resource: "statefulsets.apps", Reason: "list across all namespaces is forbidden"},
+		{Resource: "nodes", Reason: "list is forbidden: no RBAC policy matched"},
+	}
+	if got := access.skippedScopes(""); !reflect.DeepEqual(got, want) {
+		t.Errorf("skippedScopes() =\n%+v\nwant\n%+v", got, want)
+	}
+}
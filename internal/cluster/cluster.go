@@ -4,12 +4,17 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"net"
+	"net/url"
 	"os"
 	"os/exec"
 	"strings"
+	"sync"
 	"time"
 
+	"github.com/qbandev/kaddons/internal/output"
 	"github.com/qbandev/kaddons/internal/resilience"
 )
 
@@ -19,144 +24,314 @@ type DetectedAddon struct {
 	Namespace string `json:"namespace"`
 	Version   string `json:"version"`
 	Source    string `json:"source"`
+	// Images are the container images a GitOps tool reports as deployed.
+	Images []string `json:"images,omitempty"`
+	// MinKubeVersion is the lowest Kubernetes version an OLM operator's
+	// ClusterServiceVersion declares support for.
+	MinKubeVersion string `json:"min_kube_version,omitempty"`
+	// ChartVersion marks a Version read from a Helm chart reference, which
+	// is versioned independently of the addon's own releases.
+	ChartVersion bool `json:"chart_version,omitempty"`
 }
 
-// GetClusterVersion runs kubectl version and returns major.minor string.
-func GetClusterVersion(ctx context.Context) (string, error) {
+// GetServerVersion runs kubectl version and returns the API server's version.
+func GetServerVersion(ctx context.Context) (ServerVersion, error) {
 	out, err := runKubectlCommandWithRetry(ctx, "version", "--output=json")
 	if err != nil {
-		return "", fmt.Errorf("kubectl version failed: %w", err)
+		return ServerVersion{}, fmt.Errorf("kubectl version failed: %w", err)
 	}
+	return parseServerVersion(out)
+}
+
+// Context is the kubeconfig context kubectl talks to.
+type Context struct {
+	Name   string
+	Server string
+	// Namespace is the context's default namespace, empty when unset.
+	Namespace string
+}
 
-	var ver struct {
-		ServerVersion struct {
-			Major string `json:"major"`
-			Minor string `json:"minor"`
-		} `json:"serverVersion"`
+// CurrentContext returns the current kubeconfig context and its API server
+// URL, without credentials.
+func CurrentContext(ctx context.Context) (Context, error) {
+	out, err := runKubectlCommandWithRetry(ctx, "config", "view", "--minify", "--output=json")
+	if err != nil {
+		return Context{}, fmt.Errorf("kubectl config view failed: %w", err)
 	}
-	if err := json.Unmarshal(out, &ver); err != nil {
-		return "", fmt.Errorf("parsing kubectl version output: %w", err)
+	current, err := parseKubeconfigContext(out)
+	if err != nil {
+		return Context{}, err
 	}
+	if current.Name == "" {
+		// kubectl falls back to the pod's service account when there is no
+		// kubeconfig, as in the CronJob from deploy/.
+		if host := os.Getenv("KUBERNETES_SERVICE_HOST"); host != "" {
+			return inClusterContext(host, os.Getenv("KUBERNETES_SERVICE_PORT")), nil
+		}
+	}
+	return current, nil
+}
 
-	minor := strings.TrimRight(ver.ServerVersion.Minor, "+")
-	return fmt.Sprintf("%s.%s", ver.ServerVersion.Major, minor), nil
+// inClusterContext describes the API server a pod reaches through its
+// service account.
+func inClusterContext(host, port string) Context {
+	if port == "" {
+		port = "443"
+	}
+	return Context{Name: "in-cluster", Server: "https://" + net.JoinHostPort(host, port)}
 }
 
-// ListInstalledAddons discovers addons from the cluster deterministically.
-func ListInstalledAddons(ctx context.Context, namespace string) ([]DetectedAddon, error) {
-	var nsFlag []string
-	if namespace != "" {
-		nsFlag = []string{"-n", namespace}
-	} else {
-		nsFlag = []string{"--all-namespaces"}
+func parseKubeconfigContext(data []byte) (Context, error) {
+	var config struct {
+		CurrentContext string `json:"current-context"`
+		Clusters       []struct {
+			Cluster struct {
+				Server string `json:"server"`
+			} `json:"cluster"`
+		} `json:"clusters"`
+		Contexts []struct {
+			Context struct {
+				Namespace string `json:"namespace"`
+			} `json:"context"`
+		} `json:"contexts"`
+	}
+	if err := json.Unmarshal(data, &config); err != nil {
+		return Context{}, fmt.Errorf("parsing kubectl config output: %w", err)
+	}
+	current := Context{Name: config.CurrentContext}
+	if len(config.Clusters) > 0 {
+		current.Server = redactServerURL(config.Clusters[0].Cluster.Server)
+	}
+	if len(config.Contexts) > 0 {
+		current.Namespace = config.Contexts[0].Context.Namespace
 	}
+	return current, nil
+}
 
-	type resourceQuery struct {
-		resource string
-		source   string
-		isCRD    bool
+// redactServerURL drops any user info from a server URL so reports never
+// carry credentials.
+func redactServerURL(server string) string {
+	parsed, err := url.Parse(server)
+	if err != nil || parsed.User == nil {
+		return server
 	}
+	parsed.User = nil
+	return parsed.String()
+}
+
+// discoveryResource is a resource discovery lists, named as RBAC names it.
+type discoveryResource struct {
+	Group    string
+	Resource string
+}
 
-	queries := []resourceQuery{
-		{"deployments", "deployment", false},
-		{"daemonsets", "daemonset", false},
-		{"statefulsets", "statefulset", false},
-		{"helmreleases.helm.toolkit.fluxcd.io", "helmrelease", true},
-		{"applications.argoproj.io", "argocd-app", true},
+// String returns resource.group, the name kubectl and api-resources use.
+func (r discoveryResource) String() string {
+	if r.Group == "" {
+		return r.Resource
 	}
+	return r.Resource + "." + r.Group
+}
 
-	seen := make(map[string]bool)
-	var addons []DetectedAddon
+// resourceQuery is one listing of discovery. The first resource is the one
+// the query is about; the others only refine its results, and are dropped
+// from the query where they cannot be listed.
+type resourceQuery struct {
+	resources []discoveryResource
+	source    string
+	isCRD     bool
+	// parse reads one page of a list of the query's resource and returns
+	// its continue token.
+	parse func(data []byte, source string) ([]DetectedAddon, string, error)
+}
 
-	for _, q := range queries {
-		cmdArgs := append([]string{"get", q.resource, "-o", "json"}, nsFlag...)
-		out, err := runKubectlCommandWithRetry(ctx, cmdArgs...)
-		if err != nil {
-			if q.isCRD {
+// discoveryQueries are merged in order. OLM operators come first: their
+// ClusterServiceVersion holds the authoritative version, so it wins when the
+// operator's Deployment is detected under the same name.
+var discoveryQueries = []resourceQuery{
+	{[]discoveryResource{{"operators.coreos.com", "clusterserviceversions"}, {"operators.coreos.com", "subscriptions"}}, "olm", true, parseOLMOperators},
+	{[]discoveryResource{{"apps", "deployments"}}, "deployment", false, parseWorkloads},
+	{[]discoveryResource{{"apps", "daemonsets"}}, "daemonset", false, parseWorkloads},
+	{[]discoveryResource{{"apps", "statefulsets"}}, "statefulset", false, parseWorkloads},
+	{[]discoveryResource{{"helm.toolkit.fluxcd.io", "helmreleases"}}, "helmrelease", true, parseHelmReleases},
+	{[]discoveryResource{{"argoproj.io", "applications"}}, "argocd-app", true, parseArgoApplications},
+	{[]discoveryResource{{"argoproj.io", "applicationsets"}}, "argocd-appset", true, parseArgoApplications},
+}
+
+// ListInstalledAddons discovers addons from the cluster deterministically.
+// With an Access from Preflight it lists each resource only where it may,
+// namespace by namespace when it cannot list across all of them. Resources
+// are listed in parallel and page by page through the API, decoding only
+// the fields discovery reads, so memory stays bounded on large clusters.
+// Queries that fail are returned as skipped scopes; it only fails when none
+// of the workload queries it ran succeeded.
+func ListInstalledAddons(ctx context.Context, namespace string, access *Access, opts ListOptions) ([]DetectedAddon, []output.SkippedScope, error) {
+	versions := access.groupVersions()
+	if versions == nil {
+		if out, err := opts.kubectl()(ctx, nil, apiResourcesArgs...); err == nil {
+			versions = parseAPIResources(out)
+		}
+	}
+
+	type listing struct {
+		query  resourceQuery
+		target scanTarget
+		addons []DetectedAddon
+		err    error
+	}
+	var listings []*listing
+	for _, q := range discoveryQueries {
+		for _, target := range access.targets(q.resources, namespace) {
+			var served []discoveryResource
+			for _, r := range target.resources {
+				if groupVersion(r, versions) != "" {
+					served = append(served, r)
+				}
+			}
+			if len(served) == 0 || served[0] != target.resources[0] {
 				continue
 			}
-			return nil, fmt.Errorf("kubectl get %s failed: %w", q.resource, err)
+			target.resources = served
+			listings = append(listings, &listing{query: q, target: target})
 		}
+	}
 
-		var list struct {
-			Items []struct {
-				Metadata struct {
-					Name        string            `json:"name"`
-					Namespace   string            `json:"namespace"`
-					Labels      map[string]string `json:"labels"`
-					Annotations map[string]string `json:"annotations"`
-				} `json:"metadata"`
-				Spec struct {
-					Template struct {
-						Spec struct {
-							Containers []struct {
-								Image string `json:"image"`
-							} `json:"containers"`
-						} `json:"spec"`
-					} `json:"template"`
-					Source struct {
-						Chart string `json:"chart"`
-					} `json:"source"`
-				} `json:"spec"`
-			} `json:"items"`
-		}
-		if err := json.Unmarshal(out, &list); err != nil {
-			fmt.Fprintf(os.Stderr, "Warning: skipping %s due to unexpected kubectl JSON output: %v\n", q.resource, err)
+	var (
+		wg  sync.WaitGroup
+		sem = make(chan struct{}, discoveryParallelism)
+	)
+	for _, l := range listings {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+			l.addons, l.err = opts.listTarget(ctx, l.query, l.target, versions)
+		}()
+	}
+	wg.Wait()
+
+	seen := make(map[string]bool)
+	var addons []DetectedAddon
+	var skipped []output.SkippedScope
+	var workloadErr error
+	workloadsListed := false
+	for _, l := range listings {
+		q, target, err := l.query, l.target, l.err
+		if errors.Is(err, errUnexpectedOutput) {
+			fmt.Fprintf(os.Stderr, "Warning: skipping %s due to %v\n", target, err)
 			continue
 		}
-
-		for _, item := range list.Items {
-			labels := item.Metadata.Labels
-			annotations := item.Metadata.Annotations
-
-			var name string
-			switch {
-			case q.source == "argocd-app":
-				name = item.Spec.Source.Chart
-				if name == "" {
-					name = item.Metadata.Name
-				}
-			case labels["app.kubernetes.io/name"] != "":
-				name = labels["app.kubernetes.io/name"]
-			case annotations["meta.helm.sh/release-name"] != "":
-				name = annotations["meta.helm.sh/release-name"]
-			case labels["helm.sh/chart"] != "":
-				name = stripChartVersion(labels["helm.sh/chart"])
-			default:
-				name = item.Metadata.Name
+		if err != nil {
+			if q.isCRD && isMissingResourceType(err) {
+				continue
 			}
-
-			var version string
-			switch {
-			case labels["app.kubernetes.io/version"] != "":
-				version = labels["app.kubernetes.io/version"]
-			case labels["helm.sh/chart"] != "":
-				version = extractChartVersion(labels["helm.sh/chart"])
-			default:
-				if len(item.Spec.Template.Spec.Containers) > 0 {
-					version = extractImageTag(item.Spec.Template.Spec.Containers[0].Image)
-				}
+			if !q.isCRD && workloadErr == nil {
+				workloadErr = fmt.Errorf("kubectl get %s failed: %w", target, err)
 			}
-
-			key := name + "/" + item.Metadata.Namespace
+			skipped = append(skipped, output.SkippedScope{Resource: target.String(), Namespace: target.namespace, Reason: err.Error()})
+			continue
+		}
+		if !q.isCRD {
+			workloadsListed = true
+		}
+		for _, a := range l.addons {
+			key := a.Name + "/" + a.Namespace
 			if seen[key] {
 				continue
 			}
 			seen[key] = true
-
-			addons = append(addons, DetectedAddon{
-				Name:      name,
-				Namespace: item.Metadata.Namespace,
-				Version:   version,
-				Source:    q.source,
-			})
+			addons = append(addons, a)
 		}
 	}
+	if workloadErr != nil && !workloadsListed {
+		return nil, nil, workloadErr
+	}
+	return addons, skipped, nil
+}
+
+// isMissingResourceType reports whether kubectl failed because the resource
+// is not served, as for a CRD that is not installed. kubectl get --raw
+// reports it as the API's NotFound.
+func isMissingResourceType(err error) bool {
+	return strings.Contains(err.Error(), "the server doesn't have a resource type") ||
+		strings.Contains(err.Error(), "the server could not find the requested resource")
+}
+
+// objectMeta is the part of object metadata discovery reads.
+type objectMeta struct {
+	Name        string            `json:"name"`
+	Namespace   string            `json:"namespace"`
+	Labels      map[string]string `json:"labels"`
+	Annotations map[string]string `json:"annotations"`
+}
+
+// parseWorkloads reads a Deployment, DaemonSet or StatefulSet list.
+func parseWorkloads(data []byte, source string) ([]DetectedAddon, string, error) {
+	var list struct {
+		Metadata listMeta `json:"metadata"`
+		Items    []struct {
+			Metadata objectMeta `json:"metadata"`
+			Spec     struct {
+				Template struct {
+					Spec struct {
+						Containers []struct {
+							Image string `json:"image"`
+						} `json:"containers"`
+					} `json:"spec"`
+				} `json:"template"`
+			} `json:"spec"`
+		} `json:"items"`
+	}
+	if err := json.Unmarshal(data, &list); err != nil {
+		return nil, "", err
+	}
 
-	return addons, nil
+	addons := make([]DetectedAddon, 0, len(list.Items))
+	for _, item := range list.Items {
+		labels := item.Metadata.Labels
+		annotations := item.Metadata.Annotations
+
+		var name string
+		switch {
+		case labels["app.kubernetes.io/name"] != "":
+			name = labels["app.kubernetes.io/name"]
+		case annotations["meta.helm.sh/release-name"] != "":
+			name = annotations["meta.helm.sh/release-name"]
+		case labels["helm.sh/chart"] != "":
+			name = stripChartVersion(labels["helm.sh/chart"])
+		default:
+			name = item.Metadata.Name
+		}
+
+		var version string
+		switch {
+		case labels["app.kubernetes.io/version"] != "":
+			version = labels["app.kubernetes.io/version"]
+		case labels["helm.sh/chart"] != "":
+			version = extractChartVersion(labels["helm.sh/chart"])
+		default:
+			if len(item.Spec.Template.Spec.Containers) > 0 {
+				version = extractImageTag(item.Spec.Template.Spec.Containers[0].Image)
+			}
+		}
+
+		addons = append(addons, DetectedAddon{
+			Name:      name,
+			Namespace: item.Metadata.Namespace,
+			Version:   upstreamVersion(version),
+			Source:    source,
+		})
+	}
+	return addons, list.Metadata.Continue, nil
 }
 
 func runKubectlCommandWithRetry(ctx context.Context, args ...string) ([]byte, error) {
+	return runKubectlWithInput(ctx, nil, args...)
+}
+
+// runKubectlWithInput runs kubectl with stdin, retrying network errors.
+func runKubectlWithInput(ctx context.Context, stdin []byte, args ...string) ([]byte, error) {
 	policy := resilience.RetryPolicy{
 		Attempts:     3,
 		InitialDelay: 500 * time.Millisecond,
@@ -169,6 +344,9 @@ func runKubectlCommandWithRetry(ctx context.Context, args ...string) ([]byte, er
 		var stderrBuffer bytes.Buffer
 		command.Stdout = &stdoutBuffer
 		command.Stderr = &stderrBuffer
+		if stdin != nil {
+			command.Stdin = bytes.NewReader(stdin)
+		}
 		err := command.Run()
 		if err == nil {
 			return stdoutBuffer.Bytes(), nil
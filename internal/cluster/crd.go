@@ -0,0 +1,86 @@
+package cluster
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+)
+
+// CustomResourceDefinition is an installed CRD as discovery sees it.
+type CustomResourceDefinition struct {
+	Name  string
+	Group string
+	// VersionHint is the addon release the CRD was installed with, when its
+	// labels or annotations record one.
+	VersionHint string
+}
+
+// maxCRDChunkSize caps a page of CRDs. Each one carries its full
+// openAPIV3Schema, often hundreds of kilobytes, which kubectl get --raw
+// cannot ask the API server to leave out.
+const maxCRDChunkSize = 25
+
+// crdVersionAnnotations are annotations that projects stamp on their CRDs
+// with the release that shipped them, most specific first.
+var crdVersionAnnotations = []string{
+	"gateway.networking.k8s.io/bundle-version",
+	"operator.prometheus.io/version",
+}
+
+// ListCustomResourceDefinitions lists the CRDs installed in the cluster page
+// by page, like discovery's resources, decoding only their names, groups and
+// version hints. Pages hold at most maxCRDChunkSize CRDs. access, which may
+// be nil, supplies the served API version.
+func ListCustomResourceDefinitions(ctx context.Context, access *Access, opts ListOptions) ([]CustomResourceDefinition, error) {
+	opts.ChunkSize = min(opts.chunkSize(), maxCRDChunkSize)
+	var crds []CustomResourceDefinition
+	err := opts.listPages(ctx, crdResource, groupVersion(crdResource, access.groupVersions()), "", func(page []byte) (string, error) {
+		pageCRDs, next, err := parseCustomResourceDefinitions(page)
+		crds = append(crds, pageCRDs...)
+		return next, err
+	}, func() { crds = nil })
+	if err != nil {
+		return nil, fmt.Errorf("listing customresourcedefinitions failed: %w", err)
+	}
+	return crds, nil
+}
+
+// parseCustomResourceDefinitions reads a CRD list. The version hint comes
+// from a known bundle-version annotation, then app.kubernetes.io/version,
+// then the version suffix of helm.sh/chart. controller-gen's version
+// annotation is ignored, as it names the generator rather than the addon.
+func parseCustomResourceDefinitions(data []byte) ([]CustomResourceDefinition, string, error) {
+	var list struct {
+		Metadata listMeta `json:"metadata"`
+		Items    []struct {
+			Metadata objectMeta `json:"metadata"`
+			Spec     struct {
+				Group string `json:"group"`
+			} `json:"spec"`
+		} `json:"items"`
+	}
+	if err := json.Unmarshal(data, &list); err != nil {
+		return nil, "", err
+	}
+
+	crds := make([]CustomResourceDefinition, 0, len(list.Items))
+	for _, item := range list.Items {
+		if item.Spec.Group == "" {
+			continue
+		}
+		var hints []string
+		for _, key := range crdVersionAnnotations {
+			hints = append(hints, exactVersion(item.Metadata.Annotations[key]))
+		}
+		hints = append(hints,
+			exactVersion(item.Metadata.Labels["app.kubernetes.io/version"]),
+			exactVersion(extractChartVersion(item.Metadata.Labels["helm.sh/chart"])),
+		)
+		crds = append(crds, CustomResourceDefinition{
+			Name:        item.Metadata.Name,
+			Group:       item.Spec.Group,
+			VersionHint: firstNonEmpty(hints...),
+		})
+	}
+	return crds, list.Metadata.Continue, nil
+}
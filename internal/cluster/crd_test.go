@@ -0,0 +1,86 @@
+package cluster
+
+import (
+	"context"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseCustomResourceDefinitions(t *testing.T) {
+	data := `{"metadata": {"continue": "crd-page-2"}, "items": [
+		{
+			"metadata": {
+				"name": "gateways.gateway.networking.k8s.io",
+				"annotations": {"gateway.networking.k8s.io/bundle-version": "v1.2.1", "controller-gen.kubebuilder.io/version": "v0.16.5"}
+			},
+			"spec": {"group": "gateway.networking.k8s.io"}
+		},
+		{
+			"metadata": {
+				"name": "certificates.cert-manager.io",
+				"labels": {"app.kubernetes.io/version": "v1.14.2", "helm.sh/chart": "cert-manager-v1.14.2"}
+			},
+			"spec": {"group": "cert-manager.io"}
+		},
+		{
+			"metadata": {"name": "scaledobjects.keda.sh", "labels": {"helm.sh/chart": "keda-2.14.0"}},
+			"spec": {"group": "keda.sh"}
+		},
+		{
+			"metadata": {"name": "nodepools.karpenter.sh", "annotations": {"controller-gen.kubebuilder.io/version": "v0.15.0"}},
+			"spec": {"group": "karpenter.sh"}
+		},
+		{
+			"metadata": {"name": "widgets.example.com", "labels": {"app.kubernetes.io/version": "main"}},
+			"spec": {"group": "example.com"}
+		},
+		{"metadata": {"name": "broken"}, "spec": {}}
+	]}`
+
+	got, next, err := parseCustomResourceDefinitions([]byte(data))
+	if err != nil {
+		t.Fatalf("parseCustomResourceDefinitions() error = %v", err)
+	}
+	if next != "crd-page-2" {
+		t.Errorf("parseCustomResourceDefinitions() continue = %q, want crd-page-2", next)
+	}
+	want := []CustomResourceDefinition{
+		{Name: "gateways.gateway.networking.k8s.io", Group: "gateway.networking.k8s.io", VersionHint: "v1.2.1"},
+		{Name: "certificates.cert-manager.io", Group: "cert-manager.io", VersionHint: "v1.14.2"},
+		{Name: "scaledobjects.keda.sh", Group: "keda.sh", VersionHint: "2.14.0"},
+		{Name: "nodepools.karpenter.sh", Group: "karpenter.sh"},
+		{Name: "widgets.example.com", Group: "example.com"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("parseCustomResourceDefinitions() =\n%+v\nwant\n%+v", got, want)
+	}
+
+	if _, _, err := parseCustomResourceDefinitions([]byte("not json")); err == nil {
+		t.Fatal("parseCustomResourceDefinitions() on invalid JSON: want error")
+	}
+}
+
+func TestListCustomResourceDefinitions_ChunkSize(t *testing.T) {
+	tests := []struct {
+		chunkSize int
+		want      string
+	}{
+		{0, "limit=25"},
+		{500, "limit=25"},
+		{10, "limit=10"},
+	}
+	for _, tt := range tests {
+		var urls []string
+		kubectl := func(_ context.Context, _ []byte, args ...string) ([]byte, error) {
+			urls = append(urls, args[len(args)-1])
+			return []byte(`{"kind": "CustomResourceDefinitionList", "metadata": {}, "items": []}`), nil
+		}
+		if _, err := ListCustomResourceDefinitions(context.Background(), nil, ListOptions{ChunkSize: tt.chunkSize, Kubectl: kubectl}); err != nil {
+			t.Fatalf("ListCustomResourceDefinitions() error = %v", err)
+		}
+		if len(urls) != 1 || !strings.HasSuffix(urls[0], "?"+tt.want) {
+			t.Errorf("chunk size %d: requests = %v, want one with %s", tt.chunkSize, urls, tt.want)
+		}
+	}
+}
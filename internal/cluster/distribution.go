@@ -0,0 +1,223 @@
+package cluster
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/qbandev/kaddons/internal/output"
+)
+
+// Kubernetes distributions recognised by DetectDistribution.
+const (
+	DistributionEKS       = "eks"
+	DistributionGKE       = "gke"
+	DistributionAKS       = "aks"
+	DistributionOpenShift = "openshift"
+	DistributionK3s       = "k3s"
+	DistributionRKE2      = "rke2"
+	DistributionKind      = "kind"
+)
+
+// Distribution describes the Kubernetes distribution serving the cluster.
+type Distribution struct {
+	// Name is one of the Distribution constants, or empty when unknown.
+	Name string
+	// ServerVersion is the API server's full gitVersion, including any
+	// provider build, e.g. v1.30.4-eks-a737599.
+	ServerVersion string
+	// Nodes is empty when nodes could not be listed.
+	Nodes []Node
+}
+
+// Node is a cluster node's pool and the versions from its status.nodeInfo.
+type Node struct {
+	Name string
+	// Pool is the managed node group, node pool, agent pool or Karpenter
+	// NodePool the node belongs to, "control-plane" for control plane nodes,
+	// or empty when no label names one.
+	Pool           string
+	KubeletVersion string
+	// ContainerRuntimeVersion is runtime://version, e.g. containerd://1.7.22.
+	ContainerRuntimeVersion string
+	KernelVersion           string
+	OSImage                 string
+}
+
+// ServerVersion is the API server version, the serverVersion block of
+// kubectl version.
+type ServerVersion struct {
+	Major      string `json:"major"`
+	Minor      string `json:"minor"`
+	GitVersion string `json:"gitVersion"`
+}
+
+var gitVersionRe = regexp.MustCompile(`^v?(\d+)\.(\d+)`)
+
+// MajorMinor returns the server's major.minor version. gitVersion is used
+// when it parses, as providers decorate minor with suffixes such as "30+".
+func (v ServerVersion) MajorMinor() string {
+	if match := gitVersionRe.FindStringSubmatch(v.GitVersion); match != nil {
+		return match[1] + "." + match[2]
+	}
+	return fmt.Sprintf("%s.%s", v.Major, strings.TrimRight(v.Minor, "+"))
+}
+
+func parseServerVersion(data []byte) (ServerVersion, error) {
+	var ver struct {
+		ServerVersion ServerVersion `json:"serverVersion"`
+	}
+	if err := json.Unmarshal(data, &ver); err != nil {
+		return ServerVersion{}, fmt.Errorf("parsing kubectl version output: %w", err)
+	}
+	return ver.ServerVersion, nil
+}
+
+// nodeInfo is a listed Node: what distribution detection reads, plus the
+// Node reported to callers.
+type nodeInfo struct {
+	Node
+	ProviderID string
+	Labels     map[string]string
+}
+
+// nodePoolLabels name a node's pool, checked in order.
+var nodePoolLabels = []string{
+	"eks.amazonaws.com/nodegroup",
+	"cloud.google.com/gke-nodepool",
+	"kubernetes.azure.com/agentpool",
+	"karpenter.sh/nodepool",
+}
+
+func parseNodes(data []byte) ([]nodeInfo, string, error) {
+	var list struct {
+		Metadata listMeta `json:"metadata"`
+		Items    []struct {
+			Metadata objectMeta `json:"metadata"`
+			Spec     struct {
+				ProviderID string `json:"providerID"`
+			} `json:"spec"`
+			Status struct {
+				NodeInfo struct {
+					KubeletVersion          string `json:"kubeletVersion"`
+					ContainerRuntimeVersion string `json:"containerRuntimeVersion"`
+					KernelVersion           string `json:"kernelVersion"`
+					OSImage                 string `json:"osImage"`
+				} `json:"nodeInfo"`
+			} `json:"status"`
+		} `json:"items"`
+	}
+	if err := json.Unmarshal(data, &list); err != nil {
+		return nil, "", fmt.Errorf("parsing nodes list page: %w", err)
+	}
+	nodes := make([]nodeInfo, 0, len(list.Items))
+	for _, item := range list.Items {
+		info := item.Status.NodeInfo
+		nodes = append(nodes, nodeInfo{
+			Node: Node{
+				Name:                    item.Metadata.Name,
+				Pool:                    nodePool(item.Metadata.Labels),
+				KubeletVersion:          info.KubeletVersion,
+				ContainerRuntimeVersion: info.ContainerRuntimeVersion,
+				KernelVersion:           info.KernelVersion,
+				OSImage:                 info.OSImage,
+			},
+			ProviderID: item.Spec.ProviderID,
+			Labels:     item.Metadata.Labels,
+		})
+	}
+	return nodes, list.Metadata.Continue, nil
+}
+
+func nodePool(labels map[string]string) string {
+	for _, label := range nodePoolLabels {
+		if pool := labels[label]; pool != "" {
+			return pool
+		}
+	}
+	if _, ok := labels["node-role.kubernetes.io/control-plane"]; ok {
+		return "control-plane"
+	}
+	return ""
+}
+
+// DetectDistribution identifies the distribution from the API server version
+// and, when nodes can be listed, their provider IDs and labels, and returns
+// the nodes. Nodes are listed page by page like discovery's resources, and
+// not at all when access denies it, which Preflight already records. A
+// failure to list them leaves detection to the server version and is
+// returned as a skipped scope for nodes.
+func DetectDistribution(ctx context.Context, version ServerVersion, access *Access, opts ListOptions) (Distribution, []output.SkippedScope) {
+	var (
+		nodes   []nodeInfo
+		skipped []output.SkippedScope
+	)
+	if access.CanList(ResourceNodes) {
+		err := opts.listPages(ctx, nodeResource, groupVersion(nodeResource, access.groupVersions()), "", func(page []byte) (string, error) {
+			pageNodes, next, err := parseNodes(page)
+			nodes = append(nodes, pageNodes...)
+			return next, err
+		}, func() { nodes = nil })
+		if err != nil {
+			nodes = nil
+			skipped = append(skipped, output.SkippedScope{Resource: ResourceNodes, Reason: err.Error()})
+		}
+	}
+	distribution := Distribution{Name: detectDistribution(version.GitVersion, nodes), ServerVersion: version.GitVersion}
+	for _, node := range nodes {
+		distribution.Nodes = append(distribution.Nodes, node.Node)
+	}
+	return distribution, skipped
+}
+
+// detectDistribution names the distribution from a server gitVersion and the
+// cluster's nodes. Signals that only one distribution produces win: OpenShift
+// node labels, provider builds in the server version, then managed node pool
+// labels and provider IDs.
+func detectDistribution(gitVersion string, nodes []nodeInfo) string {
+	for _, node := range nodes {
+		if node.Labels["node.openshift.io/os_id"] != "" {
+			return DistributionOpenShift
+		}
+	}
+	switch {
+	case strings.Contains(gitVersion, "-eks-"):
+		return DistributionEKS
+	case strings.Contains(gitVersion, "-gke."):
+		return DistributionGKE
+	case strings.Contains(gitVersion, "+k3s"):
+		return DistributionK3s
+	case strings.Contains(gitVersion, "+rke2"):
+		return DistributionRKE2
+	}
+	for _, node := range nodes {
+		labels := node.Labels
+		switch {
+		case labels["eks.amazonaws.com/nodegroup"] != "" || labels["eks.amazonaws.com/compute-type"] != "":
+			return DistributionEKS
+		case labels["cloud.google.com/gke-nodepool"] != "":
+			return DistributionGKE
+		case labels["kubernetes.azure.com/cluster"] != "" || labels["kubernetes.azure.com/agentpool"] != "":
+			return DistributionAKS
+		case strings.HasPrefix(node.ProviderID, "k3s://"):
+			return DistributionK3s
+		case strings.HasPrefix(node.ProviderID, "kind://"):
+			return DistributionKind
+		}
+	}
+	return ""
+}
+
+// providerBuildRe matches the build suffix a provider adds to an upstream
+// release it rebuilds: v1.18.1-eksbuild.3, v1.30.0-minimal-eksbuild.3,
+// v1.30.5-gke.1014001, v1.30.4-eks-a737599, v1.30.2+k3s1 or v1.30.2+rke2r1.
+var providerBuildRe = regexp.MustCompile(`(?:-minimal)?-eksbuild\.\d+$|-gke\.\d+$|-eks-[0-9a-f]+$|\+(?:k3s|rke2r)\d+$`)
+
+// upstreamVersion strips a provider build suffix, so that provider-managed
+// addons such as the EKS VPC CNI, CoreDNS and kube-proxy compare as the
+// upstream release they are built from.
+func upstreamVersion(version string) string {
+	return providerBuildRe.ReplaceAllString(version, "")
+}
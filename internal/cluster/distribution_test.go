@@ -0,0 +1,179 @@
+package cluster
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/qbandev/kaddons/internal/output"
+)
+
+func TestParseServerVersion_MajorMinor(t *testing.T) {
+	tests := []struct {
+		name string
+		data string
+		want string
+	}{
+		{"eks", `{"serverVersion": {"major": "1", "minor": "30+", "gitVersion": "v1.30.4-eks-a737599"}}`, "1.30"},
+		{"gke", `{"serverVersion": {"major": "1", "minor": "30", "gitVersion": "v1.30.5-gke.1014001"}}`, "1.30"},
+		{"k3s", `{"serverVersion": {"major": "1", "minor": "29", "gitVersion": "v1.29.6+k3s2"}}`, "1.29"},
+		{"no gitVersion", `{"serverVersion": {"major": "1", "minor": "28+"}}`, "1.28"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			version, err := parseServerVersion([]byte(tt.data))
+			if err != nil {
+				t.Fatalf("parseServerVersion() error = %v", err)
+			}
+			if got := version.MajorMinor(); got != tt.want {
+				t.Errorf("MajorMinor() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDetectDistribution(t *testing.T) {
+	tests := []struct {
+		name       string
+		gitVersion string
+		nodes      []nodeInfo
+		want       string
+	}{
+		{name: "eks server version", gitVersion: "v1.30.4-eks-a737599", want: DistributionEKS},
+		{name: "gke server version", gitVersion: "v1.30.5-gke.1014001", want: DistributionGKE},
+		{name: "k3s server version", gitVersion: "v1.29.6+k3s2", want: DistributionK3s},
+		{name: "rke2 server version", gitVersion: "v1.28.11+rke2r1", want: DistributionRKE2},
+		{
+			name:       "aks node labels",
+			gitVersion: "v1.30.3",
+			nodes:      []nodeInfo{{ProviderID: "azure:///subscriptions/x/vm-0", Labels: map[string]string{"kubernetes.azure.com/cluster": "MC_rg_prod_westeurope"}}},
+			want:       DistributionAKS,
+		},
+		{
+			name:       "openshift node labels win",
+			gitVersion: "v1.27.6+b49f9d1",
+			nodes:      []nodeInfo{{ProviderID: "aws:///us-east-1a/i-0abc", Labels: map[string]string{"node.openshift.io/os_id": "rhcos"}}},
+			want:       DistributionOpenShift,
+		},
+		{
+			name:       "eks node group without provider build",
+			gitVersion: "v1.30.4",
+			nodes:      []nodeInfo{{ProviderID: "aws:///us-east-1a/i-0abc", Labels: map[string]string{"eks.amazonaws.com/nodegroup": "default"}}},
+			want:       DistributionEKS,
+		},
+		{name: "kind provider id", gitVersion: "v1.31.0", nodes: []nodeInfo{{ProviderID: "kind://docker/kind/kind-control-plane"}}, want: DistributionKind},
+		{name: "self-managed on aws", gitVersion: "v1.31.0", nodes: []nodeInfo{{ProviderID: "aws:///us-east-1a/i-0abc"}}, want: ""},
+		{name: "unknown", gitVersion: "v1.31.0", want: ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := detectDistribution(tt.gitVersion, tt.nodes); got != tt.want {
+				t.Errorf("detectDistribution(%q) = %q, want %q", tt.gitVersion, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDetectDistribution_NodeAccess(t *testing.T) {
+	const kindNodes = `{"items": [{"metadata": {"name": "kind-control-plane"}, "spec": {"providerID": "kind://docker/kind/kind-control-plane"}}]}`
+	nodesDenied := &Access{allowed: map[accessReview]bool{{discoveryResource{"", "nodes"}, ""}: false}}
+	tests := []struct {
+		name        string
+		access      *Access
+		out         string
+		err         error
+		wantCalls   int
+		want        string
+		wantSkipped []output.SkippedScope
+	}{
+		{name: "nodes listed", out: kindNodes, wantCalls: 1, want: DistributionKind},
+		{name: "nodes denied by preflight", access: nodesDenied, out: kindNodes, wantCalls: 0},
+		{
+			name:        "nodes list fails",
+			err:         errors.New(`exit status 1: Error from server (Forbidden): nodes is forbidden`),
+			wantCalls:   1,
+			wantSkipped: []output.SkippedScope{{Resource: ResourceNodes, Reason: `exit status 1: Error from server (Forbidden): nodes is forbidden`}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var calls []string
+			kubectl := func(_ context.Context, _ []byte, args ...string) ([]byte, error) {
+				calls = append(calls, strings.Join(args, " "))
+				return []byte(tt.out), tt.err
+			}
+			got, skipped := DetectDistribution(context.Background(), ServerVersion{GitVersion: "v1.31.0"}, tt.access, ListOptions{Kubectl: kubectl})
+			if got.Name != tt.want || got.ServerVersion != "v1.31.0" {
+				t.Errorf("DetectDistribution() = %+v, want %q on v1.31.0", got, tt.want)
+			}
+			if !reflect.DeepEqual(skipped, tt.wantSkipped) {
+				t.Errorf("DetectDistribution() skipped = %+v, want %+v", skipped, tt.wantSkipped)
+			}
+			if len(calls) != tt.wantCalls {
+				t.Errorf("kubectl ran %d times (%v), want %d", len(calls), calls, tt.wantCalls)
+			}
+			for _, call := range calls {
+				if strings.HasPrefix(call, "version") {
+					t.Errorf("DetectDistribution re-ran kubectl %s", call)
+				}
+			}
+		})
+	}
+}
+
+func TestParseNodes(t *testing.T) {
+	data := `{"items": [
+		{
+			"metadata": {"name": "n1", "labels": {"cloud.google.com/gke-nodepool": "pool-1"}},
+			"spec": {"providerID": "gce://project/europe-west1-b/n1"},
+			"status": {"nodeInfo": {"kubeletVersion": "v1.30.5-gke.1014001", "containerRuntimeVersion": "containerd://1.7.22", "kernelVersion": "6.1.100+", "osImage": "Container-Optimized OS from Google"}}
+		},
+		{"metadata": {"name": "cp", "labels": {"node-role.kubernetes.io/control-plane": ""}}, "spec": {}},
+		{"metadata": {"name": "worker"}, "spec": {}}
+	]}`
+	nodes, _, err := parseNodes([]byte(data))
+	if err != nil {
+		t.Fatalf("parseNodes() error = %v", err)
+	}
+	if len(nodes) != 3 || nodes[0].ProviderID != "gce://project/europe-west1-b/n1" || nodes[0].Labels["cloud.google.com/gke-nodepool"] != "pool-1" {
+		t.Fatalf("parseNodes() = %+v", nodes)
+	}
+	want := Node{
+		Name: "n1", Pool: "pool-1", KubeletVersion: "v1.30.5-gke.1014001", ContainerRuntimeVersion: "containerd://1.7.22",
+		KernelVersion: "6.1.100+", OSImage: "Container-Optimized OS from Google",
+	}
+	if nodes[0].Node != want {
+		t.Errorf("parseNodes() node = %+v, want %+v", nodes[0].Node, want)
+	}
+	if nodes[1].Pool != "control-plane" || nodes[2].Pool != "" {
+		t.Errorf("parseNodes() pools = %q, %q, want control-plane and none", nodes[1].Pool, nodes[2].Pool)
+	}
+	if got := detectDistribution("v1.30.5", nodes); got != DistributionGKE {
+		t.Errorf("detectDistribution() = %q, want %q", got, DistributionGKE)
+	}
+}
+
+func TestUpstreamVersion(t *testing.T) {
+	tests := []struct {
+		version string
+		want    string
+	}{
+		{"v1.18.1-eksbuild.3", "v1.18.1"},
+		{"v1.30.0-minimal-eksbuild.3", "v1.30.0"},
+		{"v1.11.1-eksbuild.9", "v1.11.1"},
+		{"v1.30.5-gke.1014001", "v1.30.5"},
+		{"v1.30.4-eks-a737599", "v1.30.4"},
+		{"v1.29.6+k3s2", "v1.29.6"},
+		{"v1.28.11+rke2r1", "v1.28.11"},
+		{"v0.37.0-rc.1", "v0.37.0-rc.1"},
+		{"1.14.2", "1.14.2"},
+		{"", ""},
+	}
+	for _, tt := range tests {
+		if got := upstreamVersion(tt.version); got != tt.want {
+			t.Errorf("upstreamVersion(%q) = %q, want %q", tt.version, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,253 @@
+package cluster
+
+import (
+	"encoding/json"
+	"path"
+	"regexp"
+	"strings"
+)
+
+// exactVersionRe matches a single release version such as 1.14.2 or
+// v0.37.0-rc.1, as opposed to a range like 1.14.x, ~1.14 or >=1.0.
+var exactVersionRe = regexp.MustCompile(`^v?\d+(?:\.\d+){1,2}(?:[-+][0-9A-Za-z.-]+)?$`)
+
+// exactVersion returns revision when it names one release. Revisions of the
+// form 1.14.2@sha256:..., which Flux records for OCI charts, are cut at the
+// digest; branch names, commit SHAs and ranges yield "".
+func exactVersion(revision string) string {
+	revision, _, _ = strings.Cut(strings.TrimSpace(revision), "@")
+	if exactVersionRe.MatchString(revision) {
+		return revision
+	}
+	return ""
+}
+
+// parseHelmReleases reads a Flux HelmRelease list. The chart comes from
+// spec.chart.spec.chart, or from the release history or chartRef when the
+// chart is referenced rather than templated. The version is the last
+// applied chart version, falling back to status.lastAttemptedRevision and
+// then to spec.chart.spec.version when it pins one release. Addons are
+// reported in spec.targetNamespace, where the chart is installed.
+func parseHelmReleases(data []byte, source string) ([]DetectedAddon, string, error) {
+	var list struct {
+		Metadata listMeta `json:"metadata"`
+		Items    []struct {
+			Metadata objectMeta `json:"metadata"`
+			Spec     struct {
+				ReleaseName     string `json:"releaseName"`
+				TargetNamespace string `json:"targetNamespace"`
+				Chart           struct {
+					Spec struct {
+						Chart   string `json:"chart"`
+						Version string `json:"version"`
+					} `json:"spec"`
+				} `json:"chart"`
+				ChartRef struct {
+					Name string `json:"name"`
+				} `json:"chartRef"`
+			} `json:"spec"`
+			Status struct {
+				History []struct {
+					ChartName    string `json:"chartName"`
+					ChartVersion string `json:"chartVersion"`
+				} `json:"history"`
+				LastAppliedRevision   string `json:"lastAppliedRevision"`
+				LastAttemptedRevision string `json:"lastAttemptedRevision"`
+			} `json:"status"`
+		} `json:"items"`
+	}
+	if err := json.Unmarshal(data, &list); err != nil {
+		return nil, "", err
+	}
+
+	addons := make([]DetectedAddon, 0, len(list.Items))
+	for _, item := range list.Items {
+		spec, status := item.Spec, item.Status
+		var latestName, latestVersion string
+		if len(status.History) > 0 {
+			// History is newest first.
+			latestName, latestVersion = status.History[0].ChartName, status.History[0].ChartVersion
+		}
+
+		name := firstNonEmpty(chartName(spec.Chart.Spec.Chart), latestName, spec.ChartRef.Name, spec.ReleaseName, item.Metadata.Name)
+		version := firstNonEmpty(
+			exactVersion(latestVersion),
+			exactVersion(status.LastAppliedRevision),
+			exactVersion(status.LastAttemptedRevision),
+			exactVersion(spec.Chart.Spec.Version),
+		)
+		addons = append(addons, DetectedAddon{
+			Name:         name,
+			Namespace:    firstNonEmpty(spec.TargetNamespace, item.Metadata.Namespace),
+			Version:      version,
+			Source:       source,
+			ChartVersion: version != "",
+		})
+	}
+	return addons, list.Metadata.Continue, nil
+}
+
+// argoSource is one source of an Argo CD Application.
+type argoSource struct {
+	RepoURL        string `json:"repoURL"`
+	Chart          string `json:"chart"`
+	Path           string `json:"path"`
+	TargetRevision string `json:"targetRevision"`
+	// Ref names a source that only supplies values files to the others.
+	Ref string `json:"ref"`
+}
+
+// argoApplicationSpec is the spec of an Application, and of the template of
+// an ApplicationSet.
+type argoApplicationSpec struct {
+	Source      *argoSource  `json:"source"`
+	Sources     []argoSource `json:"sources"`
+	Destination struct {
+		Namespace string `json:"namespace"`
+	} `json:"destination"`
+}
+
+// parseArgoApplications reads an Argo CD Application or ApplicationSet list.
+// Each Helm chart source, including every chart in spec.sources of a
+// multi-source app, becomes one addon, versioned by the revision Argo CD
+// synced, then by an exact targetRevision, then by the tag of a deployed
+// image from status.summary.images named after the chart. Apps built from a
+// Git path become one addon named after the app. ApplicationSets contribute
+// their template; sources that are still {{templated}} are skipped, as the
+// generated Applications are listed themselves.
+func parseArgoApplications(data []byte, source string) ([]DetectedAddon, string, error) {
+	var list struct {
+		Metadata listMeta `json:"metadata"`
+		Items    []struct {
+			Metadata objectMeta `json:"metadata"`
+			Spec     struct {
+				argoApplicationSpec
+				Template struct {
+					Metadata objectMeta          `json:"metadata"`
+					Spec     argoApplicationSpec `json:"spec"`
+				} `json:"template"`
+			} `json:"spec"`
+			Status struct {
+				Sync struct {
+					Revision  string   `json:"revision"`
+					Revisions []string `json:"revisions"`
+				} `json:"sync"`
+				Summary struct {
+					Images []string `json:"images"`
+				} `json:"summary"`
+			} `json:"status"`
+		} `json:"items"`
+	}
+	if err := json.Unmarshal(data, &list); err != nil {
+		return nil, "", err
+	}
+
+	var addons []DetectedAddon
+	for _, item := range list.Items {
+		spec := item.Spec.argoApplicationSpec
+		appName := item.Metadata.Name
+		if item.Spec.Template.Spec.Source != nil || len(item.Spec.Template.Spec.Sources) > 0 {
+			spec = item.Spec.Template.Spec
+			appName = item.Spec.Template.Metadata.Name
+		}
+		namespace := firstNonEmpty(spec.Destination.Namespace, item.Metadata.Namespace)
+		images := item.Status.Summary.Images
+
+		sources := spec.Sources
+		revisions := item.Status.Sync.Revisions
+		if len(sources) == 0 && spec.Source != nil {
+			sources = []argoSource{*spec.Source}
+			revisions = []string{item.Status.Sync.Revision}
+		}
+
+		foundChart := false
+		for i, src := range sources {
+			if src.Chart == "" {
+				continue
+			}
+			foundChart = true
+			if isTemplated(src.Chart) {
+				continue
+			}
+			synced := ""
+			if i < len(revisions) {
+				synced = revisions[i]
+			}
+			// The image tag is the addon's release; only a revision is a chart version.
+			chartVersion := firstNonEmpty(exactVersion(synced), exactVersion(src.TargetRevision))
+			addons = append(addons, DetectedAddon{
+				Name:         src.Chart,
+				Namespace:    namespace,
+				Version:      firstNonEmpty(chartVersion, imageVersion(src.Chart, images)),
+				Source:       source,
+				Images:       images,
+				ChartVersion: chartVersion != "",
+			})
+		}
+		if foundChart || appName == "" || isTemplated(appName) {
+			continue
+		}
+
+		// A Git or plain-manifest app: the app name is the best addon name,
+		// and a tag-like targetRevision the best version.
+		version := ""
+		for _, src := range sources {
+			if src.Ref == "" {
+				version = firstNonEmpty(version, exactVersion(src.TargetRevision))
+			}
+		}
+		addons = append(addons, DetectedAddon{
+			Name:      appName,
+			Namespace: namespace,
+			Version:   firstNonEmpty(version, imageVersion(appName, images)),
+			Source:    source,
+			Images:    images,
+		})
+	}
+	return addons, list.Metadata.Continue, nil
+}
+
+// imageVersion returns the tag of the first image whose repository name
+// contains name, e.g. v1.14.2 from quay.io/jetstack/cert-manager-controller:v1.14.2
+// for cert-manager.
+func imageVersion(name string, images []string) string {
+	name = strings.ToLower(name)
+	for _, image := range images {
+		repository := image
+		if at := strings.Index(repository, "@"); at != -1 {
+			repository = repository[:at]
+		}
+		tag := ""
+		if colon := strings.LastIndex(repository, ":"); colon > strings.LastIndex(repository, "/") {
+			repository, tag = repository[:colon], repository[colon+1:]
+		}
+		if tag != "" && strings.Contains(strings.ToLower(path.Base(repository)), name) {
+			if version := exactVersion(tag); version != "" {
+				return version
+			}
+		}
+	}
+	return ""
+}
+
+// chartName returns the chart name of a Flux chart reference, which may be a
+// path inside a Git or bucket source such as ./charts/podinfo.
+func chartName(chart string) string {
+	if chart == "" || !strings.Contains(chart, "/") {
+		return chart
+	}
+	return path.Base(strings.TrimSuffix(chart, "/"))
+}
+
+func isTemplated(value string) bool {
+	return strings.Contains(value, "{{")
+}
+
+func firstNonEmpty(values ...string) string {
+	for _, value := range values {
+		if value != "" {
+			return value
+		}
+	}
+	return ""
+}
@@ -0,0 +1,207 @@
+package cluster
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestExactVersion(t *testing.T) {
+	tests := []struct {
+		revision string
+		want     string
+	}{
+		{"1.14.2", "1.14.2"},
+		{"v0.37.0-rc.1", "v0.37.0-rc.1"},
+		{"1.14.2@sha256:0123abcd", "1.14.2"},
+		{"1.14.x", ""},
+		{">=1.0.0 <2.0.0", ""},
+		{"~1.14", ""},
+		{"*", ""},
+		{"HEAD", ""},
+		{"main@sha1:5f3c2a1", ""},
+		{"5f3c2a1b9e8d7c6b5a4f3e2d1c0b9a8f7e6d5c4b", ""},
+		{"", ""},
+	}
+	for _, tt := range tests {
+		if got := exactVersion(tt.revision); got != tt.want {
+			t.Errorf("exactVersion(%q) = %q, want %q", tt.revision, got, tt.want)
+		}
+	}
+}
+
+func TestImageVersion(t *testing.T) {
+	images := []string{
+		"registry.k8s.io/ingress-nginx/controller:v1.10.0@sha256:42b3f0e5d0846876b1791cd3afeb5f1cbbe4259d6f35651dcc1b5c980925379c",
+		"quay.io/jetstack/cert-manager-controller:v1.14.2",
+		"localhost:5000/keda:latest",
+	}
+	tests := []struct {
+		name string
+		want string
+	}{
+		{"cert-manager", "v1.14.2"},
+		{"controller", "v1.10.0"},
+		{"keda", ""},
+		{"karpenter", ""},
+	}
+	for _, tt := range tests {
+		if got := imageVersion(tt.name, images); got != tt.want {
+			t.Errorf("imageVersion(%q) = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestParseHelmReleases(t *testing.T) {
+	data := `{"items": [
+		{
+			"metadata": {"name": "cert-manager", "namespace": "flux-system"},
+			"spec": {"targetNamespace": "cert-manager", "chart": {"spec": {"chart": "cert-manager", "version": "1.14.x"}}},
+			"status": {
+				"history": [{"chartName": "cert-manager", "chartVersion": "1.14.4"}, {"chartName": "cert-manager", "chartVersion": "1.14.2"}],
+				"lastAttemptedRevision": "1.14.5"
+			}
+		},
+		{
+			"metadata": {"name": "keda", "namespace": "keda"},
+			"spec": {"chart": {"spec": {"chart": "keda", "version": ">=2.0.0"}}},
+			"status": {"lastAttemptedRevision": "2.12.1"}
+		},
+		{
+			"metadata": {"name": "podinfo", "namespace": "apps"},
+			"spec": {"chart": {"spec": {"chart": "./charts/podinfo", "version": "6.5.4"}}}
+		},
+		{
+			"metadata": {"name": "karpenter-release", "namespace": "karpenter"},
+			"spec": {"chartRef": {"kind": "OCIRepository", "name": "karpenter"}},
+			"status": {"lastAttemptedRevision": "1.0.6@sha256:9c1b2f0e"}
+		},
+		{
+			"metadata": {"name": "legacy", "namespace": "default"},
+			"spec": {"chart": {"spec": {"chart": "metrics-server"}}},
+			"status": {"lastAppliedRevision": "3.12.1", "lastAttemptedRevision": "3.12.2"}
+		}
+	]}`
+	got, _, err := parseHelmReleases([]byte(data), "helmrelease")
+	if err != nil {
+		t.Fatalf("parseHelmReleases error = %v", err)
+	}
+	want := []DetectedAddon{
+		{Name: "cert-manager", Namespace: "cert-manager", Version: "1.14.4", Source: "helmrelease", ChartVersion: true},
+		{Name: "keda", Namespace: "keda", Version: "2.12.1", Source: "helmrelease", ChartVersion: true},
+		{Name: "podinfo", Namespace: "apps", Version: "6.5.4", Source: "helmrelease", ChartVersion: true},
+		{Name: "karpenter", Namespace: "karpenter", Version: "1.0.6", Source: "helmrelease", ChartVersion: true},
+		{Name: "metrics-server", Namespace: "default", Version: "3.12.1", Source: "helmrelease", ChartVersion: true},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("parseHelmReleases =\n%+v\nwant\n%+v", got, want)
+	}
+}
+
+func TestParseArgoApplications(t *testing.T) {
+	tests := []struct {
+		name   string
+		source string
+		data   string
+		want   []DetectedAddon
+	}{
+		{
+			name:   "single chart source uses the synced revision",
+			source: "argocd-app",
+			data: `{"items": [{
+				"metadata": {"name": "cert-manager", "namespace": "argocd"},
+				"spec": {"source": {"repoURL": "https://charts.jetstack.io", "chart": "cert-manager", "targetRevision": "1.14.*"}, "destination": {"namespace": "cert-manager"}},
+				"status": {"sync": {"revision": "1.14.2"}, "summary": {"images": ["quay.io/jetstack/cert-manager-controller:v1.14.2"]}}
+			}]}`,
+			want: []DetectedAddon{{Name: "cert-manager", Namespace: "cert-manager", Version: "1.14.2", Source: "argocd-app", ChartVersion: true, Images: []string{"quay.io/jetstack/cert-manager-controller:v1.14.2"}}},
+		},
+		{
+			name:   "range without sync status falls back to image tag",
+			source: "argocd-app",
+			data: `{"items": [{
+				"metadata": {"name": "keda", "namespace": "argocd"},
+				"spec": {"source": {"chart": "keda", "targetRevision": "2.x"}},
+				"status": {"summary": {"images": ["ghcr.io/kedacore/keda-admission-webhooks:2.12.1", "ghcr.io/kedacore/keda:2.12.1"]}}
+			}]}`,
+			want: []DetectedAddon{{Name: "keda", Namespace: "argocd", Version: "2.12.1", Source: "argocd-app", Images: []string{"ghcr.io/kedacore/keda-admission-webhooks:2.12.1", "ghcr.io/kedacore/keda:2.12.1"}}},
+		},
+		{
+			name:   "multi-source app yields each chart with its revision",
+			source: "argocd-app",
+			data: `{"items": [{
+				"metadata": {"name": "platform", "namespace": "argocd"},
+				"spec": {"sources": [
+					{"repoURL": "https://github.com/acme/values.git", "targetRevision": "main", "ref": "values"},
+					{"repoURL": "https://kedacore.github.io/charts", "chart": "keda", "targetRevision": "2.12.0"},
+					{"repoURL": "https://prometheus-community.github.io/helm-charts", "chart": "kube-prometheus-stack", "targetRevision": "56.*"}
+				], "destination": {"namespace": "platform"}},
+				"status": {"sync": {"revisions": ["3f2a1b0", "2.12.0", "56.6.2"]}}
+			}]}`,
+			want: []DetectedAddon{
+				{Name: "keda", Namespace: "platform", Version: "2.12.0", Source: "argocd-app", ChartVersion: true},
+				{Name: "kube-prometheus-stack", Namespace: "platform", Version: "56.6.2", Source: "argocd-app", ChartVersion: true},
+			},
+		},
+		{
+			name:   "git path app is named after the app",
+			source: "argocd-app",
+			data: `{"items": [{
+				"metadata": {"name": "external-dns", "namespace": "argocd"},
+				"spec": {"source": {"repoURL": "https://github.com/acme/platform.git", "path": "external-dns", "targetRevision": "HEAD"}, "destination": {"namespace": "external-dns"}},
+				"status": {"sync": {"revision": "9e8d7c6"}, "summary": {"images": ["registry.k8s.io/external-dns/external-dns:v0.14.0"]}}
+			}]}`,
+			want: []DetectedAddon{{Name: "external-dns", Namespace: "external-dns", Version: "v0.14.0", Source: "argocd-app", Images: []string{"registry.k8s.io/external-dns/external-dns:v0.14.0"}}},
+		},
+		{
+			name:   "application set template",
+			source: "argocd-appset",
+			data: `{"items": [
+				{
+					"metadata": {"name": "cert-manager-all", "namespace": "argocd"},
+					"spec": {"template": {"metadata": {"name": "{{name}}-cert-manager"}, "spec": {
+						"source": {"chart": "cert-manager", "targetRevision": "v1.15.0"}, "destination": {"namespace": "cert-manager"}
+					}}}
+				},
+				{
+					"metadata": {"name": "per-cluster", "namespace": "argocd"},
+					"spec": {"template": {"metadata": {"name": "{{name}}-addons"}, "spec": {
+						"source": {"chart": "{{values.chart}}", "targetRevision": "{{values.version}}"}
+					}}}
+				}
+			]}`,
+			want: []DetectedAddon{{Name: "cert-manager", Namespace: "cert-manager", Version: "v1.15.0", Source: "argocd-appset", ChartVersion: true}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, _, err := parseArgoApplications([]byte(tt.data), tt.source)
+			if err != nil {
+				t.Fatalf("parseArgoApplications error = %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("parseArgoApplications =\n%+v\nwant\n%+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseWorkloads(t *testing.T) {
+	data := `{"items": [
+		{"metadata": {"name": "cm", "namespace": "cert-manager", "labels": {"app.kubernetes.io/name": "cert-manager", "app.kubernetes.io/version": "v1.14.2"}}},
+		{"metadata": {"name": "keda-operator", "namespace": "keda", "labels": {"helm.sh/chart": "keda-2.12.0"}}},
+		{"metadata": {"name": "coredns", "namespace": "kube-system"}, "spec": {"template": {"spec": {"containers": [{"image": "registry.k8s.io/coredns/coredns:v1.11.1"}]}}}},
+		{"metadata": {"name": "aws-node", "namespace": "kube-system"}, "spec": {"template": {"spec": {"containers": [{"image": "602401143452.dkr.ecr.us-west-2.amazonaws.com/amazon-k8s-cni:v1.18.1-eksbuild.3"}]}}}}
+	]}`
+	got, _, err := parseWorkloads([]byte(data), "deployment")
+	if err != nil {
+		t.Fatalf("parseWorkloads error = %v", err)
+	}
+	want := []DetectedAddon{
+		{Name: "cert-manager", Namespace: "cert-manager", Version: "v1.14.2", Source: "deployment"},
+		{Name: "keda", Namespace: "keda", Version: "2.12.0", Source: "deployment"},
+		{Name: "coredns", Namespace: "kube-system", Version: "v1.11.1", Source: "deployment"},
+		{Name: "aws-node", Namespace: "kube-system", Version: "v1.18.1", Source: "deployment"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("parseWorkloads =\n%+v\nwant\n%+v", got, want)
+	}
+}
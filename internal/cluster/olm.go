@@ -0,0 +1,104 @@
+package cluster
+
+import (
+	"encoding/json"
+	"regexp"
+	"strings"
+)
+
+// csvVersionSuffixRe matches the version OLM appends to a ClusterServiceVersion
+// name, as in cert-manager.v1.14.2 or etcdoperator.v0.9.4.
+var csvVersionSuffixRe = regexp.MustCompile(`\.v?(\d+(?:\.\d+){1,2}(?:[-+][0-9A-Za-z.-]+)?)$`)
+
+// olmItem is the part of a ClusterServiceVersion or Subscription discovery
+// reads.
+type olmItem struct {
+	Kind     string     `json:"kind"`
+	Metadata objectMeta `json:"metadata"`
+	Spec     struct {
+		// ClusterServiceVersion fields.
+		Version        string `json:"version"`
+		MinKubeVersion string `json:"minKubeVersion"`
+		// Subscription fields.
+		Name string `json:"name"`
+	} `json:"spec"`
+	Status struct {
+		Phase        string `json:"phase"`
+		Reason       string `json:"reason"`
+		InstalledCSV string `json:"installedCSV"`
+	} `json:"status"`
+}
+
+// parseOLMOperators reads a kubectl list of Operator Lifecycle Manager
+// ClusterServiceVersions and Subscriptions. Each installed CSV becomes one
+// addon named after the package of the Subscription that installed it, or
+// after the CSV name without its version suffix. The version is spec.version
+// and spec.minKubeVersion is kept as MinKubeVersion. CSVs that OLM copies
+// into every namespace for all-namespace operators, and CSVs being replaced
+// by an upgrade, are skipped.
+func parseOLMOperators(data []byte, source string) ([]DetectedAddon, string, error) {
+	items, next, err := parseOLMPage(data)
+	if err != nil {
+		return nil, "", err
+	}
+	return olmOperators(items, source), next, nil
+}
+
+// parseOLMPage reads the items of a list of ClusterServiceVersions and
+// Subscriptions. Items of an API list page carry no kind, so they take the
+// one of the list, as ClusterServiceVersion from ClusterServiceVersionList.
+func parseOLMPage(data []byte) ([]olmItem, string, error) {
+	var list struct {
+		Kind     string    `json:"kind"`
+		Metadata listMeta  `json:"metadata"`
+		Items    []olmItem `json:"items"`
+	}
+	if err := json.Unmarshal(data, &list); err != nil {
+		return nil, "", err
+	}
+	if kind := strings.TrimSuffix(list.Kind, "List"); kind != "" {
+		for i := range list.Items {
+			if list.Items[i].Kind == "" {
+				list.Items[i].Kind = kind
+			}
+		}
+	}
+	return list.Items, list.Metadata.Continue, nil
+}
+
+// olmOperators joins ClusterServiceVersions to the Subscriptions that
+// installed them, as parseOLMOperators describes.
+func olmOperators(items []olmItem, source string) []DetectedAddon {
+	packages := make(map[string]string)
+	for _, item := range items {
+		if item.Kind == "Subscription" && item.Status.InstalledCSV != "" && item.Spec.Name != "" {
+			packages[item.Metadata.Namespace+"/"+item.Status.InstalledCSV] = item.Spec.Name
+		}
+	}
+
+	var addons []DetectedAddon
+	for _, item := range items {
+		if item.Kind != "ClusterServiceVersion" {
+			continue
+		}
+		if item.Metadata.Labels["olm.copiedFrom"] != "" || item.Status.Reason == "Copied" {
+			continue
+		}
+		if item.Status.Phase == "Replacing" || item.Status.Phase == "Deleting" {
+			continue
+		}
+
+		name, nameVersion := item.Metadata.Name, ""
+		if match := csvVersionSuffixRe.FindStringSubmatchIndex(name); match != nil {
+			name, nameVersion = item.Metadata.Name[:match[0]], item.Metadata.Name[match[2]:match[3]]
+		}
+		addons = append(addons, DetectedAddon{
+			Name:           firstNonEmpty(packages[item.Metadata.Namespace+"/"+item.Metadata.Name], name),
+			Namespace:      item.Metadata.Namespace,
+			Version:        firstNonEmpty(exactVersion(item.Spec.Version), exactVersion(nameVersion)),
+			Source:         source,
+			MinKubeVersion: strings.TrimPrefix(strings.TrimSpace(item.Spec.MinKubeVersion), "v"),
+		})
+	}
+	return addons
+}
@@ -0,0 +1,70 @@
+package cluster
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseOLMOperators(t *testing.T) {
+	data := `{"apiVersion": "v1", "kind": "List", "items": [
+		{
+			"kind": "ClusterServiceVersion",
+			"metadata": {"name": "cert-manager-operator.v1.13.1", "namespace": "cert-manager-operator"},
+			"spec": {"version": "1.13.1", "minKubeVersion": "1.25.0"},
+			"status": {"phase": "Succeeded"}
+		},
+		{
+			"kind": "ClusterServiceVersion",
+			"metadata": {"name": "cert-manager-operator.v1.12.0", "namespace": "cert-manager-operator"},
+			"spec": {"version": "1.12.0"},
+			"status": {"phase": "Replacing"}
+		},
+		{
+			"kind": "ClusterServiceVersion",
+			"metadata": {"name": "keda.v2.14.0", "namespace": "openshift-keda"},
+			"spec": {"version": "2.14.0", "minKubeVersion": "v1.27.0"},
+			"status": {"phase": "Succeeded"}
+		},
+		{
+			"kind": "ClusterServiceVersion",
+			"metadata": {"name": "keda.v2.14.0", "namespace": "team-a", "labels": {"olm.copiedFrom": "openshift-keda"}},
+			"spec": {"version": "2.14.0"},
+			"status": {"phase": "Succeeded", "reason": "Copied"}
+		},
+		{
+			"kind": "ClusterServiceVersion",
+			"metadata": {"name": "etcdoperator.v0.9.4", "namespace": "operators"},
+			"spec": {},
+			"status": {"phase": "Succeeded"}
+		},
+		{
+			"kind": "Subscription",
+			"metadata": {"name": "openshift-cert-manager-operator", "namespace": "cert-manager-operator"},
+			"spec": {"name": "openshift-cert-manager-operator", "channel": "stable-v1"},
+			"status": {"installedCSV": "cert-manager-operator.v1.13.1"}
+		},
+		{
+			"kind": "Subscription",
+			"metadata": {"name": "keda", "namespace": "other"},
+			"spec": {"name": "custom-metrics-autoscaler"},
+			"status": {"installedCSV": "keda.v2.14.0"}
+		}
+	]}`
+
+	got, _, err := parseOLMOperators([]byte(data), "olm")
+	if err != nil {
+		t.Fatalf("parseOLMOperators() error = %v", err)
+	}
+	want := []DetectedAddon{
+		{Name: "openshift-cert-manager-operator", Namespace: "cert-manager-operator", Version: "1.13.1", Source: "olm", MinKubeVersion: "1.25.0"},
+		{Name: "keda", Namespace: "openshift-keda", Version: "2.14.0", Source: "olm", MinKubeVersion: "1.27.0"},
+		{Name: "etcdoperator", Namespace: "operators", Version: "0.9.4", Source: "olm"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("parseOLMOperators() =\n%+v\nwant\n%+v", got, want)
+	}
+
+	if _, _, err := parseOLMOperators([]byte("{"), "olm"); err == nil {
+		t.Fatal("parseOLMOperators() on invalid JSON: want error")
+	}
+}
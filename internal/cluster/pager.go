@@ -0,0 +1,170 @@
+package cluster
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// DefaultChunkSize is the number of objects a list request returns, as for
+// kubectl's --chunk-size.
+const DefaultChunkSize = 500
+
+// discoveryParallelism caps the list requests discovery runs at once.
+const discoveryParallelism = 4
+
+// maxListRestarts bounds how often one list starts over after its continue
+// token expired.
+const maxListRestarts = 3
+
+// defaultGroupVersions are the API versions discovery lists when
+// api-resources is unavailable. A group's preferred version may not serve
+// the resource: operators.coreos.com prefers v2, which has no
+// ClusterServiceVersions.
+var defaultGroupVersions = map[string]string{
+	"":                       "v1",
+	"apps":                   "apps/v1",
+	"apiextensions.k8s.io":   "apiextensions.k8s.io/v1",
+	"operators.coreos.com":   "operators.coreos.com/v1alpha1",
+	"helm.toolkit.fluxcd.io": "helm.toolkit.fluxcd.io/v2",
+	"argoproj.io":            "argoproj.io/v1alpha1",
+}
+
+// listMeta is the metadata of a list page. Parsers decode it along with the
+// page's items, so each page is unmarshalled once.
+type listMeta struct {
+	Continue string `json:"continue"`
+}
+
+// errUnexpectedOutput marks a list page discovery could not read, as opposed
+// to a list request that failed.
+var errUnexpectedOutput = errors.New("unexpected kubectl JSON output")
+
+// ListOptions tune how ListInstalledAddons lists resources.
+type ListOptions struct {
+	// ChunkSize is the number of objects each list request returns. Zero
+	// uses DefaultChunkSize.
+	ChunkSize int
+	// Kubectl runs kubectl with stdin. Nil runs the kubectl binary; tests
+	// replace it.
+	Kubectl func(ctx context.Context, stdin []byte, args ...string) ([]byte, error)
+}
+
+func (o ListOptions) kubectl() func(ctx context.Context, stdin []byte, args ...string) ([]byte, error) {
+	if o.Kubectl == nil {
+		return runKubectlWithInput
+	}
+	return o.Kubectl
+}
+
+func (o ListOptions) chunkSize() int {
+	if o.ChunkSize <= 0 {
+		return DefaultChunkSize
+	}
+	return o.ChunkSize
+}
+
+// groupVersion returns the API version r is listed at, or "" when r is not
+// served. Without api-resources output every resource is assumed served at
+// its default version.
+func groupVersion(r discoveryResource, versions map[string]string) string {
+	if versions != nil {
+		return versions[r.String()]
+	}
+	return defaultGroupVersions[r.Group]
+}
+
+// listPath is the API path of r in namespace, or across all namespaces when
+// namespace is empty.
+func listPath(r discoveryResource, groupVersion, namespace string) string {
+	path := "/apis/" + groupVersion
+	if r.Group == "" {
+		path = "/api/" + groupVersion
+	}
+	if namespace != "" {
+		path += "/namespaces/" + url.PathEscape(namespace)
+	}
+	return path + "/" + r.Resource
+}
+
+// pageURL asks for at most limit objects of the list at path, from where the
+// page that returned token ended.
+func pageURL(path string, limit int, token string) string {
+	query := url.Values{"limit": {strconv.Itoa(limit)}}
+	if token != "" {
+		query.Set("continue", token)
+	}
+	return path + "?" + query.Encode()
+}
+
+// listPages lists r chunkSize objects at a time, passing each page to visit
+// before the next is requested, so only one page is held at once. visit
+// returns the page's continue token, empty on the last page. When the
+// API server has compacted away the list's snapshot it answers a continue
+// token with 410 Gone; the list then starts over from the first page, after
+// restart discards what visit kept.
+func (o ListOptions) listPages(ctx context.Context, r discoveryResource, groupVersion, namespace string, visit func(page []byte) (string, error), restart func()) error {
+	path := listPath(r, groupVersion, namespace)
+	token := ""
+	restarts := 0
+	for {
+		page, err := o.kubectl()(ctx, nil, "get", "--raw", pageURL(path, o.chunkSize(), token))
+		if err != nil && token != "" && isExpiredContinue(err) && restarts < maxListRestarts {
+			restarts++
+			token = ""
+			restart()
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		next, err := visit(page)
+		if err != nil {
+			return fmt.Errorf("%w: %v", errUnexpectedOutput, err)
+		}
+		if next == "" {
+			return nil
+		}
+		token = next
+	}
+}
+
+// isExpiredContinue reports whether a page request failed because its
+// continue token expired: the API's 410 Gone with reason Expired.
+func isExpiredContinue(err error) bool {
+	return strings.Contains(err.Error(), "(Expired)") ||
+		strings.Contains(err.Error(), "continue parameter is too old")
+}
+
+// listTarget lists a target of q, parsing page by page. Only OLM queries
+// list several resources; Subscriptions name ClusterServiceVersions that may
+// be on other pages, so the items are joined before they are read.
+func (o ListOptions) listTarget(ctx context.Context, q resourceQuery, target scanTarget, versions map[string]string) ([]DetectedAddon, error) {
+	if len(target.resources) > 1 {
+		var items []olmItem
+		for _, r := range target.resources {
+			listed := len(items)
+			err := o.listPages(ctx, r, groupVersion(r, versions), target.namespace, func(page []byte) (string, error) {
+				pageItems, next, err := parseOLMPage(page)
+				items = append(items, pageItems...)
+				return next, err
+			}, func() { items = items[:listed] })
+			if err != nil {
+				return nil, err
+			}
+		}
+		return olmOperators(items, q.source), nil
+	}
+
+	r := target.resources[0]
+	var addons []DetectedAddon
+	err := o.listPages(ctx, r, groupVersion(r, versions), target.namespace, func(page []byte) (string, error) {
+		found, next, err := q.parse(page, q.source)
+		addons = append(addons, found...)
+		return next, err
+	}, func() { addons = nil })
+	return addons, err
+}
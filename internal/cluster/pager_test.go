@@ -0,0 +1,261 @@
+package cluster
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/qbandev/kaddons/internal/output"
+)
+
+// fakeAPI answers kubectl api-resources and get --raw from canned output,
+// keyed by the raw URL.
+type fakeAPI struct {
+	apiResources string
+	pages        map[string]string
+	errs         map[string]error
+
+	mu   sync.Mutex
+	urls []string
+}
+
+func (f *fakeAPI) run(_ context.Context, _ []byte, args ...string) ([]byte, error) {
+	if args[0] == "api-resources" {
+		if f.apiResources == "" {
+			return nil, errors.New("exit status 1: unable to retrieve the complete list of server APIs")
+		}
+		return []byte(f.apiResources), nil
+	}
+	rawURL := args[len(args)-1]
+	f.mu.Lock()
+	f.urls = append(f.urls, rawURL)
+	f.mu.Unlock()
+	path, _, _ := strings.Cut(rawURL, "?")
+	if err := f.errs[path]; err != nil {
+		return nil, err
+	}
+	if page, ok := f.pages[rawURL]; ok {
+		return []byte(page), nil
+	}
+	return nil, errors.New("exit status 1: Error from server (NotFound): the server could not find the requested resource")
+}
+
+func TestListPathAndPageURL(t *testing.T) {
+	tests := []struct {
+		resource     discoveryResource
+		groupVersion string
+		namespace    string
+		token        string
+		want         string
+	}{
+		{deploymentResource, "apps/v1", "", "", "/apis/apps/v1/deployments?limit=2"},
+		{deploymentResource, "apps/v1", "team-a", "", "/apis/apps/v1/namespaces/team-a/deployments?limit=2"},
+		{nodeResource, "v1", "", "", "/api/v1/nodes?limit=2"},
+		{csvResource, "operators.coreos.com/v1alpha1", "", "eyJ2IjoibWV0YS5rOHMuaW8vdjEiLCJydiI6MTJ9", "/apis/operators.coreos.com/v1alpha1/clusterserviceversions?continue=eyJ2IjoibWV0YS5rOHMuaW8vdjEiLCJydiI6MTJ9&limit=2"},
+	}
+	for _, tt := range tests {
+		if got := pageURL(listPath(tt.resource, tt.groupVersion, tt.namespace), 2, tt.token); got != tt.want {
+			t.Errorf("pageURL(listPath(%s, %q)) = %q, want %q", tt.resource, tt.namespace, got, tt.want)
+		}
+	}
+}
+
+func TestListInstalledAddons_Pages(t *testing.T) {
+	api := &fakeAPI{
+		apiResources: `deployments           deploy   apps/v1                         true   Deployment
+daemonsets            ds       apps/v1                         true   DaemonSet
+statefulsets          sts      apps/v1                         true   StatefulSet
+clusterserviceversions csv     operators.coreos.com/v1alpha1   true   ClusterServiceVersion
+subscriptions         sub      operators.coreos.com/v1alpha1   true   Subscription
+`,
+		pages: map[string]string{
+			"/apis/apps/v1/deployments?limit=2": `{"kind": "DeploymentList", "metadata": {"continue": "page-2"}, "items": [
+				{"metadata": {"name": "cert-manager", "namespace": "cert-manager", "labels": {"app.kubernetes.io/version": "v1.14.2"}}},
+				{"metadata": {"name": "keda-operator", "namespace": "keda", "labels": {"app.kubernetes.io/name": "keda"}},
+				 "spec": {"template": {"spec": {"containers": [{"image": "ghcr.io/kedacore/keda:2.12.0"}]}}}}
+			]}`,
+			"/apis/apps/v1/deployments?continue=page-2&limit=2": `{"kind": "DeploymentList", "metadata": {}, "items": [
+				{"metadata": {"name": "etcd-operator", "namespace": "operators", "labels": {"app.kubernetes.io/version": "0.9.2"}}}
+			]}`,
+			"/apis/apps/v1/statefulsets?limit=2": `{"kind": "StatefulSetList", "metadata": {}, "items": []}`,
+			"/apis/operators.coreos.com/v1alpha1/clusterserviceversions?limit=2": `{"kind": "ClusterServiceVersionList", "metadata": {"continue": "csv-2"}, "items": [
+				{"metadata": {"name": "etcdoperator.v0.9.4", "namespace": "operators"}, "spec": {"version": "0.9.4"}, "status": {"phase": "Succeeded"}}
+			]}`,
+			"/apis/operators.coreos.com/v1alpha1/clusterserviceversions?continue=csv-2&limit=2": `{"kind": "ClusterServiceVersionList", "metadata": {}, "items": [
+				{"metadata": {"name": "etcdoperator.v0.9.4", "namespace": "team-a", "labels": {"olm.copiedFrom": "operators"}}, "spec": {"version": "0.9.4"}}
+			]}`,
+			"/apis/operators.coreos.com/v1alpha1/subscriptions?limit=2": `{"kind": "SubscriptionList", "metadata": {}, "items": [
+				{"metadata": {"name": "etcd", "namespace": "operators"}, "spec": {"name": "etcd"}, "status": {"installedCSV": "etcdoperator.v0.9.4"}}
+			]}`,
+		},
+		errs: map[string]error{
+			"/apis/apps/v1/daemonsets": errors.New(`exit status 1: Error from server (Forbidden): daemonsets.apps is forbidden`),
+		},
+	}
+
+	addons, skipped, err := ListInstalledAddons(context.Background(), "", nil, ListOptions{ChunkSize: 2, Kubectl: api.run})
+	if err != nil {
+		t.Fatalf("ListInstalledAddons() error = %v", err)
+	}
+	want := []DetectedAddon{
+		{Name: "etcd", Namespace: "operators", Version: "0.9.4", Source: "olm"},
+		{Name: "cert-manager", Namespace: "cert-manager", Version: "v1.14.2", Source: "deployment"},
+		{Name: "keda", Namespace: "keda", Version: "2.12.0", Source: "deployment"},
+		{Name: "etcd-operator", Namespace: "operators", Version: "0.9.2", Source: "deployment"},
+	}
+	if !reflect.DeepEqual(addons, want) {
+		t.Errorf("ListInstalledAddons() addons =\n%+v\nwant\n%+v", addons, want)
+	}
+	wantSkipped := []output.SkippedScope{{Resource: "daemonsets.apps", Reason: "exit status 1: Error from server (Forbidden): daemonsets.apps is forbidden"}}
+	if !reflect.DeepEqual(skipped, wantSkipped) {
+		t.Errorf("ListInstalledAddons() skipped = %+v, want %+v", skipped, wantSkipped)
+	}
+	for _, url := range api.urls {
+		if strings.Contains(url, "argoproj.io") || strings.Contains(url, "fluxcd.io") {
+			t.Errorf("requested %s, which api-resources does not serve", url)
+		}
+	}
+	if !slices.Contains(api.urls, "/apis/apps/v1/deployments?continue=page-2&limit=2") {
+		t.Errorf("second deployments page was not requested: %v", api.urls)
+	}
+}
+
+func TestListInstalledAddons_WithoutAPIResources(t *testing.T) {
+	api := &fakeAPI{
+		pages: map[string]string{
+			"/apis/apps/v1/namespaces/team-a/deployments?limit=500": `{"kind": "DeploymentList", "metadata": {}, "items": [
+				{"metadata": {"name": "podinfo", "namespace": "team-a", "labels": {"app.kubernetes.io/version": "6.5.0"}}}
+			]}`,
+			"/apis/apps/v1/namespaces/team-a/daemonsets?limit=500":   `{"kind": "DaemonSetList", "metadata": {}, "items": [`,
+			"/apis/apps/v1/namespaces/team-a/statefulsets?limit=500": `{"kind": "StatefulSetList", "metadata": {}, "items": []}`,
+		},
+	}
+
+	// Missing CRDs are NotFound at their default versions and skipped
+	// silently; the truncated DaemonSet page is skipped with a warning.
+	addons, skipped, err := ListInstalledAddons(context.Background(), "team-a", nil, ListOptions{Kubectl: api.run})
+	if err != nil {
+		t.Fatalf("ListInstalledAddons() error = %v", err)
+	}
+	want := []DetectedAddon{{Name: "podinfo", Namespace: "team-a", Version: "6.5.0", Source: "deployment"}}
+	if !reflect.DeepEqual(addons, want) || len(skipped) != 0 {
+		t.Errorf("ListInstalledAddons() = %+v, skipped %+v; want %+v and nothing skipped", addons, skipped, want)
+	}
+	if !slices.Contains(api.urls, "/apis/operators.coreos.com/v1alpha1/namespaces/team-a/clusterserviceversions?limit=500") {
+		t.Errorf("ClusterServiceVersions were not listed at their default version: %v", api.urls)
+	}
+
+	failing := &fakeAPI{errs: map[string]error{
+		"/apis/apps/v1/deployments":  errors.New("exit status 1: Unable to connect to the server"),
+		"/apis/apps/v1/daemonsets":   errors.New("exit status 1: Unable to connect to the server"),
+		"/apis/apps/v1/statefulsets": errors.New("exit status 1: Unable to connect to the server"),
+	}}
+	if _, _, err := ListInstalledAddons(context.Background(), "", nil, ListOptions{Kubectl: failing.run}); err == nil {
+		t.Error("ListInstalledAddons() with every workload listing failing: want error")
+	}
+}
+
+// expiredContinue is kubectl's error for a continue token the API server
+// answered with 410 Gone.
+var expiredContinue = errors.New("exit status 1: Error from server (Expired): The provided continue parameter is too old to display a consistent list result.")
+
+func TestListCustomResourceDefinitions_RestartsExpiredList(t *testing.T) {
+	const first = "/apis/apiextensions.k8s.io/v1/customresourcedefinitions?limit=1"
+	var urls []string
+	expired := false
+	kubectl := func(_ context.Context, _ []byte, args ...string) ([]byte, error) {
+		rawURL := args[len(args)-1]
+		urls = append(urls, rawURL)
+		switch rawURL {
+		case first:
+			return []byte(`{"kind": "CustomResourceDefinitionList", "metadata": {"continue": "crd-2"}, "items": [
+				{"metadata": {"name": "certificates.cert-manager.io", "labels": {"app.kubernetes.io/version": "v1.14.2"}}, "spec": {"group": "cert-manager.io"}}
+			]}`), nil
+		case "/apis/apiextensions.k8s.io/v1/customresourcedefinitions?continue=crd-2&limit=1":
+			// The first attempt outlives the list's snapshot.
+			if !expired {
+				expired = true
+				return nil, expiredContinue
+			}
+			return []byte(`{"kind": "CustomResourceDefinitionList", "metadata": {}, "items": [
+				{"metadata": {"name": "scaledobjects.keda.sh"}, "spec": {"group": "keda.sh"}}
+			]}`), nil
+		}
+		return nil, errors.New("exit status 1: unexpected request " + rawURL)
+	}
+
+	crds, err := ListCustomResourceDefinitions(context.Background(), nil, ListOptions{ChunkSize: 1, Kubectl: kubectl})
+	if err != nil {
+		t.Fatalf("ListCustomResourceDefinitions() error = %v", err)
+	}
+	want := []CustomResourceDefinition{
+		{Name: "certificates.cert-manager.io", Group: "cert-manager.io", VersionHint: "v1.14.2"},
+		{Name: "scaledobjects.keda.sh", Group: "keda.sh"},
+	}
+	if !reflect.DeepEqual(crds, want) {
+		t.Errorf("ListCustomResourceDefinitions() =\n%+v\nwant\n%+v", crds, want)
+	}
+	if got := len(urls); got != 4 || urls[2] != first {
+		t.Errorf("requests = %v, want the list restarted from its first page", urls)
+	}
+}
+
+func TestListPages_GivesUpOnRepeatedExpiry(t *testing.T) {
+	calls := 0
+	kubectl := func(_ context.Context, _ []byte, args ...string) ([]byte, error) {
+		calls++
+		if strings.Contains(args[len(args)-1], "continue=") {
+			return nil, expiredContinue
+		}
+		return []byte(`{"kind": "NodeList", "metadata": {"continue": "next"}, "items": [{"metadata": {"name": "node-a"}}]}`), nil
+	}
+
+	distribution, skipped := DetectDistribution(context.Background(), ServerVersion{GitVersion: "v1.31.0"}, nil, ListOptions{Kubectl: kubectl})
+	if len(distribution.Nodes) != 0 {
+		t.Errorf("DetectDistribution() nodes = %+v, want none from an incomplete list", distribution.Nodes)
+	}
+	if len(skipped) != 1 || skipped[0].Resource != ResourceNodes || !strings.Contains(skipped[0].Reason, "Expired") {
+		t.Errorf("DetectDistribution() skipped = %+v, want the expired nodes list", skipped)
+	}
+	if want := 2 * (maxListRestarts + 1); calls != want {
+		t.Errorf("kubectl ran %d times, want %d", calls, want)
+	}
+}
+
+func TestListTarget_RestartDropsPartialResults(t *testing.T) {
+	expired := false
+	kubectl := func(_ context.Context, _ []byte, args ...string) ([]byte, error) {
+		switch args[len(args)-1] {
+		case "/apis/apps/v1/deployments?limit=1":
+			return []byte(`{"kind": "DeploymentList", "metadata": {"continue": "d-2"}, "items": [
+				{"metadata": {"name": "cert-manager", "namespace": "cert-manager", "labels": {"app.kubernetes.io/version": "v1.14.2"}}}
+			]}`), nil
+		case "/apis/apps/v1/deployments?continue=d-2&limit=1":
+			if !expired {
+				expired = true
+				return nil, expiredContinue
+			}
+			return []byte(`{"kind": "DeploymentList", "metadata": {}, "items": [
+				{"metadata": {"name": "podinfo", "namespace": "team-a", "labels": {"app.kubernetes.io/version": "6.5.0"}}}
+			]}`), nil
+		}
+		return []byte(`{"kind": "List", "metadata": {}, "items": []}`), nil
+	}
+
+	q := discoveryQueries[1] // deployments
+	addons, err := ListOptions{ChunkSize: 1, Kubectl: kubectl}.listTarget(context.Background(), q, scanTarget{resources: q.resources}, nil)
+	if err != nil {
+		t.Fatalf("listTarget() error = %v", err)
+	}
+	want := []DetectedAddon{
+		{Name: "cert-manager", Namespace: "cert-manager", Version: "v1.14.2", Source: "deployment"},
+		{Name: "podinfo", Namespace: "team-a", Version: "6.5.0", Source: "deployment"},
+	}
+	if !reflect.DeepEqual(addons, want) {
+		t.Errorf("listTarget() =\n%+v\nwant\n%+v", addons, want)
+	}
+}
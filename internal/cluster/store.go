@@ -0,0 +1,185 @@
+package cluster
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/qbandev/kaddons/internal/output"
+)
+
+// Report store kinds accepted by --store.
+const (
+	StoreConfigMap      = "configmap"
+	StoreCustomResource = "crd"
+)
+
+// StoreKinds lists the supported report store kinds.
+var StoreKinds = []string{StoreConfigMap, StoreCustomResource}
+
+// The AddonCompatibilityReport custom resource, defined by deploy/crd.yaml.
+const (
+	ReportGroup      = "kaddons.qbandev.github.io"
+	ReportVersion    = "v1alpha1"
+	ReportKind       = "AddonCompatibilityReport"
+	ReportResource   = "addoncompatibilityreports"
+	ReportAPIVersion = ReportGroup + "/" + ReportVersion
+)
+
+// DefaultReportName names the ConfigMap or custom resource a report is saved
+// to when --store-name is not given.
+const DefaultReportName = "kaddons-report"
+
+// ReportDataKey is the ConfigMap key holding the JSON report.
+const ReportDataKey = "report.json"
+
+// maxReportBytes caps a stored report. It is the API server's limit on
+// ConfigMap data, and keeps a custom resource's status within etcd's 1.5 MiB
+// request limit.
+const maxReportBytes = 1 << 20
+
+// fieldManager owns the fields kaddons writes with server-side apply.
+const fieldManager = "kaddons"
+
+// ReportStore saves reports into the cluster, so that other in-cluster tools
+// and kubectl get can read the latest result. Objects are written with
+// kubectl server-side apply, so each run replaces the previous report and
+// no last-applied annotation doubles the stored size.
+type ReportStore struct {
+	// Kind is StoreConfigMap or StoreCustomResource.
+	Kind string
+	// Name is the object name; DefaultReportName when empty.
+	Name string
+	// Namespace is the object namespace. Empty uses kubectl's namespace,
+	// which for a pod is its service account's namespace.
+	Namespace string
+	// Kubectl runs kubectl with stdin. Nil runs the kubectl binary; tests
+	// substitute a fake.
+	Kubectl func(ctx context.Context, stdin []byte, args ...string) ([]byte, error)
+}
+
+// ValidateStoreKind checks a --store value. Empty means no store.
+func ValidateStoreKind(kind string) error {
+	switch kind {
+	case "", StoreConfigMap, StoreCustomResource:
+		return nil
+	}
+	return fmt.Errorf("unsupported report store %q (supported: %s)", kind, strings.Join(StoreKinds, ", "))
+}
+
+// Save writes report to the store's ConfigMap or AddonCompatibilityReport.
+func (s ReportStore) Save(ctx context.Context, report output.CompatibilityReport) error {
+	reportJSON, err := json.Marshal(report)
+	if err != nil {
+		return fmt.Errorf("marshaling report: %w", err)
+	}
+	if err := ValidateStoreKind(s.Kind); err != nil {
+		return err
+	}
+	// Checked before anything is applied, so a report that is too large
+	// leaves no object without its status behind.
+	if len(reportJSON) > maxReportBytes {
+		return fmt.Errorf("report is %d bytes, over the %d byte %s limit; filter with --namespace or --addons", len(reportJSON), maxReportBytes, s.kindName())
+	}
+	summary := output.Summarize(report.Addons)
+
+	switch s.Kind {
+	case StoreConfigMap:
+		data := map[string]string{
+			ReportDataKey:  string(reportJSON),
+			"k8s-version":  report.K8sVersion,
+			"compatible":   strconv.Itoa(summary.Compatible),
+			"incompatible": strconv.Itoa(summary.Incompatible),
+			"unknown":      strconv.Itoa(summary.Unknown),
+		}
+		if report.Metadata != nil {
+			data["generated-at"] = report.Metadata.GeneratedAt
+		}
+		return s.apply(ctx, s.object("v1", "ConfigMap", map[string]any{"data": data}))
+	case StoreCustomResource:
+		if err := s.apply(ctx, s.object(ReportAPIVersion, ReportKind, nil)); err != nil {
+			return err
+		}
+		status := reportStatus{
+			K8sVersion:   report.K8sVersion,
+			Compatible:   summary.Compatible,
+			Incompatible: summary.Incompatible,
+			Unknown:      summary.Unknown,
+			Report:       report,
+		}
+		if report.Metadata != nil {
+			status.GeneratedAt = report.Metadata.GeneratedAt
+		}
+		return s.apply(ctx, s.object(ReportAPIVersion, ReportKind, map[string]any{"status": status}), "--subresource=status")
+	}
+	return nil
+}
+
+// reportStatus is the status of an AddonCompatibilityReport. The counts back
+// the printer columns of kubectl get.
+type reportStatus struct {
+	K8sVersion   string                     `json:"k8sVersion"`
+	GeneratedAt  string                     `json:"generatedAt,omitempty"`
+	Compatible   int                        `json:"compatible"`
+	Incompatible int                        `json:"incompatible"`
+	Unknown      int                        `json:"unknown"`
+	Report       output.CompatibilityReport `json:"report"`
+}
+
+// object builds a manifest with the store's name, namespace and labels plus
+// the given top-level fields.
+func (s ReportStore) object(apiVersion, kind string, fields map[string]any) map[string]any {
+	metadata := map[string]any{
+		"name":   s.name(),
+		"labels": map[string]string{"app.kubernetes.io/managed-by": "kaddons"},
+	}
+	if s.Namespace != "" {
+		metadata["namespace"] = s.Namespace
+	}
+	object := map[string]any{"apiVersion": apiVersion, "kind": kind, "metadata": metadata}
+	for key, value := range fields {
+		object[key] = value
+	}
+	return object
+}
+
+// String names the stored object, e.g. "ConfigMap kaddons/kaddons-report".
+func (s ReportStore) String() string {
+	if s.Namespace == "" {
+		return s.kindName() + " " + s.name()
+	}
+	return s.kindName() + " " + s.Namespace + "/" + s.name()
+}
+
+func (s ReportStore) kindName() string {
+	if s.Kind == StoreCustomResource {
+		return ReportKind
+	}
+	return "ConfigMap"
+}
+
+func (s ReportStore) name() string {
+	if s.Name == "" {
+		return DefaultReportName
+	}
+	return s.Name
+}
+
+func (s ReportStore) apply(ctx context.Context, object map[string]any, extraArgs ...string) error {
+	manifest, err := json.Marshal(object)
+	if err != nil {
+		return fmt.Errorf("marshaling %s manifest: %w", object["kind"], err)
+	}
+	args := []string{"apply", "--server-side", "--field-manager=" + fieldManager, "--force-conflicts", "--output=name", "--filename=-"}
+	args = append(args, extraArgs...)
+	run := s.Kubectl
+	if run == nil {
+		run = runKubectlWithInput
+	}
+	if _, err := run(ctx, manifest, args...); err != nil {
+		return fmt.Errorf("storing report in %s: %w", s, err)
+	}
+	return nil
+}
@@ -0,0 +1,249 @@
+package cluster
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"os"
+	"reflect"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/qbandev/kaddons/internal/output"
+	"gopkg.in/yaml.v3"
+)
+
+// fakeKubectl records kubectl invocations instead of running them.
+type fakeKubectl struct {
+	calls []fakeKubectlCall
+	err   error
+}
+
+type fakeKubectlCall struct {
+	args     []string
+	manifest map[string]any
+}
+
+func (f *fakeKubectl) run(_ context.Context, stdin []byte, args ...string) ([]byte, error) {
+	call := fakeKubectlCall{args: args}
+	if err := json.Unmarshal(stdin, &call.manifest); err != nil {
+		return nil, err
+	}
+	f.calls = append(f.calls, call)
+	return nil, f.err
+}
+
+func storeTestReport() output.CompatibilityReport {
+	metadata := output.NewMetadata(output.ToolInfo{Name: "kaddons", Version: "v1.4.0"}, output.DatabaseInfo{Entries: 1}, output.AnalysisInfo{Provider: output.AnalysisProviderNone})
+	return output.CompatibilityReport{
+		K8sVersion: "1.31",
+		Metadata:   metadata,
+		Addons: []output.AddonCompatibility{
+			{Name: "cert-manager", Namespace: "cert-manager", InstalledVersion: "v1.14.2", Compatible: output.StatusFalse},
+			{Name: "keda", Namespace: "keda", InstalledVersion: "2.12.0", Compatible: output.StatusTrue},
+			{Name: "widget", Compatible: output.StatusUnknown},
+		},
+	}
+}
+
+func TestReportStore_SaveConfigMap(t *testing.T) {
+	kubectl := &fakeKubectl{}
+	store := ReportStore{Kind: StoreConfigMap, Namespace: "kaddons", Kubectl: kubectl.run}
+	report := storeTestReport()
+	if err := store.Save(context.Background(), report); err != nil {
+		t.Fatalf("Save error = %v", err)
+	}
+	if len(kubectl.calls) != 1 {
+		t.Fatalf("got %d kubectl calls, want 1", len(kubectl.calls))
+	}
+	call := kubectl.calls[0]
+	if !slices.Contains(call.args, "--server-side") || !slices.Contains(call.args, "--filename=-") {
+		t.Errorf("args = %v, want server-side apply from stdin", call.args)
+	}
+	manifest := call.manifest
+	metadata := manifest["metadata"].(map[string]any)
+	if manifest["kind"] != "ConfigMap" || metadata["name"] != DefaultReportName || metadata["namespace"] != "kaddons" {
+		t.Errorf("manifest header = %v %v", manifest["kind"], metadata)
+	}
+	data := manifest["data"].(map[string]any)
+	want := map[string]any{"k8s-version": "1.31", "compatible": "1", "incompatible": "1", "unknown": "1", "generated-at": report.Metadata.GeneratedAt}
+	for key, value := range want {
+		if data[key] != value {
+			t.Errorf("data[%s] = %v, want %v", key, data[key], value)
+		}
+	}
+	var stored output.CompatibilityReport
+	if err := json.Unmarshal([]byte(data[ReportDataKey].(string)), &stored); err != nil {
+		t.Fatalf("%s is not a report: %v", ReportDataKey, err)
+	}
+	if !reflect.DeepEqual(stored.Addons, report.Addons) {
+		t.Errorf("stored addons = %+v, want %+v", stored.Addons, report.Addons)
+	}
+}
+
+func TestReportStore_SaveCustomResource(t *testing.T) {
+	kubectl := &fakeKubectl{}
+	store := ReportStore{Kind: StoreCustomResource, Name: "nightly", Kubectl: kubectl.run}
+	if err := store.Save(context.Background(), storeTestReport()); err != nil {
+		t.Fatalf("Save error = %v", err)
+	}
+	if len(kubectl.calls) != 2 {
+		t.Fatalf("got %d kubectl calls, want object then status", len(kubectl.calls))
+	}
+
+	object, status := kubectl.calls[0], kubectl.calls[1]
+	if object.manifest["apiVersion"] != ReportAPIVersion || object.manifest["kind"] != ReportKind {
+		t.Errorf("object manifest = %v", object.manifest)
+	}
+	if _, ok := object.manifest["metadata"].(map[string]any)["namespace"]; ok {
+		t.Error("namespace set although none was given")
+	}
+	if slices.Contains(object.args, "--subresource=status") || !slices.Contains(status.args, "--subresource=status") {
+		t.Errorf("status must be applied separately: %v then %v", object.args, status.args)
+	}
+	fields := status.manifest["status"].(map[string]any)
+	if fields["k8sVersion"] != "1.31" || fields["compatible"] != 1.0 || fields["incompatible"] != 1.0 || fields["unknown"] != 1.0 {
+		t.Errorf("status = %v", fields)
+	}
+	if addons := fields["report"].(map[string]any)["addons"].([]any); len(addons) != 3 {
+		t.Errorf("status report has %d addons, want 3", len(addons))
+	}
+}
+
+func TestReportStore_Errors(t *testing.T) {
+	large := storeTestReport()
+	large.Addons[0].Note = strings.Repeat("x", maxReportBytes)
+
+	tests := []struct {
+		name    string
+		store   ReportStore
+		report  output.CompatibilityReport
+		wantErr string
+	}{
+		{name: "report over ConfigMap limit", store: ReportStore{Kind: StoreConfigMap}, report: large, wantErr: "ConfigMap limit; filter with --namespace or --addons"},
+		{name: "report over custom resource limit", store: ReportStore{Kind: StoreCustomResource}, report: large, wantErr: "AddonCompatibilityReport limit; filter with --namespace or --addons"},
+		{name: "kubectl failure", store: ReportStore{Kind: StoreCustomResource}, report: storeTestReport(), wantErr: "storing report in AddonCompatibilityReport kaddons-report: forbidden"},
+		{name: "unknown kind", store: ReportStore{Kind: "secret"}, report: storeTestReport(), wantErr: `unsupported report store "secret"`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			kubectl := &fakeKubectl{err: errors.New("forbidden")}
+			tt.store.Kubectl = kubectl.run
+			err := tt.store.Save(context.Background(), tt.report)
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("error = %v, want %q", err, tt.wantErr)
+			}
+			if tt.report.Addons[0].Note != "" && len(kubectl.calls) != 0 {
+				t.Errorf("oversized report applied %d objects, want none", len(kubectl.calls))
+			}
+		})
+	}
+}
+
+func TestValidateStoreKind(t *testing.T) {
+	for _, kind := range []string{"", StoreConfigMap, StoreCustomResource} {
+		if err := ValidateStoreKind(kind); err != nil {
+			t.Errorf("ValidateStoreKind(%q) error = %v", kind, err)
+		}
+	}
+	if err := ValidateStoreKind("ConfigMap"); err == nil {
+		t.Error("ValidateStoreKind(ConfigMap) succeeded, want an error")
+	}
+}
+
+// TestDeployManifests keeps deploy/ in step with the store: the CRD defines
+// the resource and status fields Save writes, and the service account may
+// write them.
+func TestDeployManifests(t *testing.T) {
+	var crd struct {
+		Spec struct {
+			Group string `yaml:"group"`
+			Names struct {
+				Kind   string `yaml:"kind"`
+				Plural string `yaml:"plural"`
+			} `yaml:"names"`
+			Versions []struct {
+				Name         string         `yaml:"name"`
+				Subresources map[string]any `yaml:"subresources"`
+				Schema       struct {
+					OpenAPIV3Schema struct {
+						Properties struct {
+							Status struct {
+								Properties map[string]any `yaml:"properties"`
+							} `yaml:"status"`
+						} `yaml:"properties"`
+					} `yaml:"openAPIV3Schema"`
+				} `yaml:"schema"`
+			} `yaml:"versions"`
+		} `yaml:"spec"`
+	}
+	data, err := os.ReadFile("../../deploy/crd.yaml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := yaml.Unmarshal(data, &crd); err != nil {
+		t.Fatalf("parsing crd.yaml: %v", err)
+	}
+	if crd.Spec.Group != ReportGroup || crd.Spec.Names.Kind != ReportKind || crd.Spec.Names.Plural != ReportResource {
+		t.Errorf("CRD defines %s %s/%s, want %s %s/%s", crd.Spec.Names.Kind, crd.Spec.Group, crd.Spec.Names.Plural, ReportKind, ReportGroup, ReportResource)
+	}
+	if len(crd.Spec.Versions) != 1 || crd.Spec.Versions[0].Name != ReportVersion {
+		t.Fatalf("CRD versions = %+v, want only %s", crd.Spec.Versions, ReportVersion)
+	}
+	version := crd.Spec.Versions[0]
+	if _, ok := version.Subresources["status"]; !ok {
+		t.Error("CRD has no status subresource")
+	}
+	statusType := reflect.TypeOf(reportStatus{})
+	for i := range statusType.NumField() {
+		name, _, _ := strings.Cut(statusType.Field(i).Tag.Get("json"), ",")
+		if _, ok := version.Schema.OpenAPIV3Schema.Properties.Status.Properties[name]; !ok {
+			t.Errorf("CRD status schema has no property %q", name)
+		}
+	}
+
+	rbac, err := os.ReadFile("../../deploy/rbac.yaml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	granted := make(map[string]bool)
+	decoder := yaml.NewDecoder(strings.NewReader(string(rbac)))
+	for {
+		var document struct {
+			Kind     string `yaml:"kind"`
+			Metadata struct {
+				Name string `yaml:"name"`
+			} `yaml:"metadata"`
+			Rules []struct {
+				APIGroups []string `yaml:"apiGroups"`
+				Resources []string `yaml:"resources"`
+				Verbs     []string `yaml:"verbs"`
+			} `yaml:"rules"`
+		}
+		if err := decoder.Decode(&document); errors.Is(err, io.EOF) {
+			break
+		} else if err != nil {
+			t.Fatalf("parsing rbac.yaml: %v", err)
+		}
+		if document.Kind != "Role" || document.Metadata.Name != "kaddons-report-writer" {
+			continue
+		}
+		for _, rule := range document.Rules {
+			if !slices.Contains(rule.Verbs, "create") || !slices.Contains(rule.Verbs, "patch") {
+				continue
+			}
+			for _, group := range rule.APIGroups {
+				for _, resource := range rule.Resources {
+					granted[group+"/"+resource] = true
+				}
+			}
+		}
+	}
+	for _, want := range []string{"/configmaps", ReportGroup + "/" + ReportResource, ReportGroup + "/" + ReportResource + "/status"} {
+		if !granted[want] {
+			t.Errorf("kaddons-report-writer Role cannot create and patch %s", want)
+		}
+	}
+}
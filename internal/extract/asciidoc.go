@@ -0,0 +1,169 @@
+package extract
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// ExtractAsciiDocMatrix parses AsciiDoc content for |=== table blocks containing
+// compatibility data. Returns nil map and nil error when no parseable table is found.
+func ExtractAsciiDocMatrix(content string) (map[string][]string, error) {
+	return tableMatrix(firstTableCompatibility(RawFormatAsciiDoc, parseAsciiDocTables(content))), nil
+}
+
+// asciiDocColsRe reads the cols attribute of a block attribute line such as
+// [cols="1,2,2",options="header"] or [cols=3*].
+var asciiDocColsRe = regexp.MustCompile(`cols\s*=\s*(?:"([^"\]]*)"|([^,\]\s]+))`)
+
+// asciiDocCellSpecRe matches a cell specifier before the "|" separator, e.g.
+// "2+" (column span), ".2+" (row span), "a" (style) or "2*" (duplication).
+var asciiDocCellSpecRe = regexp.MustCompile(`^(?:(\d+)(?:\.\d+)?\+|\.\d+\+|(\d+)\*)?[<^>]?(?:\.[<^>])?[adehlmsv]?$`)
+
+// parseAsciiDocTables extracts all |=== tables from AsciiDoc content.
+// Rows are rebuilt from the column count (cols attribute, or the number of cells
+// on the first line) because AsciiDoc lets one row span several source lines.
+func parseAsciiDocTables(content string) [][][]string {
+	var tables [][][]string
+	lines := strings.Split(content, "\n")
+
+	for i := 0; i < len(lines); i++ {
+		if strings.TrimSpace(lines[i]) != "|===" {
+			continue
+		}
+
+		columns := 0
+		if i > 0 {
+			columns = asciiDocColumnCount(strings.TrimSpace(lines[i-1]))
+		}
+
+		var cells []string
+		firstLineCells := 0
+		end := i + 1
+		for ; end < len(lines); end++ {
+			trimmed := strings.TrimSpace(lines[end])
+			if trimmed == "|===" {
+				break
+			}
+			if trimmed == "" || strings.HasPrefix(trimmed, "//") {
+				continue
+			}
+			lineCells, continuation := parseAsciiDocLine(trimmed)
+			if continuation {
+				// Text without a leading "|" continues the previous cell.
+				if len(cells) > 0 {
+					cells[len(cells)-1] = strings.TrimSpace(cells[len(cells)-1] + " " + trimmed)
+				}
+				continue
+			}
+			if firstLineCells == 0 {
+				firstLineCells = len(lineCells)
+			}
+			cells = append(cells, lineCells...)
+		}
+		i = end
+
+		if columns == 0 {
+			columns = firstLineCells
+		}
+		if columns < 2 || len(cells) > maxCells {
+			// Single-column tables carry no matrix; over-limit tables are discarded
+			// entirely to avoid truncated matrices.
+			continue
+		}
+
+		var rows [][]string
+		for start := 0; start+columns <= len(cells); start += columns {
+			rows = append(rows, cells[start:start+columns])
+		}
+		if len(rows) > 0 {
+			tables = append(tables, rows)
+		}
+	}
+	return tables
+}
+
+// asciiDocColumnCount reads the column count from a block attribute line.
+// Returns 0 when the line has no usable cols attribute.
+func asciiDocColumnCount(attributeLine string) int {
+	if !strings.HasPrefix(attributeLine, "[") {
+		return 0
+	}
+	m := asciiDocColsRe.FindStringSubmatch(attributeLine)
+	if m == nil {
+		return 0
+	}
+	spec := m[1] + m[2]
+	if n, err := strconv.Atoi(strings.TrimSpace(spec)); err == nil {
+		return n
+	}
+	columns := 0
+	for _, entry := range strings.Split(spec, ",") {
+		// "3*" repeats one column spec three times; anything else is one column.
+		if count, _, ok := strings.Cut(strings.TrimSpace(entry), "*"); ok {
+			if n, err := strconv.Atoi(count); err == nil {
+				columns += n
+				continue
+			}
+		}
+		columns++
+	}
+	return columns
+}
+
+// parseAsciiDocLine splits one table source line into cells. Column spans are
+// expanded into repeated cells so rows stay aligned with the header.
+// continuation is true when the line does not start a new cell.
+func parseAsciiDocLine(line string) (cells []string, continuation bool) {
+	separator := strings.Index(line, "|")
+	if separator < 0 || !asciiDocCellSpecRe.MatchString(line[:separator]) {
+		return nil, true
+	}
+
+	parts := strings.Split(line[separator+1:], "|")
+	spec := line[:separator]
+	for i, part := range parts {
+		text := part
+		nextSpec := ""
+		if i < len(parts)-1 {
+			// The specifier for the next cell is glued to the end of this one ("a |2+| b").
+			// Only span/duplication specifiers are recognized here; a lone style
+			// letter is indistinguishable from cell text.
+			fields := strings.Fields(part)
+			if len(fields) > 0 {
+				last := fields[len(fields)-1]
+				if strings.ContainsAny(last, "+*") && asciiDocCellSpecRe.MatchString(last) && strings.HasSuffix(part, last) {
+					nextSpec = last
+					text = strings.TrimSuffix(part, last)
+				}
+			}
+		}
+		cell := cleanAsciiDocCell(text)
+		for n := asciiDocSpan(spec); n > 0; n-- {
+			cells = append(cells, cell)
+		}
+		spec = nextSpec
+	}
+	return cells, false
+}
+
+// asciiDocSpan returns how many column slots a cell specifier occupies.
+func asciiDocSpan(spec string) int {
+	m := asciiDocCellSpecRe.FindStringSubmatch(spec)
+	if m == nil {
+		return 1
+	}
+	for _, group := range m[1:] {
+		if n, err := strconv.Atoi(group); err == nil && n > 0 {
+			return n
+		}
+	}
+	return 1
+}
+
+// cleanAsciiDocCell strips inline formatting marks (*bold*, _italic_, `mono`, +passthrough+).
+func cleanAsciiDocCell(text string) string {
+	text = strings.TrimSpace(text)
+	text = strings.Trim(text, "*_`+")
+	return strings.TrimSpace(text)
+}
@@ -0,0 +1,91 @@
+package extract
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestExtractAsciiDocMatrix_CellsPerLine(t *testing.T) {
+	content := `= Compatibility
+
+[cols="1,1",options="header"]
+|===
+|Version
+|Kubernetes
+
+|v1.14.0
+|1.28, 1.29, 1.30
+
+|*v1.13.0*
+|` + "`1.27`" + `
+|===
+`
+	matrix, err := ExtractAsciiDocMatrix(content)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := map[string][]string{
+		"v1.14.0": {"1.28", "1.29", "1.30"},
+		"v1.13.0": {"1.27"},
+	}
+	if !reflect.DeepEqual(matrix, want) {
+		t.Errorf("matrix = %v, want %v", matrix, want)
+	}
+}
+
+func TestExtractAsciiDocMatrix_RowsOnOneLine(t *testing.T) {
+	content := `|===
+| Release | 1.29 | 1.30 | 1.31
+
+| 2.3.0 | ✓ | ✓ | ✓
+| 2.2.0 | ✓ | ✓ |
+|===`
+	matrix, _ := ExtractAsciiDocMatrix(content)
+	if got := matrix["2.3.0"]; !reflect.DeepEqual(got, []string{"1.29", "1.30", "1.31"}) {
+		t.Errorf("2.3.0 = %v", got)
+	}
+	if got := matrix["2.2.0"]; !reflect.DeepEqual(got, []string{"1.29", "1.30"}) {
+		t.Errorf("2.2.0 = %v", got)
+	}
+}
+
+func TestExtractAsciiDocMatrix_ColumnSpan(t *testing.T) {
+	content := `[cols="3*"]
+|===
+|Version |1.30 |1.31
+|v0.9 2+|yes
+|===`
+	matrix, _ := ExtractAsciiDocMatrix(content)
+	if got := matrix["v0.9"]; !reflect.DeepEqual(got, []string{"1.30", "1.31"}) {
+		t.Errorf("v0.9 = %v, want span expanded to both K8s columns", got)
+	}
+}
+
+func TestExtractAsciiDocMatrix_NoTable(t *testing.T) {
+	matrix, err := ExtractAsciiDocMatrix("= Title\n\nJust text | with a pipe.\n")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if matrix != nil {
+		t.Errorf("expected nil matrix, got %v", matrix)
+	}
+}
+
+func TestAsciiDocColumnCount(t *testing.T) {
+	tests := []struct {
+		line string
+		want int
+	}{
+		{`[cols="1,2,2"]`, 3},
+		{`[cols="3*",options="header"]`, 3},
+		{`[cols=4*]`, 4},
+		{`[cols="2*,1"]`, 3},
+		{`[options="header"]`, 0},
+		{`Some text`, 0},
+	}
+	for _, tt := range tests {
+		if got := asciiDocColumnCount(tt.line); got != tt.want {
+			t.Errorf("asciiDocColumnCount(%q) = %d, want %d", tt.line, got, tt.want)
+		}
+	}
+}
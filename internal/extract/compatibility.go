@@ -0,0 +1,105 @@
+package extract
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"time"
+
+	"github.com/qbandev/kaddons/internal/addon"
+)
+
+// Extraction strategies recorded in provenance.
+const (
+	StrategyVersionHeader = "version-header"
+	StrategyLabeledColumn = "labeled-column"
+	StrategyProse         = "prose"
+	StrategyStructured    = "structured"
+)
+
+// Compatibility holds compatibility data recognized by an extractor.
+// Matrix maps addon versions to supported K8s versions; MinVersion/MaxVersion are
+// page-wide K8s bounds (e.g. "minimum Kubernetes version: 1.25" or a Helm
+// kubeVersion constraint) that apply when no per-version entry is available.
+type Compatibility struct {
+	Matrix     map[string][]string
+	MinVersion string
+	MaxVersion string
+	// Strategy is one of the Strategy* constants.
+	Strategy string
+	// Locator says where in the document the data was found, e.g.
+	// "markdown table 2", "kubeVersion" or "text".
+	Locator string
+}
+
+// HasBounds reports whether a page-wide minimum or maximum K8s version was found.
+func (p *Compatibility) HasBounds() bool {
+	return p != nil && (p.MinVersion != "" || p.MaxVersion != "")
+}
+
+// Confidence grades how much the extracted data can be trusted. Structured
+// sources are authoritative; tables are trusted when they have more than one
+// row; prose is heuristic, and prose bounds without a matrix least of all.
+func (p *Compatibility) Confidence() string {
+	switch p.Strategy {
+	case StrategyStructured:
+		return addon.ConfidenceHigh
+	case StrategyVersionHeader, StrategyLabeledColumn:
+		if len(p.Matrix) >= 2 {
+			return addon.ConfidenceHigh
+		}
+		return addon.ConfidenceMedium
+	default:
+		if len(p.Matrix) > 0 {
+			return addon.ConfidenceMedium
+		}
+		return addon.ConfidenceLow
+	}
+}
+
+// Provenance builds the provenance record for data extracted from content
+// fetched at sourceURL.
+func (p *Compatibility) Provenance(sourceURL string, content string, extractedAt time.Time) *addon.Provenance {
+	return &addon.Provenance{
+		SourceURL:    sourceURL,
+		ContentHash:  ContentHash(content),
+		TableLocator: p.Locator,
+		Strategy:     p.Strategy,
+		ExtractedAt:  extractedAt.UTC().Format(time.RFC3339),
+		Confidence:   p.Confidence(),
+	}
+}
+
+// ContentHash returns the "sha256:<hex>" digest recorded in provenance.
+func ContentHash(content string) string {
+	sum := sha256.Sum256([]byte(content))
+	return "sha256:" + hex.EncodeToString(sum[:])
+}
+
+// ExtractPage runs the extractor chain used by kaddons-extract on one fetched
+// page: the structured extractor when sourceType is set, otherwise table
+// extraction with a prose fallback. isRaw marks repository markup (Markdown,
+// AsciiDoc, reStructuredText) as opposed to HTML. Returns nil if the page
+// yields neither a matrix nor bounds.
+func ExtractPage(pageURL string, raw string, isRaw bool, sourceType string) *Compatibility {
+	if IsStructuredSourceType(sourceType) {
+		structured, _ := ExtractStructured(sourceType, raw)
+		return structured
+	}
+
+	var table, prose *Compatibility
+	if isRaw {
+		table, _ = ExtractRawTable(pageURL, raw)
+		if table == nil {
+			prose = ExtractProseCompatibility(raw)
+		}
+	} else {
+		table, _ = ExtractHTMLTable(raw)
+		if table == nil {
+			prose = ExtractHTMLProseCompatibility(raw)
+		}
+	}
+	if table != nil {
+		return table
+	}
+	return prose
+}
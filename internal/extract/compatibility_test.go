@@ -0,0 +1,51 @@
+package extract
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/qbandev/kaddons/internal/addon"
+)
+
+func TestCompatibilityConfidence(t *testing.T) {
+	twoRows := map[string][]string{"v1.1.0": {"1.30"}, "v1.0.0": {"1.29"}}
+	oneRow := map[string][]string{"v1.0.0": {"1.29"}}
+	tests := []struct {
+		name string
+		c    Compatibility
+		want string
+	}{
+		{"structured bounds", Compatibility{Strategy: StrategyStructured, MinVersion: "1.25"}, addon.ConfidenceHigh},
+		{"multi-row table", Compatibility{Strategy: StrategyLabeledColumn, Matrix: twoRows}, addon.ConfidenceHigh},
+		{"single-row table", Compatibility{Strategy: StrategyVersionHeader, Matrix: oneRow}, addon.ConfidenceMedium},
+		{"prose matrix", Compatibility{Strategy: StrategyProse, Matrix: twoRows}, addon.ConfidenceMedium},
+		{"prose bounds", Compatibility{Strategy: StrategyProse, MinVersion: "1.25"}, addon.ConfidenceLow},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.c.Confidence(); got != tt.want {
+				t.Errorf("Confidence() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCompatibilityProvenance(t *testing.T) {
+	c := &Compatibility{Strategy: StrategyStructured, Locator: "kubeVersion", MinVersion: "1.25"}
+	at := time.Date(2026, 10, 18, 12, 0, 0, 0, time.FixedZone("CEST", 2*60*60))
+
+	p := c.Provenance("https://example.com/Chart.yaml", "kubeVersion: \">=1.25.0-0\"", at)
+	if p.SourceURL != "https://example.com/Chart.yaml" || p.TableLocator != "kubeVersion" || p.Strategy != StrategyStructured {
+		t.Errorf("unexpected provenance: %+v", p)
+	}
+	if p.ExtractedAt != "2026-10-18T10:00:00Z" {
+		t.Errorf("ExtractedAt = %q, want UTC RFC 3339", p.ExtractedAt)
+	}
+	if !strings.HasPrefix(p.ContentHash, "sha256:") || len(p.ContentHash) != len("sha256:")+64 {
+		t.Errorf("ContentHash = %q, want sha256:<64 hex>", p.ContentHash)
+	}
+	if p.ContentHash != ContentHash("kubeVersion: \">=1.25.0-0\"") {
+		t.Error("ContentHash should be deterministic")
+	}
+}
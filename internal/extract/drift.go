@@ -0,0 +1,180 @@
+package extract
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/qbandev/kaddons/internal/addon"
+)
+
+// Drift describes how a live compatibility page differs from the data stored
+// for an addon. Keys of the maps are addon versions as written in the stored
+// matrix (AddedVersions uses the page's spelling); values are K8s versions.
+type Drift struct {
+	// AddedVersions are addon versions on the page that the stored matrix lacks.
+	AddedVersions map[string][]string `json:"added_versions,omitempty"`
+	// AddedK8sVersions are K8s versions the page lists for an addon version
+	// that the stored entry does not.
+	AddedK8sVersions map[string][]string `json:"added_k8s_versions,omitempty"`
+	// RemovedK8sVersions are K8s versions the stored entry lists for an addon
+	// version that the page no longer does.
+	RemovedK8sVersions map[string][]string `json:"removed_k8s_versions,omitempty"`
+	// Contradictions are disagreements that cannot be merged, such as a
+	// different minimum K8s version or an entry with no K8s versions in common.
+	Contradictions []string `json:"contradictions,omitempty"`
+}
+
+// IsEmpty reports whether the page agrees with the stored data.
+func (d Drift) IsEmpty() bool {
+	return len(d.AddedVersions) == 0 && len(d.AddedK8sVersions) == 0 &&
+		len(d.RemovedK8sVersions) == 0 && len(d.Contradictions) == 0
+}
+
+// IsAdditive reports whether the drift only adds data, so it can be merged
+// without dropping anything a reviewer curated.
+func (d Drift) IsAdditive() bool {
+	return !d.IsEmpty() && len(d.RemovedK8sVersions) == 0 && len(d.Contradictions) == 0
+}
+
+// DiffCompatibility compares the stored compatibility data of an addon with
+// data freshly extracted from its compatibility page. Stored addon versions
+// missing from the page are not reported: projects routinely prune old releases
+// from their matrices, and the stored entries remain correct.
+func DiffCompatibility(stored addon.Addon, live *Compatibility) Drift {
+	var d Drift
+	if live == nil {
+		return d
+	}
+
+	storedKeys := make(map[string]string, len(stored.KubernetesCompatibility))
+	for key := range stored.KubernetesCompatibility {
+		storedKeys[normalizeDriftKey(key)] = key
+	}
+
+	liveKeys := make([]string, 0, len(live.Matrix))
+	for key := range live.Matrix {
+		liveKeys = append(liveKeys, key)
+	}
+	sort.Strings(liveKeys)
+
+	for _, liveKey := range liveKeys {
+		liveVersions := normalizeK8sVersions(live.Matrix[liveKey])
+		storedKey, ok := storedKeys[normalizeDriftKey(liveKey)]
+		if !ok {
+			if d.AddedVersions == nil {
+				d.AddedVersions = make(map[string][]string)
+			}
+			d.AddedVersions[liveKey] = liveVersions
+			continue
+		}
+
+		storedVersions := normalizeK8sVersions(stored.KubernetesCompatibility[storedKey])
+		added := subtractVersions(liveVersions, storedVersions)
+		removed := subtractVersions(storedVersions, liveVersions)
+		if len(storedVersions) > 0 && len(added) == len(liveVersions) && len(removed) == len(storedVersions) {
+			d.Contradictions = append(d.Contradictions, fmt.Sprintf(
+				"%s: stored K8s %s, page lists %s", storedKey,
+				strings.Join(storedVersions, ", "), strings.Join(liveVersions, ", ")))
+			continue
+		}
+		if len(added) > 0 {
+			if d.AddedK8sVersions == nil {
+				d.AddedK8sVersions = make(map[string][]string)
+			}
+			d.AddedK8sVersions[storedKey] = added
+		}
+		if len(removed) > 0 {
+			if d.RemovedK8sVersions == nil {
+				d.RemovedK8sVersions = make(map[string][]string)
+			}
+			d.RemovedK8sVersions[storedKey] = removed
+		}
+	}
+
+	if contradiction := diffBound("minimum", stored.KubernetesMinVersion, live.MinVersion); contradiction != "" {
+		d.Contradictions = append(d.Contradictions, contradiction)
+	}
+	if contradiction := diffBound("maximum", stored.KubernetesMaxVersion, live.MaxVersion); contradiction != "" {
+		d.Contradictions = append(d.Contradictions, contradiction)
+	}
+	return d
+}
+
+// MergeAdditive applies the additive parts of d to the stored addon. New
+// addon versions follow the stored matrix's "v" prefix convention (the page's
+// spelling when nothing is stored yet), and K8s version lists are kept sorted.
+func MergeAdditive(stored *addon.Addon, d Drift) {
+	if len(d.AddedVersions) == 0 && len(d.AddedK8sVersions) == 0 {
+		return
+	}
+	matchStyle := len(stored.KubernetesCompatibility) > 0
+	prefixed := storedKeysPrefixed(stored.KubernetesCompatibility)
+	if stored.KubernetesCompatibility == nil {
+		stored.KubernetesCompatibility = make(map[string][]string)
+	}
+	for key, versions := range d.AddedVersions {
+		if matchStyle {
+			key = strings.TrimPrefix(key, "v")
+			if prefixed {
+				key = "v" + key
+			}
+		}
+		stored.KubernetesCompatibility[key] = sortK8sVersions(versions)
+	}
+	for key, versions := range d.AddedK8sVersions {
+		stored.KubernetesCompatibility[key] = sortK8sVersions(append(
+			normalizeK8sVersions(stored.KubernetesCompatibility[key]), versions...))
+	}
+}
+
+// storedKeysPrefixed reports whether the stored matrix spells addon versions
+// with a leading "v"; ties favor the prefixed form.
+func storedKeysPrefixed(matrix map[string][]string) bool {
+	prefixed := 0
+	for key := range matrix {
+		if strings.HasPrefix(key, "v") {
+			prefixed++
+		}
+	}
+	return prefixed*2 >= len(matrix)
+}
+
+func normalizeDriftKey(key string) string {
+	return strings.TrimPrefix(strings.ToLower(strings.TrimSpace(key)), "v")
+}
+
+func normalizeK8sVersions(versions []string) []string {
+	normalized := make([]string, 0, len(versions))
+	for _, v := range versions {
+		normalized = append(normalized, strings.TrimPrefix(strings.TrimSpace(v), "v"))
+	}
+	return sortK8sVersions(normalized)
+}
+
+// subtractVersions returns the versions in a that are not in b, in a's order.
+func subtractVersions(a, b []string) []string {
+	present := make(map[string]bool, len(b))
+	for _, v := range b {
+		present[v] = true
+	}
+	var diff []string
+	for _, v := range a {
+		if !present[v] {
+			diff = append(diff, v)
+		}
+	}
+	return diff
+}
+
+// diffBound reports a stored and a live page-wide bound that are both set and
+// disagree. A bound present on only one side is not a contradiction: stored
+// bounds are often hand-curated, and pages with a matrix rarely state bounds.
+func diffBound(kind, stored, live string) string {
+	stored = strings.TrimPrefix(stored, "v")
+	live = strings.TrimPrefix(live, "v")
+	if stored == "" || live == "" || stored == live {
+		return ""
+	}
+	return fmt.Sprintf("%s K8s version: stored %s, page states %s", kind, stored, live)
+}
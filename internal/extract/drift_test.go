@@ -0,0 +1,129 @@
+package extract
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/qbandev/kaddons/internal/addon"
+)
+
+func TestDiffCompatibility(t *testing.T) {
+	stored := addon.Addon{
+		Name: "example",
+		KubernetesCompatibility: map[string][]string{
+			"v1.14.0": {"1.28", "1.29"},
+			"v1.13.0": {"1.27", "1.28"},
+			"v1.12.0": {"1.26"},
+			"v1.11.0": {"1.25"},
+		},
+	}
+	live := &Compatibility{Matrix: map[string][]string{
+		"1.15.0":  {"1.30", "1.29"},
+		"v1.14.0": {"1.28", "1.29", "1.30"},
+		"v1.13.0": {"1.28"},
+		"v1.12.0": {"1.31"},
+	}}
+
+	d := DiffCompatibility(stored, live)
+
+	if want := map[string][]string{"1.15.0": {"1.29", "1.30"}}; !reflect.DeepEqual(d.AddedVersions, want) {
+		t.Errorf("AddedVersions = %v, want %v", d.AddedVersions, want)
+	}
+	if want := map[string][]string{"v1.14.0": {"1.30"}}; !reflect.DeepEqual(d.AddedK8sVersions, want) {
+		t.Errorf("AddedK8sVersions = %v, want %v", d.AddedK8sVersions, want)
+	}
+	if want := map[string][]string{"v1.13.0": {"1.27"}}; !reflect.DeepEqual(d.RemovedK8sVersions, want) {
+		t.Errorf("RemovedK8sVersions = %v, want %v", d.RemovedK8sVersions, want)
+	}
+	if want := []string{"v1.12.0: stored K8s 1.26, page lists 1.31"}; !reflect.DeepEqual(d.Contradictions, want) {
+		t.Errorf("Contradictions = %v, want %v", d.Contradictions, want)
+	}
+	if d.IsAdditive() {
+		t.Error("expected drift with removals and contradictions to be non-additive")
+	}
+}
+
+func TestDiffCompatibility_NoDrift(t *testing.T) {
+	stored := addon.Addon{KubernetesCompatibility: map[string][]string{"v2.0.0": {"1.29", "1.30"}}}
+	live := &Compatibility{Matrix: map[string][]string{"2.0.0": {"v1.30", "1.29"}}}
+
+	d := DiffCompatibility(stored, live)
+	if !d.IsEmpty() {
+		t.Errorf("expected no drift, got %+v", d)
+	}
+	if d.IsAdditive() {
+		t.Error("empty drift must not be additive")
+	}
+}
+
+func TestDiffCompatibility_Bounds(t *testing.T) {
+	tests := []struct {
+		name   string
+		stored addon.Addon
+		live   *Compatibility
+		want   []string
+	}{
+		{
+			name:   "differing minimum",
+			stored: addon.Addon{KubernetesMinVersion: "1.25"},
+			live:   &Compatibility{MinVersion: "1.27"},
+			want:   []string{"minimum K8s version: stored 1.25, page states 1.27"},
+		},
+		{
+			name:   "page adds maximum",
+			stored: addon.Addon{KubernetesMinVersion: "1.25"},
+			live:   &Compatibility{MinVersion: "1.25", MaxVersion: "1.31"},
+		},
+		{
+			name:   "nil live data",
+			stored: addon.Addon{KubernetesMinVersion: "1.25"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d := DiffCompatibility(tt.stored, tt.live)
+			if !reflect.DeepEqual(d.Contradictions, tt.want) {
+				t.Errorf("Contradictions = %v, want %v", d.Contradictions, tt.want)
+			}
+		})
+	}
+}
+
+func TestMergeAdditive(t *testing.T) {
+	stored := addon.Addon{KubernetesCompatibility: map[string][]string{
+		"v1.14.0": {"1.28", "1.29"},
+		"v1.13.0": {"1.27"},
+	}}
+	live := &Compatibility{Matrix: map[string][]string{
+		"1.15.0":  {"1.30", "1.29"},
+		"v1.14.0": {"1.30", "1.28", "1.29"},
+	}}
+
+	d := DiffCompatibility(stored, live)
+	if !d.IsAdditive() {
+		t.Fatalf("expected additive drift, got %+v", d)
+	}
+	MergeAdditive(&stored, d)
+
+	want := map[string][]string{
+		"v1.15.0": {"1.29", "1.30"},
+		"v1.14.0": {"1.28", "1.29", "1.30"},
+		"v1.13.0": {"1.27"},
+	}
+	if !reflect.DeepEqual(stored.KubernetesCompatibility, want) {
+		t.Errorf("merged matrix = %v, want %v", stored.KubernetesCompatibility, want)
+	}
+	if again := DiffCompatibility(stored, live); !again.IsEmpty() {
+		t.Errorf("expected no drift after merge, got %+v", again)
+	}
+}
+
+func TestMergeAdditive_BoundsOnlyAddonKeepsPageSpelling(t *testing.T) {
+	stored := addon.Addon{KubernetesMinVersion: "1.25"}
+	live := &Compatibility{Matrix: map[string][]string{"2.3.0": {"1.30"}}}
+
+	MergeAdditive(&stored, DiffCompatibility(stored, live))
+	if want := map[string][]string{"2.3.0": {"1.30"}}; !reflect.DeepEqual(stored.KubernetesCompatibility, want) {
+		t.Errorf("merged matrix = %v, want %v", stored.KubernetesCompatibility, want)
+	}
+}
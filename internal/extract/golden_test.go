@@ -0,0 +1,121 @@
+package extract
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"flag"
+	"net/http"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/qbandev/kaddons/internal/addon"
+	"github.com/qbandev/kaddons/internal/fetch"
+)
+
+var updateGolden = flag.Bool("update", false, "rewrite testdata/golden from the recorded fixtures")
+
+// recordedAddons are the addons whose compatibility pages are recorded in
+// testdata/fixtures. Refresh with:
+//
+//	go run ./cmd/kaddons-extract --record internal/extract/testdata/fixtures --filter <name>
+//	go test ./internal/extract -run TestRecordedPages -update
+var recordedAddons = []string{
+	"cert-manager",
+	"ingress-nginx",
+	"Karpenter",
+	"KEDA",
+	"kube-state-metrics",
+	"metrics-server",
+}
+
+// goldenCompatibility is the on-disk form of a golden extraction result.
+type goldenCompatibility struct {
+	Addon      string              `json:"addon"`
+	URL        string              `json:"url"`
+	Matrix     map[string][]string `json:"matrix,omitempty"`
+	MinVersion string              `json:"min_version,omitempty"`
+	MaxVersion string              `json:"max_version,omitempty"`
+	Strategy   string              `json:"strategy,omitempty"`
+	Locator    string              `json:"locator,omitempty"`
+	Confidence string              `json:"confidence,omitempty"`
+}
+
+// TestRecordedPages_GoldenMatrices replays recorded compatibility pages through
+// the real fetch pipeline and compares the extracted data with golden files,
+// so extractor changes that alter results on real-world pages are caught.
+func TestRecordedPages_GoldenMatrices(t *testing.T) {
+	addons, err := addon.LoadAddons()
+	if err != nil {
+		t.Fatalf("LoadAddons: %v", err)
+	}
+	byName := make(map[string]addon.Addon, len(addons))
+	for _, a := range addons {
+		byName[a.Name] = a
+	}
+	client := &http.Client{Transport: &fetch.ReplayTransport{Dir: filepath.Join("testdata", "fixtures")}}
+
+	for _, name := range recordedAddons {
+		t.Run(name, func(t *testing.T) {
+			a, ok := byName[name]
+			if !ok {
+				t.Fatalf("addon %q not in database", name)
+			}
+			page, err := fetch.CompatibilityPageFullWithClient(context.Background(), client, a.CompatibilityMatrixURL)
+			if err != nil {
+				t.Fatalf("replaying %s: %v", a.CompatibilityMatrixURL, err)
+			}
+
+			got := goldenCompatibility{Addon: a.Name, URL: a.CompatibilityMatrixURL}
+			if c := ExtractPage(a.CompatibilityMatrixURL, page.Raw, page.IsRaw, a.CompatibilitySourceType); c != nil {
+				got.Matrix = c.Matrix
+				got.MinVersion = c.MinVersion
+				got.MaxVersion = c.MaxVersion
+				got.Strategy = c.Strategy
+				got.Locator = c.Locator
+				got.Confidence = c.Confidence()
+			}
+
+			// Every recorded page has a compatibility table or statement.
+			if got.Matrix == nil && got.MinVersion == "" && got.MaxVersion == "" {
+				t.Errorf("no compatibility data extracted from %s", a.CompatibilityMatrixURL)
+			}
+
+			goldenPath := filepath.Join("testdata", "golden", goldenFileName(name))
+			if *updateGolden {
+				// Keep ">=" threshold keys readable in the golden file.
+				var buf bytes.Buffer
+				enc := json.NewEncoder(&buf)
+				enc.SetEscapeHTML(false)
+				enc.SetIndent("", "  ")
+				if err := enc.Encode(got); err != nil {
+					t.Fatalf("marshaling golden: %v", err)
+				}
+				if err := os.WriteFile(goldenPath, buf.Bytes(), 0o600); err != nil {
+					t.Fatalf("writing golden: %v", err)
+				}
+				return
+			}
+
+			data, err := os.ReadFile(goldenPath) // #nosec G304 -- fixed testdata path
+			if err != nil {
+				t.Fatalf("reading golden (run with -update to create it): %v", err)
+			}
+			var want goldenCompatibility
+			if err := json.Unmarshal(data, &want); err != nil {
+				t.Fatalf("parsing golden: %v", err)
+			}
+			if !reflect.DeepEqual(got, want) {
+				gotJSON, _ := json.MarshalIndent(got, "", "  ")
+				t.Errorf("extraction differs from %s:\n%s", goldenPath, gotJSON)
+			}
+		})
+	}
+}
+
+func goldenFileName(addonName string) string {
+	return strings.ToLower(addonName) + ".json"
+}
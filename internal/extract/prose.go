@@ -0,0 +1,442 @@
+package extract
+
+import (
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// maxProseRangeSpan caps how many K8s minors a single prose range may expand to.
+const maxProseRangeSpan = 40
+
+// ExtractProseCompatibility parses Markdown or plain-text content for compatibility
+// statements written as sentences ("v1.14 supports Kubernetes 1.24 through 1.31"),
+// bullet lists under version headings, and minimum/maximum Kubernetes version
+// statements. Returns nil when nothing is recognized.
+func ExtractProseCompatibility(content string) *Compatibility {
+	state := newProseState()
+	var heading proseHeading
+	for _, rawLine := range strings.Split(content, "\n") {
+		trimmed := strings.TrimSpace(rawLine)
+		if trimmed == "" || isMarkdownTableRow(trimmed) {
+			continue
+		}
+
+		if text, ok := proseHeadingText(trimmed); ok {
+			text = cleanProseLine(text)
+			heading = classifyProseHeading(text)
+			state.addSentences(text)
+			continue
+		}
+
+		line := cleanProseLine(trimmed)
+		if item, ok := proseBulletText(line); ok {
+			switch heading.kind {
+			case headingAddonVersion:
+				if proseK8sKeywordRe.MatchString(item) && !proseChangeWordRe.MatchString(item) {
+					state.addMatrixVersions(heading.addonVersion, parseK8sVersionSpec(afterK8sKeyword(item)))
+				}
+			case headingK8sList:
+				state.listVersions = append(state.listVersions, extractProseK8sVersions(item)...)
+			}
+			state.addSentences(item)
+			continue
+		}
+
+		state.addSentences(line)
+	}
+	return state.result()
+}
+
+// ExtractHTMLProseCompatibility is like ExtractProseCompatibility but accepts HTML.
+// Headings and list items are rewritten to their Markdown equivalents before
+// tags are stripped so that version headings keep scoping their bullet lists.
+func ExtractHTMLProseCompatibility(content string) *Compatibility {
+	text := htmlHeadingOpenRe.ReplaceAllString(content, "\n# ")
+	text = htmlListItemOpenRe.ReplaceAllString(text, "\n- ")
+	text = htmlBlockBoundaryRe.ReplaceAllString(text, "\n")
+	text = htmlTagStripRe.ReplaceAllString(text, " ")
+	text = htmlEntityReplacer.Replace(text)
+	return ExtractProseCompatibility(text)
+}
+
+var (
+	htmlHeadingOpenRe   = regexp.MustCompile(`(?i)<h[1-6][^>]*>`)
+	htmlListItemOpenRe  = regexp.MustCompile(`(?i)<li[^>]*>`)
+	htmlBlockBoundaryRe = regexp.MustCompile(`(?i)</?(?:p|div|br|ul|ol|section|article|table|tr)[^>]*>|</(?:h[1-6]|li)>`)
+	htmlEntityReplacer  = strings.NewReplacer("&nbsp;", " ", "&ndash;", "–", "&mdash;", "—", "&gt;", ">", "&lt;", "<", "&ge;", "≥", "&le;", "≤", "&amp;", "&")
+)
+
+var (
+	markdownLinkRe      = regexp.MustCompile(`\[([^\]]*)\]\([^)]*\)`)
+	markdownHeadingRe   = regexp.MustCompile(`^#{1,6}\s+(.+?)\s*#*$`)
+	markdownBoldLineRe  = regexp.MustCompile(`^(?:\*\*|__)(.+?)(?:\*\*|__):?$`)
+	markdownBulletRe    = regexp.MustCompile(`^(?:[-*+•]|\d+[.)])\s+(.+)$`)
+	proseSentenceSplit  = regexp.MustCompile(`[.;!?](?:\s+|$)`)
+	proseK8sKeywordRe   = regexp.MustCompile(`(?i)\b(?:kubernetes|k8s)\b`)
+	proseK8sVersionRe   = regexp.MustCompile(`\bv?(1\.\d{1,2})(?:\.\d+)?\b`)
+	proseAddonVersionRe = regexp.MustCompile(`\bv?\d+\.\d+(?:\.\d+)?(?:\.x)?\b`)
+	proseSupportWordRe  = regexp.MustCompile(`(?i)\b(?:support|compatib|tested|requirement|works)`)
+	proseStopWordRe     = regexp.MustCompile(`(?i)\b(?:but|except|excluding|not|deprecated|unsupported|removed)\b`)
+	// proseChangeWordRe marks changelog bullets ("Added support for Kubernetes 1.31")
+	// that describe a delta rather than the full supported set.
+	proseChangeWordRe = regexp.MustCompile(`(?i)\b(?:add(?:s|ed)?|drop(?:s|ped)?|remov(?:e|es|ed)|fix(?:es|ed)?|bump(?:s|ed)?|upgrad(?:e|es|ed)|deprecat(?:e|es|ed))\b`)
+)
+
+// proseSubjectRe matches "<addon version> [and later] <verb> Kubernetes <spec>".
+var proseSubjectRe = regexp.MustCompile(`(?i)\b(v?\d+\.\d+(?:\.\d+)?(?:\.x)?)(\s+(?:and|or)\s+(?:later|newer|above)|\+)?,?\s+` +
+	`(?:supports?|is\s+compatible\s+with|are\s+compatible\s+with|works\s+with|is\s+supported\s+(?:on|with|by)|` +
+	`(?:is|was|has\s+been)\s+tested\s+(?:on|with|against)|requires?|targets?)\s+` +
+	`(?:kubernetes|k8s)\b`)
+
+// proseKubernetesPrefixRe detects a version token that is itself a K8s version ("Kubernetes 1.30 supports ...").
+var proseKubernetesPrefixRe = regexp.MustCompile(`(?i)(?:kubernetes|k8s)\s*(?:versions?\s*)?$`)
+
+var (
+	proseRangeRe = regexp.MustCompile(`(?i)^\s*(?:versions?\s+)?(?:from\s+)?(?:v?(1\.\d{1,2})(?:\.\d+)?)\s*(?:through|thru|to|until|up\s+to|-|–|—|\.\.)\s*(?:kubernetes\s+|k8s\s+)?v?(1\.\d{1,2})(?:\.\d+)?\b`)
+	proseFloorRe = regexp.MustCompile(`(?i)^\s*(?:versions?\s+)?(?:>=|≥)?\s*v?(1\.\d{1,2})(?:\.\d+)?\s*(?:\+|(?:or|and)\s+(?:later|newer|higher|above|greater))`)
+)
+
+// proseNamedVersionRe finds an addon version a sentence is about, before its
+// first Kubernetes mention: "v2.0", "version 2.0", "release 2.0" or "chart 2.0".
+var proseNamedVersionRe = regexp.MustCompile(`(?i)(?:\bv|\b(?:version|release|chart)\s+v?)(\d+\.\d+(?:\.\d+)?(?:\.x)?)\b`)
+
+// Page-wide bound statements.
+var (
+	proseMinStatementRes = []*regexp.Regexp{
+		regexp.MustCompile(`(?i)\b(?:minimum|min\.?|lowest)\s+(?:supported\s+|required\s+)?(?:kubernetes|k8s)\s+version\s*(?:is|:|of|=)?\s*v?(1\.\d{1,2})\b`),
+		regexp.MustCompile(`(?i)\b(?:kubernetes|k8s)\s*(?:version\s*)?(?:>=|≥)\s*v?(1\.\d{1,2})\b`),
+		regexp.MustCompile(`(?i)\b(?:kubernetes|k8s)\s+(?:versions?\s+)?v?(1\.\d{1,2})(?:\.\d+)?\s*(?:\+|(?:or|and)\s+(?:later|newer|higher|above|greater))`),
+	}
+	proseMaxStatementRes = []*regexp.Regexp{
+		regexp.MustCompile(`(?i)\b(?:maximum|max\.?|highest|latest)\s+(?:supported\s+)?(?:kubernetes|k8s)\s+version\s*(?:is|:|=)?\s*v?(1\.\d{1,2})\b`),
+		regexp.MustCompile(`(?i)\b(?:kubernetes|k8s)\s*(?:version\s*)?(?:<=|≤)\s*v?(1\.\d{1,2})\b`),
+		regexp.MustCompile(`(?i)\b(?:kubernetes|k8s)\s+(?:versions?\s+)?up\s+to\s+v?(1\.\d{1,2})\b`),
+	}
+	proseGlobalRangeRe = regexp.MustCompile(`(?i)\b(?:kubernetes|k8s)\s+(?:versions?\s+)?(?:from\s+)?v?(1\.\d{1,2})(?:\.\d+)?\s*(?:through|thru|to|until|-|–|—|\.\.)\s*v?(1\.\d{1,2})(?:\.\d+)?\b`)
+)
+
+type proseHeadingKind int
+
+const (
+	headingNone proseHeadingKind = iota
+	headingAddonVersion
+	headingK8sList
+)
+
+type proseHeading struct {
+	kind         proseHeadingKind
+	addonVersion string
+}
+
+type proseState struct {
+	matrix       map[string][]string
+	cellCount    int
+	minVersions  map[string]bool
+	maxVersions  map[string]bool
+	listVersions []string
+	// floors maps an addon version key such as "2.0+" to the lowest
+	// Kubernetes version it requires. A floor sets no maximum, so it never
+	// becomes matrix entries.
+	floors map[string]string
+}
+
+func newProseState() *proseState {
+	return &proseState{
+		matrix:      make(map[string][]string),
+		minVersions: make(map[string]bool),
+		maxVersions: make(map[string]bool),
+		floors:      make(map[string]string),
+	}
+}
+
+// cleanProseLine strips Markdown inline formatting that would otherwise split tokens.
+func cleanProseLine(line string) string {
+	s := strings.TrimSpace(line)
+	s = markdownLinkRe.ReplaceAllString(s, "$1")
+	s = strings.NewReplacer("`", "", "**", "", "__", "").Replace(s)
+	return strings.Join(strings.Fields(s), " ")
+}
+
+func proseHeadingText(line string) (string, bool) {
+	if m := markdownHeadingRe.FindStringSubmatch(line); m != nil {
+		return m[1], true
+	}
+	if m := markdownBoldLineRe.FindStringSubmatch(line); m != nil {
+		return m[1], true
+	}
+	return "", false
+}
+
+func proseBulletText(line string) (string, bool) {
+	if m := markdownBulletRe.FindStringSubmatch(line); m != nil {
+		return m[1], true
+	}
+	return "", false
+}
+
+// classifyProseHeading decides whether a heading scopes bullets to one addon
+// version ("## v1.14") or introduces a page-wide K8s version list
+// ("### Supported Kubernetes versions").
+func classifyProseHeading(text string) proseHeading {
+	if proseK8sKeywordRe.MatchString(text) {
+		if proseSupportWordRe.MatchString(text) && !proseAddonVersionRe.MatchString(text) {
+			return proseHeading{kind: headingK8sList}
+		}
+		return proseHeading{}
+	}
+	if version := proseAddonVersionRe.FindString(text); version != "" {
+		return proseHeading{kind: headingAddonVersion, addonVersion: version}
+	}
+	return proseHeading{}
+}
+
+// addSentences applies sentence-level rules to every sentence in text.
+func (s *proseState) addSentences(text string) {
+	for _, sentence := range splitProseSentences(text) {
+		if s.addSubjectStatements(sentence) {
+			continue
+		}
+		s.addBoundStatements(sentence)
+	}
+}
+
+// splitProseSentences splits on sentence punctuation without breaking version
+// numbers, which never have whitespace after their dots.
+func splitProseSentences(text string) []string {
+	var sentences []string
+	for _, part := range proseSentenceSplit.Split(text, -1) {
+		if part = strings.TrimSpace(part); part != "" {
+			sentences = append(sentences, part)
+		}
+	}
+	return sentences
+}
+
+// addSubjectStatements records "<addon version> supports Kubernetes ..." statements.
+// Returns true if at least one statement in the sentence was recognized.
+func (s *proseState) addSubjectStatements(sentence string) bool {
+	recognized := false
+	matches := proseSubjectRe.FindAllStringSubmatchIndex(sentence, -1)
+	for i, m := range matches {
+		if proseKubernetesPrefixRe.MatchString(sentence[:m[2]]) {
+			continue
+		}
+		versionKey := sentence[m[2]:m[3]]
+		if m[4] >= 0 {
+			versionKey += "+"
+		}
+		specEnd := len(sentence)
+		if i+1 < len(matches) {
+			specEnd = matches[i+1][0]
+		}
+		spec := sentence[m[1]:specEnd]
+		if floor := proseFloorRe.FindStringSubmatch(spec); floor != nil {
+			s.addFloor(versionKey, floor[1])
+			recognized = true
+			continue
+		}
+		versions := parseK8sVersionSpec(spec)
+		if len(versions) == 0 {
+			continue
+		}
+		s.addMatrixVersions(versionKey, versions)
+		recognized = true
+	}
+	return recognized
+}
+
+// addBoundStatements records minimum and maximum Kubernetes versions. Only a
+// sentence that names no addon version bounds the whole page; a floor for
+// one addon version ("Version 2.0 needs Kubernetes 1.28 or later") is kept
+// with the other floors instead, and its lone maximum is dropped.
+func (s *proseState) addBoundStatements(sentence string) {
+	addonVersion := proseNamedVersion(sentence)
+	if m := proseGlobalRangeRe.FindStringSubmatch(sentence); m != nil && proseSupportWordRe.MatchString(sentence) {
+		if addonVersion != "" {
+			s.addMatrixVersions(addonVersion, expandK8sRange(m[1], m[2]))
+			return
+		}
+		s.minVersions[m[1]] = true
+		s.maxVersions[m[2]] = true
+		return
+	}
+	for _, re := range proseMinStatementRes {
+		if m := re.FindStringSubmatch(sentence); m != nil {
+			if addonVersion != "" {
+				s.addFloor(addonVersion, m[1])
+			} else {
+				s.minVersions[m[1]] = true
+			}
+			break
+		}
+	}
+	if addonVersion != "" {
+		return
+	}
+	for _, re := range proseMaxStatementRes {
+		if m := re.FindStringSubmatch(sentence); m != nil {
+			s.maxVersions[m[1]] = true
+			break
+		}
+	}
+}
+
+// proseNamedVersion returns the addon version named before the sentence's
+// first Kubernetes mention, or "".
+func proseNamedVersion(sentence string) string {
+	before := sentence
+	if loc := proseK8sKeywordRe.FindStringIndex(sentence); loc != nil {
+		before = sentence[:loc[0]]
+	}
+	if m := proseNamedVersionRe.FindStringSubmatch(before); m != nil {
+		return m[1]
+	}
+	return ""
+}
+
+// addFloor records that addonVersion and later need at least k8sVersion.
+// The first floor of a version wins.
+func (s *proseState) addFloor(addonVersion string, k8sVersion string) {
+	if !strings.HasSuffix(addonVersion, "+") {
+		addonVersion += "+"
+	}
+	if _, ok := s.floors[addonVersion]; !ok {
+		s.floors[addonVersion] = k8sVersion
+	}
+}
+
+func (s *proseState) addMatrixVersions(addonVersion string, k8sVersions []string) {
+	if addonVersion == "" || len(k8sVersions) == 0 {
+		return
+	}
+	if s.cellCount+len(k8sVersions) > maxCells {
+		return
+	}
+	s.cellCount += len(k8sVersions)
+	s.matrix[addonVersion] = appendUnique(s.matrix[addonVersion], k8sVersions...)
+}
+
+// result assembles the extracted data. Conflicting page-wide bounds (two
+// different "minimum" statements) are dropped rather than guessed.
+func (s *proseState) result() *Compatibility {
+	result := &Compatibility{Strategy: StrategyProse, Locator: "text"}
+	if len(s.matrix) > 0 {
+		result.Matrix = s.matrix
+	}
+	if len(s.listVersions) > 0 {
+		sorted := sortK8sVersions(s.listVersions)
+		s.minVersions[sorted[0]] = true
+		s.maxVersions[sorted[len(sorted)-1]] = true
+	}
+	if len(s.minVersions) == 1 {
+		result.MinVersion = onlyKey(s.minVersions)
+	} else if len(s.minVersions) == 0 {
+		result.MinVersion = s.lowestFloor()
+	}
+	if len(s.maxVersions) == 1 {
+		result.MaxVersion = onlyKey(s.maxVersions)
+	}
+	if result.MinVersion != "" && result.MaxVersion != "" && compareMinorVersions(result.MinVersion, result.MaxVersion) > 0 {
+		result.MinVersion, result.MaxVersion = "", ""
+	}
+	if result.Matrix == nil && !result.HasBounds() {
+		return nil
+	}
+	return result
+}
+
+// lowestFloor returns the lowest Kubernetes version any addon version
+// floor requires, or "". Later addon versions only raise their floor, so it
+// is the minimum the page states for every version it names a floor for.
+func (s *proseState) lowestFloor() string {
+	lowest := ""
+	for _, floor := range s.floors {
+		if lowest == "" || compareMinorVersions(floor, lowest) < 0 {
+			lowest = floor
+		}
+	}
+	return lowest
+}
+
+// afterK8sKeyword returns the text following the first "Kubernetes"/"K8s" mention.
+func afterK8sKeyword(text string) string {
+	loc := proseK8sKeywordRe.FindStringIndex(text)
+	if loc == nil {
+		return text
+	}
+	return text[loc[1]:]
+}
+
+// parseK8sVersionSpec interprets the text after "Kubernetes" as a range
+// ("1.24 through 1.31"), a floor ("1.24 or later", returned as-is since it has
+// no upper bound to expand), or a list ("1.29, 1.30 and 1.31").
+func parseK8sVersionSpec(spec string) []string {
+	if m := proseRangeRe.FindStringSubmatch(spec); m != nil {
+		return expandK8sRange(m[1], m[2])
+	}
+	if proseFloorRe.MatchString(spec) {
+		return nil
+	}
+	if loc := proseStopWordRe.FindStringIndex(spec); loc != nil {
+		spec = spec[:loc[0]]
+	}
+	return extractProseK8sVersions(spec)
+}
+
+func extractProseK8sVersions(text string) []string {
+	var versions []string
+	for _, m := range proseK8sVersionRe.FindAllStringSubmatch(text, -1) {
+		if isK8sVersion(m[1]) {
+			versions = appendUnique(versions, m[1])
+		}
+	}
+	return versions
+}
+
+// expandK8sRange lists every minor between lo and hi inclusive.
+func expandK8sRange(lo string, hi string) []string {
+	loMinor, loOK := k8sMinor(lo)
+	hiMinor, hiOK := k8sMinor(hi)
+	if !loOK || !hiOK || hiMinor < loMinor || hiMinor-loMinor > maxProseRangeSpan {
+		return nil
+	}
+	versions := make([]string, 0, hiMinor-loMinor+1)
+	for minor := loMinor; minor <= hiMinor; minor++ {
+		versions = append(versions, "1."+strconv.Itoa(minor))
+	}
+	return versions
+}
+
+func k8sMinor(version string) (int, bool) {
+	if !isK8sVersion(version) {
+		return 0, false
+	}
+	minor, err := strconv.Atoi(strings.SplitN(strings.TrimPrefix(version, "v"), ".", 2)[1])
+	if err != nil {
+		return 0, false
+	}
+	return minor, true
+}
+
+func compareMinorVersions(a string, b string) int {
+	aMinor, _ := k8sMinor(a)
+	bMinor, _ := k8sMinor(b)
+	return aMinor - bMinor
+}
+
+func sortK8sVersions(versions []string) []string {
+	sorted := appendUnique(nil, versions...)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return compareMinorVersions(sorted[i], sorted[j]) < 0
+	})
+	return sorted
+}
+
+func onlyKey(set map[string]bool) string {
+	for key := range set {
+		return key
+	}
+	return ""
+}
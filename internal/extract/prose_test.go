@@ -0,0 +1,238 @@
+package extract
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestExtractProseCompatibility_SentenceRange(t *testing.T) {
+	content := `# Releases
+
+cert-manager v1.14 supports Kubernetes 1.24 through 1.31. Version v1.13 is compatible with Kubernetes 1.23 - 1.27.
+`
+	prose := ExtractProseCompatibility(content)
+	if prose == nil {
+		t.Fatal("expected non-nil result")
+	}
+
+	want := []string{"1.24", "1.25", "1.26", "1.27", "1.28", "1.29", "1.30", "1.31"}
+	if got := prose.Matrix["v1.14"]; !reflect.DeepEqual(got, want) {
+		t.Errorf("v1.14 = %v, want %v", got, want)
+	}
+	if got := prose.Matrix["v1.13"]; len(got) != 5 {
+		t.Errorf("v1.13 supports %d versions, want 5: %v", len(got), got)
+	}
+	if prose.HasBounds() {
+		t.Errorf("expected no page-wide bounds, got min=%q max=%q", prose.MinVersion, prose.MaxVersion)
+	}
+}
+
+func TestExtractProseCompatibility_SentenceList(t *testing.T) {
+	content := "Release 2.3.0 is tested with Kubernetes 1.29, 1.30 and 1.31 but not 1.28."
+	prose := ExtractProseCompatibility(content)
+	if prose == nil {
+		t.Fatal("expected non-nil result")
+	}
+	want := []string{"1.29", "1.30", "1.31"}
+	if got := prose.Matrix["2.3.0"]; !reflect.DeepEqual(got, want) {
+		t.Errorf("2.3.0 = %v, want %v", got, want)
+	}
+}
+
+func TestExtractProseCompatibility_ThresholdSubject(t *testing.T) {
+	content := "v2.5.0 and later support Kubernetes 1.29 to 1.31."
+	prose := ExtractProseCompatibility(content)
+	if prose == nil {
+		t.Fatal("expected non-nil result")
+	}
+	if _, ok := prose.Matrix["v2.5.0+"]; !ok {
+		t.Errorf("expected threshold key v2.5.0+, got %v", prose.Matrix)
+	}
+}
+
+func TestExtractProseCompatibility_KubernetesSubjectIgnored(t *testing.T) {
+	content := "Kubernetes 1.30 supports Kubernetes 1.29 nodes during upgrades."
+	prose := ExtractProseCompatibility(content)
+	if prose != nil && len(prose.Matrix) > 0 {
+		t.Errorf("expected no matrix when the subject is a Kubernetes version, got %v", prose.Matrix)
+	}
+}
+
+func TestExtractProseCompatibility_BulletsUnderVersionHeadings(t *testing.T) {
+	content := `## v0.37
+
+- Supported Kubernetes versions: 1.29, 1.30, 1.31
+- Added support for Kubernetes 1.31 metrics
+
+## v0.36
+
+* Kubernetes 1.27 - 1.30
+
+## Installation
+
+- Kubernetes 1.20 is fine for testing
+`
+	prose := ExtractProseCompatibility(content)
+	if prose == nil {
+		t.Fatal("expected non-nil result")
+	}
+	if got := prose.Matrix["v0.37"]; !reflect.DeepEqual(got, []string{"1.29", "1.30", "1.31"}) {
+		t.Errorf("v0.37 = %v, want [1.29 1.30 1.31]", got)
+	}
+	if got := prose.Matrix["v0.36"]; !reflect.DeepEqual(got, []string{"1.27", "1.28", "1.29", "1.30"}) {
+		t.Errorf("v0.36 = %v, want [1.27 1.28 1.29 1.30]", got)
+	}
+	if len(prose.Matrix) != 2 {
+		t.Errorf("expected bullets outside version headings to be ignored, got %v", prose.Matrix)
+	}
+}
+
+func TestExtractProseCompatibility_KubernetesListHeadingBounds(t *testing.T) {
+	content := `### Supported Kubernetes versions
+
+- 1.31
+- 1.29
+- 1.30
+`
+	prose := ExtractProseCompatibility(content)
+	if prose == nil {
+		t.Fatal("expected non-nil result")
+	}
+	if prose.MinVersion != "1.29" || prose.MaxVersion != "1.31" {
+		t.Errorf("bounds = %q..%q, want 1.29..1.31", prose.MinVersion, prose.MaxVersion)
+	}
+	if prose.Matrix != nil {
+		t.Errorf("expected nil matrix, got %v", prose.Matrix)
+	}
+}
+
+func TestExtractProseCompatibility_MinimumStatements(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		wantMin string
+		wantMax string
+	}{
+		{"minimum label", "Minimum Kubernetes version: 1.25", "1.25", ""},
+		{"or later", "This chart requires Kubernetes 1.26 or later.", "1.26", ""},
+		{"plus suffix", "Prerequisites: **Kubernetes 1.27+**", "1.27", ""},
+		{"operator", "Requires `k8s >= 1.28`", "1.28", ""},
+		{"maximum", "The maximum supported Kubernetes version is 1.31.", "", "1.31"},
+		{"global range", "Kubernetes versions 1.24 through 1.30 are supported.", "1.24", "1.30"},
+		{"conflicting minimums dropped", "Minimum Kubernetes version: 1.25. Requires Kubernetes 1.27 or later.", "", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			prose := ExtractProseCompatibility(tt.content)
+			if tt.wantMin == "" && tt.wantMax == "" {
+				if prose != nil {
+					t.Fatalf("expected nil result, got %+v", prose)
+				}
+				return
+			}
+			if prose == nil {
+				t.Fatal("expected non-nil result")
+			}
+			if prose.MinVersion != tt.wantMin || prose.MaxVersion != tt.wantMax {
+				t.Errorf("bounds = %q..%q, want %q..%q", prose.MinVersion, prose.MaxVersion, tt.wantMin, tt.wantMax)
+			}
+		})
+	}
+}
+
+func TestExtractProseCompatibility_VersionFloors(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		want    map[string][]string
+		wantMin string
+	}{
+		{
+			name:    "subject floor",
+			content: "v2.0 requires Kubernetes 1.28 or later.",
+			wantMin: "1.28",
+		},
+		{
+			name:    "floor is not capped at the newest version named",
+			content: "v1.9 supports Kubernetes 1.27 through 1.30. v2.0 requires Kubernetes 1.28 or later.",
+			want:    map[string][]string{"v1.9": {"1.27", "1.28", "1.29", "1.30"}},
+			wantMin: "1.28",
+		},
+		{
+			name:    "lowest of several floors",
+			content: "v3.0 requires Kubernetes 1.30 or later. v2.0 requires Kubernetes 1.28 or later.",
+			wantMin: "1.28",
+		},
+		{
+			name:    "named version with a minimum statement",
+			content: "Version 3.1 needs Kubernetes >= 1.27. Chart 3.2 runs on Kubernetes up to 1.29.",
+			wantMin: "1.27",
+		},
+		{
+			name:    "page-wide minimum wins over floors",
+			content: "The minimum Kubernetes version is 1.25. v2.0 requires Kubernetes 1.28 or later.",
+			wantMin: "1.25",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			prose := ExtractProseCompatibility(tt.content)
+			if prose == nil {
+				t.Fatal("expected non-nil result")
+			}
+			if prose.MinVersion != tt.wantMin || prose.MaxVersion != "" {
+				t.Errorf("bounds = %q..%q, want %q and no maximum", prose.MinVersion, prose.MaxVersion, tt.wantMin)
+			}
+			if !reflect.DeepEqual(prose.Matrix, tt.want) {
+				t.Errorf("matrix = %v, want %v", prose.Matrix, tt.want)
+			}
+		})
+	}
+}
+
+func TestExtractProseCompatibility_IgnoresTablesAndUnrelatedText(t *testing.T) {
+	content := `# Just some docs
+
+This addon is great. Helm 3.8 is recommended.
+
+| Version | Kubernetes |
+| --- | --- |
+| v1.0.0 supports Kubernetes 1.28 | 1.28 |
+`
+	prose := ExtractProseCompatibility(content)
+	if prose != nil {
+		t.Errorf("expected nil result, got %+v", prose)
+	}
+}
+
+func TestExtractHTMLProseCompatibility_HeadingsAndListItems(t *testing.T) {
+	content := `<html><body>
+<h2>Karpenter v1.0</h2>
+<ul><li>Kubernetes <code>1.28</code> &ndash; <code>1.31</code></li></ul>
+<p>Karpenter v0.37 supports Kubernetes 1.26 through 1.30.</p>
+</body></html>`
+
+	prose := ExtractHTMLProseCompatibility(content)
+	if prose == nil {
+		t.Fatal("expected non-nil result")
+	}
+	if got := prose.Matrix["v1.0"]; len(got) != 4 {
+		t.Errorf("v1.0 supports %d versions, want 4: %v", len(got), got)
+	}
+	if got := prose.Matrix["v0.37"]; len(got) != 5 {
+		t.Errorf("v0.37 supports %d versions, want 5: %v", len(got), got)
+	}
+}
+
+func TestExpandK8sRange(t *testing.T) {
+	if got := expandK8sRange("1.29", "1.31"); !reflect.DeepEqual(got, []string{"1.29", "1.30", "1.31"}) {
+		t.Errorf("expandK8sRange(1.29, 1.31) = %v", got)
+	}
+	if got := expandK8sRange("1.31", "1.29"); got != nil {
+		t.Errorf("expected nil for inverted range, got %v", got)
+	}
+	if got := expandK8sRange("2.1", "2.3"); got != nil {
+		t.Errorf("expected nil for non-K8s versions, got %v", got)
+	}
+}
@@ -0,0 +1,259 @@
+package extract
+
+import (
+	"regexp"
+	"strings"
+)
+
+// ExtractRSTMatrix parses reStructuredText content for grid tables, simple
+// tables and list-table directives containing compatibility data. Returns nil
+// map and nil error when no parseable table is found.
+func ExtractRSTMatrix(content string) (map[string][]string, error) {
+	return tableMatrix(firstTableCompatibility(RawFormatRST, parseRSTTables(content))), nil
+}
+
+// parseRSTTables collects grid tables, then simple tables, then list-tables.
+func parseRSTTables(content string) [][][]string {
+	lines := strings.Split(strings.ReplaceAll(content, "\t", "    "), "\n")
+	var tables [][][]string
+	tables = append(tables, parseRSTGridTables(lines)...)
+	tables = append(tables, parseRSTSimpleTables(lines)...)
+	tables = append(tables, parseRSTListTables(lines)...)
+	return tables
+}
+
+var (
+	// rstGridBorderRe matches grid table borders: +-----+-----+ or +=====+=====+.
+	rstGridBorderRe = regexp.MustCompile(`^\+(?:[-=]+\+)+$`)
+	// rstSimpleBorderRe matches simple table borders with at least two columns: ===  =====.
+	rstSimpleBorderRe = regexp.MustCompile(`^=+(?: +=+)+$`)
+	// rstListTableRe matches the list-table directive.
+	rstListTableRe = regexp.MustCompile(`^(\s*)\.\.\s+list-table::`)
+)
+
+// parseRSTGridTables extracts grid tables. Column boundaries come from the "+"
+// positions of the top border; text lines between two borders form one row,
+// with multi-line cells joined by spaces.
+func parseRSTGridTables(lines []string) [][][]string {
+	var tables [][][]string
+	for i := 0; i < len(lines); i++ {
+		top := strings.TrimSpace(lines[i])
+		if !rstGridBorderRe.MatchString(top) {
+			continue
+		}
+		indent := len(lines[i]) - len(strings.TrimLeft(lines[i], " "))
+		boundaries := rstGridBoundaries([]rune(top))
+
+		var rows [][]string
+		var pending []string
+		cellCount := 0
+		oversize := false
+		end := i + 1
+		for ; end < len(lines); end++ {
+			line := strings.TrimRight(lines[end], " ")
+			if len(line) < indent {
+				break
+			}
+			body := []rune(line[indent:])
+			trimmed := string(body)
+			if rstGridBorderRe.MatchString(trimmed) {
+				if pending != nil {
+					rows = append(rows, pending)
+					cellCount += len(pending)
+					pending = nil
+				}
+				continue
+			}
+			if !strings.HasPrefix(trimmed, "|") {
+				break
+			}
+			cells := sliceRSTGridColumns(body, boundaries)
+			if pending == nil {
+				pending = cells
+			} else {
+				for c := range pending {
+					pending[c] = strings.TrimSpace(pending[c] + " " + cells[c])
+				}
+			}
+			if cellCount+len(pending) > maxCells {
+				oversize = true
+			}
+		}
+		i = end - 1
+		if oversize || len(rows) < 2 {
+			continue
+		}
+		tables = append(tables, rows)
+	}
+	return tables
+}
+
+// rstGridBoundaries returns the rune offsets of every "+" in a grid border.
+func rstGridBoundaries(border []rune) []int {
+	var boundaries []int
+	for i, r := range border {
+		if r == '+' {
+			boundaries = append(boundaries, i)
+		}
+	}
+	return boundaries
+}
+
+// parseRSTSimpleTables extracts simple tables. Column extents come from the
+// runs of "=" in the top border; the last column extends to the end of the
+// line. A line whose first column is blank continues the previous row.
+func parseRSTSimpleTables(lines []string) [][][]string {
+	var tables [][][]string
+	for i := 0; i < len(lines); i++ {
+		top := strings.TrimRight(lines[i], " ")
+		if !rstSimpleBorderRe.MatchString(strings.TrimLeft(top, " ")) {
+			continue
+		}
+		indent := len(top) - len(strings.TrimLeft(top, " "))
+		starts := rstSimpleColumnStarts([]rune(top[indent:]))
+
+		var rows [][]string
+		bordersSeen := 1
+		cellCount := 0
+		end := i + 1
+		for ; end < len(lines); end++ {
+			line := strings.TrimRight(lines[end], " ")
+			if strings.TrimSpace(line) == "" {
+				continue
+			}
+			if len(line) < indent {
+				break
+			}
+			body := []rune(line[indent:])
+			if rstSimpleBorderRe.MatchString(string(body)) || isRSTSimpleUnderline(string(body)) {
+				bordersSeen++
+				if bordersSeen == 3 {
+					break
+				}
+				continue
+			}
+			cells := sliceRSTSimpleColumns(body, starts)
+			if cells[0] == "" && len(rows) > 0 {
+				previous := rows[len(rows)-1]
+				for c := range previous {
+					previous[c] = strings.TrimSpace(previous[c] + " " + cells[c])
+				}
+				continue
+			}
+			rows = append(rows, cells)
+			cellCount += len(cells)
+		}
+		i = end
+		if bordersSeen < 3 || cellCount > maxCells || len(rows) < 2 {
+			continue
+		}
+		tables = append(tables, rows)
+	}
+	return tables
+}
+
+// isRSTSimpleUnderline matches column-group underlines ("-----  -----") used
+// inside simple table headers.
+func isRSTSimpleUnderline(line string) bool {
+	return strings.Trim(line, "- ") == "" && strings.Contains(line, "-")
+}
+
+func rstSimpleColumnStarts(border []rune) []int {
+	var starts []int
+	for i, r := range border {
+		if r == '=' && (i == 0 || border[i-1] == ' ') {
+			starts = append(starts, i)
+		}
+	}
+	return starts
+}
+
+func sliceRSTSimpleColumns(line []rune, starts []int) []string {
+	cells := make([]string, len(starts))
+	for c, start := range starts {
+		if start >= len(line) {
+			continue
+		}
+		stop := len(line)
+		if c+1 < len(starts) && starts[c+1] < len(line) {
+			stop = starts[c+1]
+		}
+		cells[c] = cleanRSTCell(string(line[start:stop]))
+	}
+	return cells
+}
+
+// sliceRSTGridColumns cuts a grid table line at the border boundaries.
+func sliceRSTGridColumns(line []rune, boundaries []int) []string {
+	cells := make([]string, 0, len(boundaries)-1)
+	for c := 0; c+1 < len(boundaries); c++ {
+		start, stop := boundaries[c]+1, boundaries[c+1]
+		if start >= len(line) {
+			cells = append(cells, "")
+			continue
+		}
+		if stop > len(line) {
+			stop = len(line)
+		}
+		cells = append(cells, cleanRSTCell(string(line[start:stop])))
+	}
+	return cells
+}
+
+// parseRSTListTables extracts ".. list-table::" directives, where each
+// "* -" item starts a row and each nested "-" item starts a cell.
+func parseRSTListTables(lines []string) [][][]string {
+	var tables [][][]string
+	for i := 0; i < len(lines); i++ {
+		m := rstListTableRe.FindStringSubmatch(lines[i])
+		if m == nil {
+			continue
+		}
+		directiveIndent := len(m[1])
+
+		var rows [][]string
+		cellCount := 0
+		end := i + 1
+		for ; end < len(lines); end++ {
+			line := strings.TrimRight(lines[end], " ")
+			trimmed := strings.TrimSpace(line)
+			if trimmed == "" {
+				continue
+			}
+			if len(line)-len(strings.TrimLeft(line, " ")) <= directiveIndent {
+				break
+			}
+			switch {
+			case strings.HasPrefix(trimmed, ":"):
+				// Directive options (:header-rows:, :widths:) carry no cell data.
+			case strings.HasPrefix(trimmed, "* -"):
+				rows = append(rows, []string{cleanRSTCell(strings.TrimPrefix(trimmed, "* -"))})
+				cellCount++
+			case strings.HasPrefix(trimmed, "- ") || trimmed == "-":
+				if len(rows) > 0 {
+					rows[len(rows)-1] = append(rows[len(rows)-1], cleanRSTCell(strings.TrimPrefix(trimmed, "-")))
+					cellCount++
+				}
+			default:
+				// Continuation of the current cell's paragraph.
+				if len(rows) > 0 {
+					row := rows[len(rows)-1]
+					row[len(row)-1] = strings.TrimSpace(row[len(row)-1] + " " + cleanRSTCell(trimmed))
+				}
+			}
+		}
+		i = end - 1
+		if cellCount > maxCells || len(rows) < 2 {
+			continue
+		}
+		tables = append(tables, rows)
+	}
+	return tables
+}
+
+// cleanRSTCell strips inline markup: double-backtick literals, **strong** and *emphasis*.
+func cleanRSTCell(text string) string {
+	text = strings.TrimSpace(text)
+	text = strings.Trim(text, "`*")
+	return strings.TrimSpace(text)
+}
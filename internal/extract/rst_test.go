@@ -0,0 +1,84 @@
+package extract
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestExtractRSTMatrix_GridTable(t *testing.T) {
+	content := `Compatibility
+=============
+
++---------------+---------------------+
+| Version       | Kubernetes          |
++===============+=====================+
+| ` + "``v1.14.0``" + `   | 1.28, 1.29,         |
+|               | 1.30                |
++---------------+---------------------+
+| v1.13.0       | 1.27                |
++---------------+---------------------+
+`
+	matrix, err := ExtractRSTMatrix(content)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := map[string][]string{
+		"v1.14.0": {"1.28", "1.29", "1.30"},
+		"v1.13.0": {"1.27"},
+	}
+	if !reflect.DeepEqual(matrix, want) {
+		t.Errorf("matrix = %v, want %v", matrix, want)
+	}
+}
+
+func TestExtractRSTMatrix_SimpleTable(t *testing.T) {
+	content := `=======  ====  ====  ====
+Release  1.29  1.30  1.31
+=======  ====  ====  ====
+2.3.0    ✓     ✓     ✓
+2.2.0    ✓     ✓
+=======  ====  ====  ====
+`
+	matrix, _ := ExtractRSTMatrix(content)
+	if got := matrix["2.3.0"]; !reflect.DeepEqual(got, []string{"1.29", "1.30", "1.31"}) {
+		t.Errorf("2.3.0 = %v", got)
+	}
+	if got := matrix["2.2.0"]; !reflect.DeepEqual(got, []string{"1.29", "1.30"}) {
+		t.Errorf("2.2.0 = %v", got)
+	}
+}
+
+func TestExtractRSTMatrix_ListTable(t *testing.T) {
+	content := `.. list-table:: Supported versions
+   :header-rows: 1
+   :widths: 20 80
+
+   * - Addon version
+     - Kubernetes versions
+   * - **v0.37**
+     - 1.26 - 1.30
+   * - v0.36
+     - 1.25,
+       1.26
+
+After the table.
+`
+	matrix, _ := ExtractRSTMatrix(content)
+	if got := matrix["v0.37"]; !reflect.DeepEqual(got, []string{"1.26", "1.27", "1.28", "1.29", "1.30"}) {
+		t.Errorf("v0.37 = %v", got)
+	}
+	if got := matrix["v0.36"]; !reflect.DeepEqual(got, []string{"1.25", "1.26"}) {
+		t.Errorf("v0.36 = %v, want continuation line joined", got)
+	}
+}
+
+func TestExtractRSTMatrix_NoTable(t *testing.T) {
+	content := "Title\n=====\n\nSome prose about Kubernetes 1.30.\n"
+	matrix, err := ExtractRSTMatrix(content)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if matrix != nil {
+		t.Errorf("expected nil matrix, got %v", matrix)
+	}
+}
@@ -0,0 +1,428 @@
+package extract
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Structured compatibility source types, stored in the addon database as
+// compatibility_source_type. An empty type means the page is Markdown or HTML
+// and goes through table and prose extraction.
+const (
+	SourceTypeYAML      = "yaml"
+	SourceTypeJSON      = "json"
+	SourceTypeHelmChart = "helm-chart"
+)
+
+// IsStructuredSourceType reports whether sourceType names a structured extractor.
+func IsStructuredSourceType(sourceType string) bool {
+	switch sourceType {
+	case SourceTypeYAML, SourceTypeJSON, SourceTypeHelmChart:
+		return true
+	default:
+		return false
+	}
+}
+
+// ExtractStructured dispatches content to the extractor for sourceType.
+// Returns nil and nil error when the document holds no recognizable
+// compatibility data, and an error when it cannot be parsed at all.
+func ExtractStructured(sourceType string, content string) (*Compatibility, error) {
+	switch sourceType {
+	case SourceTypeYAML, SourceTypeJSON:
+		// YAML 1.2 is a superset of JSON, and parsing both through yaml.Node keeps
+		// scalars like 1.30 as written instead of collapsing them to the float 1.3.
+		return ExtractYAMLCompatibility(content)
+	case SourceTypeHelmChart:
+		return ExtractHelmChartCompatibility(content)
+	default:
+		return nil, fmt.Errorf("unsupported compatibility source type %q", sourceType)
+	}
+}
+
+// ExtractYAMLCompatibility parses a machine-readable compatibility document
+// (for example a compatibility.yaml in the project repository, or JSON served by
+// a docs site). Recognized shapes, optionally nested under a key such as
+// "compatibility" or "matrix":
+//
+//	v1.14: ["1.28", "1.29"]            # addon version -> K8s list
+//	v1.14: ">=1.24.0-0 <1.32.0-0"      # addon version -> semver constraint
+//	- version: v1.14                   # list of entries with a K8s field
+//	  kubernetes: "1.24 - 1.31"
+//	kubeVersion: ">=1.25.0-0"          # page-wide constraint
+func ExtractYAMLCompatibility(content string) (*Compatibility, error) {
+	var document yaml.Node
+	if err := yaml.Unmarshal([]byte(content), &document); err != nil {
+		return nil, fmt.Errorf("parsing structured compatibility document: %w", err)
+	}
+	result := &Compatibility{Matrix: make(map[string][]string), Strategy: StrategyStructured, Locator: "document"}
+	collectStructuredCompatibility(resolveYAMLNode(&document), result, 0)
+	return finalizeStructured(result), nil
+}
+
+// ExtractHelmChartCompatibility reads the kubeVersion constraint from a Helm
+// Chart.yaml and converts it to K8s min/max bounds.
+func ExtractHelmChartCompatibility(content string) (*Compatibility, error) {
+	var chart struct {
+		KubeVersion string `yaml:"kubeVersion"`
+	}
+	if err := yaml.Unmarshal([]byte(content), &chart); err != nil {
+		return nil, fmt.Errorf("parsing Chart.yaml: %w", err)
+	}
+	minVersion, maxVersion, ok := ParseKubeVersionConstraint(chart.KubeVersion)
+	if !ok {
+		return nil, nil
+	}
+	return &Compatibility{MinVersion: minVersion, MaxVersion: maxVersion, Strategy: StrategyStructured, Locator: "kubeVersion"}, nil
+}
+
+// maxStructuredDepth bounds recursion through wrapper keys.
+const maxStructuredDepth = 4
+
+// structuredWrapperKeys hold the actual matrix one level down.
+var structuredWrapperKeys = map[string]bool{
+	"compatibility":            true,
+	"kubernetes_compatibility": true,
+	"kubernetescompatibility":  true,
+	"compatibility_matrix":     true,
+	"compatibilitymatrix":      true,
+	"matrix":                   true,
+	"versions":                 true,
+	"releases":                 true,
+	"supported_versions":       true,
+	"supportedversions":        true,
+	"kubernetes":               true,
+}
+
+// structuredVersionKeys name the addon version inside a list entry.
+var structuredVersionKeys = []string{"version", "release", "app_version", "appversion", "chart_version", "chartversion", "addon_version", "addonversion"}
+
+// structuredK8sKeys name the supported K8s versions inside a list entry.
+var structuredK8sKeys = []string{
+	"kubernetes", "kubernetes_versions", "kubernetesversions", "kubernetes_version", "kubernetesversion",
+	"supported_kubernetes_versions", "supportedkubernetesversions", "k8s", "k8s_versions", "k8sversions",
+	"kubeversion", "kube_version",
+}
+
+var (
+	structuredMinKeys = []string{"min_kubernetes_version", "minkubernetesversion", "kubernetes_min_version", "kubernetesminversion", "minkubeversion", "min_kube_version"}
+	structuredMaxKeys = []string{"max_kubernetes_version", "maxkubernetesversion", "kubernetes_max_version", "kubernetesmaxversion", "maxkubeversion", "max_kube_version"}
+)
+
+// structuredAddonVersionKeyRe accepts matrix keys the stored resolver understands.
+var structuredAddonVersionKeyRe = regexp.MustCompile(`^(?:>=\s*)?v?\d+\.(?:\d+|x)(?:\.(?:\d+|x))?(?:[-+].*)?$`)
+
+func collectStructuredCompatibility(node *yaml.Node, result *Compatibility, depth int) {
+	if node == nil || depth > maxStructuredDepth {
+		return
+	}
+	switch node.Kind {
+	case yaml.SequenceNode:
+		for _, item := range node.Content {
+			addStructuredEntry(resolveYAMLNode(item), result)
+		}
+	case yaml.MappingNode:
+		if addStructuredEntry(node, result) {
+			return
+		}
+		applyStructuredBounds(node, result)
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			key := strings.TrimSpace(node.Content[i].Value)
+			value := resolveYAMLNode(node.Content[i+1])
+			switch {
+			case structuredWrapperKeys[strings.ToLower(key)]:
+				collectStructuredCompatibility(value, result, depth+1)
+			case structuredAddonVersionKeyRe.MatchString(strings.ToLower(key)):
+				result.addEntry(key, k8sVersionsFromNode(value))
+			}
+		}
+	}
+}
+
+// addStructuredEntry handles a mapping that describes one addon version
+// ({version: v1.14, kubernetes: [...]}). Returns false if the mapping has no
+// version field.
+func addStructuredEntry(node *yaml.Node, result *Compatibility) bool {
+	if node == nil || node.Kind != yaml.MappingNode {
+		return false
+	}
+	versionNode := lookupYAMLKey(node, structuredVersionKeys...)
+	if versionNode == nil || versionNode.Kind != yaml.ScalarNode {
+		return false
+	}
+	addonVersion := strings.TrimSpace(versionNode.Value)
+	if !structuredAddonVersionKeyRe.MatchString(strings.ToLower(addonVersion)) {
+		return false
+	}
+
+	var k8sVersions []string
+	if k8sNode := lookupYAMLKey(node, structuredK8sKeys...); k8sNode != nil {
+		k8sVersions = k8sVersionsFromNode(k8sNode)
+	} else {
+		minNode := lookupYAMLKey(node, structuredMinKeys...)
+		maxNode := lookupYAMLKey(node, structuredMaxKeys...)
+		if minNode != nil && maxNode != nil {
+			k8sVersions = expandK8sRange(normalizeStructuredK8sVersion(minNode.Value), normalizeStructuredK8sVersion(maxNode.Value))
+		}
+	}
+	result.addEntry(addonVersion, k8sVersions)
+	return true
+}
+
+// applyStructuredBounds records page-wide bounds from top-level keys such as
+// kubeVersion or minKubernetesVersion.
+func applyStructuredBounds(node *yaml.Node, result *Compatibility) {
+	if constraintNode := lookupYAMLKey(node, "kubeversion", "kube_version"); constraintNode != nil && constraintNode.Kind == yaml.ScalarNode {
+		if minVersion, maxVersion, ok := ParseKubeVersionConstraint(constraintNode.Value); ok {
+			result.MinVersion, result.MaxVersion = minVersion, maxVersion
+		}
+	}
+	if minNode := lookupYAMLKey(node, structuredMinKeys...); minNode != nil && minNode.Kind == yaml.ScalarNode {
+		result.MinVersion = normalizeStructuredK8sVersion(minNode.Value)
+	}
+	if maxNode := lookupYAMLKey(node, structuredMaxKeys...); maxNode != nil && maxNode.Kind == yaml.ScalarNode {
+		result.MaxVersion = normalizeStructuredK8sVersion(maxNode.Value)
+	}
+}
+
+func (p *Compatibility) addEntry(addonVersion string, k8sVersions []string) {
+	if addonVersion == "" || len(k8sVersions) == 0 {
+		return
+	}
+	p.Matrix[addonVersion] = appendUnique(p.Matrix[addonVersion], k8sVersions...)
+}
+
+// k8sVersionsFromNode reads a K8s version list, a semver constraint, a range
+// string ("1.24 - 1.31"), or a {min, max} mapping.
+func k8sVersionsFromNode(node *yaml.Node) []string {
+	if node == nil {
+		return nil
+	}
+	switch node.Kind {
+	case yaml.SequenceNode:
+		var versions []string
+		for _, item := range node.Content {
+			versions = appendUnique(versions, k8sVersionsFromNode(resolveYAMLNode(item))...)
+		}
+		return versions
+	case yaml.MappingNode:
+		minNode := lookupYAMLKey(node, append([]string{"min", "minimum", "from"}, structuredMinKeys...)...)
+		maxNode := lookupYAMLKey(node, append([]string{"max", "maximum", "to"}, structuredMaxKeys...)...)
+		if minNode != nil && maxNode != nil {
+			return expandK8sRange(normalizeStructuredK8sVersion(minNode.Value), normalizeStructuredK8sVersion(maxNode.Value))
+		}
+		return nil
+	case yaml.ScalarNode:
+		value := strings.TrimSpace(node.Value)
+		if strings.ContainsAny(value, "<>=~^|") {
+			if minVersion, maxVersion, ok := ParseKubeVersionConstraint(value); ok && minVersion != "" && maxVersion != "" {
+				return expandK8sRange(minVersion, maxVersion)
+			}
+			return nil
+		}
+		if versions := parseK8sVersionSpec(value); len(versions) > 0 {
+			return versions
+		}
+		if version := normalizeStructuredK8sVersion(value); version != "" {
+			return []string{version}
+		}
+	}
+	return nil
+}
+
+func finalizeStructured(result *Compatibility) *Compatibility {
+	cellCount := 0
+	for _, k8sVersions := range result.Matrix {
+		cellCount += len(k8sVersions)
+	}
+	if cellCount > maxCells || len(result.Matrix) == 0 {
+		result.Matrix = nil
+	}
+	for addonVersion, k8sVersions := range result.Matrix {
+		result.Matrix[addonVersion] = sortK8sVersions(k8sVersions)
+	}
+	if result.MinVersion != "" && result.MaxVersion != "" && compareMinorVersions(result.MinVersion, result.MaxVersion) > 0 {
+		result.MinVersion, result.MaxVersion = "", ""
+	}
+	if result.Matrix == nil && !result.HasBounds() {
+		return nil
+	}
+	return result
+}
+
+func resolveYAMLNode(node *yaml.Node) *yaml.Node {
+	for node != nil && (node.Kind == yaml.DocumentNode || node.Kind == yaml.AliasNode) {
+		if node.Kind == yaml.AliasNode {
+			node = node.Alias
+			continue
+		}
+		if len(node.Content) == 0 {
+			return nil
+		}
+		node = node.Content[0]
+	}
+	return node
+}
+
+// lookupYAMLKey returns the value of the first key (case-insensitive) present in a mapping.
+func lookupYAMLKey(node *yaml.Node, keys ...string) *yaml.Node {
+	if node == nil || node.Kind != yaml.MappingNode {
+		return nil
+	}
+	for _, wanted := range keys {
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			if strings.EqualFold(strings.TrimSpace(node.Content[i].Value), wanted) {
+				return resolveYAMLNode(node.Content[i+1])
+			}
+		}
+	}
+	return nil
+}
+
+// normalizeStructuredK8sVersion reduces "v1.30.2" or "1.30.0-0" to "1.30".
+func normalizeStructuredK8sVersion(value string) string {
+	m := proseK8sVersionRe.FindStringSubmatch(strings.TrimSpace(value))
+	if m == nil || !isK8sVersion(m[1]) {
+		return ""
+	}
+	return m[1]
+}
+
+// kubeVersionComparatorRe splits a constraint into operator/version pairs,
+// tolerating whitespace between the operator and the version (">= 1.25").
+var kubeVersionComparatorRe = regexp.MustCompile(`(>=|<=|!=|>|<|=|~|\^)?\s*v?(\d+)(?:\.(\d+|x|X|\*))?(?:\.(\d+|x|X|\*))?(?:-[0-9A-Za-z.-]+)?`)
+
+// ParseKubeVersionConstraint converts a Helm-style semver constraint such as
+// ">=1.25.0-0 <1.32.0-0" into inclusive K8s minor bounds ("1.25", "1.31").
+// Alternatives joined by "||" are merged into their enclosing range. Either
+// bound may be empty when the constraint is open-ended; ok is false when the
+// constraint expresses no K8s bound at all or is unsatisfiable.
+func ParseKubeVersionConstraint(constraint string) (minVersion string, maxVersion string, ok bool) {
+	constraint = strings.TrimSpace(constraint)
+	if constraint == "" {
+		return "", "", false
+	}
+
+	overallLo, overallHi := -1, -1
+	loOpen, hiOpen := false, false
+	for _, alternative := range strings.Split(constraint, "||") {
+		lo, hi, valid := parseKubeVersionRange(alternative)
+		if !valid {
+			continue
+		}
+		if lo < 0 {
+			loOpen = true
+		} else if overallLo < 0 || lo < overallLo {
+			overallLo = lo
+		}
+		if hi < 0 {
+			hiOpen = true
+		} else if hi > overallHi {
+			overallHi = hi
+		}
+	}
+	if loOpen {
+		overallLo = -1
+	}
+	if hiOpen {
+		overallHi = -1
+	}
+	if overallLo < 0 && overallHi < 0 {
+		return "", "", false
+	}
+	if overallLo >= 0 {
+		minVersion = "1." + strconv.Itoa(overallLo)
+	}
+	if overallHi >= 0 {
+		maxVersion = "1." + strconv.Itoa(overallHi)
+	}
+	return minVersion, maxVersion, true
+}
+
+// parseKubeVersionRange evaluates one space/comma-separated comparator set.
+// Returned minors are -1 when unbounded on that side.
+func parseKubeVersionRange(rangeText string) (lo int, hi int, ok bool) {
+	rangeText = strings.TrimSpace(rangeText)
+	if parts := strings.SplitN(rangeText, " - ", 2); len(parts) == 2 {
+		loMinor, loOK := constraintMinor(strings.TrimSpace(parts[0]))
+		hiMinor, hiOK := constraintMinor(strings.TrimSpace(parts[1]))
+		if !loOK || !hiOK || hiMinor < loMinor {
+			return -1, -1, false
+		}
+		return loMinor, hiMinor, true
+	}
+
+	lo, hi = -1, -1
+	matched := false
+	for _, m := range kubeVersionComparatorRe.FindAllStringSubmatch(rangeText, -1) {
+		operator, major, minorText, patchText := m[1], m[2], m[3], m[4]
+		if major != "1" {
+			// Non-1.x bounds (">=0.0.0", "<2.0.0") never constrain a K8s minor.
+			continue
+		}
+		minor, minorErr := strconv.Atoi(minorText)
+		minorWildcard := minorErr != nil
+		patchIsZero := patchText == "" || patchText == "0"
+		matched = true
+		switch operator {
+		case ">=", ">":
+			if !minorWildcard {
+				lo = maxBound(lo, minor)
+			}
+		case "<":
+			if minorWildcard {
+				continue
+			}
+			if patchIsZero {
+				hi = minBound(hi, minor-1)
+			} else {
+				hi = minBound(hi, minor)
+			}
+		case "<=":
+			if !minorWildcard {
+				hi = minBound(hi, minor)
+			}
+		case "^":
+			if !minorWildcard {
+				lo = maxBound(lo, minor)
+			}
+		case "!=":
+			// Exclusions never move a bound.
+		default: // "=", "~", or bare version
+			if !minorWildcard {
+				lo = maxBound(lo, minor)
+				hi = minBound(hi, minor)
+			}
+		}
+	}
+	if !matched || (lo >= 0 && hi >= 0 && hi < lo) {
+		return -1, -1, false
+	}
+	return lo, hi, true
+}
+
+func constraintMinor(version string) (int, bool) {
+	normalized := normalizeStructuredK8sVersion(version)
+	if normalized == "" {
+		return 0, false
+	}
+	return k8sMinor(normalized)
+}
+
+func maxBound(current int, candidate int) int {
+	if current < 0 || candidate > current {
+		return candidate
+	}
+	return current
+}
+
+func minBound(current int, candidate int) int {
+	if current < 0 || candidate < current {
+		return candidate
+	}
+	return current
+}
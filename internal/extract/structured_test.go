@@ -0,0 +1,186 @@
+package extract
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestExtractStructured_YAMLVersionMap(t *testing.T) {
+	content := `compatibility:
+  v1.14:
+    - "1.28"
+    - "1.29"
+    - 1.30
+  v1.13: ">=1.24.0-0 <1.28.0-0"
+  v1.12: "1.22 - 1.25"
+`
+	got, err := ExtractStructured(SourceTypeYAML, content)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got == nil {
+		t.Fatal("expected non-nil result")
+	}
+
+	want := map[string][]string{
+		"v1.14": {"1.28", "1.29", "1.30"},
+		"v1.13": {"1.24", "1.25", "1.26", "1.27"},
+		"v1.12": {"1.22", "1.23", "1.24", "1.25"},
+	}
+	if !reflect.DeepEqual(got.Matrix, want) {
+		t.Errorf("matrix = %v, want %v", got.Matrix, want)
+	}
+}
+
+func TestExtractStructured_YAMLEntryList(t *testing.T) {
+	content := `releases:
+  - version: "2.3.0"
+    kubernetes: ["1.29", "1.30", "1.31"]
+  - version: "2.2.0"
+    minKubernetesVersion: "1.27"
+    maxKubernetesVersion: "1.30"
+  - name: docs
+    kubernetes: ["1.20"]
+`
+	got, err := ExtractStructured(SourceTypeYAML, content)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got == nil {
+		t.Fatal("expected non-nil result")
+	}
+	if v := got.Matrix["2.3.0"]; !reflect.DeepEqual(v, []string{"1.29", "1.30", "1.31"}) {
+		t.Errorf("2.3.0 = %v", v)
+	}
+	if v := got.Matrix["2.2.0"]; !reflect.DeepEqual(v, []string{"1.27", "1.28", "1.29", "1.30"}) {
+		t.Errorf("2.2.0 = %v", v)
+	}
+	if len(got.Matrix) != 2 {
+		t.Errorf("expected entries without a version to be skipped, got %v", got.Matrix)
+	}
+}
+
+func TestExtractStructured_JSON(t *testing.T) {
+	content := `{"versions": [{"version": "v0.37", "kubernetesVersions": ["1.26", "1.27", "1.28", "1.29", "1.30"]}], "minKubernetesVersion": "1.26"}`
+	got, err := ExtractStructured(SourceTypeJSON, content)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got == nil {
+		t.Fatal("expected non-nil result")
+	}
+	if v := got.Matrix["v0.37"]; len(v) != 5 {
+		t.Errorf("v0.37 = %v, want 5 versions", v)
+	}
+	if got.MinVersion != "1.26" {
+		t.Errorf("MinVersion = %q, want 1.26", got.MinVersion)
+	}
+}
+
+func TestExtractStructured_HelmChart(t *testing.T) {
+	content := `apiVersion: v2
+name: cert-manager
+version: v1.14.4
+kubeVersion: ">=1.25.0-0 <1.32.0-0"
+dependencies:
+  - name: common
+    version: 1.2.3
+`
+	got, err := ExtractStructured(SourceTypeHelmChart, content)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got == nil {
+		t.Fatal("expected non-nil result")
+	}
+	if got.MinVersion != "1.25" || got.MaxVersion != "1.31" {
+		t.Errorf("bounds = %q..%q, want 1.25..1.31", got.MinVersion, got.MaxVersion)
+	}
+	if got.Matrix != nil {
+		t.Errorf("expected nil matrix, got %v", got.Matrix)
+	}
+}
+
+func TestExtractStructured_NoData(t *testing.T) {
+	tests := []struct {
+		name       string
+		sourceType string
+		content    string
+	}{
+		{"chart without kubeVersion", SourceTypeHelmChart, "apiVersion: v2\nname: foo\nversion: 1.0.0\n"},
+		{"unrelated yaml", SourceTypeYAML, "image:\n  repository: foo\n  tag: 1.2.3\n"},
+		{"empty json", SourceTypeJSON, "{}"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ExtractStructured(tt.sourceType, tt.content)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got != nil {
+				t.Errorf("expected nil result, got %+v", got)
+			}
+		})
+	}
+}
+
+func TestExtractStructured_CellLimit(t *testing.T) {
+	// 30 versions of 41 minors each: few keys, but more cells than maxCells.
+	var content strings.Builder
+	content.WriteString("compatibility:\n")
+	for i := 0; i < 30; i++ {
+		fmt.Fprintf(&content, "  v1.%d: \"1.0 - 1.40\"\n", i)
+	}
+	got, err := ExtractStructured(SourceTypeYAML, content.String())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got != nil {
+		t.Errorf("expected nil result over the cell limit, got %d matrix keys", len(got.Matrix))
+	}
+}
+
+func TestExtractStructured_Errors(t *testing.T) {
+	if _, err := ExtractStructured("toml", "a = 1"); err == nil {
+		t.Error("expected error for unsupported source type")
+	}
+	if _, err := ExtractStructured(SourceTypeYAML, "key: [unclosed"); err == nil {
+		t.Error("expected error for malformed YAML")
+	}
+}
+
+func TestParseKubeVersionConstraint(t *testing.T) {
+	tests := []struct {
+		constraint string
+		wantMin    string
+		wantMax    string
+		wantOK     bool
+	}{
+		{">=1.25.0-0 <1.32.0-0", "1.25", "1.31", true},
+		{">= 1.25.0", "1.25", "", true},
+		{">=1.23.0-0", "1.23", "", true},
+		{"<1.30", "", "1.29", true},
+		{"<1.30.5", "", "1.30", true},
+		{"<=1.31.x", "", "1.31", true},
+		{"~1.28.0", "1.28", "1.28", true},
+		{"^1.26.0", "1.26", "", true},
+		{"1.27.x", "1.27", "1.27", true},
+		{"1.24 - 1.29", "1.24", "1.29", true},
+		{">=1.20.0 <1.24.0 || >=1.26.0 <1.29.0", "1.20", "1.28", true},
+		{">=1.22.0 || >=1.19.0 <1.21.0", "1.19", "", true},
+		{">=0.0.0-0", "", "", false},
+		{">=1.30.0 <1.28.0", "", "", false},
+		{"", "", "", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.constraint, func(t *testing.T) {
+			gotMin, gotMax, ok := ParseKubeVersionConstraint(tt.constraint)
+			if ok != tt.wantOK || gotMin != tt.wantMin || gotMax != tt.wantMax {
+				t.Errorf("ParseKubeVersionConstraint(%q) = %q, %q, %v; want %q, %q, %v",
+					tt.constraint, gotMin, gotMax, ok, tt.wantMin, tt.wantMax, tt.wantOK)
+			}
+		})
+	}
+}
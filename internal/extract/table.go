@@ -1,7 +1,12 @@
 package extract
 
 import (
+	"fmt"
+	"html"
+	"net/url"
+	"path"
 	"regexp"
+	"strconv"
 	"strings"
 )
 
@@ -18,25 +23,99 @@ var addonVersionPattern = regexp.MustCompile(`^v?\d+\.\d+(?:\.\d+)?(?:[._-].*)?$
 // a map of addon-version → []k8s-versions. Returns nil map and nil error when no
 // parseable table is found — this is an expected path, not an error.
 func ExtractMarkdownMatrix(content string) (map[string][]string, error) {
-	tables := parseMarkdownTables(content)
-	for _, table := range tables {
-		if matrix := extractMatrixFromRows(table); len(matrix) > 0 {
-			return matrix, nil
+	return tableMatrix(firstTableCompatibility(RawFormatMarkdown, parseMarkdownTables(content))), nil
+}
+
+// Raw document formats recognized by DetectRawFormat.
+const (
+	RawFormatMarkdown = "markdown"
+	RawFormatAsciiDoc = "asciidoc"
+	RawFormatRST      = "rst"
+)
+
+// asciiDocTableDelimiterRe and rstTableMarkerRe sniff table syntax when the URL
+// has no telling extension (e.g. a repo root that resolves to README.adoc).
+var (
+	asciiDocTableDelimiterRe = regexp.MustCompile(`(?m)^\|===\s*$`)
+	rstTableMarkerRe         = regexp.MustCompile(`(?m)^\s*(?:\+(?:-+\+){2,}|={2,}(?: +={2,})+|\.\.\s+list-table::)\s*$`)
+)
+
+// DetectRawFormat identifies the markup of raw (non-HTML) content from the
+// page URL's file extension, falling back to sniffing for table syntax.
+func DetectRawFormat(pageURL string, content string) string {
+	if parsed, err := url.Parse(pageURL); err == nil {
+		switch strings.ToLower(path.Ext(parsed.Path)) {
+		case ".adoc", ".asciidoc", ".asc":
+			return RawFormatAsciiDoc
+		case ".rst", ".rest":
+			return RawFormatRST
+		case ".md", ".markdown":
+			return RawFormatMarkdown
+		}
+	}
+	switch {
+	case asciiDocTableDelimiterRe.MatchString(content):
+		return RawFormatAsciiDoc
+	case rstTableMarkerRe.MatchString(content):
+		return RawFormatRST
+	default:
+		return RawFormatMarkdown
+	}
+}
+
+// ExtractRawMatrix extracts a compatibility matrix from raw repository content,
+// dispatching to the Markdown, AsciiDoc or reStructuredText table parser by
+// DetectRawFormat. Returns nil map and nil error when no parseable table is found.
+func ExtractRawMatrix(pageURL string, content string) (map[string][]string, error) {
+	table, err := ExtractRawTable(pageURL, content)
+	return tableMatrix(table), err
+}
+
+// ExtractRawTable is ExtractRawMatrix with the strategy and table locator
+// recorded, for provenance. Returns nil and nil error when no parseable table is found.
+func ExtractRawTable(pageURL string, content string) (*Compatibility, error) {
+	switch format := DetectRawFormat(pageURL, content); format {
+	case RawFormatAsciiDoc:
+		return firstTableCompatibility(format, parseAsciiDocTables(content)), nil
+	case RawFormatRST:
+		return firstTableCompatibility(format, parseRSTTables(content)), nil
+	default:
+		return firstTableCompatibility(RawFormatMarkdown, parseMarkdownTables(content)), nil
+	}
+}
+
+// ExtractHTMLTable is ExtractHTMLMatrix with the strategy and table locator
+// recorded, for provenance. Returns nil and nil error when no parseable table is found.
+func ExtractHTMLTable(content string) (*Compatibility, error) {
+	return firstTableCompatibility("html", parseHTMLTables(content)), nil
+}
+
+// firstTableCompatibility returns the first table that yields a matrix,
+// located as "<format> table <n>" (1-based, in document order).
+func firstTableCompatibility(format string, tables [][][]string) *Compatibility {
+	for i, table := range tables {
+		if matrix, strategy := extractMatrixAndStrategyFromRows(table); len(matrix) > 0 {
+			return &Compatibility{
+				Matrix:   matrix,
+				Strategy: strategy,
+				Locator:  fmt.Sprintf("%s table %d", format, i+1),
+			}
 		}
 	}
-	return nil, nil
+	return nil
+}
+
+func tableMatrix(table *Compatibility) map[string][]string {
+	if table == nil {
+		return nil
+	}
+	return table.Matrix
 }
 
 // ExtractHTMLMatrix parses HTML content for <table> elements containing compatibility
 // data. Returns nil map and nil error when no parseable table is found.
 func ExtractHTMLMatrix(content string) (map[string][]string, error) {
-	tables := parseHTMLTables(content)
-	for _, table := range tables {
-		if matrix := extractMatrixFromRows(table); len(matrix) > 0 {
-			return matrix, nil
-		}
-	}
-	return nil, nil
+	return tableMatrix(firstTableCompatibility("html", parseHTMLTables(content))), nil
 }
 
 // parseMarkdownTables extracts all Markdown tables from content.
@@ -47,9 +126,14 @@ func parseMarkdownTables(content string) [][][]string {
 	tableCellCount := 0
 	skipOversize := false
 
-	for _, line := range strings.Split(content, "\n") {
+	lines := strings.Split(content, "\n")
+	for i, line := range lines {
 		trimmed := strings.TrimSpace(line)
-		if !isMarkdownTableRow(trimmed) {
+		// Rows may omit the outer pipes; such a table is recognized by the
+		// separator row under its header.
+		bare := strings.Contains(trimmed, "|") &&
+			(len(currentTable) > 0 || i+1 < len(lines) && isBareMarkdownSeparatorRow(lines[i+1]))
+		if !isMarkdownTableRow(trimmed) && !bare {
 			if len(currentTable) > 0 {
 				tables = append(tables, currentTable)
 			}
@@ -112,6 +196,13 @@ func isMarkdownSeparatorRow(line string) bool {
 	return true
 }
 
+// isBareMarkdownSeparatorRow detects a separator row of a table whose rows
+// omit the outer pipes, like "---|---" or "--- | :---:".
+func isBareMarkdownSeparatorRow(line string) bool {
+	trimmed := strings.TrimSpace(line)
+	return strings.Contains(trimmed, "|") && strings.Contains(trimmed, "-") && isMarkdownSeparatorRow(trimmed)
+}
+
 // parseMarkdownRow splits a Markdown table row into cells.
 func parseMarkdownRow(line string) []string {
 	// Remove leading/trailing pipe
@@ -168,6 +259,7 @@ func parseHTMLTables(content string) [][][]string {
 			for _, cellMatch := range cellMatches {
 				// Strip inner HTML tags and normalize whitespace
 				text := htmlTagStripRe.ReplaceAllString(cellMatch[1], " ")
+				text = html.UnescapeString(text)
 				text = strings.Join(strings.Fields(text), " ")
 				text = strings.TrimSpace(text)
 				cells = append(cells, text)
@@ -193,13 +285,20 @@ func parseHTMLTables(content string) [][][]string {
 // The first row is treated as headers. It looks for columns containing K8s versions
 // and an addon version column.
 func extractMatrixFromRows(rows [][]string) map[string][]string {
+	matrix, _ := extractMatrixAndStrategyFromRows(rows)
+	return matrix
+}
+
+// extractMatrixAndStrategyFromRows is extractMatrixFromRows that also reports
+// which strategy (StrategyVersionHeader or StrategyLabeledColumn) matched.
+func extractMatrixAndStrategyFromRows(rows [][]string) (map[string][]string, string) {
 	if len(rows) < 2 {
-		return nil
+		return nil, ""
 	}
 
 	headers := rows[0]
 	if len(headers) < 2 {
-		return nil
+		return nil, ""
 	}
 
 	// Identify column roles
@@ -216,19 +315,32 @@ func extractMatrixFromRows(rows [][]string) map[string][]string {
 			}
 		}
 		if addonVersionCol < 0 {
-			return nil
+			return nil, ""
 		}
-		return buildMatrixFromVersionHeaders(rows, headers, addonVersionCol, k8sVersionCols)
+		if matrix := buildMatrixFromVersionHeaders(rows, headers, addonVersionCol, k8sVersionCols); matrix != nil {
+			return matrix, StrategyVersionHeader
+		}
+		// Transposed tables list the minimum addon version under each
+		// Kubernetes version (e.g., Karpenter's ">= 1.0.5").
+		if matrix := buildThresholdMatrixFromVersionHeaders(rows, headers, k8sVersionCols); matrix != nil {
+			return matrix, StrategyVersionHeader
+		}
+		return nil, ""
 	}
 
 	// Strategy 2: Headers contain labels like "Kubernetes Version", "K8s Version"
 	// and the data rows contain version strings.
-	addonVersionCol, k8sCol := identifyLabeledColumns(headers)
-	if addonVersionCol >= 0 && k8sCol >= 0 {
-		return buildMatrixFromLabeledColumns(rows, addonVersionCol, k8sCol)
+	addonCols, k8sCol := identifyLabeledColumns(headers)
+	if k8sCol < 0 {
+		return nil, ""
+	}
+	for _, col := range addonCols {
+		if matrix := buildMatrixFromLabeledColumns(rows, col, k8sCol); matrix != nil {
+			return matrix, StrategyLabeledColumn
+		}
 	}
 
-	return nil
+	return nil, ""
 }
 
 // identifyK8sVersionColumns returns a map of column indices whose header is a K8s version string.
@@ -249,26 +361,26 @@ var k8sHeaderPattern = regexp.MustCompile(`(?i)(?:kubernetes|k8s)\s*(?:version)?
 // addonVersionHeaderPattern matches header labels that indicate an addon version column.
 var addonVersionHeaderPattern = regexp.MustCompile(`(?i)(?:version|release|addon|chart|app|operator)\s*(?:version)?`)
 
-// identifyLabeledColumns finds addon-version and K8s-version columns by header labels.
-func identifyLabeledColumns(headers []string) (addonCol int, k8sCol int) {
-	addonCol = -1
+// identifyLabeledColumns finds the K8s-version column by its header label and
+// lists the candidate addon-version columns: those labeled as versions or
+// releases first, left to right, then the remaining columns (a product name
+// such as "KEDA" often heads the addon version column).
+func identifyLabeledColumns(headers []string) (addonCols []int, k8sCol int) {
 	k8sCol = -1
+	var labeled, unlabeled []int
 
 	for i, h := range headers {
-		lower := strings.ToLower(h)
-		if k8sHeaderPattern.MatchString(h) {
+		switch {
+		case k8sHeaderPattern.MatchString(h):
 			k8sCol = i
-		} else if addonVersionHeaderPattern.MatchString(h) {
-			addonCol = i
-		} else if lower == "version" || lower == "release" {
-			// Generic "Version" column — treat as addon version if we don't have one yet
-			if addonCol < 0 {
-				addonCol = i
-			}
+		case addonVersionHeaderPattern.MatchString(h):
+			labeled = append(labeled, i)
+		default:
+			unlabeled = append(unlabeled, i)
 		}
 	}
 
-	return addonCol, k8sCol
+	return append(labeled, unlabeled...), k8sCol
 }
 
 // buildMatrixFromVersionHeaders builds a matrix when K8s versions are column headers.
@@ -317,8 +429,13 @@ func buildMatrixFromVersionHeaders(rows [][]string, headers []string, addonCol i
 // buildMatrixFromLabeledColumns builds a matrix when columns are labeled
 // (e.g., "Addon Version" | "Kubernetes Version"). Each row maps one addon version
 // to one or more K8s versions (which may be comma/space separated in a single cell).
+// Addon versions may look like K8s versions here (cert-manager's "1.18"): the
+// K8s column is known by its label. A floor such as "1.27+" is expanded up to
+// the newest K8s version the table names.
 func buildMatrixFromLabeledColumns(rows [][]string, addonCol int, k8sCol int) map[string][]string {
 	matrix := make(map[string][]string)
+	floors := make(map[string]string)
+	newest := ""
 
 	for _, row := range rows[1:] {
 		if addonCol >= len(row) || k8sCol >= len(row) {
@@ -326,45 +443,130 @@ func buildMatrixFromLabeledColumns(rows [][]string, addonCol int, k8sCol int) ma
 		}
 
 		addonVersion := normalizeVersionCell(row[addonCol])
-		if !isAddonVersion(addonVersion) {
+		if !addonVersionPattern.MatchString(addonVersion) {
 			continue
 		}
 
 		k8sCell := row[k8sCol]
 		k8sVersions := extractK8sVersionsFromCell(k8sCell)
+		for _, v := range k8sVersions {
+			if newest == "" || compareMinorVersions(v, newest) > 0 {
+				newest = v
+			}
+		}
+		if m := tableFloorRe.FindStringSubmatch(k8sCell); m != nil && len(k8sVersions) == 1 {
+			if _, ok := floors[addonVersion]; !ok {
+				floors[addonVersion] = m[1]
+			}
+			continue
+		}
 		if len(k8sVersions) > 0 {
 			existing := matrix[addonVersion]
 			matrix[addonVersion] = appendUnique(existing, k8sVersions...)
 		}
 	}
 
+	for addonVersion, floor := range floors {
+		matrix[addonVersion] = appendUnique(matrix[addonVersion], expandK8sRange(floor, newest)...)
+	}
 	if len(matrix) == 0 {
 		return nil
 	}
 	return matrix
 }
 
+// buildThresholdMatrixFromVersionHeaders builds a matrix from a table whose
+// cells give the minimum addon version for each K8s version header. Each
+// minimum becomes a ">=" key supporting every K8s version whose minimum is
+// no higher, so the newest addon versions support the most K8s versions.
+// Only the first row with minimums is read.
+func buildThresholdMatrixFromVersionHeaders(rows [][]string, headers []string, k8sCols map[int]bool) map[string][]string {
+	for _, row := range rows[1:] {
+		var k8sVersions, minimums []string
+		for colIdx, hdr := range headers {
+			if !k8sCols[colIdx] || colIdx >= len(row) {
+				continue
+			}
+			m := tableMinimumRe.FindStringSubmatch(normalizeVersionCell(row[colIdx]))
+			headerVersion := normalizeK8sVersionFromHeader(hdr)
+			if m == nil || headerVersion == "" {
+				continue
+			}
+			k8sVersions = append(k8sVersions, headerVersion)
+			minimums = append(minimums, m[1])
+		}
+		if len(minimums) == 0 {
+			continue
+		}
+
+		matrix := make(map[string][]string, len(minimums))
+		for _, minimum := range minimums {
+			var supported []string
+			for i, other := range minimums {
+				if compareAddonVersions(other, minimum) <= 0 {
+					supported = append(supported, k8sVersions[i])
+				}
+			}
+			matrix[">="+minimum] = sortK8sVersions(supported)
+		}
+		return matrix
+	}
+	return nil
+}
+
+// tableMinimumRe matches a minimum addon version cell like ">= 1.0.5".
+var tableMinimumRe = regexp.MustCompile(`^>=\s*(v?\d+\.\d+(?:\.\d+)?)$`)
+
+// tableFloorRe matches a K8s version floor like "1.27+" in a table cell.
+var tableFloorRe = regexp.MustCompile(`v?(\d+\.\d+)\+`)
+
+// tableRangeRe matches a K8s version range like "1.29 → 1.33" or "v1.30 - v1.32".
+var tableRangeRe = regexp.MustCompile(`v?(\d+\.\d+)\s*(?:-|–|→|to|through)\s*v?(\d+\.\d+)`)
+
+// compareAddonVersions compares dotted numeric addon versions, ignoring a
+// leading "v"; missing components count as zero.
+func compareAddonVersions(a string, b string) int {
+	aParts := strings.Split(strings.TrimPrefix(a, "v"), ".")
+	bParts := strings.Split(strings.TrimPrefix(b, "v"), ".")
+	for i := 0; i < len(aParts) || i < len(bParts); i++ {
+		var aNum, bNum int
+		if i < len(aParts) {
+			aNum, _ = strconv.Atoi(aParts[i])
+		}
+		if i < len(bParts) {
+			bNum, _ = strconv.Atoi(bParts[i])
+		}
+		if aNum != bNum {
+			return aNum - bNum
+		}
+	}
+	return 0
+}
+
 // k8sCellVersionRe extracts version numbers from a cell that may contain ranges or lists.
 var k8sCellVersionRe = regexp.MustCompile(`\d+\.\d+`)
 
 // extractK8sVersionsFromCell parses a cell that may contain one or more K8s versions,
-// possibly separated by commas, spaces, dashes (ranges), or other delimiters.
+// possibly separated by commas, spaces, or other delimiters. Ranges
+// ("1.29 → 1.33") are expanded to every minor they span.
 func extractK8sVersionsFromCell(cell string) []string {
-	matches := k8sCellVersionRe.FindAllString(cell, -1)
 	var versions []string
-	for _, m := range matches {
+	for _, m := range k8sCellVersionRe.FindAllString(cell, -1) {
 		if isK8sVersion(m) {
-			versions = append(versions, m)
+			versions = appendUnique(versions, m)
 		}
 	}
-	return versions
+	for _, m := range tableRangeRe.FindAllStringSubmatch(cell, -1) {
+		versions = appendUnique(versions, expandK8sRange(m[1], m[2])...)
+	}
+	return sortK8sVersions(versions)
 }
 
 // normalizeVersionCell strips common prefixes/suffixes and whitespace from version cells.
 func normalizeVersionCell(cell string) string {
 	s := strings.TrimSpace(cell)
-	// Strip backticks and inline code markers
-	s = strings.Trim(s, "`")
+	// Strip backticks, inline code and bold markers
+	s = strings.Trim(s, "`*")
 	s = strings.TrimSpace(s)
 	return s
 }
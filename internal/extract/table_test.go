@@ -2,6 +2,7 @@ package extract
 
 import (
 	"fmt"
+	"reflect"
 	"strings"
 	"testing"
 )
@@ -166,9 +167,9 @@ func TestExtractHTMLMatrix_LabeledColumns(t *testing.T) {
 	if matrix == nil {
 		t.Fatal("expected non-nil matrix")
 	}
-	// "1.29 - 1.31" extracts the two endpoint versions; ranges are not expanded.
-	if got := matrix["v3.0.0"]; len(got) != 2 {
-		t.Errorf("v3.0.0 supports %d versions, want 2 (range endpoints): %v", len(got), got)
+	// "1.29 - 1.31" is expanded to every minor in the range.
+	if got := matrix["v3.0.0"]; len(got) != 3 {
+		t.Errorf("v3.0.0 supports %d versions, want 3 (expanded range): %v", len(got), got)
 	}
 	if got := matrix["v2.5.0"]; len(got) != 2 {
 		t.Errorf("v2.5.0 supports %d versions, want 2: %v", len(got), got)
@@ -289,7 +290,7 @@ func TestExtractK8sVersionsFromCell(t *testing.T) {
 		want int
 	}{
 		{"1.28, 1.29, 1.30", 3},
-		{"1.28 - 1.30", 2},      // range endpoints only (no expansion)
+		{"1.28 - 1.30", 3},      // range expanded to every minor
 		{"1.28", 1},              // single version
 		{"v1.28+", 1},            // with suffix
 		{"no versions here", 0},  // no version strings
@@ -349,9 +350,9 @@ func TestExtractMarkdownMatrix_K8sVersionRange(t *testing.T) {
 	if matrix == nil {
 		t.Fatal("expected non-nil matrix")
 	}
-	// "1.28 - 1.31" should extract 1.28 and 1.31 as individual versions
-	if got := matrix["v1.5.0"]; len(got) < 2 {
-		t.Errorf("v1.5.0 supports %d versions, want >= 2: %v", len(got), got)
+	// "1.28 - 1.31" should expand to 1.28 through 1.31
+	if got := matrix["v1.5.0"]; !reflect.DeepEqual(got, []string{"1.28", "1.29", "1.30", "1.31"}) {
+		t.Errorf("v1.5.0 = %v, want [1.28 1.29 1.30 1.31]", got)
 	}
 }
 
@@ -480,3 +481,137 @@ func TestParseHTMLTables_CellCapDiscardsOversizeTable(t *testing.T) {
 		t.Fatalf("expected 2 rows in surviving table, got %d", len(tables[0]))
 	}
 }
+
+func TestDetectRawFormat(t *testing.T) {
+	tests := []struct {
+		name    string
+		pageURL string
+		content string
+		want    string
+	}{
+		{"adoc extension", "https://github.com/org/repo/blob/main/docs/compat.adoc", "", RawFormatAsciiDoc},
+		{"rst extension", "https://raw.githubusercontent.com/org/repo/main/docs/install.rst", "", RawFormatRST},
+		{"md extension wins over content", "https://github.com/org/repo/blob/main/README.md", "|===\n", RawFormatMarkdown},
+		{"sniff asciidoc", "https://github.com/org/repo", "= Title\n\n|===\n|a |b\n|===\n", RawFormatAsciiDoc},
+		{"sniff rst grid", "https://github.com/org/repo", "+----+----+\n| a  | b  |\n+----+----+\n", RawFormatRST},
+		{"sniff rst list-table", "https://github.com/org/repo", ".. list-table::\n", RawFormatRST},
+		{"default markdown", "https://github.com/org/repo", "| a | b |\n| - | - |\n", RawFormatMarkdown},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := DetectRawFormat(tt.pageURL, tt.content); got != tt.want {
+				t.Errorf("DetectRawFormat() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestExtractRawMatrix_DispatchesByFormat(t *testing.T) {
+	content := "|===\n|Version |Kubernetes\n|v2.0.0 |1.30\n|===\n"
+	matrix, err := ExtractRawMatrix("https://github.com/org/repo/blob/main/COMPAT.adoc", content)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got := matrix["v2.0.0"]; len(got) != 1 || got[0] != "1.30" {
+		t.Errorf("v2.0.0 = %v, want [1.30]", got)
+	}
+}
+
+func TestExtractRawTable_RecordsStrategyAndLocator(t *testing.T) {
+	content := `| Name | Value |
+| --- | --- |
+| foo | bar |
+
+| Addon Version | Kubernetes Version |
+| --- | --- |
+| v2.1.0 | 1.29, 1.30 |
+| v2.0.0 | 1.28 |
+`
+	table, err := ExtractRawTable("https://github.com/org/repo/blob/main/README.md", content)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if table == nil {
+		t.Fatal("expected non-nil table")
+	}
+	if table.Strategy != StrategyLabeledColumn {
+		t.Errorf("Strategy = %q, want %q", table.Strategy, StrategyLabeledColumn)
+	}
+	if table.Locator != "markdown table 2" {
+		t.Errorf("Locator = %q, want %q", table.Locator, "markdown table 2")
+	}
+}
+
+func TestExtractHTMLTable_VersionHeaderStrategy(t *testing.T) {
+	content := `<table><tr><th>Version</th><th>1.30</th></tr><tr><td>v1.10.0</td><td>Yes</td></tr></table>`
+	table, _ := ExtractHTMLTable(content)
+	if table == nil {
+		t.Fatal("expected non-nil table")
+	}
+	if table.Strategy != StrategyVersionHeader || table.Locator != "html table 1" {
+		t.Errorf("got strategy=%q locator=%q", table.Strategy, table.Locator)
+	}
+}
+
+func TestExtractMatrixFromRows_TableShapes(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		html    bool
+		want    map[string][]string
+	}{
+		{
+			name: "rows without outer pipes and floors",
+			content: `Metrics Server | Metrics API group/version | Supported Kubernetes version
+---------------|---------------------------|-----------------------------
+0.8.x          | ` + "`metrics.k8s.io/v1beta1`" + `  | 1.31+
+0.7.x          | ` + "`metrics.k8s.io/v1beta1`" + `  | 1.29+
+`,
+			want: map[string][]string{"0.8.x": {"1.31"}, "0.7.x": {"1.29", "1.30", "1.31"}},
+		},
+		{
+			name: "unlabeled addon column with bold versions",
+			content: `| kube-state-metrics | Kubernetes client-go Version |
+|--------------------|:----------------------------:|
+| **v2.14.0**        | v1.31                        |
+| **main**           | v1.34                        |
+`,
+			want: map[string][]string{"v2.14.0": {"1.31"}},
+		},
+		{
+			name: "first labeled column with versions wins",
+			content: `<table>
+<tr><th>Release</th><th>Release Date</th><th>Supported Kubernetes versions</th><th>Supported OpenShift versions</th></tr>
+<tr><td>1.18</td><td>Jun 10, 2025</td><td>1.29 → 1.31</td><td>4.16 → 4.19</td></tr>
+</table>`,
+			html: true,
+			want: map[string][]string{"1.18": {"1.29", "1.30", "1.31"}},
+		},
+		{
+			name: "minimum addon versions under version headers",
+			content: `<table>
+<tr><th>KUBERNETES</th><th>1.29</th><th>1.30</th><th>1.31</th></tr>
+<tr><td>karpenter</td><td>&gt;= 0.34</td><td>&gt;= 0.37</td><td>&gt;= 1.0.5</td></tr>
+</table>`,
+			html: true,
+			want: map[string][]string{
+				">=0.34":  {"1.29"},
+				">=0.37":  {"1.29", "1.30"},
+				">=1.0.5": {"1.29", "1.30", "1.31"},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got map[string][]string
+			if tt.html {
+				got, _ = ExtractHTMLMatrix(tt.content)
+			} else {
+				got, _ = ExtractMarkdownMatrix(tt.content)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("matrix = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
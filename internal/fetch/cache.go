@@ -0,0 +1,156 @@
+package fetch
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// PageCache memoizes fetched compatibility pages by URL so addons sharing a
+// page, or repeated requests to a long-running server, fetch it once. Failed
+// fetches are not cached. It is safe for concurrent use.
+type PageCache struct {
+	// TTL bounds how long a page is reused. Zero keeps pages for the life of
+	// the cache, which suits a single CLI run.
+	TTL time.Duration
+	// Dir, when set, also keeps pages on disk (see SavePage), so they survive
+	// restarts and pages saved by kaddons-extract are reused. TTL applies to
+	// them by the time they were fetched.
+	Dir string
+	// Fetch retrieves a page; nil uses CompatibilityPageFull.
+	Fetch func(ctx context.Context, pageURL string) (FetchedPage, error)
+	// Now is the clock used for TTL checks; nil uses time.Now.
+	Now func() time.Time
+
+	mu      sync.Mutex
+	entries map[string]cachedPage
+}
+
+type cachedPage struct {
+	page      FetchedPage
+	fetchedAt time.Time
+}
+
+// NewPageCache returns a cache whose entries expire after ttl (never when
+// ttl is zero).
+func NewPageCache(ttl time.Duration) *PageCache {
+	return &PageCache{TTL: ttl}
+}
+
+// Get returns the cached page for pageURL, fetching it on a miss or after
+// expiry. Concurrent misses for the same URL may each fetch; the last one wins.
+func (c *PageCache) Get(ctx context.Context, pageURL string) (FetchedPage, error) {
+	now := time.Now
+	if c.Now != nil {
+		now = c.Now
+	}
+
+	c.mu.Lock()
+	entry, ok := c.entries[pageURL]
+	c.mu.Unlock()
+	if !ok && c.Dir != "" {
+		entry, ok = loadPage(c.Dir, pageURL)
+	}
+	if ok && (c.TTL <= 0 || now().Sub(entry.fetchedAt) < c.TTL) {
+		c.store(pageURL, entry)
+		return entry.page, nil
+	}
+
+	fetchPage := c.Fetch
+	if fetchPage == nil {
+		fetchPage = CompatibilityPageFull
+	}
+	page, err := fetchPage(ctx, pageURL)
+	if err != nil {
+		return FetchedPage{}, err
+	}
+
+	entry = cachedPage{page: page, fetchedAt: now()}
+	c.store(pageURL, entry)
+	if c.Dir != "" {
+		// Best effort: the page is still served from memory.
+		_ = savePage(c.Dir, pageURL, entry)
+	}
+	return page, nil
+}
+
+func (c *PageCache) store(pageURL string, entry cachedPage) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.entries == nil {
+		c.entries = make(map[string]cachedPage)
+	}
+	c.entries[pageURL] = entry
+}
+
+// diskPage is the on-disk form of a cached page.
+type diskPage struct {
+	URL       string    `json:"url"`
+	FetchedAt time.Time `json:"fetched_at"`
+	Text      string    `json:"text"`
+	Raw       string    `json:"raw"`
+	IsRaw     bool      `json:"is_raw"`
+}
+
+// PageCacheFile is the file under dir that holds the page of pageURL: the
+// hex SHA-256 of the URL with a .json extension.
+func PageCacheFile(dir string, pageURL string) string {
+	sum := sha256.Sum256([]byte(pageURL))
+	return filepath.Join(dir, hex.EncodeToString(sum[:])+".json")
+}
+
+// SavePage writes page, fetched now, to the on-disk page cache in dir, where
+// a PageCache with that Dir finds it.
+func SavePage(dir string, pageURL string, page FetchedPage) error {
+	return savePage(dir, pageURL, cachedPage{page: page, fetchedAt: time.Now()})
+}
+
+func savePage(dir string, pageURL string, entry cachedPage) error {
+	data, err := json.Marshal(diskPage{
+		URL:       pageURL,
+		FetchedAt: entry.fetchedAt.UTC(),
+		Text:      entry.page.Text,
+		Raw:       entry.page.Raw,
+		IsRaw:     entry.page.IsRaw,
+	})
+	if err != nil {
+		return err
+	}
+	// Write and rename so a concurrent reader never sees a partial file.
+	tmp, err := os.CreateTemp(dir, ".page-*")
+	if err != nil {
+		return err
+	}
+	if _, err := tmp.Write(data); err != nil {
+		_ = tmp.Close()
+		_ = os.Remove(tmp.Name())
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		_ = os.Remove(tmp.Name())
+		return err
+	}
+	return os.Rename(tmp.Name(), PageCacheFile(dir, pageURL))
+}
+
+// loadPage reads the page of pageURL from dir. A missing, unreadable or
+// mismatched file is a miss.
+func loadPage(dir string, pageURL string) (cachedPage, bool) {
+	data, err := os.ReadFile(PageCacheFile(dir, pageURL)) // #nosec G304 -- name derived from a hash
+	if err != nil {
+		return cachedPage{}, false
+	}
+	var stored diskPage
+	if err := json.Unmarshal(data, &stored); err != nil || stored.URL != pageURL {
+		return cachedPage{}, false
+	}
+	return cachedPage{
+		page:      FetchedPage{Text: stored.Text, Raw: stored.Raw, IsRaw: stored.IsRaw},
+		fetchedAt: stored.FetchedAt,
+	}, true
+}
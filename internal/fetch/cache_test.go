@@ -0,0 +1,101 @@
+package fetch
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestPageCache_Get(t *testing.T) {
+	tests := []struct {
+		name        string
+		ttl         time.Duration
+		advance     time.Duration
+		failFirst   bool
+		wantFetches int
+	}{
+		{name: "no TTL reuses page", ttl: 0, advance: 48 * time.Hour, wantFetches: 1},
+		{name: "within TTL reuses page", ttl: time.Hour, advance: 30 * time.Minute, wantFetches: 1},
+		{name: "expired page is refetched", ttl: time.Hour, advance: 2 * time.Hour, wantFetches: 2},
+		{name: "failed fetch is not cached", ttl: 0, failFirst: true, wantFetches: 2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			now := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+			fetches := 0
+			cache := &PageCache{
+				TTL: tt.ttl,
+				Now: func() time.Time { return now },
+				Fetch: func(ctx context.Context, pageURL string) (FetchedPage, error) {
+					fetches++
+					if tt.failFirst && fetches == 1 {
+						return FetchedPage{}, errors.New("HTTP 503")
+					}
+					return FetchedPage{Text: pageURL, Raw: pageURL, IsRaw: true}, nil
+				},
+			}
+
+			_, firstErr := cache.Get(context.Background(), "https://example.com/compat")
+			if tt.failFirst != (firstErr != nil) {
+				t.Fatalf("first Get error = %v, failFirst = %v", firstErr, tt.failFirst)
+			}
+			now = now.Add(tt.advance)
+			page, err := cache.Get(context.Background(), "https://example.com/compat")
+			if err != nil {
+				t.Fatalf("second Get error = %v", err)
+			}
+			if page.Raw != "https://example.com/compat" || !page.IsRaw {
+				t.Errorf("unexpected page %+v", page)
+			}
+			if fetches != tt.wantFetches {
+				t.Errorf("fetches = %d, want %d", fetches, tt.wantFetches)
+			}
+		})
+	}
+}
+
+func TestPageCache_Dir(t *testing.T) {
+	const pageURL = "https://example.com/compat"
+	dir := t.TempDir()
+	now := time.Now()
+	fetches := 0
+	newCache := func() *PageCache {
+		return &PageCache{
+			TTL: time.Hour,
+			Dir: dir,
+			Now: func() time.Time { return now },
+			Fetch: func(ctx context.Context, pageURL string) (FetchedPage, error) {
+				fetches++
+				return FetchedPage{Text: "fetched", Raw: "<table></table>"}, nil
+			},
+		}
+	}
+
+	if err := SavePage(dir, pageURL, FetchedPage{Text: "saved", Raw: "| a | b |", IsRaw: true}); err != nil {
+		t.Fatalf("SavePage() error = %v", err)
+	}
+	page, err := newCache().Get(context.Background(), pageURL)
+	if err != nil || page.Text != "saved" || !page.IsRaw || fetches != 0 {
+		t.Fatalf("Get() = %+v, %v after %d fetches; want the saved page without fetching", page, err, fetches)
+	}
+
+	// An expired page on disk is refetched and the file replaced.
+	now = now.Add(2 * time.Hour)
+	if page, err := newCache().Get(context.Background(), pageURL); err != nil || page.Text != "fetched" || fetches != 1 {
+		t.Fatalf("Get() = %+v, %v after %d fetches; want a refetch", page, err, fetches)
+	}
+	if page, err := newCache().Get(context.Background(), pageURL); err != nil || page.Text != "fetched" || fetches != 1 {
+		t.Fatalf("Get() from a new cache = %+v, %v after %d fetches; want the refetched page from disk", page, err, fetches)
+	}
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 1 || filepath.Join(dir, entries[0].Name()) != PageCacheFile(dir, pageURL) {
+		t.Errorf("cache dir holds %v, want only %s", entries, PageCacheFile(dir, pageURL))
+	}
+}
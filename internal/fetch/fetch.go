@@ -90,11 +90,12 @@ type FetchedPage struct {
 	// Text is the normalized content (HTML stripped, whitespace cleaned) used by the LLM.
 	Text string
 	// Raw is the original fetched content before normalization.
-	// For GitHub raw URLs this is Markdown; for other URLs this is HTML.
+	// For GitHub raw URLs this is Markdown (or AsciiDoc/reStructuredText for
+	// .adoc/.rst files); for other URLs this is HTML.
 	Raw string
 	// IsRaw is true when the fetched content is from raw.githubusercontent.com
 	// (either by conversion or because the URL was already a raw GitHub URL),
-	// meaning the raw content is Markdown rather than HTML.
+	// meaning the raw content is repository markup rather than HTML.
 	IsRaw bool
 }
 
@@ -303,12 +304,19 @@ func truncateToValidUTF8Prefix(text string, maxBytes int) string {
 	return text[:truncateIndex]
 }
 
+// hostBreaker is shared by every fetch in the process, so a documentation host
+// that is down stops costing three attempts per addon.
+var hostBreaker = resilience.NewCircuitBreaker(5, 30*time.Second)
+
 func doRequestWithRetry(ctx context.Context, client *http.Client, request *http.Request) (*http.Response, error) {
 	policy := resilience.RetryPolicy{
 		Attempts:     3,
 		InitialDelay: 500 * time.Millisecond,
 		MaxDelay:     time.Second,
 		Multiplier:   2,
+		Jitter:       0.2,
+		MaxElapsed:   10 * time.Second,
+		Breaker:      hostBreaker,
 	}
 	return resilience.DoHTTPRequestWithRetry(ctx, client, request, policy)
 }
@@ -0,0 +1,273 @@
+package fetch
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ErrNotGitHubRepository is returned when an addon repository is not hosted
+// on github.com, so there is no releases API to query.
+var ErrNotGitHubRepository = errors.New("not a GitHub repository")
+
+// ErrGitHubRateLimited is returned while the GitHub API rate limit is
+// exhausted. The client makes no further requests until the limit resets.
+var ErrGitHubRateLimited = errors.New("GitHub API rate limit exceeded")
+
+// ErrNoStableRelease is returned when a repository has no release or tag that
+// looks like a stable semantic version.
+var ErrNoStableRelease = errors.New("no stable release found")
+
+const defaultGitHubAPIURL = "https://api.github.com"
+
+// releaseTagRe matches a stable version at the end of a tag, after an optional
+// component prefix: "v1.14.2", "controller-v1.10.0", "kube-state-metrics-2.13.0".
+// Pre-release suffixes ("-rc.1", "-beta") do not match.
+var releaseTagRe = regexp.MustCompile(`^(?:.*[-/_])?v?(\d+)\.(\d+)(?:\.(\d+))?$`)
+
+// GitHubRelease is the newest stable upstream release of a repository.
+type GitHubRelease struct {
+	// Repository is "owner/repo".
+	Repository string
+	// Tag is the tag name as published, e.g. "controller-v1.10.0".
+	Tag string
+	// Version is the version parsed from Tag without prefix, e.g. "1.10.0".
+	Version string
+	// PublishedAt is the release date. It is zero when the version came from
+	// the tags API, which carries no dates.
+	PublishedAt time.Time
+}
+
+// GitHubClient queries the GitHub releases and tags APIs. It is safe for
+// concurrent use.
+type GitHubClient struct {
+	HTTPClient *http.Client
+	// BaseURL is the API root; tests point it at an httptest server.
+	BaseURL string
+	// Token is sent as a bearer token when set. Unauthenticated clients get
+	// 60 requests per hour, authenticated ones 5,000.
+	Token string
+
+	mu           sync.Mutex
+	limitedUntil time.Time
+}
+
+// NewGitHubClient returns a client for api.github.com that authenticates with
+// the GITHUB_TOKEN environment variable when it is set.
+func NewGitHubClient() *GitHubClient {
+	return &GitHubClient{
+		HTTPClient: &http.Client{Timeout: 10 * time.Second},
+		BaseURL:    defaultGitHubAPIURL,
+		Token:      strings.TrimSpace(os.Getenv("GITHUB_TOKEN")),
+	}
+}
+
+// GitHubRepository returns "owner/repo" for a github.com repository URL.
+func GitHubRepository(repositoryURL string) (string, bool) {
+	parsed, err := url.Parse(strings.TrimSpace(repositoryURL))
+	if err != nil || !strings.EqualFold(parsed.Hostname(), "github.com") {
+		return "", false
+	}
+	var segments []string
+	for _, s := range strings.Split(parsed.Path, "/") {
+		if s != "" {
+			segments = append(segments, s)
+		}
+	}
+	if len(segments) < 2 {
+		return "", false
+	}
+	return segments[0] + "/" + strings.TrimSuffix(segments[1], ".git"), true
+}
+
+type gitHubReleaseResponse struct {
+	TagName     string    `json:"tag_name"`
+	Draft       bool      `json:"draft"`
+	Prerelease  bool      `json:"prerelease"`
+	PublishedAt time.Time `json:"published_at"`
+}
+
+type gitHubTagResponse struct {
+	Name string `json:"name"`
+}
+
+// LatestRelease returns the highest stable release of the repository at
+// repositoryURL. Releases are preferred because they carry a date; projects
+// that only push tags fall back to the tags API. Tags of Helm charts
+// published from the same repository are ignored, since they version the
+// chart rather than the addon.
+func (c *GitHubClient) LatestRelease(ctx context.Context, repositoryURL string) (GitHubRelease, error) {
+	repository, ok := GitHubRepository(repositoryURL)
+	if !ok {
+		return GitHubRelease{}, fmt.Errorf("%w: %s", ErrNotGitHubRepository, repositoryURL)
+	}
+
+	var releases []gitHubReleaseResponse
+	if err := c.getJSON(ctx, "/repos/"+repository+"/releases?per_page=50", &releases); err != nil {
+		return GitHubRelease{}, err
+	}
+	best := GitHubRelease{Repository: repository}
+	var bestParts []int
+	for _, r := range releases {
+		if r.Draft || r.Prerelease {
+			continue
+		}
+		parts, version, ok := parseReleaseTag(r.TagName)
+		if ok && compareReleaseParts(parts, bestParts) > 0 {
+			best.Tag, best.Version, best.PublishedAt, bestParts = r.TagName, version, r.PublishedAt, parts
+		}
+	}
+	if bestParts != nil {
+		return best, nil
+	}
+
+	var tags []gitHubTagResponse
+	if err := c.getJSON(ctx, "/repos/"+repository+"/tags?per_page=100", &tags); err != nil {
+		return GitHubRelease{}, err
+	}
+	for _, t := range tags {
+		parts, version, ok := parseReleaseTag(t.Name)
+		if ok && compareReleaseParts(parts, bestParts) > 0 {
+			best.Tag, best.Version, bestParts = t.Name, version, parts
+		}
+	}
+	if bestParts == nil {
+		return GitHubRelease{}, fmt.Errorf("%w for %s", ErrNoStableRelease, repository)
+	}
+	return best, nil
+}
+
+func (c *GitHubClient) getJSON(ctx context.Context, path string, target any) error {
+	if until, limited := c.rateLimitedUntil(); limited {
+		return fmt.Errorf("%w until %s", ErrGitHubRateLimited, until.UTC().Format(time.RFC3339))
+	}
+
+	baseURL := c.BaseURL
+	if baseURL == "" {
+		baseURL = defaultGitHubAPIURL
+	}
+	req, err := http.NewRequestWithContext(ctx, "GET", strings.TrimRight(baseURL, "/")+path, nil)
+	if err != nil {
+		return fmt.Errorf("creating request: %w", err)
+	}
+	req.Header.Set("Accept", "application/vnd.github+json")
+	req.Header.Set("X-GitHub-Api-Version", "2022-11-28")
+	if c.Token != "" {
+		req.Header.Set("Authorization", "Bearer "+c.Token)
+	}
+
+	client := c.HTTPClient
+	if client == nil {
+		client = &http.Client{Timeout: 10 * time.Second}
+	}
+	resp, err := doRequestWithRetry(ctx, client, req)
+	if err != nil {
+		return fmt.Errorf("HTTP request failed: %w", err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	// GitHub reports an exhausted primary limit as 403 or 429 with a zero
+	// remaining count; remember the reset so later calls do not spend time on
+	// requests that cannot succeed.
+	if resp.Header.Get("X-RateLimit-Remaining") == "0" {
+		reset := rateLimitReset(resp.Header.Get("X-RateLimit-Reset"))
+		c.setRateLimitedUntil(reset)
+		if resp.StatusCode == http.StatusForbidden || resp.StatusCode == http.StatusTooManyRequests {
+			return fmt.Errorf("%w until %s", ErrGitHubRateLimited, reset.UTC().Format(time.RFC3339))
+		}
+	}
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("unexpected status %d", resp.StatusCode)
+	}
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 2<<20))
+	if err != nil {
+		return fmt.Errorf("reading response body: %w", err)
+	}
+	if err := json.Unmarshal(body, target); err != nil {
+		return fmt.Errorf("parsing GitHub response: %w", err)
+	}
+	return nil
+}
+
+func (c *GitHubClient) rateLimitedUntil() (time.Time, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.limitedUntil, time.Now().Before(c.limitedUntil)
+}
+
+func (c *GitHubClient) setRateLimitedUntil(until time.Time) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if until.After(c.limitedUntil) {
+		c.limitedUntil = until
+	}
+}
+
+// rateLimitReset parses X-RateLimit-Reset (Unix seconds). A missing or
+// malformed value assumes the hourly window GitHub uses.
+func rateLimitReset(value string) time.Time {
+	seconds, err := strconv.ParseInt(strings.TrimSpace(value), 10, 64)
+	if err != nil || seconds <= 0 {
+		return time.Now().Add(time.Hour)
+	}
+	return time.Unix(seconds, 0)
+}
+
+// parseReleaseTag returns the numeric parts and the plain version of a stable
+// release tag. Chart tags such as "helm-chart-4.11.0" are rejected.
+func parseReleaseTag(tag string) ([]int, string, bool) {
+	lower := strings.ToLower(strings.TrimSpace(tag))
+	if strings.Contains(lower, "chart") || strings.Contains(lower, "helm") {
+		return nil, "", false
+	}
+	m := releaseTagRe.FindStringSubmatch(lower)
+	if m == nil {
+		return nil, "", false
+	}
+	parts := make([]int, 0, 3)
+	for _, s := range m[1:] {
+		if s == "" {
+			continue
+		}
+		n, err := strconv.Atoi(s)
+		if err != nil {
+			return nil, "", false
+		}
+		parts = append(parts, n)
+	}
+	version := m[1] + "." + m[2]
+	if m[3] != "" {
+		version += "." + m[3]
+	}
+	return parts, version, true
+}
+
+func compareReleaseParts(a, b []int) int {
+	for i := 0; i < len(a) || i < len(b); i++ {
+		var x, y int
+		if i < len(a) {
+			x = a[i]
+		}
+		if i < len(b) {
+			y = b[i]
+		}
+		if x != y {
+			if x < y {
+				return -1
+			}
+			return 1
+		}
+	}
+	return 0
+}
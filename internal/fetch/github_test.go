@@ -0,0 +1,198 @@
+package fetch
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestGitHubRepository(t *testing.T) {
+	tests := []struct {
+		name   string
+		input  string
+		want   string
+		wantOK bool
+	}{
+		{name: "repo root", input: "https://github.com/cert-manager/cert-manager", want: "cert-manager/cert-manager", wantOK: true},
+		{name: "trailing slash and .git", input: "https://github.com/kedacore/keda.git/", want: "kedacore/keda", wantOK: true},
+		{name: "deep link", input: "https://github.com/kubernetes/ingress-nginx/tree/main/charts", want: "kubernetes/ingress-nginx", wantOK: true},
+		{name: "org only", input: "https://github.com/kubernetes", wantOK: false},
+		{name: "gitlab", input: "https://gitlab.com/gitlab-org/gitlab-runner", wantOK: false},
+		{name: "empty", input: "", wantOK: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := GitHubRepository(tt.input)
+			if ok != tt.wantOK || got != tt.want {
+				t.Errorf("GitHubRepository(%q) = %q, %v; want %q, %v", tt.input, got, ok, tt.want, tt.wantOK)
+			}
+		})
+	}
+}
+
+func TestParseReleaseTag(t *testing.T) {
+	tests := []struct {
+		tag    string
+		want   string
+		wantOK bool
+	}{
+		{tag: "v1.14.2", want: "1.14.2", wantOK: true},
+		{tag: "1.30", want: "1.30", wantOK: true},
+		{tag: "controller-v1.10.0", want: "1.10.0", wantOK: true},
+		{tag: "kube-state-metrics-2.13.0", want: "2.13.0", wantOK: true},
+		{tag: "v1.15.0-rc.1", wantOK: false},
+		{tag: "v2.0.0-beta", wantOK: false},
+		{tag: "helm-chart-4.11.0", wantOK: false},
+		{tag: "kube-prometheus-stack-58.0.0-chart", wantOK: false},
+		{tag: "nightly", wantOK: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.tag, func(t *testing.T) {
+			_, got, ok := parseReleaseTag(tt.tag)
+			if ok != tt.wantOK || got != tt.want {
+				t.Errorf("parseReleaseTag(%q) = %q, %v; want %q, %v", tt.tag, got, ok, tt.want, tt.wantOK)
+			}
+		})
+	}
+}
+
+func newGitHubTestClient(server *httptest.Server, token string) *GitHubClient {
+	return &GitHubClient{HTTPClient: server.Client(), BaseURL: server.URL, Token: token}
+}
+
+func TestGitHubClient_LatestRelease(t *testing.T) {
+	tests := []struct {
+		name        string
+		releases    string
+		tags        string
+		wantTag     string
+		wantVersion string
+		wantDate    string
+		wantErr     error
+	}{
+		{
+			name: "highest stable release wins over newer backport and prerelease",
+			releases: `[
+				{"tag_name":"v1.13.6","published_at":"2024-06-10T00:00:00Z"},
+				{"tag_name":"v1.15.0-rc.1","prerelease":true,"published_at":"2024-06-05T00:00:00Z"},
+				{"tag_name":"v1.14.5","published_at":"2024-05-01T00:00:00Z"},
+				{"tag_name":"v1.16.0","draft":true}
+			]`,
+			wantTag:     "v1.14.5",
+			wantVersion: "1.14.5",
+			wantDate:    "2024-05-01",
+		},
+		{
+			name: "chart releases from the same repository are skipped",
+			releases: `[
+				{"tag_name":"helm-chart-4.11.0","published_at":"2024-07-01T00:00:00Z"},
+				{"tag_name":"controller-v1.10.1","published_at":"2024-04-26T00:00:00Z"}
+			]`,
+			wantTag:     "controller-v1.10.1",
+			wantVersion: "1.10.1",
+			wantDate:    "2024-04-26",
+		},
+		{
+			name:        "tags fallback when no releases are published",
+			releases:    `[]`,
+			tags:        `[{"name":"v0.7.1"},{"name":"v0.7.2"},{"name":"v0.8.0-alpha.1"}]`,
+			wantTag:     "v0.7.2",
+			wantVersion: "0.7.2",
+		},
+		{
+			name:     "no stable version anywhere",
+			releases: `[{"tag_name":"nightly","published_at":"2024-01-01T00:00:00Z"}]`,
+			tags:     `[{"name":"latest"}]`,
+			wantErr:  ErrNoStableRelease,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				switch r.URL.Path {
+				case "/repos/acme/widget/releases":
+					_, _ = io.WriteString(w, tt.releases)
+				case "/repos/acme/widget/tags":
+					_, _ = io.WriteString(w, tt.tags)
+				default:
+					http.NotFound(w, r)
+				}
+			}))
+			defer server.Close()
+
+			got, err := newGitHubTestClient(server, "").LatestRelease(context.Background(), "https://github.com/acme/widget")
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("error = %v, want %v", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got.Repository != "acme/widget" || got.Tag != tt.wantTag || got.Version != tt.wantVersion {
+				t.Errorf("release = %+v, want tag %q version %q", got, tt.wantTag, tt.wantVersion)
+			}
+			gotDate := ""
+			if !got.PublishedAt.IsZero() {
+				gotDate = got.PublishedAt.Format(time.DateOnly)
+			}
+			if gotDate != tt.wantDate {
+				t.Errorf("published = %q, want %q", gotDate, tt.wantDate)
+			}
+		})
+	}
+}
+
+func TestGitHubClient_SendsToken(t *testing.T) {
+	var gotAuth string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotAuth = r.Header.Get("Authorization")
+		_, _ = io.WriteString(w, `[{"tag_name":"v1.0.0","published_at":"2024-01-01T00:00:00Z"}]`)
+	}))
+	defer server.Close()
+
+	if _, err := newGitHubTestClient(server, "test-token").LatestRelease(context.Background(), "https://github.com/acme/widget"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if gotAuth != "Bearer test-token" {
+		t.Errorf("Authorization = %q, want bearer token", gotAuth)
+	}
+}
+
+func TestGitHubClient_RateLimitStopsFurtherRequests(t *testing.T) {
+	var requests atomic.Int32
+	reset := time.Now().Add(time.Hour).Unix()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		w.Header().Set("X-RateLimit-Remaining", "0")
+		w.Header().Set("X-RateLimit-Reset", strconv.FormatInt(reset, 10))
+		w.WriteHeader(http.StatusForbidden)
+	}))
+	defer server.Close()
+
+	client := newGitHubTestClient(server, "")
+	for i := 0; i < 3; i++ {
+		_, err := client.LatestRelease(context.Background(), "https://github.com/acme/widget")
+		if !errors.Is(err, ErrGitHubRateLimited) {
+			t.Fatalf("call %d: error = %v, want ErrGitHubRateLimited", i, err)
+		}
+	}
+	if got := requests.Load(); got != 1 {
+		t.Errorf("server saw %d requests, want 1", got)
+	}
+}
+
+func TestGitHubClient_NonGitHubRepository(t *testing.T) {
+	client := &GitHubClient{BaseURL: "http://127.0.0.1:0"}
+	_, err := client.LatestRelease(context.Background(), "https://gitlab.com/acme/widget")
+	if !errors.Is(err, ErrNotGitHubRepository) {
+		t.Errorf("error = %v, want ErrNotGitHubRepository", err)
+	}
+}
@@ -0,0 +1,168 @@
+package fetch
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// ErrFixtureNotFound is returned by ReplayTransport when no fixture was
+// recorded for a request.
+var ErrFixtureNotFound = errors.New("no recorded fixture")
+
+// maxFixtureBodyBytes bounds recorded bodies. Page fetches read at most 2 MB,
+// so anything larger would never be seen by callers anyway.
+const maxFixtureBodyBytes = 4 << 20
+
+// fixtureHeaders are the response headers kept in fixtures. Everything else
+// (cookies, rate-limit counters, request IDs) is dropped so fixtures stay
+// reviewable and free of credentials.
+var fixtureHeaders = []string{"Content-Type", "Location", "Retry-After"}
+
+// Fixture is one recorded HTTP exchange, stored as JSON in a fixture directory.
+type Fixture struct {
+	Method     string            `json:"method"`
+	URL        string            `json:"url"`
+	StatusCode int               `json:"status_code"`
+	Headers    map[string]string `json:"headers,omitempty"`
+	Body       string            `json:"body"`
+}
+
+// FixturePath returns the file a request is recorded to: the host followed by
+// a short hash of method and URL (without fragment), e.g.
+// "raw.githubusercontent.com-1a2b3c4d5e6f.json".
+func FixturePath(dir, method, rawURL string) string {
+	key := method + " " + fixtureURL(rawURL)
+	sum := sha256.Sum256([]byte(key))
+	host := "invalid-host"
+	if u, err := url.Parse(rawURL); err == nil && u.Hostname() != "" {
+		host = strings.ToLower(u.Hostname())
+	}
+	return filepath.Join(dir, host+"-"+hex.EncodeToString(sum[:6])+".json")
+}
+
+func fixtureURL(rawURL string) string {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return rawURL
+	}
+	u.Fragment = ""
+	return u.String()
+}
+
+// RecordingTransport performs requests with Next (http.DefaultTransport when
+// nil) and writes every response, including redirects and error statuses, to
+// Dir so a ReplayTransport can serve it later.
+type RecordingTransport struct {
+	Dir  string
+	Next http.RoundTripper
+}
+
+// RoundTrip implements http.RoundTripper.
+func (t *RecordingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	next := t.Next
+	if next == nil {
+		next = http.DefaultTransport
+	}
+	resp, err := next.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxFixtureBodyBytes))
+	_ = resp.Body.Close()
+	if err != nil {
+		return nil, fmt.Errorf("reading response body for recording: %w", err)
+	}
+	resp.Body = io.NopCloser(bytes.NewReader(body))
+	resp.ContentLength = int64(len(body))
+
+	fixture := Fixture{
+		Method:     req.Method,
+		URL:        fixtureURL(req.URL.String()),
+		StatusCode: resp.StatusCode,
+		Body:       string(body),
+	}
+	for _, name := range fixtureHeaders {
+		if value := resp.Header.Get(name); value != "" {
+			if fixture.Headers == nil {
+				fixture.Headers = make(map[string]string)
+			}
+			fixture.Headers[name] = value
+		}
+	}
+	if err := writeFixture(FixturePath(t.Dir, req.Method, req.URL.String()), fixture); err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+func writeFixture(path string, fixture Fixture) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0o750); err != nil {
+		return fmt.Errorf("creating fixture directory: %w", err)
+	}
+	// Keep HTML bodies readable in diffs instead of escaping <, > and &.
+	var buf bytes.Buffer
+	encoder := json.NewEncoder(&buf)
+	encoder.SetEscapeHTML(false)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(fixture); err != nil {
+		return fmt.Errorf("marshaling fixture: %w", err)
+	}
+	if err := os.WriteFile(path, buf.Bytes(), 0o600); err != nil {
+		return fmt.Errorf("writing fixture: %w", err)
+	}
+	return nil
+}
+
+// ReplayTransport serves responses recorded by RecordingTransport from Dir and
+// never touches the network. Requests without a fixture fail with
+// ErrFixtureNotFound.
+type ReplayTransport struct {
+	Dir string
+}
+
+// RoundTrip implements http.RoundTripper.
+func (t *ReplayTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.Body != nil {
+		_ = req.Body.Close()
+	}
+	path := FixturePath(t.Dir, req.Method, req.URL.String())
+	data, err := os.ReadFile(path) // #nosec G304 -- path is derived from a hash inside the configured fixture directory
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, fmt.Errorf("%w for %s %s", ErrFixtureNotFound, req.Method, fixtureURL(req.URL.String()))
+	}
+	if err != nil {
+		return nil, fmt.Errorf("reading fixture: %w", err)
+	}
+
+	var fixture Fixture
+	if err := json.Unmarshal(data, &fixture); err != nil {
+		return nil, fmt.Errorf("parsing fixture %s: %w", filepath.Base(path), err)
+	}
+
+	header := make(http.Header, len(fixture.Headers))
+	for name, value := range fixture.Headers {
+		header.Set(name, value)
+	}
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", fixture.StatusCode, http.StatusText(fixture.StatusCode)),
+		StatusCode:    fixture.StatusCode,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        header,
+		Body:          io.NopCloser(strings.NewReader(fixture.Body)),
+		ContentLength: int64(len(fixture.Body)),
+		Request:       req,
+	}, nil
+}
@@ -0,0 +1,101 @@
+package fetch
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestRecordingTransport_ReplaysRecordedExchanges(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/old":
+			http.Redirect(w, r, "/docs", http.StatusMovedPermanently)
+		case "/docs":
+			w.Header().Set("Content-Type", "text/markdown; charset=utf-8")
+			w.Header().Set("Set-Cookie", "session=secret")
+			_, _ = io.WriteString(w, "| Version | Kubernetes |\n|---|---|\n| v1.2.0 | 1.30 |\n")
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	dir := t.TempDir()
+	recorder := &http.Client{Transport: &RecordingTransport{Dir: dir, Next: server.Client().Transport}}
+	liveBody := getBody(t, recorder, server.URL+"/old#compatibility")
+	if code := getStatus(t, recorder, server.URL+"/missing"); code != http.StatusNotFound {
+		t.Fatalf("live status = %d, want 404", code)
+	}
+
+	// The server is gone; everything below must come from fixtures.
+	server.Close()
+	replayer := &http.Client{Transport: &ReplayTransport{Dir: dir}}
+	if got := getBody(t, replayer, server.URL+"/old"); got != liveBody {
+		t.Errorf("replayed body = %q, want %q", got, liveBody)
+	}
+	if code := getStatus(t, replayer, server.URL+"/missing"); code != http.StatusNotFound {
+		t.Errorf("replayed status = %d, want 404", code)
+	}
+
+	data, err := os.ReadFile(FixturePath(dir, http.MethodGet, server.URL+"/docs"))
+	if err != nil {
+		t.Fatalf("reading fixture: %v", err)
+	}
+	if strings.Contains(string(data), "secret") {
+		t.Error("fixture must not record Set-Cookie")
+	}
+	if !strings.Contains(string(data), "text/markdown") {
+		t.Error("fixture should record Content-Type")
+	}
+}
+
+func TestReplayTransport_MissingFixture(t *testing.T) {
+	client := &http.Client{Transport: &ReplayTransport{Dir: t.TempDir()}}
+	_, err := client.Get("https://example.com/docs")
+	if !errors.Is(err, ErrFixtureNotFound) {
+		t.Fatalf("expected ErrFixtureNotFound, got %v", err)
+	}
+}
+
+func TestFixturePath(t *testing.T) {
+	a := FixturePath("fixtures", http.MethodGet, "https://example.com/docs#compat")
+	b := FixturePath("fixtures", http.MethodGet, "https://example.com/docs")
+	if a != b {
+		t.Errorf("fragment should not change fixture path: %s != %s", a, b)
+	}
+	if !strings.HasPrefix(a, "fixtures/example.com-") || !strings.HasSuffix(a, ".json") {
+		t.Errorf("unexpected fixture path %s", a)
+	}
+	if FixturePath("fixtures", http.MethodHead, "https://example.com/docs") == a {
+		t.Error("method should be part of the fixture key")
+	}
+}
+
+func getBody(t *testing.T, client *http.Client, rawURL string) string {
+	t.Helper()
+	resp, err := client.Get(rawURL)
+	if err != nil {
+		t.Fatalf("GET %s: %v", rawURL, err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatalf("reading body: %v", err)
+	}
+	return string(body)
+}
+
+func getStatus(t *testing.T, client *http.Client, rawURL string) int {
+	t.Helper()
+	resp, err := client.Get(rawURL)
+	if err != nil {
+		t.Fatalf("GET %s: %v", rawURL, err)
+	}
+	_ = resp.Body.Close()
+	return resp.StatusCode
+}
@@ -0,0 +1,188 @@
+package inventory
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/qbandev/kaddons/internal/cluster"
+)
+
+// Source values recorded on addons that did not come from cluster discovery.
+const (
+	SourceArgs = "args"
+	SourceFile = "inventory"
+)
+
+// maxInventoryBytes bounds inventory files; a thousand addons fit in well under 1 MB.
+const maxInventoryBytes = 4 << 20
+
+// entry is one addon in a JSON inventory. "installed_version" is accepted so
+// a kaddons JSON report can be fed back in as an inventory.
+type entry struct {
+	Name             string `json:"name"`
+	Version          string `json:"version"`
+	InstalledVersion string `json:"installed_version"`
+	Namespace        string `json:"namespace"`
+}
+
+// ParseArgs parses "name@version" arguments. The version is optional
+// ("cert-manager" checks the addon without a version); an argument with more
+// than one "@" is rejected.
+func ParseArgs(args []string) ([]cluster.DetectedAddon, error) {
+	addons := make([]cluster.DetectedAddon, 0, len(args))
+	for _, arg := range args {
+		name, version, _ := strings.Cut(strings.TrimSpace(arg), "@")
+		name = strings.TrimSpace(name)
+		if name == "" {
+			return nil, fmt.Errorf("invalid addon %q: expected name@version", arg)
+		}
+		if strings.Contains(version, "@") {
+			return nil, fmt.Errorf("invalid addon %q: more than one @", arg)
+		}
+		addons = append(addons, cluster.DetectedAddon{
+			Name:    name,
+			Version: strings.TrimSpace(version),
+			Source:  SourceArgs,
+		})
+	}
+	return addons, nil
+}
+
+// Load reads an inventory file. Files ending in .csv are CSV with a header row
+// naming "name" and "version" (or "installed_version") columns and an
+// optional "namespace" column; anything else is JSON, either an array of
+// {"name", "version", "namespace"} objects or an object with an "addons"
+// array, which includes kaddons' own JSON reports.
+func Load(path string) ([]cluster.DetectedAddon, error) {
+	file, err := os.Open(filepath.Clean(path))
+	if err != nil {
+		return nil, fmt.Errorf("opening inventory: %w", err)
+	}
+	defer func() { _ = file.Close() }()
+
+	data, err := io.ReadAll(io.LimitReader(file, maxInventoryBytes+1))
+	if err != nil {
+		return nil, fmt.Errorf("reading inventory: %w", err)
+	}
+	if len(data) > maxInventoryBytes {
+		return nil, fmt.Errorf("inventory %s is larger than %d bytes", path, maxInventoryBytes)
+	}
+
+	var addons []cluster.DetectedAddon
+	if strings.EqualFold(filepath.Ext(path), ".csv") {
+		addons, err = parseCSV(data)
+	} else {
+		addons, err = parseJSON(data)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("parsing inventory %s: %w", path, err)
+	}
+	if len(addons) == 0 {
+		return nil, fmt.Errorf("inventory %s lists no addons", path)
+	}
+	return addons, nil
+}
+
+func parseJSON(data []byte) ([]cluster.DetectedAddon, error) {
+	data = bytes.TrimSpace(data)
+	var entries []entry
+	if len(data) > 0 && data[0] == '{' {
+		var wrapped struct {
+			Addons []entry `json:"addons"`
+		}
+		if err := json.Unmarshal(data, &wrapped); err != nil {
+			return nil, err
+		}
+		entries = wrapped.Addons
+	} else if err := json.Unmarshal(data, &entries); err != nil {
+		return nil, err
+	}
+
+	addons := make([]cluster.DetectedAddon, 0, len(entries))
+	for i, e := range entries {
+		version := e.Version
+		if version == "" {
+			version = e.InstalledVersion
+		}
+		a, err := newAddon(e.Name, version, e.Namespace)
+		if err != nil {
+			return nil, fmt.Errorf("entry %d: %w", i, err)
+		}
+		addons = append(addons, a)
+	}
+	return addons, nil
+}
+
+func parseCSV(data []byte) ([]cluster.DetectedAddon, error) {
+	reader := csv.NewReader(bytes.NewReader(data))
+	reader.TrimLeadingSpace = true
+	header, err := reader.Read()
+	if errors.Is(err, io.EOF) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	nameCol, versionCol, namespaceCol := -1, -1, -1
+	for i, column := range header {
+		switch strings.ToLower(strings.TrimSpace(strings.TrimPrefix(column, "\ufeff"))) {
+		case "name":
+			nameCol = i
+		case "version", "installed_version":
+			if versionCol < 0 {
+				versionCol = i
+			}
+		case "namespace":
+			namespaceCol = i
+		}
+	}
+	if nameCol < 0 {
+		return nil, errors.New(`CSV header must include a "name" column`)
+	}
+
+	var addons []cluster.DetectedAddon
+	for {
+		record, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		line, _ := reader.FieldPos(0)
+		a, err := newAddon(field(record, nameCol), field(record, versionCol), field(record, namespaceCol))
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", line, err)
+		}
+		addons = append(addons, a)
+	}
+	return addons, nil
+}
+
+func field(record []string, index int) string {
+	if index < 0 || index >= len(record) {
+		return ""
+	}
+	return record[index]
+}
+
+func newAddon(name, version, namespace string) (cluster.DetectedAddon, error) {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return cluster.DetectedAddon{}, errors.New("name is required")
+	}
+	return cluster.DetectedAddon{
+		Name:      name,
+		Version:   strings.TrimSpace(version),
+		Namespace: strings.TrimSpace(namespace),
+		Source:    SourceFile,
+	}, nil
+}
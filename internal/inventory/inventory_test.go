@@ -0,0 +1,117 @@
+package inventory
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/qbandev/kaddons/internal/cluster"
+)
+
+func TestParseArgs(t *testing.T) {
+	tests := []struct {
+		name    string
+		args    []string
+		want    []cluster.DetectedAddon
+		wantErr string
+	}{
+		{
+			name: "name and version",
+			args: []string{"cert-manager@1.13", " karpenter@v0.37.0 "},
+			want: []cluster.DetectedAddon{
+				{Name: "cert-manager", Version: "1.13", Source: SourceArgs},
+				{Name: "karpenter", Version: "v0.37.0", Source: SourceArgs},
+			},
+		},
+		{
+			name: "version is optional",
+			args: []string{"keda", "metrics-server@"},
+			want: []cluster.DetectedAddon{
+				{Name: "keda", Source: SourceArgs},
+				{Name: "metrics-server", Source: SourceArgs},
+			},
+		},
+		{name: "missing name", args: []string{"@1.0"}, wantErr: "expected name@version"},
+		{name: "two separators", args: []string{"a@1@2"}, wantErr: "more than one @"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseArgs(tt.args)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ParseArgs() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLoad(t *testing.T) {
+	tests := []struct {
+		name     string
+		file     string
+		contents string
+		want     []cluster.DetectedAddon
+		wantErr  string
+	}{
+		{
+			name:     "JSON array",
+			file:     "inventory.json",
+			contents: `[{"name":"cert-manager","version":"1.13"},{"name":"karpenter","version":"0.37.0","namespace":"karpenter"}]`,
+			want: []cluster.DetectedAddon{
+				{Name: "cert-manager", Version: "1.13", Source: SourceFile},
+				{Name: "karpenter", Version: "0.37.0", Namespace: "karpenter", Source: SourceFile},
+			},
+		},
+		{
+			name:     "kaddons report",
+			file:     "report.json",
+			contents: `{"k8s_version":"1.30","addons":[{"name":"keda","namespace":"keda","installed_version":"2.12.0","compatible":"true"}]}`,
+			want:     []cluster.DetectedAddon{{Name: "keda", Version: "2.12.0", Namespace: "keda", Source: SourceFile}},
+		},
+		{
+			name:     "CSV with BOM, reordered columns and quoted field",
+			file:     "inventory.CSV",
+			contents: "\ufeffnamespace,Name,installed_version\nkube-system,\"metrics-server\",v0.7.1\n,keda,\n",
+			want: []cluster.DetectedAddon{
+				{Name: "metrics-server", Version: "v0.7.1", Namespace: "kube-system", Source: SourceFile},
+				{Name: "keda", Source: SourceFile},
+			},
+		},
+		{name: "CSV without name column", file: "bad.csv", contents: "addon,version\nkeda,2.12\n", wantErr: `"name" column`},
+		{name: "CSV row without name", file: "bad.csv", contents: "name,version\nkeda,2.12\n,1.0\n", wantErr: "line 3: name is required"},
+		{name: "JSON entry without name", file: "bad.json", contents: `[{"version":"1.0"}]`, wantErr: "entry 0: name is required"},
+		{name: "malformed JSON", file: "bad.json", contents: `[{"name":`, wantErr: "parsing inventory"},
+		{name: "empty inventory", file: "empty.json", contents: `[]`, wantErr: "lists no addons"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), tt.file)
+			if err := os.WriteFile(path, []byte(tt.contents), 0o600); err != nil {
+				t.Fatal(err)
+			}
+			got, err := Load(path)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Load() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
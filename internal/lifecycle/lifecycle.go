@@ -0,0 +1,154 @@
+package lifecycle
+
+import (
+	_ "embed"
+	"encoding/json"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/qbandev/kaddons/internal/addon"
+	"github.com/qbandev/kaddons/internal/cluster"
+	"github.com/qbandev/kaddons/internal/output"
+)
+
+//go:embed kubernetes_lifecycle.json
+var snapshotJSON []byte
+
+// ProductKubernetes is the endoflife.date product for upstream Kubernetes.
+const ProductKubernetes = "kubernetes"
+
+// distributionProducts maps managed distributions to their endoflife.date
+// product. Other distributions follow the upstream release lifecycle.
+var distributionProducts = map[string]string{
+	cluster.DistributionEKS: "amazon-eks",
+	cluster.DistributionGKE: "google-kubernetes-engine",
+	cluster.DistributionAKS: "azure-kubernetes-service",
+}
+
+// Products returns the endoflife.date products to evaluate a cluster of the
+// given distribution against, most specific first. Upstream Kubernetes is
+// always last, as the fallback when a provider has no data for a version.
+func Products(distribution string) []string {
+	if product, ok := distributionProducts[distribution]; ok {
+		return []string{product, ProductKubernetes}
+	}
+	return []string{ProductKubernetes}
+}
+
+// Snapshot returns the embedded release cycles of an endoflife.date product,
+// used when the live data cannot be fetched. It covers upstream Kubernetes,
+// Amazon EKS, GKE and AKS; other products have no embedded cycles.
+func Snapshot(product string) []addon.EOLCycle {
+	var snapshot struct {
+		Products map[string][]addon.EOLCycle `json:"products"`
+	}
+	if err := json.Unmarshal(snapshotJSON, &snapshot); err != nil {
+		return nil
+	}
+	return snapshot.Products[product]
+}
+
+// Evaluate places version, a major.minor Kubernetes version, in the support
+// lifecycle described by cycles as of now. The eol date ends standard
+// support; extendedSupport, or an lts date, ends extended support. A version
+// missing from cycles has status unknown.
+func Evaluate(version, product string, cycles []addon.EOLCycle, now time.Time) *output.ClusterSupport {
+	support := &output.ClusterSupport{Version: version, Product: product, Status: output.SupportUnknown}
+	today := now.UTC().Truncate(24 * time.Hour)
+
+	current, ok := findCycle(version, cycles)
+	if !ok {
+		return support
+	}
+	standardEnd, standardEnded := supportEnd(current.EOL, today)
+	extendedEnd, extendedEnded := supportEnd(current.ExtendedSupport, today)
+	if extendedEnd == "" {
+		extendedEnd, extendedEnded = supportEnd(current.LTS, today)
+	}
+	support.StandardSupportEnds = standardEnd
+	support.ExtendedSupportEnds = extendedEnd
+
+	switch {
+	case !standardEnded:
+		support.Status = output.SupportStandard
+		support.DaysRemaining = daysUntil(standardEnd, today)
+	case extendedEnd != "" && !extendedEnded:
+		support.Status = output.SupportExtended
+		support.DaysRemaining = daysUntil(extendedEnd, today)
+	default:
+		support.Status = output.SupportEnded
+	}
+
+	// The oldest newer cycle still in standard support is the upgrade target.
+	sorted := append([]addon.EOLCycle(nil), cycles...)
+	sort.Slice(sorted, func(i, j int) bool { return compareVersions(sorted[i].Cycle, sorted[j].Cycle) < 0 })
+	for _, cycle := range sorted {
+		if compareVersions(cycle.Cycle, version) <= 0 {
+			continue
+		}
+		if _, ended := supportEnd(cycle.EOL, today); !ended {
+			support.NextSupportedVersion = cycle.Cycle
+			break
+		}
+	}
+	return support
+}
+
+func findCycle(version string, cycles []addon.EOLCycle) (addon.EOLCycle, bool) {
+	for _, cycle := range cycles {
+		if compareVersions(cycle.Cycle, version) == 0 {
+			return cycle, true
+		}
+	}
+	return addon.EOLCycle{}, false
+}
+
+// supportEnd reads an endoflife.date field that is either a date or a bool
+// meaning "has ended". It returns the date, if any, and whether that phase
+// of support is over by today.
+func supportEnd(field any, today time.Time) (string, bool) {
+	switch v := field.(type) {
+	case bool:
+		return "", v
+	case string:
+		end, err := time.Parse("2006-01-02", v)
+		if err != nil {
+			return "", false
+		}
+		return v, !today.Before(end)
+	default:
+		return "", false
+	}
+}
+
+func daysUntil(date string, today time.Time) *int {
+	end, err := time.Parse("2006-01-02", date)
+	if err != nil {
+		return nil
+	}
+	days := int(end.Sub(today).Hours() / 24)
+	return &days
+}
+
+// compareVersions compares major.minor versions numerically, so that 1.9
+// sorts before 1.10.
+func compareVersions(a, b string) int {
+	aMajor, aMinor := splitVersion(a)
+	bMajor, bMinor := splitVersion(b)
+	switch {
+	case aMajor != bMajor:
+		return aMajor - bMajor
+	default:
+		return aMinor - bMinor
+	}
+}
+
+func splitVersion(version string) (int, int) {
+	major, minor, _ := strings.Cut(strings.TrimPrefix(strings.TrimSpace(version), "v"), ".")
+	minor, _, _ = strings.Cut(minor, ".")
+	majorValue, _ := strconv.Atoi(major)
+	minorValue, _ := strconv.Atoi(minor)
+	return majorValue, minorValue
+}
@@ -0,0 +1,154 @@
+package lifecycle
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/qbandev/kaddons/internal/addon"
+	"github.com/qbandev/kaddons/internal/cluster"
+	"github.com/qbandev/kaddons/internal/output"
+)
+
+func TestEvaluate(t *testing.T) {
+	cycles := []addon.EOLCycle{
+		{Cycle: "1.31", EOL: "2025-11-26", ExtendedSupport: "2026-11-26"},
+		{Cycle: "1.9", EOL: true},
+		{Cycle: "1.30", EOL: "2025-07-23", ExtendedSupport: "2026-07-23"},
+		{Cycle: "1.33", EOL: "2026-07-29", ExtendedSupport: "2027-07-29"},
+		{Cycle: "1.32", EOL: "2026-03-23", ExtendedSupport: "2027-03-23"},
+		{Cycle: "1.29", EOL: "2025-03-23", LTS: "2026-03-23"},
+		{Cycle: "1.34", EOL: false},
+	}
+	now := time.Date(2026, 3, 1, 15, 4, 5, 0, time.UTC)
+	days := func(n int) *int { return &n }
+
+	tests := []struct {
+		version string
+		want    output.ClusterSupport
+	}{
+		{
+			version: "1.32",
+			want: output.ClusterSupport{Status: output.SupportStandard, StandardSupportEnds: "2026-03-23", ExtendedSupportEnds: "2027-03-23",
+				DaysRemaining: days(22), NextSupportedVersion: "1.33"},
+		},
+		{
+			version: "1.30",
+			want: output.ClusterSupport{Status: output.SupportExtended, StandardSupportEnds: "2025-07-23", ExtendedSupportEnds: "2026-07-23",
+				DaysRemaining: days(144), NextSupportedVersion: "1.32"},
+		},
+		{
+			version: "1.29",
+			want: output.ClusterSupport{Status: output.SupportExtended, StandardSupportEnds: "2025-03-23", ExtendedSupportEnds: "2026-03-23",
+				DaysRemaining: days(22), NextSupportedVersion: "1.32"},
+		},
+		{version: "1.9", want: output.ClusterSupport{Status: output.SupportEnded, NextSupportedVersion: "1.32"}},
+		{version: "1.34", want: output.ClusterSupport{Status: output.SupportStandard}},
+		{version: "1.27", want: output.ClusterSupport{Status: output.SupportUnknown}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.version, func(t *testing.T) {
+			tt.want.Version, tt.want.Product = tt.version, "amazon-eks"
+			got := Evaluate(tt.version, "amazon-eks", cycles, now)
+			if !reflect.DeepEqual(*got, tt.want) {
+				t.Errorf("Evaluate(%s) = %+v (days %v), want %+v (days %v)", tt.version, *got, deref(got.DaysRemaining), tt.want, deref(tt.want.DaysRemaining))
+			}
+		})
+	}
+
+	ended := Evaluate("1.30", "amazon-eks", cycles, time.Date(2026, 7, 23, 0, 0, 0, 0, time.UTC))
+	if ended.Status != output.SupportEnded || ended.DaysRemaining != nil {
+		t.Errorf("Evaluate on the extended support end date = %+v, want end_of_life without days", *ended)
+	}
+}
+
+func deref(days *int) any {
+	if days == nil {
+		return nil
+	}
+	return *days
+}
+
+func TestProducts(t *testing.T) {
+	tests := []struct {
+		distribution string
+		want         []string
+	}{
+		{cluster.DistributionEKS, []string{"amazon-eks", ProductKubernetes}},
+		{cluster.DistributionGKE, []string{"google-kubernetes-engine", ProductKubernetes}},
+		{cluster.DistributionAKS, []string{"azure-kubernetes-service", ProductKubernetes}},
+		{cluster.DistributionK3s, []string{ProductKubernetes}},
+		{"", []string{ProductKubernetes}},
+	}
+	for _, tt := range tests {
+		if got := Products(tt.distribution); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("Products(%q) = %v, want %v", tt.distribution, got, tt.want)
+		}
+	}
+}
+
+func TestSnapshot(t *testing.T) {
+	for _, product := range []string{ProductKubernetes, "amazon-eks", "google-kubernetes-engine", "azure-kubernetes-service"} {
+		cycles := Snapshot(product)
+		if len(cycles) == 0 {
+			t.Fatalf("Snapshot(%q) is empty", product)
+		}
+		for _, cycle := range cycles {
+			eol, ok := cycle.EOL.(string)
+			if !ok {
+				t.Errorf("%s %s: eol = %v, want a date", product, cycle.Cycle, cycle.EOL)
+				continue
+			}
+			if _, err := time.Parse("2006-01-02", eol); err != nil {
+				t.Errorf("%s %s: eol %q is not a date", product, cycle.Cycle, eol)
+			}
+			if _, err := time.Parse("2006-01-02", cycle.ReleaseDate); err != nil || cycle.ReleaseDate >= eol {
+				t.Errorf("%s %s: release date %q is not a date before eol %s", product, cycle.Cycle, cycle.ReleaseDate, eol)
+			}
+		}
+	}
+	for _, product := range []string{"amazon-eks", "google-kubernetes-engine", "azure-kubernetes-service"} {
+		for _, cycle := range Snapshot(product) {
+			if cycle.ExtendedSupport == nil && cycle.LTS == nil {
+				t.Errorf("%s %s has no extended support date", product, cycle.Cycle)
+			}
+		}
+	}
+	if got := Snapshot("no-such-product"); got != nil {
+		t.Errorf("Snapshot(unknown) = %v, want nil", got)
+	}
+}
+
+// TestSnapshot_ManagedDistributionsOffline checks that GKE and AKS clusters
+// are judged by their own provider's dates when only the snapshot is
+// available, rather than by the upstream Kubernetes lifecycle.
+func TestSnapshot_ManagedDistributionsOffline(t *testing.T) {
+	now := time.Date(2026, 1, 10, 0, 0, 0, 0, time.UTC)
+	tests := []struct {
+		distribution string
+		version      string
+		wantProduct  string
+		wantStatus   string
+	}{
+		{cluster.DistributionGKE, "1.30", "google-kubernetes-engine", output.SupportExtended},
+		{cluster.DistributionGKE, "1.33", "google-kubernetes-engine", output.SupportStandard},
+		{cluster.DistributionAKS, "1.30", "azure-kubernetes-service", output.SupportExtended},
+		{cluster.DistributionAKS, "1.33", "azure-kubernetes-service", output.SupportStandard},
+	}
+	for _, tt := range tests {
+		t.Run(tt.distribution+" "+tt.version, func(t *testing.T) {
+			product := Products(tt.distribution)[0]
+			got := Evaluate(tt.version, product, Snapshot(product), now)
+			if got.Product != tt.wantProduct || got.Status != tt.wantStatus {
+				t.Errorf("Evaluate = %s %s, want %s %s", got.Product, got.Status, tt.wantProduct, tt.wantStatus)
+			}
+			if got.StandardSupportEnds == "" || got.ExtendedSupportEnds == "" || got.DaysRemaining == nil {
+				t.Errorf("Evaluate = %+v, want standard and extended support dates and days remaining", *got)
+			}
+			upstream := Evaluate(tt.version, ProductKubernetes, Snapshot(ProductKubernetes), now)
+			if got.StandardSupportEnds == upstream.StandardSupportEnds {
+				t.Errorf("%s %s uses the upstream standard support end %s", tt.distribution, tt.version, upstream.StandardSupportEnds)
+			}
+		})
+	}
+}
@@ -0,0 +1,212 @@
+package lifecycle
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/qbandev/kaddons/internal/addon"
+	"github.com/qbandev/kaddons/internal/cluster"
+	"github.com/qbandev/kaddons/internal/output"
+)
+
+// ungroupedPool names the pool of nodes without a pool label.
+const ungroupedPool = "ungrouped"
+
+const (
+	skewPolicyURL          = "https://kubernetes.io/releases/version-skew-policy/#kubelet"
+	containerdLifecycleURL = "https://endoflife.date/containerd"
+)
+
+var minorVersionRe = regexp.MustCompile(`^v?(\d+)\.(\d+)`)
+
+// NodePools groups nodes by pool and checks each pool's kubelets against the
+// version skew policy for serverVersion, the running kube-apiserver, and for
+// targetVersion when it is a different minor, plus its containerd releases
+// against containerdCycles. A nil containerdCycles leaves the containerd
+// check unknown.
+func NodePools(nodes []cluster.Node, serverVersion, targetVersion string, containerdCycles []addon.EOLCycle, now time.Time) []output.NodePool {
+	byPool := make(map[string][]cluster.Node)
+	for _, node := range nodes {
+		pool := node.Pool
+		if pool == "" {
+			pool = ungroupedPool
+		}
+		byPool[pool] = append(byPool[pool], node)
+	}
+	names := make([]string, 0, len(byPool))
+	for name := range byPool {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	server, serverOK := minorVersion(serverVersion)
+	target, targetOK := minorVersion(targetVersion)
+	today := now.UTC().Truncate(24 * time.Hour)
+	pools := make([]output.NodePool, 0, len(names))
+	for _, name := range names {
+		members := byPool[name]
+		pool := output.NodePool{Name: name, Nodes: len(members)}
+		var kubelets, runtimes, kernels, images []string
+		for _, node := range members {
+			kubelets = append(kubelets, node.KubeletVersion)
+			runtimes = append(runtimes, node.ContainerRuntimeVersion)
+			kernels = append(kernels, node.KernelVersion)
+			images = append(images, node.OSImage)
+		}
+		pool.KubeletVersions = distinct(kubelets)
+		pool.ContainerRuntimeVersions = distinct(runtimes)
+		pool.KernelVersions = distinct(kernels)
+		pool.OSImages = distinct(images)
+
+		if serverOK {
+			pool.Findings = append(pool.Findings, kubeletSkew(output.CheckKubeletSkew, pool.KubeletVersions, server, ""))
+		} else {
+			pool.Findings = append(pool.Findings, output.NodeFinding{Check: output.CheckKubeletSkew, Compatible: output.StatusUnknown,
+				Note: fmt.Sprintf("kube-apiserver version %q could not be parsed", serverVersion)})
+		}
+		if serverOK && targetOK && target != server {
+			pool.Findings = append(pool.Findings, kubeletSkew(output.CheckKubeletTargetSkew, pool.KubeletVersions, target,
+				fmt.Sprintf("before the control plane moves to %s: ", target)))
+		}
+		if finding, ok := containerdSupport(pool.ContainerRuntimeVersions, containerdCycles, today); ok {
+			pool.Findings = append(pool.Findings, finding)
+		}
+		pools = append(pools, pool)
+	}
+	return pools
+}
+
+// kubeletSkew applies the kubelet skew policy against a kube-apiserver minor
+// version: a kubelet must not be newer than the apiserver, and may be up to
+// three minor versions older, or two for kubelets older than 1.25.
+func kubeletSkew(check string, kubeletVersions []string, apiserver, prefix string) output.NodeFinding {
+	finding := output.NodeFinding{Check: check, Compatible: output.StatusTrue}
+	var minors, problems, unparsed []string
+	for _, version := range kubeletVersions {
+		kubelet, ok := minorVersion(version)
+		if !ok {
+			unparsed = append(unparsed, version)
+			continue
+		}
+		minors = append(minors, kubelet)
+	}
+	minors = distinctMinors(minors)
+	apiMajor, apiMinor := splitVersion(apiserver)
+	for _, kubelet := range minors {
+		major, minor := splitVersion(kubelet)
+		allowed := 3
+		if compareVersions(kubelet, "1.25") < 0 {
+			allowed = 2
+		}
+		switch {
+		case compareVersions(kubelet, apiserver) > 0:
+			problems = append(problems, fmt.Sprintf("kubelet %s is newer than kube-apiserver %s", kubelet, apiserver))
+		case major == apiMajor && apiMinor-minor > allowed:
+			problems = append(problems, fmt.Sprintf("kubelet %s is %d minor versions older than kube-apiserver %s; at most %d are supported",
+				kubelet, apiMinor-minor, apiserver, allowed))
+		}
+	}
+
+	switch {
+	case len(problems) > 0:
+		finding.Compatible = output.StatusFalse
+		finding.Note = fmt.Sprintf("%s%s (%s)", prefix, strings.Join(problems, "; "), skewPolicyURL)
+	case len(unparsed) > 0:
+		finding.Compatible = output.StatusUnknown
+		finding.Note = fmt.Sprintf("%skubelet version %q could not be parsed (%s)", prefix, strings.Join(unparsed, ", "), skewPolicyURL)
+	case len(minors) == 0:
+		finding.Compatible = output.StatusUnknown
+		finding.Note = prefix + "no kubelet version reported"
+	default:
+		finding.Note = fmt.Sprintf("%skubelet %s within the supported skew of kube-apiserver %s (%s)", prefix, strings.Join(minors, ", "), apiserver, skewPolicyURL)
+	}
+	return finding
+}
+
+// containerdSupport checks the containerd releases among runtimeVersions for
+// end of life. Its second result is false when the pool runs no containerd.
+func containerdSupport(runtimeVersions []string, cycles []addon.EOLCycle, today time.Time) (output.NodeFinding, bool) {
+	var minors []string
+	for _, runtime := range runtimeVersions {
+		version, found := strings.CutPrefix(runtime, "containerd://")
+		if !found {
+			continue
+		}
+		if minor, ok := minorVersion(version); ok {
+			minors = append(minors, minor)
+		}
+	}
+	minors = distinctMinors(minors)
+	if len(minors) == 0 {
+		return output.NodeFinding{}, false
+	}
+
+	finding := output.NodeFinding{Check: output.CheckContainerRuntimeEOL, Compatible: output.StatusTrue}
+	if cycles == nil {
+		finding.Compatible = output.StatusUnknown
+		finding.Note = fmt.Sprintf("containerd %s: end-of-life data unavailable (%s)", strings.Join(minors, ", "), containerdLifecycleURL)
+		return finding, true
+	}
+	var parts []string
+	for _, minor := range minors {
+		cycle, ok := findCycle(minor, cycles)
+		if !ok {
+			if finding.Compatible == output.StatusTrue {
+				finding.Compatible = output.StatusUnknown
+			}
+			parts = append(parts, fmt.Sprintf("containerd %s is not listed", minor))
+			continue
+		}
+		end, ended := supportEnd(cycle.EOL, today)
+		switch {
+		case ended && end != "":
+			finding.Compatible = output.StatusFalse
+			parts = append(parts, fmt.Sprintf("containerd %s reached end of life on %s", minor, end))
+		case ended:
+			finding.Compatible = output.StatusFalse
+			parts = append(parts, fmt.Sprintf("containerd %s has reached end of life", minor))
+		case end != "":
+			parts = append(parts, fmt.Sprintf("containerd %s is supported until %s", minor, end))
+		default:
+			parts = append(parts, fmt.Sprintf("containerd %s is supported", minor))
+		}
+	}
+	finding.Note = fmt.Sprintf("%s (%s)", strings.Join(parts, "; "), containerdLifecycleURL)
+	return finding, true
+}
+
+// minorVersion returns the major.minor of a version such as v1.30.4-eks-a737599
+// or 1.7.22.
+func minorVersion(version string) (string, bool) {
+	match := minorVersionRe.FindStringSubmatch(strings.TrimSpace(version))
+	if match == nil {
+		return "", false
+	}
+	return match[1] + "." + match[2], true
+}
+
+// distinct returns the non-empty values sorted, without repeats.
+func distinct(values []string) []string {
+	seen := make(map[string]bool, len(values))
+	var result []string
+	for _, value := range values {
+		if value == "" || seen[value] {
+			continue
+		}
+		seen[value] = true
+		result = append(result, value)
+	}
+	sort.Strings(result)
+	return result
+}
+
+// distinctMinors returns major.minor versions sorted numerically, without
+// repeats.
+func distinctMinors(minors []string) []string {
+	minors = distinct(minors)
+	sort.Slice(minors, func(i, j int) bool { return compareVersions(minors[i], minors[j]) < 0 })
+	return minors
+}
@@ -0,0 +1,118 @@
+package lifecycle
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/qbandev/kaddons/internal/addon"
+	"github.com/qbandev/kaddons/internal/cluster"
+	"github.com/qbandev/kaddons/internal/output"
+)
+
+func TestNodePools(t *testing.T) {
+	nodes := []cluster.Node{
+		{Name: "a", Pool: "workers", KubeletVersion: "v1.27.9-eks-5e0fdde", ContainerRuntimeVersion: "containerd://1.6.28", KernelVersion: "5.10.210", OSImage: "Amazon Linux 2"},
+		{Name: "b", Pool: "workers", KubeletVersion: "v1.30.4-eks-a737599", ContainerRuntimeVersion: "containerd://1.7.22", KernelVersion: "5.10.210", OSImage: "Amazon Linux 2"},
+		{Name: "c", KubeletVersion: "v1.30.0", ContainerRuntimeVersion: "cri-o://1.30.0"},
+		{Name: "d", Pool: "gpu", KubeletVersion: "v1.31.0", ContainerRuntimeVersion: "containerd://2.0.0"},
+	}
+	cycles := []addon.EOLCycle{
+		{Cycle: "2.0", EOL: "2026-11-07"},
+		{Cycle: "1.7", EOL: "2026-03-10"},
+		{Cycle: "1.6", EOL: "2025-07-23"},
+	}
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+
+	got := NodePools(nodes, "v1.30.4-eks-a737599", "1.31", cycles, now)
+	want := []output.NodePool{
+		{
+			Name: "gpu", Nodes: 1, KubeletVersions: []string{"v1.31.0"}, ContainerRuntimeVersions: []string{"containerd://2.0.0"},
+			Findings: []output.NodeFinding{
+				{Check: output.CheckKubeletSkew, Compatible: output.StatusFalse, Note: "kubelet 1.31 is newer than kube-apiserver 1.30 (" + skewPolicyURL + ")"},
+				{Check: output.CheckKubeletTargetSkew, Compatible: output.StatusTrue, Note: "before the control plane moves to 1.31: kubelet 1.31 within the supported skew of kube-apiserver 1.31 (" + skewPolicyURL + ")"},
+				{Check: output.CheckContainerRuntimeEOL, Compatible: output.StatusTrue, Note: "containerd 2.0 is supported until 2026-11-07 (" + containerdLifecycleURL + ")"},
+			},
+		},
+		{
+			Name: "ungrouped", Nodes: 1, KubeletVersions: []string{"v1.30.0"}, ContainerRuntimeVersions: []string{"cri-o://1.30.0"},
+			Findings: []output.NodeFinding{
+				{Check: output.CheckKubeletSkew, Compatible: output.StatusTrue, Note: "kubelet 1.30 within the supported skew of kube-apiserver 1.30 (" + skewPolicyURL + ")"},
+				{Check: output.CheckKubeletTargetSkew, Compatible: output.StatusTrue, Note: "before the control plane moves to 1.31: kubelet 1.30 within the supported skew of kube-apiserver 1.31 (" + skewPolicyURL + ")"},
+			},
+		},
+		{
+			Name: "workers", Nodes: 2, KubeletVersions: []string{"v1.27.9-eks-5e0fdde", "v1.30.4-eks-a737599"},
+			ContainerRuntimeVersions: []string{"containerd://1.6.28", "containerd://1.7.22"}, KernelVersions: []string{"5.10.210"}, OSImages: []string{"Amazon Linux 2"},
+			Findings: []output.NodeFinding{
+				{Check: output.CheckKubeletSkew, Compatible: output.StatusTrue, Note: "kubelet 1.27, 1.30 within the supported skew of kube-apiserver 1.30 (" + skewPolicyURL + ")"},
+				{Check: output.CheckKubeletTargetSkew, Compatible: output.StatusFalse, Note: "before the control plane moves to 1.31: kubelet 1.27 is 4 minor versions older than kube-apiserver 1.31; at most 3 are supported (" + skewPolicyURL + ")"},
+				{Check: output.CheckContainerRuntimeEOL, Compatible: output.StatusFalse, Note: "containerd 1.6 reached end of life on 2025-07-23; containerd 1.7 is supported until 2026-03-10 (" + containerdLifecycleURL + ")"},
+			},
+		},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("NodePools() =\n%+v\nwant\n%+v", got, want)
+	}
+}
+
+func TestNodePools_Checks(t *testing.T) {
+	now := time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name   string
+		node   cluster.Node
+		server string
+		target string
+		cycles []addon.EOLCycle
+		want   map[string]output.Status
+	}{
+		{
+			name:   "kubelets before 1.25 allow two minors of skew",
+			node:   cluster.Node{KubeletVersion: "v1.24.17", ContainerRuntimeVersion: "containerd://1.6.20"},
+			server: "v1.27.3",
+			want:   map[string]output.Status{output.CheckKubeletSkew: output.StatusFalse, output.CheckContainerRuntimeEOL: output.StatusUnknown},
+		},
+		{
+			name:   "same target minor skips the target check",
+			node:   cluster.Node{KubeletVersion: "v1.30.1"},
+			server: "v1.30.4",
+			target: "1.30",
+			want:   map[string]output.Status{output.CheckKubeletSkew: output.StatusTrue},
+		},
+		{
+			name:   "unparsable kubelet version",
+			node:   cluster.Node{KubeletVersion: "unknown"},
+			server: "v1.30.4",
+			want:   map[string]output.Status{output.CheckKubeletSkew: output.StatusUnknown},
+		},
+		{
+			name:   "unlisted containerd release",
+			node:   cluster.Node{KubeletVersion: "v1.30.1", ContainerRuntimeVersion: "containerd://1.5.18"},
+			server: "v1.30.4",
+			cycles: []addon.EOLCycle{{Cycle: "1.7", EOL: false}},
+			want:   map[string]output.Status{output.CheckKubeletSkew: output.StatusTrue, output.CheckContainerRuntimeEOL: output.StatusUnknown},
+		},
+		{
+			name:   "unparsable server version",
+			node:   cluster.Node{KubeletVersion: "v1.30.1"},
+			server: "",
+			target: "1.31",
+			want:   map[string]output.Status{output.CheckKubeletSkew: output.StatusUnknown},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pools := NodePools([]cluster.Node{tt.node}, tt.server, tt.target, tt.cycles, now)
+			if len(pools) != 1 {
+				t.Fatalf("NodePools() = %d pools, want 1", len(pools))
+			}
+			got := make(map[string]output.Status)
+			for _, finding := range pools[0].Findings {
+				got[finding.Check] = finding.Compatible
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("NodePools() findings = %+v, want statuses %v", pools[0].Findings, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,257 @@
+package metrics
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Label is one name="value" pair on a sample.
+type Label struct {
+	Name  string
+	Value string
+}
+
+// Sample is one value of a metric family.
+type Sample struct {
+	Labels []Label
+	Value  float64
+}
+
+// Family is a named metric with its samples, ready to write.
+type Family struct {
+	Name string
+	Help string
+	// Type is "counter" or "gauge".
+	Type    string
+	Samples []Sample
+}
+
+// Registry holds counter vectors and writes them in the Prometheus text
+// exposition format. It is safe for concurrent use.
+type Registry struct {
+	mu       sync.Mutex
+	counters []*CounterVec
+}
+
+// NewRegistry returns an empty registry.
+func NewRegistry() *Registry {
+	return &Registry{}
+}
+
+// NewCounterVec registers a counter partitioned by the given label names.
+func (r *Registry) NewCounterVec(name, help string, labelNames ...string) *CounterVec {
+	counter := &CounterVec{name: name, help: help, labelNames: labelNames, values: make(map[string]*counterValue)}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.counters = append(r.counters, counter)
+	return counter
+}
+
+// Families returns a snapshot of every counter that has at least one sample,
+// sorted by name.
+func (r *Registry) Families() []Family {
+	r.mu.Lock()
+	counters := append([]*CounterVec(nil), r.counters...)
+	r.mu.Unlock()
+
+	families := make([]Family, 0, len(counters))
+	for _, counter := range counters {
+		if family := counter.family(); len(family.Samples) > 0 {
+			families = append(families, family)
+		}
+	}
+	sort.Slice(families, func(i, j int) bool { return families[i].Name < families[j].Name })
+	return families
+}
+
+// Write writes every non-empty counter in the registry.
+func (r *Registry) Write(w io.Writer) error {
+	return WriteFamilies(w, r.Families())
+}
+
+// CounterVec is a monotonically increasing value per label combination.
+type CounterVec struct {
+	name       string
+	help       string
+	labelNames []string
+
+	mu     sync.Mutex
+	values map[string]*counterValue
+}
+
+type counterValue struct {
+	labelValues []string
+	value       float64
+}
+
+// Add adds v, which must not be negative, to the counter for labelValues.
+// labelValues must match the counter's label names in number and order.
+func (c *CounterVec) Add(v float64, labelValues ...string) {
+	if v < 0 {
+		panic(fmt.Sprintf("metrics: counter %s cannot decrease", c.name))
+	}
+	if len(labelValues) != len(c.labelNames) {
+		panic(fmt.Sprintf("metrics: counter %s takes %d label values, got %d", c.name, len(c.labelNames), len(labelValues)))
+	}
+	key := strings.Join(labelValues, "\xff")
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	entry, ok := c.values[key]
+	if !ok {
+		entry = &counterValue{labelValues: append([]string(nil), labelValues...)}
+		c.values[key] = entry
+	}
+	entry.value += v
+}
+
+// Inc adds one to the counter for labelValues.
+func (c *CounterVec) Inc(labelValues ...string) {
+	c.Add(1, labelValues...)
+}
+
+// Value returns the current count for labelValues.
+func (c *CounterVec) Value(labelValues ...string) float64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if entry, ok := c.values[strings.Join(labelValues, "\xff")]; ok {
+		return entry.value
+	}
+	return 0
+}
+
+func (c *CounterVec) family() Family {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	family := Family{Name: c.name, Help: c.help, Type: "counter", Samples: make([]Sample, 0, len(c.values))}
+	keys := make([]string, 0, len(c.values))
+	for key := range c.values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		entry := c.values[key]
+		labels := make([]Label, len(c.labelNames))
+		for i, name := range c.labelNames {
+			labels[i] = Label{Name: name, Value: entry.labelValues[i]}
+		}
+		family.Samples = append(family.Samples, Sample{Labels: labels, Value: entry.value})
+	}
+	return family
+}
+
+// WriteFamilies writes families in the Prometheus text exposition format
+// (version 0.0.4), which is also what the node exporter textfile collector
+// reads. Samples carry no timestamps, as the textfile collector rejects them.
+func WriteFamilies(w io.Writer, families []Family) error {
+	var b strings.Builder
+	for _, family := range families {
+		fmt.Fprintf(&b, "# HELP %s %s\n", family.Name, escapeHelp(family.Help))
+		fmt.Fprintf(&b, "# TYPE %s %s\n", family.Name, family.Type)
+		for _, sample := range family.Samples {
+			b.WriteString(family.Name)
+			if len(sample.Labels) > 0 {
+				b.WriteByte('{')
+				for i, label := range sample.Labels {
+					if i > 0 {
+						b.WriteByte(',')
+					}
+					fmt.Fprintf(&b, "%s=\"%s\"", label.Name, escapeLabelValue(label.Value))
+				}
+				b.WriteByte('}')
+			}
+			b.WriteByte(' ')
+			b.WriteString(formatValue(sample.Value))
+			b.WriteByte('\n')
+		}
+	}
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// ContentType is the HTTP content type of the text exposition format.
+const ContentType = "text/plain; version=0.0.4; charset=utf-8"
+
+var (
+	helpEscaper  = strings.NewReplacer(`\`, `\\`, "\n", `\n`)
+	labelEscaper = strings.NewReplacer(`\`, `\\`, "\n", `\n`, `"`, `\"`)
+)
+
+func escapeHelp(help string) string {
+	return helpEscaper.Replace(help)
+}
+
+func escapeLabelValue(value string) string {
+	return labelEscaper.Replace(value)
+}
+
+func formatValue(v float64) string {
+	switch {
+	case math.IsInf(v, 1):
+		return "+Inf"
+	case math.IsInf(v, -1):
+		return "-Inf"
+	case math.IsNaN(v):
+		return "NaN"
+	}
+	if v == math.Trunc(v) && math.Abs(v) < 1e15 {
+		// Whole numbers such as Unix timestamps read better without an exponent.
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	}
+	return strconv.FormatFloat(v, 'g', -1, 64)
+}
+
+// Default holds the counters the kaddons pipeline updates while it runs.
+var Default = NewRegistry()
+
+// Fetch failure kinds counted by FetchFailures.
+const (
+	FetchCompatibilityPage = "compatibility_page"
+	FetchEOLCatalog        = "eol_catalog"
+	FetchEOL               = "eol"
+	FetchGitHubRelease     = "github_release"
+)
+
+// LLM call results counted by LLMCalls.
+const (
+	LLMSuccess = "success"
+	LLMError   = "error"
+)
+
+// Pipeline counters. Known label values start at zero so that rate() and
+// absence alerts work before the first failure.
+var (
+	FetchFailures = Default.NewCounterVec("kaddons_fetch_failures_total",
+		"Failed fetches of compatibility data, by kind.", "kind")
+	LLMCalls = Default.NewCounterVec("kaddons_llm_calls_total",
+		"LLM generate calls, including retries, by result.", "result")
+	PhaseDuration = Default.NewCounterVec("kaddons_phase_duration_seconds_total",
+		"Time spent in pipeline phases, in seconds.", "phase")
+	PhaseRuns = Default.NewCounterVec("kaddons_phase_runs_total",
+		"Completed pipeline phases.", "phase")
+)
+
+func init() {
+	for _, kind := range []string{FetchCompatibilityPage, FetchEOLCatalog, FetchEOL, FetchGitHubRelease} {
+		FetchFailures.Add(0, kind)
+	}
+	for _, result := range []string{LLMSuccess, LLMError} {
+		LLMCalls.Add(0, result)
+	}
+}
+
+// ObservePhase records that a pipeline phase which began at start has ended.
+func ObservePhase(phase string, start time.Time) {
+	PhaseDuration.Add(time.Since(start).Seconds(), phase)
+	PhaseRuns.Inc(phase)
+}
+
+// Gauge returns a family with a single gauge sample.
+func Gauge(name, help string, value float64, labels ...Label) Family {
+	return Family{Name: name, Help: help, Type: "gauge", Samples: []Sample{{Labels: labels, Value: value}}}
+}
@@ -0,0 +1,102 @@
+package metrics
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRegistry_Write(t *testing.T) {
+	registry := NewRegistry()
+	requests := registry.NewCounterVec("test_requests_total", "Requests served.", "route", "code")
+	registry.NewCounterVec("test_empty_total", "Never incremented.", "kind")
+	requests.Inc("GET /b", "200")
+	requests.Add(2, "GET /a", "404")
+	requests.Inc("GET /b", "200")
+
+	var buf bytes.Buffer
+	if err := registry.Write(&buf); err != nil {
+		t.Fatalf("Write error = %v", err)
+	}
+	want := `# HELP test_requests_total Requests served.
+# TYPE test_requests_total counter
+test_requests_total{route="GET /a",code="404"} 2
+test_requests_total{route="GET /b",code="200"} 2
+`
+	if buf.String() != want {
+		t.Errorf("Write =\n%s\nwant\n%s", buf.String(), want)
+	}
+	if got := requests.Value("GET /b", "200"); got != 2 {
+		t.Errorf("Value = %v, want 2", got)
+	}
+}
+
+func TestWriteFamilies_Escaping(t *testing.T) {
+	families := []Family{
+		Gauge("test_info", "Help with a \\ and\na newline.", 1, Label{Name: "note", Value: "say \"hi\"\\\n"}),
+		{Name: "test_values", Help: "Values.", Type: "gauge", Samples: []Sample{{Value: 0.25}, {Value: -1}, {Value: 1792329748}, {Value: 1e21}}},
+	}
+	var buf bytes.Buffer
+	if err := WriteFamilies(&buf, families); err != nil {
+		t.Fatalf("WriteFamilies error = %v", err)
+	}
+	for _, want := range []string{
+		`# HELP test_info Help with a \\ and\na newline.`,
+		`test_info{note="say \"hi\"\\\n"} 1`,
+		"test_values 0.25\n",
+		"test_values -1\n",
+		"test_values 1792329748\n",
+		"test_values 1e+21\n",
+	} {
+		if !strings.Contains(buf.String(), want) {
+			t.Errorf("output missing %q:\n%s", want, buf.String())
+		}
+	}
+}
+
+func TestCounterVec_RejectsBadUse(t *testing.T) {
+	tests := []struct {
+		name string
+		call func(*CounterVec)
+	}{
+		{name: "negative", call: func(c *CounterVec) { c.Add(-1, "a") }},
+		{name: "wrong label count", call: func(c *CounterVec) { c.Inc("a", "b") }},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			defer func() {
+				if recover() == nil {
+					t.Error("expected a panic")
+				}
+			}()
+			tt.call(NewRegistry().NewCounterVec("test_total", "Test.", "kind"))
+		})
+	}
+}
+
+func TestDefault_PipelineCounters(t *testing.T) {
+	var buf bytes.Buffer
+	if err := Default.Write(&buf); err != nil {
+		t.Fatalf("Write error = %v", err)
+	}
+	for _, want := range []string{
+		`kaddons_fetch_failures_total{kind="compatibility_page"}`,
+		`kaddons_fetch_failures_total{kind="github_release"}`,
+		`kaddons_llm_calls_total{result="error"}`,
+		`kaddons_llm_calls_total{result="success"}`,
+	} {
+		if !strings.Contains(buf.String(), want) {
+			t.Errorf("default registry missing %s:\n%s", want, buf.String())
+		}
+	}
+
+	before := PhaseRuns.Value("test_phase")
+	ObservePhase("test_phase", time.Now().Add(-time.Second))
+	if got := PhaseRuns.Value("test_phase"); got != before+1 {
+		t.Errorf("phase runs = %v, want %v", got, before+1)
+	}
+	if got := PhaseDuration.Value("test_phase"); got < 1 {
+		t.Errorf("phase duration = %v, want at least 1s", got)
+	}
+}
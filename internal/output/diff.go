@@ -0,0 +1,335 @@
+package output
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"html/template"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// Change is a field value before and after.
+type Change struct {
+	From string `json:"from"`
+	To   string `json:"to"`
+}
+
+// AddonChange lists the fields that changed for an addon present in both
+// reports. Unchanged fields are nil.
+type AddonChange struct {
+	Name             string  `json:"name"`
+	Namespace        string  `json:"namespace"`
+	InstalledVersion *Change `json:"installed_version,omitempty"`
+	Compatible       *Change `json:"compatible,omitempty"`
+	DataSource       *Change `json:"data_source,omitempty"`
+	// Regression is true when an addon that was compatible no longer is
+	// (true → false or true → unknown).
+	Regression bool `json:"regression,omitempty"`
+}
+
+// ReportDiff describes what changed between two compatibility reports. Addons
+// are identified by name and namespace, case-insensitively.
+type ReportDiff struct {
+	OldK8sVersion string `json:"old_k8s_version"`
+	NewK8sVersion string `json:"new_k8s_version"`
+	// Added and Removed hold the full entries from the new and old report.
+	Added     []AddonCompatibility `json:"added"`
+	Removed   []AddonCompatibility `json:"removed"`
+	Changed   []AddonChange        `json:"changed"`
+	Unchanged int                  `json:"unchanged"`
+}
+
+// K8sVersionChanged reports whether the reports target different K8s versions.
+func (d ReportDiff) K8sVersionChanged() bool {
+	return d.OldK8sVersion != d.NewK8sVersion
+}
+
+// IsEmpty reports whether nothing changed between the reports.
+func (d ReportDiff) IsEmpty() bool {
+	return !d.K8sVersionChanged() && len(d.Added) == 0 && len(d.Removed) == 0 && len(d.Changed) == 0
+}
+
+// Regressions counts addons that stopped being compatible.
+func (d ReportDiff) Regressions() int {
+	count := 0
+	for _, c := range d.Changed {
+		if c.Regression {
+			count++
+		}
+	}
+	return count
+}
+
+// LoadReport reads a JSON compatibility report written by kaddons.
+func LoadReport(path string) (CompatibilityReport, error) {
+	data, err := os.ReadFile(filepath.Clean(path))
+	if err != nil {
+		return CompatibilityReport{}, fmt.Errorf("reading report: %w", err)
+	}
+	var report CompatibilityReport
+	if err := json.Unmarshal(data, &report); err != nil {
+		return CompatibilityReport{}, fmt.Errorf("parsing report %s: %w", path, err)
+	}
+	return report, nil
+}
+
+// DiffReports compares two reports. Results are sorted by name, then namespace.
+func DiffReports(oldReport, newReport CompatibilityReport) ReportDiff {
+	diff := ReportDiff{
+		OldK8sVersion: oldReport.K8sVersion,
+		NewK8sVersion: newReport.K8sVersion,
+		Added:         []AddonCompatibility{},
+		Removed:       []AddonCompatibility{},
+		Changed:       []AddonChange{},
+	}
+
+	oldByKey := make(map[string]AddonCompatibility, len(oldReport.Addons))
+	for _, a := range oldReport.Addons {
+		oldByKey[diffKey(a)] = a
+	}
+	seen := make(map[string]bool, len(newReport.Addons))
+	for _, a := range newReport.Addons {
+		key := diffKey(a)
+		seen[key] = true
+		before, ok := oldByKey[key]
+		if !ok {
+			diff.Added = append(diff.Added, a)
+			continue
+		}
+		change := AddonChange{
+			Name:             a.Name,
+			Namespace:        a.Namespace,
+			InstalledVersion: fieldChange(before.InstalledVersion, a.InstalledVersion),
+			Compatible:       fieldChange(string(before.Compatible), string(a.Compatible)),
+			DataSource:       fieldChange(before.DataSource, a.DataSource),
+		}
+		if change.InstalledVersion == nil && change.Compatible == nil && change.DataSource == nil {
+			diff.Unchanged++
+			continue
+		}
+		change.Regression = before.Compatible == StatusTrue && a.Compatible != StatusTrue
+		diff.Changed = append(diff.Changed, change)
+	}
+	for _, a := range oldReport.Addons {
+		if !seen[diffKey(a)] {
+			diff.Removed = append(diff.Removed, a)
+		}
+	}
+
+	sortAddons := func(addons []AddonCompatibility) {
+		sort.SliceStable(addons, func(i, j int) bool { return diffKey(addons[i]) < diffKey(addons[j]) })
+	}
+	sortAddons(diff.Added)
+	sortAddons(diff.Removed)
+	sort.SliceStable(diff.Changed, func(i, j int) bool {
+		return changeKey(diff.Changed[i]) < changeKey(diff.Changed[j])
+	})
+	return diff
+}
+
+func diffKey(a AddonCompatibility) string {
+	return strings.ToLower(a.Name) + "|" + strings.ToLower(a.Namespace)
+}
+
+func changeKey(c AddonChange) string {
+	return strings.ToLower(c.Name) + "|" + strings.ToLower(c.Namespace)
+}
+
+func fieldChange(from, to string) *Change {
+	if from == to {
+		return nil
+	}
+	return &Change{From: from, To: to}
+}
+
+// FormatDiff renders a diff as "json", "markdown" or "html".
+func FormatDiff(diff ReportDiff, format string) ([]byte, error) {
+	switch format {
+	case "json":
+		out, err := json.MarshalIndent(diff, "", "  ")
+		if err != nil {
+			return nil, fmt.Errorf("marshaling diff: %w", err)
+		}
+		return append(out, '\n'), nil
+	case "markdown":
+		return []byte(markdownDiff(diff)), nil
+	case "html":
+		return htmlDiff(diff)
+	default:
+		return nil, fmt.Errorf("unsupported diff format %q (supported: json, markdown, html)", format)
+	}
+}
+
+func markdownDiff(diff ReportDiff) string {
+	var b strings.Builder
+	b.WriteString("## kaddons report diff\n\n")
+	if diff.K8sVersionChanged() {
+		fmt.Fprintf(&b, "Kubernetes version: %s → %s\n\n", diff.OldK8sVersion, diff.NewK8sVersion)
+	} else {
+		fmt.Fprintf(&b, "Kubernetes version: %s\n\n", diff.NewK8sVersion)
+	}
+	fmt.Fprintf(&b, "%d added, %d removed, %d changed (%d regressions), %d unchanged\n",
+		len(diff.Added), len(diff.Removed), len(diff.Changed), diff.Regressions(), diff.Unchanged)
+
+	if len(diff.Changed) > 0 {
+		b.WriteString("\n### Changed\n\n")
+		b.WriteString("| Name | Namespace | Installed | Compatible | Source |\n")
+		b.WriteString("|------|-----------|-----------|------------|--------|\n")
+		for _, c := range diff.Changed {
+			compatible := describeChange(c.Compatible)
+			if c.Regression {
+				compatible = "**" + compatible + "** (regression)"
+			}
+			fmt.Fprintf(&b, "| %s | %s | %s | %s | %s |\n", markdownCell(c.Name), markdownCell(c.Namespace),
+				markdownCell(describeChange(c.InstalledVersion)), markdownCell(compatible), markdownCell(describeChange(c.DataSource)))
+		}
+	}
+	writeAddons := func(title string, addons []AddonCompatibility) {
+		if len(addons) == 0 {
+			return
+		}
+		fmt.Fprintf(&b, "\n### %s\n\n", title)
+		b.WriteString("| Name | Namespace | Installed | Compatible | Source |\n")
+		b.WriteString("|------|-----------|-----------|------------|--------|\n")
+		for _, a := range addons {
+			fmt.Fprintf(&b, "| %s | %s | %s | %s | %s |\n", markdownCell(a.Name), markdownCell(a.Namespace),
+				markdownCell(a.InstalledVersion), markdownCell(string(a.Compatible)), markdownCell(a.DataSource))
+		}
+	}
+	writeAddons("Added", diff.Added)
+	writeAddons("Removed", diff.Removed)
+	return b.String()
+}
+
+// describeChange renders "from → to", or "unchanged" for a nil change.
+func describeChange(c *Change) string {
+	if c == nil {
+		return "unchanged"
+	}
+	from, to := c.From, c.To
+	if from == "" {
+		from = "none"
+	}
+	if to == "" {
+		to = "none"
+	}
+	return from + " → " + to
+}
+
+// markdownCell escapes characters that would break a Markdown table row.
+func markdownCell(value string) string {
+	value = strings.ReplaceAll(value, "|", `\|`)
+	return strings.ReplaceAll(value, "\n", " ")
+}
+
+type htmlDiffRow struct {
+	Name       string
+	Namespace  string
+	Installed  string
+	Compatible string
+	DataSource string
+	Regression bool
+}
+
+type htmlDiffData struct {
+	OldK8sVersion string
+	NewK8sVersion string
+	Changed       []htmlDiffRow
+	Added         []htmlDiffRow
+	Removed       []htmlDiffRow
+	Regressions   int
+	Unchanged     int
+}
+
+func htmlDiff(diff ReportDiff) ([]byte, error) {
+	data := htmlDiffData{
+		OldK8sVersion: diff.OldK8sVersion,
+		NewK8sVersion: diff.NewK8sVersion,
+		Regressions:   diff.Regressions(),
+		Unchanged:     diff.Unchanged,
+	}
+	for _, c := range diff.Changed {
+		data.Changed = append(data.Changed, htmlDiffRow{
+			Name:       c.Name,
+			Namespace:  c.Namespace,
+			Installed:  describeChange(c.InstalledVersion),
+			Compatible: describeChange(c.Compatible),
+			DataSource: describeChange(c.DataSource),
+			Regression: c.Regression,
+		})
+	}
+	toRows := func(addons []AddonCompatibility) []htmlDiffRow {
+		rows := make([]htmlDiffRow, 0, len(addons))
+		for _, a := range addons {
+			rows = append(rows, htmlDiffRow{
+				Name:       a.Name,
+				Namespace:  a.Namespace,
+				Installed:  a.InstalledVersion,
+				Compatible: string(a.Compatible),
+				DataSource: a.DataSource,
+			})
+		}
+		return rows
+	}
+	data.Added = toRows(diff.Added)
+	data.Removed = toRows(diff.Removed)
+
+	diffTemplate, err := template.New("kaddons-diff").Parse(htmlDiffTemplate)
+	if err != nil {
+		return nil, fmt.Errorf("parsing HTML diff template: %w", err)
+	}
+	var buf bytes.Buffer
+	if err := diffTemplate.Execute(&buf, data); err != nil {
+		return nil, fmt.Errorf("rendering HTML diff: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+const htmlDiffTemplate = `<!DOCTYPE html>
+<html lang="en">
+<head>
+  <meta charset="UTF-8">
+  <meta name="viewport" content="width=device-width, initial-scale=1.0">
+  <title>kaddons Report Diff</title>
+  <style>
+` + htmlReportStyles + `    h2 { margin:24px 0 8px 0; font-size:18px; }
+  </style>
+</head>
+<body>
+  <h1>kaddons Report Diff</h1>
+  <div class="meta">Kubernetes version: {{ if ne .OldK8sVersion .NewK8sVersion }}{{ .OldK8sVersion }} → {{ end }}{{ .NewK8sVersion }}</div>
+  <div class="summary">
+    <span class="pill">Added: {{ len .Added }}</span>
+    <span class="pill">Removed: {{ len .Removed }}</span>
+    <span class="pill">Changed: {{ len .Changed }}</span>
+    <span class="pill pill-false">Regressions: {{ .Regressions }}</span>
+    <span class="pill">Unchanged: {{ .Unchanged }}</span>
+  </div>
+  {{ define "rows" }}
+  <table>
+    <thead>
+      <tr><th>Name</th><th>Namespace</th><th>Installed</th><th>Compatibility</th><th>Source</th></tr>
+    </thead>
+    <tbody>
+      {{ range . }}
+      <tr>
+        <td>{{ .Name }}</td>
+        <td>{{ .Namespace }}</td>
+        <td>{{ .Installed }}</td>
+        <td>{{ if .Regression }}<span class="status-chip status-false">{{ .Compatible }}</span>{{ else }}{{ .Compatible }}{{ end }}</td>
+        <td>{{ .DataSource }}</td>
+      </tr>
+      {{ end }}
+    </tbody>
+  </table>
+  {{ end }}
+  {{ if .Changed }}<h2>Changed</h2>{{ template "rows" .Changed }}{{ end }}
+  {{ if .Added }}<h2>Added</h2>{{ template "rows" .Added }}{{ end }}
+  {{ if .Removed }}<h2>Removed</h2>{{ template "rows" .Removed }}{{ end }}
+  {{ if not (or .Changed .Added .Removed) }}<p class="muted">No addon changes.</p>{{ end }}
+</body>
+</html>
+`
@@ -0,0 +1,164 @@
+package output
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func diffTestReports() (CompatibilityReport, CompatibilityReport) {
+	oldReport := CompatibilityReport{
+		K8sVersion: "1.30",
+		Addons: []AddonCompatibility{
+			{Name: "cert-manager", Namespace: "cert-manager", InstalledVersion: "v1.14.2", Compatible: StatusTrue, DataSource: DataSourceStored},
+			{Name: "karpenter", Namespace: "karpenter", InstalledVersion: "0.37.0", Compatible: StatusTrue, DataSource: DataSourceStored},
+			{Name: "keda", Namespace: "keda", InstalledVersion: "2.12.0", Compatible: StatusUnknown, DataSource: DataSourceLocal},
+			{Name: "old-addon", Namespace: "default", InstalledVersion: "1.0.0", Compatible: StatusTrue, DataSource: DataSourceStored},
+			{Name: "metrics-server", Namespace: "kube-system", InstalledVersion: "v0.7.1", Compatible: StatusTrue, DataSource: DataSourceExtracted},
+		},
+	}
+	newReport := CompatibilityReport{
+		K8sVersion: "1.31",
+		Addons: []AddonCompatibility{
+			{Name: "cert-manager", Namespace: "cert-manager", InstalledVersion: "v1.15.0", Compatible: StatusTrue, DataSource: DataSourceStored},
+			{Name: "Karpenter", Namespace: "karpenter", InstalledVersion: "0.37.0", Compatible: StatusFalse, DataSource: DataSourceStored},
+			{Name: "keda", Namespace: "keda", InstalledVersion: "2.12.0", Compatible: StatusTrue, DataSource: DataSourceRuntime},
+			{Name: "metrics-server", Namespace: "kube-system", InstalledVersion: "v0.7.1", Compatible: StatusTrue, DataSource: DataSourceExtracted},
+			{Name: "new-addon", Namespace: "default", InstalledVersion: "2.0.0", Compatible: StatusUnknown, DataSource: DataSourceLocal},
+		},
+	}
+	return oldReport, newReport
+}
+
+func TestDiffReports(t *testing.T) {
+	oldReport, newReport := diffTestReports()
+	diff := DiffReports(oldReport, newReport)
+
+	if !diff.K8sVersionChanged() || diff.OldK8sVersion != "1.30" || diff.NewK8sVersion != "1.31" {
+		t.Errorf("K8s version change not detected: %s -> %s", diff.OldK8sVersion, diff.NewK8sVersion)
+	}
+	if len(diff.Added) != 1 || diff.Added[0].Name != "new-addon" {
+		t.Errorf("added = %+v, want new-addon", diff.Added)
+	}
+	if len(diff.Removed) != 1 || diff.Removed[0].Name != "old-addon" {
+		t.Errorf("removed = %+v, want old-addon", diff.Removed)
+	}
+	if diff.Unchanged != 1 {
+		t.Errorf("unchanged = %d, want 1", diff.Unchanged)
+	}
+
+	want := []AddonChange{
+		{Name: "cert-manager", Namespace: "cert-manager", InstalledVersion: &Change{From: "v1.14.2", To: "v1.15.0"}},
+		{Name: "Karpenter", Namespace: "karpenter", Compatible: &Change{From: "true", To: "false"}, Regression: true},
+		{
+			Name: "keda", Namespace: "keda",
+			Compatible: &Change{From: "unknown", To: "true"},
+			DataSource: &Change{From: DataSourceLocal, To: DataSourceRuntime},
+		},
+	}
+	if !reflect.DeepEqual(diff.Changed, want) {
+		got, _ := json.Marshal(diff.Changed)
+		t.Errorf("changed = %s", got)
+	}
+	if diff.Regressions() != 1 {
+		t.Errorf("regressions = %d, want 1", diff.Regressions())
+	}
+}
+
+func TestDiffReports_Identical(t *testing.T) {
+	report, _ := diffTestReports()
+	diff := DiffReports(report, report)
+	if !diff.IsEmpty() {
+		t.Errorf("expected empty diff, got %+v", diff)
+	}
+	out, err := FormatDiff(diff, "json")
+	if err != nil {
+		t.Fatalf("FormatDiff(json) error = %v", err)
+	}
+	if !strings.Contains(string(out), `"added": []`) || !strings.Contains(string(out), `"changed": []`) {
+		t.Errorf("empty sections should marshal as [], got %s", out)
+	}
+}
+
+func TestFormatDiff(t *testing.T) {
+	oldReport, newReport := diffTestReports()
+	diff := DiffReports(oldReport, newReport)
+
+	tests := []struct {
+		format string
+		want   []string
+	}{
+		{
+			format: "json",
+			want:   []string{`"old_k8s_version": "1.30"`, `"regression": true`, `"from": "v1.14.2"`},
+		},
+		{
+			format: "markdown",
+			want: []string{
+				"Kubernetes version: 1.30 → 1.31",
+				"1 added, 1 removed, 3 changed (1 regressions), 1 unchanged",
+				"| Karpenter | karpenter | unchanged | **true → false** (regression) | unchanged |",
+				"| keda | keda | unchanged | unknown → true | local → llm |",
+				"### Removed",
+			},
+		},
+		{
+			format: "html",
+			want: []string{
+				"Kubernetes version: 1.30 → 1.31",
+				"Regressions: 1",
+				`<span class="status-chip status-false">true → false</span>`,
+				"<h2>Added</h2>",
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.format, func(t *testing.T) {
+			out, err := FormatDiff(diff, tt.format)
+			if err != nil {
+				t.Fatalf("FormatDiff(%s) error = %v", tt.format, err)
+			}
+			for _, want := range tt.want {
+				if !strings.Contains(string(out), want) {
+					t.Errorf("%s output missing %q:\n%s", tt.format, want, out)
+				}
+			}
+		})
+	}
+
+	if _, err := FormatDiff(diff, "yaml"); err == nil {
+		t.Error("expected error for unsupported format")
+	}
+}
+
+func TestMarkdownCell_EscapesPipes(t *testing.T) {
+	if got := markdownCell("a|b\nc"); got != `a\|b c` {
+		t.Errorf("markdownCell = %q", got)
+	}
+}
+
+func TestLoadReport(t *testing.T) {
+	dir := t.TempDir()
+	valid := filepath.Join(dir, "report.json")
+	if err := os.WriteFile(valid, []byte(`{"k8s_version":"1.31","addons":[{"name":"keda","compatible":true}]}`), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	report, err := LoadReport(valid)
+	if err != nil {
+		t.Fatalf("LoadReport error = %v", err)
+	}
+	if report.K8sVersion != "1.31" || len(report.Addons) != 1 || report.Addons[0].Compatible != StatusTrue {
+		t.Errorf("unexpected report %+v", report)
+	}
+
+	invalid := filepath.Join(dir, "invalid.json")
+	if err := os.WriteFile(invalid, []byte(`not json`), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := LoadReport(invalid); err == nil || !strings.Contains(err.Error(), "invalid.json") {
+		t.Errorf("expected parse error naming the file, got %v", err)
+	}
+}
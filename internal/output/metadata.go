@@ -0,0 +1,96 @@
+package output
+
+import (
+	_ "embed"
+	"time"
+)
+
+// ReportSchemaVersion is the version of the JSON Schema that JSON reports
+// follow, recorded as metadata.schema_version. Adding an optional field keeps
+// the version; removing a field or changing its meaning bumps it.
+const ReportSchemaVersion = "1"
+
+// ReportSchema is the JSON Schema (draft 2020-12) for CompatibilityReport.
+//
+//go:embed schema/compatibility-report.v1.json
+var ReportSchema []byte
+
+// Metadata records what produced a report, so archived reports can be traced
+// back to a tool build, database revision, cluster and model.
+type Metadata struct {
+	SchemaVersion string       `json:"schema_version"`
+	GeneratedAt   string       `json:"generated_at"` // RFC 3339, UTC
+	Tool          ToolInfo     `json:"tool"`
+	Database      DatabaseInfo `json:"database"`
+	// Cluster is nil for kaddons check, which contacts no cluster.
+	Cluster  *ClusterInfo  `json:"cluster,omitempty"`
+	Analysis AnalysisInfo  `json:"analysis"`
+	Filters  *Filters      `json:"filters,omitempty"`
+	Timings  []PhaseTiming `json:"timings,omitempty"`
+}
+
+// ToolInfo identifies the kaddons build.
+type ToolInfo struct {
+	Name    string `json:"name"`
+	Version string `json:"version"`
+	Commit  string `json:"commit,omitempty"`
+}
+
+// DatabaseInfo identifies the embedded addon database.
+type DatabaseInfo struct {
+	Hash    string `json:"hash"` // "sha256:<hex>" of the embedded JSON
+	Entries int    `json:"entries"`
+}
+
+// ClusterInfo identifies the kubeconfig context that was scanned.
+type ClusterInfo struct {
+	Context string `json:"context,omitempty"`
+	Server  string `json:"server,omitempty"`
+	// Distribution is eks, gke, aks, openshift, k3s, rke2 or kind when
+	// it could be detected.
+	Distribution string `json:"distribution,omitempty"`
+	// ServerVersion is the API server's full version, including any
+	// provider build such as -eks-a737599.
+	ServerVersion string `json:"server_version,omitempty"`
+}
+
+// Analysis providers recorded in AnalysisInfo.
+const (
+	AnalysisProviderGemini = "gemini"
+	AnalysisProviderNone   = "none"
+)
+
+// AnalysisInfo records whether LLM analysis was configured and with which model.
+type AnalysisInfo struct {
+	Provider string `json:"provider"`
+	Model    string `json:"model,omitempty"`
+}
+
+// Filters records the discovery filters a scan was run with.
+type Filters struct {
+	Namespace string   `json:"namespace,omitempty"`
+	Addons    []string `json:"addons,omitempty"`
+}
+
+// PhaseTiming is the wall-clock duration of one pipeline phase.
+type PhaseTiming struct {
+	Phase      string `json:"phase"`
+	DurationMS int64  `json:"duration_ms"`
+}
+
+// NewMetadata returns metadata stamped with the current schema version and
+// time. Callers fill in the rest as the run progresses.
+func NewMetadata(tool ToolInfo, database DatabaseInfo, analysis AnalysisInfo) *Metadata {
+	return &Metadata{
+		SchemaVersion: ReportSchemaVersion,
+		GeneratedAt:   time.Now().UTC().Format(time.RFC3339),
+		Tool:          tool,
+		Database:      database,
+		Analysis:      analysis,
+	}
+}
+
+// AddTiming records the time elapsed since start as the duration of phase.
+func (m *Metadata) AddTiming(phase string, start time.Time) {
+	m.Timings = append(m.Timings, PhaseTiming{Phase: phase, DurationMS: time.Since(start).Milliseconds()})
+}
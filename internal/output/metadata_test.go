@@ -0,0 +1,306 @@
+package output
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math"
+	"reflect"
+	"regexp"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/qbandev/kaddons/internal/addon"
+)
+
+// schemaValidator checks a decoded JSON value against the subset of JSON
+// Schema the report schema uses: $ref into $defs, type, const, enum,
+// required, properties, additionalProperties: false, items, pattern and
+// minimum.
+type schemaValidator struct {
+	root map[string]any
+}
+
+func loadReportSchema(t *testing.T) map[string]any {
+	t.Helper()
+	var schema map[string]any
+	if err := json.Unmarshal(ReportSchema, &schema); err != nil {
+		t.Fatalf("ReportSchema is not valid JSON: %v", err)
+	}
+	return schema
+}
+
+func (v schemaValidator) resolve(schema map[string]any) map[string]any {
+	ref, ok := schema["$ref"].(string)
+	if !ok {
+		return schema
+	}
+	name := strings.TrimPrefix(ref, "#/$defs/")
+	return v.root["$defs"].(map[string]any)[name].(map[string]any)
+}
+
+func (v schemaValidator) validate(schema map[string]any, value any, path string) []string {
+	schema = v.resolve(schema)
+	var errs []string
+	fail := func(format string, args ...any) {
+		errs = append(errs, path+": "+fmt.Sprintf(format, args...))
+	}
+
+	if want, ok := schema["type"].(string); ok && !jsonTypeMatches(want, value) {
+		fail("got %T, want %s", value, want)
+		return errs
+	}
+	if want, ok := schema["const"]; ok && value != want {
+		fail("got %v, want const %v", value, want)
+	}
+	if enum, ok := schema["enum"].([]any); ok {
+		found := false
+		for _, allowed := range enum {
+			found = found || value == allowed
+		}
+		if !found {
+			fail("%v is not one of %v", value, enum)
+		}
+	}
+	if pattern, ok := schema["pattern"].(string); ok {
+		if s, _ := value.(string); !regexp.MustCompile(pattern).MatchString(s) {
+			fail("%q does not match %s", s, pattern)
+		}
+	}
+	if minimum, ok := schema["minimum"].(float64); ok {
+		if n, _ := value.(float64); n < minimum {
+			fail("%v is below minimum %v", n, minimum)
+		}
+	}
+
+	switch typed := value.(type) {
+	case map[string]any:
+		properties, _ := schema["properties"].(map[string]any)
+		required, _ := schema["required"].([]any)
+		for _, name := range required {
+			if _, ok := typed[name.(string)]; !ok {
+				fail("missing required property %q", name)
+			}
+		}
+		for name, child := range typed {
+			propertySchema, ok := properties[name].(map[string]any)
+			if !ok {
+				if schema["additionalProperties"] == false {
+					fail("unexpected property %q", name)
+				}
+				continue
+			}
+			errs = append(errs, v.validate(propertySchema, child, path+"."+name)...)
+		}
+	case []any:
+		if items, ok := schema["items"].(map[string]any); ok {
+			for i, child := range typed {
+				errs = append(errs, v.validate(items, child, fmt.Sprintf("%s[%d]", path, i))...)
+			}
+		}
+	}
+	return errs
+}
+
+func jsonTypeMatches(want string, value any) bool {
+	switch want {
+	case "object":
+		_, ok := value.(map[string]any)
+		return ok
+	case "array":
+		_, ok := value.([]any)
+		return ok
+	case "string":
+		_, ok := value.(string)
+		return ok
+	case "integer":
+		n, ok := value.(float64)
+		return ok && n == math.Trunc(n)
+	case "boolean":
+		_, ok := value.(bool)
+		return ok
+	}
+	return false
+}
+
+func metadataTestReport() CompatibilityReport {
+	behind := 2
+	metadata := NewMetadata(
+		ToolInfo{Name: "kaddons", Version: "v1.4.0", Commit: "abc1234"},
+		DatabaseInfo{Hash: addon.DatabaseHash(), Entries: 42},
+		AnalysisInfo{Provider: AnalysisProviderGemini, Model: "gemini-3-flash-preview"},
+	)
+	metadata.Cluster = &ClusterInfo{Context: "prod", Server: "https://10.0.0.1:6443", Distribution: "eks", ServerVersion: "v1.31.2-eks-7f9249a"}
+	metadata.Filters = &Filters{Namespace: "karpenter", Addons: []string{"karpenter"}}
+	metadata.AddTiming("discovery", time.Now())
+	daysLeft := 120
+	return CompatibilityReport{
+		K8sVersion: "1.31",
+		Metadata:   metadata,
+		Cluster: &ClusterSupport{
+			Version: "1.31", Product: "amazon-eks", Status: SupportExtended, StandardSupportEnds: "2025-11-26",
+			ExtendedSupportEnds: "2026-11-26", DaysRemaining: &daysLeft, NextSupportedVersion: "1.32",
+		},
+		NodePools: []NodePool{{
+			Name: "default", Nodes: 3, KubeletVersions: []string{"v1.28.15-eks-1552ad0", "v1.31.2-eks-7f9249a"},
+			ContainerRuntimeVersions: []string{"containerd://1.7.22"}, KernelVersions: []string{"6.1.112-124.190.amzn2023.x86_64"},
+			OSImages: []string{"Amazon Linux 2023.6.20241031"},
+			Findings: []NodeFinding{
+				{Check: CheckKubeletSkew, Compatible: StatusTrue, Note: "kubelet 1.28, 1.31 within the supported skew of kube-apiserver 1.31"},
+				{Check: CheckContainerRuntimeEOL, Compatible: StatusUnknown, Note: "containerd 1.7: end-of-life data unavailable"},
+			},
+		}},
+		SkippedScopes: []SkippedScope{
+			{Resource: "statefulsets.apps", Reason: "list across all namespaces is forbidden; listed only in karpenter"},
+			{Resource: "subscriptions.operators.coreos.com", Namespace: "karpenter", Reason: "list is forbidden"},
+		},
+		Addons: []AddonCompatibility{{
+			Name: "karpenter", Namespace: "karpenter", InstalledVersion: "0.37.0", Compatible: StatusFalse,
+			LatestCompatibleVersion: "1.0.0", Note: "Upgrade", DataSource: DataSourceExtracted,
+			Provenance: &addon.Provenance{
+				SourceURL: "https://karpenter.sh/docs/upgrading/compatibility/", Strategy: "version-header",
+				ExtractedAt: "2026-01-02T03:04:05Z", Confidence: addon.ConfidenceHigh,
+				ContentHash: "sha256:" + strings.Repeat("a", 64),
+			},
+			LatestUpstreamVersion: "v1.2.0", LatestUpstreamReleaseDate: "2026-01-01", MinorVersionsBehind: &behind,
+		}},
+	}
+}
+
+func TestReportSchema_ValidatesReports(t *testing.T) {
+	validator := schemaValidator{root: loadReportSchema(t)}
+
+	var rendered bytes.Buffer
+	if err := renderReport(&rendered, "json", metadataTestReport(), false); err != nil {
+		t.Fatalf("rendering JSON report: %v", err)
+	}
+
+	tests := []struct {
+		name    string
+		report  string
+		wantErr string
+	}{
+		{name: "report with metadata", report: rendered.String()},
+		{name: "report without metadata", report: `{"k8s_version":"1.30","addons":[{"name":"keda","namespace":"keda","installed_version":"2.12.0","compatible":"unknown"}]}`},
+		{name: "invalid status", report: `{"k8s_version":"1.30","addons":[{"name":"keda","namespace":"","installed_version":"","compatible":"maybe"}]}`, wantErr: "is not one of"},
+		{name: "unknown field", report: `{"k8s_version":"1.30","addons":[],"context":"prod"}`, wantErr: `unexpected property "context"`},
+		{name: "missing addon name", report: `{"k8s_version":"1.30","addons":[{"namespace":"","installed_version":"","compatible":"true"}]}`, wantErr: `missing required property "name"`},
+		{
+			name:    "metadata without tool",
+			report:  `{"k8s_version":"1.30","addons":[],"metadata":{"schema_version":"1","generated_at":"2026-01-01T00:00:00Z","database":{"hash":"sha256:` + strings.Repeat("0", 64) + `","entries":1},"analysis":{"provider":"none"}}}`,
+			wantErr: `missing required property "tool"`,
+		},
+		{
+			name:    "future schema version",
+			report:  `{"k8s_version":"1.30","addons":[],"metadata":{"schema_version":"2","generated_at":"","tool":{"name":"kaddons","version":"dev"},"database":{"hash":"sha256:` + strings.Repeat("0", 64) + `","entries":1},"analysis":{"provider":"none"}}}`,
+			wantErr: "want const 1",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var value any
+			if err := json.Unmarshal([]byte(tt.report), &value); err != nil {
+				t.Fatalf("test report is not JSON: %v", err)
+			}
+			errs := validator.validate(validator.root, value, "$")
+			if tt.wantErr == "" {
+				if len(errs) > 0 {
+					t.Fatalf("unexpected schema errors: %v\n%s", errs, tt.report)
+				}
+				return
+			}
+			if !strings.Contains(strings.Join(errs, "\n"), tt.wantErr) {
+				t.Fatalf("schema errors = %v, want %q", errs, tt.wantErr)
+			}
+		})
+	}
+}
+
+// TestReportSchema_MatchesGoTypes keeps the schema in step with the report
+// types: every JSON field has a schema property and vice versa, and exactly
+// the fields without omitempty are required.
+func TestReportSchema_MatchesGoTypes(t *testing.T) {
+	validator := schemaValidator{root: loadReportSchema(t)}
+	checkSchemaMatchesType(t, validator, validator.root, reflect.TypeOf(CompatibilityReport{}), "$")
+}
+
+func checkSchemaMatchesType(t *testing.T, v schemaValidator, schema map[string]any, typ reflect.Type, path string) {
+	t.Helper()
+	schema = v.resolve(schema)
+	for typ.Kind() == reflect.Pointer || typ.Kind() == reflect.Slice {
+		if typ.Kind() == reflect.Slice {
+			schema = v.resolve(schema["items"].(map[string]any))
+		}
+		typ = typ.Elem()
+	}
+	if typ.Kind() != reflect.Struct {
+		return
+	}
+
+	properties, _ := schema["properties"].(map[string]any)
+	var wantRequired, gotRequired []string
+	required, _ := schema["required"].([]any)
+	for _, name := range required {
+		gotRequired = append(gotRequired, name.(string))
+	}
+	seen := make(map[string]bool)
+	for i := range typ.NumField() {
+		field := typ.Field(i)
+		name, options, _ := strings.Cut(field.Tag.Get("json"), ",")
+		if name == "" || name == "-" {
+			continue
+		}
+		seen[name] = true
+		if !strings.Contains(options, "omitempty") {
+			wantRequired = append(wantRequired, name)
+		}
+		propertySchema, ok := properties[name].(map[string]any)
+		if !ok {
+			t.Errorf("%s: schema has no property %q for %s.%s", path, name, typ.Name(), field.Name)
+			continue
+		}
+		checkSchemaMatchesType(t, v, propertySchema, field.Type, path+"."+name)
+	}
+	for name := range properties {
+		if !seen[name] {
+			t.Errorf("%s: schema property %q has no field in %s", path, name, typ.Name())
+		}
+	}
+	sort.Strings(wantRequired)
+	sort.Strings(gotRequired)
+	if !reflect.DeepEqual(wantRequired, gotRequired) {
+		t.Errorf("%s: schema requires %v, %s has non-omitempty fields %v", path, gotRequired, typ.Name(), wantRequired)
+	}
+}
+
+func TestReportSchema_Version(t *testing.T) {
+	schema := loadReportSchema(t)
+	id, _ := schema["$id"].(string)
+	if !strings.HasSuffix(id, ".v"+ReportSchemaVersion+".json") {
+		t.Errorf("schema $id %q does not name version %s", id, ReportSchemaVersion)
+	}
+	metadata := schema["$defs"].(map[string]any)["metadata"].(map[string]any)
+	schemaVersion := metadata["properties"].(map[string]any)["schema_version"].(map[string]any)
+	if schemaVersion["const"] != ReportSchemaVersion {
+		t.Errorf("schema_version const = %v, want %s", schemaVersion["const"], ReportSchemaVersion)
+	}
+	if got := NewMetadata(ToolInfo{}, DatabaseInfo{}, AnalysisInfo{}).SchemaVersion; got != ReportSchemaVersion {
+		t.Errorf("NewMetadata schema version = %q, want %q", got, ReportSchemaVersion)
+	}
+}
+
+func TestWriteReport_MetadataInHumanFormats(t *testing.T) {
+	report := metadataTestReport()
+	for _, format := range []string{"html", "markdown"} {
+		var buf bytes.Buffer
+		if err := renderReport(&buf, format, report, false); err != nil {
+			t.Fatalf("rendering %s: %v", format, err)
+		}
+		want := "generated " + report.Metadata.GeneratedAt + " by kaddons v1.4.0"
+		if !strings.Contains(buf.String(), want) {
+			t.Errorf("%s report missing %q", format, want)
+		}
+	}
+}
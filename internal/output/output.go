@@ -2,12 +2,14 @@ package output
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"html/template"
-	"os"
-	"path/filepath"
+	"io"
 	"regexp"
 	"strings"
+
+	"github.com/qbandev/kaddons/internal/addon"
 )
 
 var reportURLPattern = regexp.MustCompile(`https?://[^\s<]+`)
@@ -42,6 +44,9 @@ const (
 	DataSourceRuntime   = "llm"
 	DataSourceLocal     = "local"
 	DataSourceExtracted = "extracted"
+	// DataSourceOLM marks verdicts from the spec.minKubeVersion of an OLM
+	// operator's ClusterServiceVersion, used when the database has no data.
+	DataSourceOLM = "olm"
 )
 
 // AddonCompatibility represents the compatibility verdict for a single addon.
@@ -53,15 +58,105 @@ type AddonCompatibility struct {
 	LatestCompatibleVersion string `json:"latest_compatible_version,omitempty"`
 	Note                    string `json:"note,omitempty"`
 	DataSource              string `json:"data_source,omitempty"`
+	// Provenance is set for stored data written by kaddons-extract --sync and for
+	// data_source "extracted"; LLM and local-only verdicts carry none.
+	Provenance *addon.Provenance `json:"provenance,omitempty"`
+	// LatestUpstreamVersion is the newest stable GitHub release (or tag) of the
+	// addon's repository, independent of cluster compatibility.
+	LatestUpstreamVersion string `json:"latest_upstream_version,omitempty"`
+	// LatestUpstreamReleaseDate is the publication date (YYYY-MM-DD) of
+	// LatestUpstreamVersion; empty when the version came from a tag.
+	LatestUpstreamReleaseDate string `json:"latest_upstream_release_date,omitempty"`
+	// MinorVersionsBehind counts minor releases between the installed version
+	// and LatestUpstreamVersion. It is omitted across major versions, when
+	// the installed version cannot be parsed, or when it is a chart version.
+	MinorVersionsBehind *int `json:"minor_versions_behind,omitempty"`
 }
 
 // CompatibilityReport is the top-level output structure.
 type CompatibilityReport struct {
-	K8sVersion string               `json:"k8s_version"`
-	Addons     []AddonCompatibility `json:"addons"`
+	K8sVersion string `json:"k8s_version"`
+	// Metadata is absent from reports written before schema version 1 and
+	// from API server responses.
+	Metadata *Metadata `json:"metadata,omitempty"`
+	// Cluster is the support lifecycle of K8sVersion itself.
+	Cluster *ClusterSupport `json:"cluster,omitempty"`
+	// NodePools are absent when nodes could not be listed, which
+	// SkippedScopes then records, and from kaddons check reports.
+	NodePools []NodePool `json:"node_pools,omitempty"`
+	// SkippedScopes are the resources and namespaces discovery could not
+	// read, so addons there are missing from Addons.
+	SkippedScopes []SkippedScope       `json:"skipped_scopes,omitempty"`
+	Addons        []AddonCompatibility `json:"addons"`
+}
+
+// SkippedScope is a resource discovery could not list in a namespace, or in
+// any namespace when Namespace is empty.
+type SkippedScope struct {
+	// Resource is resource.group as kubectl names it, e.g. deployments.apps.
+	Resource  string `json:"resource"`
+	Namespace string `json:"namespace,omitempty"`
+	Reason    string `json:"reason"`
+}
+
+// Support statuses of a Kubernetes version.
+const (
+	SupportStandard = "standard"
+	SupportExtended = "extended"
+	SupportEnded    = "end_of_life"
+	SupportUnknown  = "unknown"
+)
+
+// ClusterSupport describes where the cluster's Kubernetes version is in the
+// support lifecycle of its distribution.
+type ClusterSupport struct {
+	Version string `json:"version"`
+	// Product is the endoflife.date product the dates come from, e.g.
+	// kubernetes or amazon-eks.
+	Product             string `json:"product"`
+	Status              string `json:"support_status"`
+	StandardSupportEnds string `json:"standard_support_ends,omitempty"`
+	ExtendedSupportEnds string `json:"extended_support_ends,omitempty"`
+	// DaysRemaining counts the days left in the current support phase, and
+	// is omitted once support has ended or when no date is known.
+	DaysRemaining *int `json:"days_remaining,omitempty"`
+	// NextSupportedVersion is the oldest newer version still in standard
+	// support, the upgrade to target.
+	NextSupportedVersion string `json:"next_supported_version,omitempty"`
+}
+
+// Node checks reported for each node pool.
+const (
+	// CheckKubeletSkew compares kubelets with the running kube-apiserver.
+	CheckKubeletSkew = "kubelet_skew"
+	// CheckKubeletTargetSkew compares kubelets with the Kubernetes version
+	// checked against, when it differs from the running kube-apiserver.
+	CheckKubeletTargetSkew = "kubelet_target_skew"
+	// CheckContainerRuntimeEOL checks containerd releases for end of life.
+	CheckContainerRuntimeEOL = "container_runtime_eol"
+)
+
+// NodePool summarizes the nodes of one pool and the checks run on them.
+type NodePool struct {
+	// Name is the pool's name, or "ungrouped" for nodes without a pool label.
+	Name                     string        `json:"name"`
+	Nodes                    int           `json:"nodes"`
+	KubeletVersions          []string      `json:"kubelet_versions"`
+	ContainerRuntimeVersions []string      `json:"container_runtime_versions,omitempty"`
+	KernelVersions           []string      `json:"kernel_versions,omitempty"`
+	OSImages                 []string      `json:"os_images,omitempty"`
+	Findings                 []NodeFinding `json:"findings"`
+}
+
+// NodeFinding is the result of one node check on a pool.
+type NodeFinding struct {
+	Check      string `json:"check"`
+	Compatible Status `json:"compatible"`
+	Note       string `json:"note"`
 }
 
-// FormatOutput parses raw JSON from the LLM and writes the selected output format.
+// FormatOutput parses raw JSON from the LLM and writes it, without metadata,
+// to every sink in the output spec (see WriteReport).
 func FormatOutput(rawJSON string, k8sVersion string, format string, outputPath string) ([]AddonCompatibility, error) {
 	var addons []AddonCompatibility
 	if err := json.Unmarshal([]byte(rawJSON), &addons); err != nil {
@@ -72,27 +167,27 @@ func FormatOutput(rawJSON string, k8sVersion string, format string, outputPath s
 		return nil, fmt.Errorf("parsing agent JSON output: %w\nRaw output (first 500 chars):\n%s", err, truncated)
 	}
 
-	switch format {
-	case "json":
-		report := CompatibilityReport{
-			K8sVersion: k8sVersion,
-			Addons:     addons,
-		}
-		out, err := json.MarshalIndent(report, "", "  ")
-		if err != nil {
-			return nil, fmt.Errorf("marshaling report: %w", err)
-		}
-		fmt.Println(string(out))
-		return addons, nil
-	case "html":
-		if err := writeHTMLReport(addons, k8sVersion, outputPath); err != nil {
-			return nil, err
+	if err := WriteReport(CompatibilityReport{K8sVersion: k8sVersion, Addons: addons}, format, outputPath); err != nil {
+		return nil, err
+	}
+	return addons, nil
+}
+
+// WriteReport writes report to every sink in the output spec (see
+// ParseSinks). htmlPath is where a bare "html" sink writes. A failing sink
+// does not stop the others; their errors are joined.
+func WriteReport(report CompatibilityReport, spec, htmlPath string) error {
+	sinks, err := ParseSinks(spec, htmlPath)
+	if err != nil {
+		return err
+	}
+	var errs []error
+	for _, sink := range sinks {
+		if err := writeSink(sink, report); err != nil {
+			errs = append(errs, err)
 		}
-		fmt.Fprintf(os.Stderr, "HTML report written to %s\n", outputPath)
-		return addons, nil
-	default:
-		return nil, fmt.Errorf("unsupported output format %q (supported: json, html)", format)
 	}
+	return errors.Join(errs...)
 }
 
 type htmlReportRow struct {
@@ -105,19 +200,94 @@ type htmlReportRow struct {
 	Note                    template.HTML
 	DataSourceClass         string
 	DataSourceLabel         string
+	DataSourceTitle         string
+	Upstream                string
+}
+
+type htmlNodeRow struct {
+	Pool            string
+	Nodes           int
+	KubeletVersions string
+	RuntimeVersions string
+	Check           string
+	CompatibleClass string
+	CompatibleLabel string
+	Note            template.HTML
 }
 
 type htmlReportData struct {
-	K8sVersion   string
-	Addons       []htmlReportRow
+	K8sVersion string
+	Generated  string
+	Support    string
+	Skipped    []string
+	Addons     []htmlReportRow
+	NodeRows   []htmlNodeRow
+	Summary
+}
+
+// Summary counts verdicts by status. The HTML, Markdown and table formats and
+// the final stderr line all report these counts.
+type Summary struct {
 	Compatible   int
 	Incompatible int
 	Unknown      int
 }
 
-func writeHTMLReport(addons []AddonCompatibility, k8sVersion string, outputPath string) error {
+// Summarize counts addons by compatibility status.
+func Summarize(addons []AddonCompatibility) Summary {
+	var summary Summary
+	for _, a := range addons {
+		switch a.Compatible {
+		case StatusTrue:
+			summary.Compatible++
+		case StatusFalse:
+			summary.Incompatible++
+		default:
+			summary.Unknown++
+		}
+	}
+	return summary
+}
+
+// describeProvenance summarizes provenance for the data source tooltip.
+func describeProvenance(provenance *addon.Provenance) string {
+	if provenance == nil {
+		return ""
+	}
+	parts := []string{provenance.Confidence + " confidence", provenance.Strategy}
+	if provenance.TableLocator != "" {
+		parts = append(parts, provenance.TableLocator)
+	}
+	parts = append(parts, "extracted "+provenance.ExtractedAt)
+	return strings.Join(parts, " · ")
+}
+
+// describeUpstream summarizes the latest upstream release for the Installed column.
+func describeUpstream(addon AddonCompatibility) string {
+	if addon.LatestUpstreamVersion == "" {
+		return ""
+	}
+	parts := []string{"upstream " + addon.LatestUpstreamVersion}
+	if addon.LatestUpstreamReleaseDate != "" {
+		parts = append(parts, addon.LatestUpstreamReleaseDate)
+	}
+	if addon.MinorVersionsBehind != nil {
+		switch behind := *addon.MinorVersionsBehind; behind {
+		case 0:
+			parts = append(parts, "current minor")
+		case 1:
+			parts = append(parts, "1 minor behind")
+		default:
+			parts = append(parts, fmt.Sprintf("%d minors behind", behind))
+		}
+	}
+	return strings.Join(parts, " · ")
+}
+
+func writeHTMLReport(w io.Writer, report CompatibilityReport) error {
+	addons := report.Addons
 	rows := make([]htmlReportRow, 0, len(addons))
-	data := htmlReportData{K8sVersion: k8sVersion}
+	data := htmlReportData{K8sVersion: report.K8sVersion, Generated: describeGeneration(report.Metadata), Support: describeClusterSupport(report.Cluster), Summary: Summarize(addons)}
 	for _, addon := range addons {
 		row := htmlReportRow{
 			Name:                    addon.Name,
@@ -125,20 +295,19 @@ func writeHTMLReport(addons []AddonCompatibility, k8sVersion string, outputPath
 			InstalledVersion:        addon.InstalledVersion,
 			LatestCompatibleVersion: addon.LatestCompatibleVersion,
 			Note:                    linkifyReportNote(addon.Note),
+			DataSourceTitle:         describeProvenance(addon.Provenance),
+			Upstream:                describeUpstream(addon),
 		}
 		switch addon.Compatible {
 		case StatusTrue:
 			row.CompatibleClass = "status-true"
 			row.CompatibleLabel = "compatible"
-			data.Compatible++
 		case StatusFalse:
 			row.CompatibleClass = "status-false"
 			row.CompatibleLabel = "incompatible"
-			data.Incompatible++
 		default:
 			row.CompatibleClass = "status-unknown"
 			row.CompatibleLabel = "unknown"
-			data.Unknown++
 		}
 		switch addon.DataSource {
 		case DataSourceStored:
@@ -147,6 +316,9 @@ func writeHTMLReport(addons []AddonCompatibility, k8sVersion string, outputPath
 		case DataSourceExtracted:
 			row.DataSourceClass = "source-extracted"
 			row.DataSourceLabel = "extracted"
+		case DataSourceOLM:
+			row.DataSourceClass = "source-olm"
+			row.DataSourceLabel = "olm"
 		case DataSourceRuntime:
 			row.DataSourceClass = "source-llm"
 			row.DataSourceLabel = "llm"
@@ -160,43 +332,98 @@ func writeHTMLReport(addons []AddonCompatibility, k8sVersion string, outputPath
 		rows = append(rows, row)
 	}
 	data.Addons = rows
-
-	if outputPath == "" {
-		outputPath = "./kaddons-report.html"
+	for _, scope := range report.SkippedScopes {
+		data.Skipped = append(data.Skipped, DescribeSkippedScope(scope))
 	}
-	outputPath = filepath.Clean(outputPath)
-	outputDirectoryPath := filepath.Dir(outputPath)
-	outputFileName := filepath.Base(outputPath)
-	if outputFileName == "." || outputFileName == string(filepath.Separator) {
-		return fmt.Errorf("invalid output path: %s", outputPath)
+	for _, pool := range report.NodePools {
+		for _, finding := range pool.Findings {
+			data.NodeRows = append(data.NodeRows, htmlNodeRow{
+				Pool:            pool.Name,
+				Nodes:           pool.Nodes,
+				KubeletVersions: strings.Join(pool.KubeletVersions, ", "),
+				RuntimeVersions: strings.Join(pool.ContainerRuntimeVersions, ", "),
+				Check:           finding.Check,
+				CompatibleClass: "status-" + string(statusOrUnknown(finding.Compatible)),
+				CompatibleLabel: statusLabel(finding.Compatible),
+				Note:            linkifyReportNote(finding.Note),
+			})
+		}
 	}
 
 	reportTemplate, err := template.New("kaddons-report").Parse(htmlTemplate)
 	if err != nil {
 		return fmt.Errorf("parsing HTML template: %w", err)
 	}
-
-	if err := os.MkdirAll(outputDirectoryPath, 0o750); err != nil {
-		return fmt.Errorf("creating output directory: %w", err)
+	if err := reportTemplate.Execute(w, data); err != nil {
+		return fmt.Errorf("writing HTML report: %w", err)
 	}
+	return nil
+}
 
-	outputRoot, err := os.OpenRoot(outputDirectoryPath)
-	if err != nil {
-		return fmt.Errorf("opening output directory root: %w", err)
+// DescribeSkippedScope renders a skipped scope on one line, e.g.
+// "deployments.apps in team-a: list is forbidden".
+func DescribeSkippedScope(scope SkippedScope) string {
+	where := "cluster-wide"
+	if scope.Namespace != "" {
+		where = "in " + scope.Namespace
 	}
-	defer func() { _ = outputRoot.Close() }()
+	return fmt.Sprintf("%s %s: %s", scope.Resource, where, scope.Reason)
+}
 
-	file, err := outputRoot.Create(outputFileName)
-	if err != nil {
-		return fmt.Errorf("creating HTML report file: %w", err)
+// statusOrUnknown maps anything but true and false to unknown.
+func statusOrUnknown(status Status) Status {
+	if status == StatusTrue || status == StatusFalse {
+		return status
 	}
-	defer func() { _ = file.Close() }()
+	return StatusUnknown
+}
 
-	if err := reportTemplate.Execute(file, data); err != nil {
-		return fmt.Errorf("writing HTML report: %w", err)
+// describeGeneration renders "generated <time> by kaddons <version>" for
+// report headers, or "" for reports without metadata.
+func describeGeneration(metadata *Metadata) string {
+	if metadata == nil {
+		return ""
 	}
+	return fmt.Sprintf("generated %s by %s %s", metadata.GeneratedAt, metadata.Tool.Name, metadata.Tool.Version)
+}
 
-	return nil
+// describeClusterSupport summarizes the cluster's support lifecycle in one
+// line, e.g. "amazon-eks 1.30: extended support, 120 days left (until
+// 2026-07-23); upgrade to 1.31".
+func describeClusterSupport(support *ClusterSupport) string {
+	if support == nil {
+		return ""
+	}
+	var b strings.Builder
+	fmt.Fprintf(&b, "%s %s: ", support.Product, support.Version)
+	end := support.StandardSupportEnds
+	switch support.Status {
+	case SupportStandard:
+		b.WriteString("standard support")
+	case SupportExtended:
+		b.WriteString("extended support")
+		end = support.ExtendedSupportEnds
+	case SupportEnded:
+		b.WriteString("end of life")
+		if support.ExtendedSupportEnds != "" {
+			end = support.ExtendedSupportEnds
+		}
+		if end != "" {
+			fmt.Fprintf(&b, " since %s", end)
+		}
+	default:
+		b.WriteString("support status unknown")
+	}
+	if support.DaysRemaining != nil {
+		fmt.Fprintf(&b, ", %d days left", *support.DaysRemaining)
+		if end != "" {
+			fmt.Fprintf(&b, " (until %s)", end)
+		}
+	}
+	if support.NextSupportedVersion != "" {
+		fmt.Fprintf(&b, "; upgrade to %s", support.NextSupportedVersion)
+	}
+	return b.String()
 }
 
 func linkifyReportNote(note string) template.HTML {
@@ -208,15 +435,10 @@ func linkifyReportNote(note string) template.HTML {
 	return template.HTML(withLinks)
 }
 
-const htmlTemplate = `<!DOCTYPE html>
-<html lang="en">
-<head>
-  <meta charset="UTF-8">
-  <meta name="viewport" content="width=device-width, initial-scale=1.0">
-  <title>kaddons Compatibility Report</title>
-  <style>
-    body { background:#0b0f14; color:#e6edf3; font-family:Inter,system-ui,-apple-system,sans-serif; margin:0; padding:24px; }
+// htmlReportStyles is shared by the compatibility report and the report diff.
+const htmlReportStyles = `    body { background:#0b0f14; color:#e6edf3; font-family:Inter,system-ui,-apple-system,sans-serif; margin:0; padding:24px; }
     h1 { margin:0 0 8px 0; font-size:24px; }
+    h2 { margin:24px 0 12px 0; font-size:18px; }
     .meta { color:#9fb0c3; margin-bottom:16px; }
     .summary { display:flex; gap:12px; margin:0 0 16px 0; }
     .pill { border-radius:999px; padding:6px 10px; font-size:13px; border:1px solid #2b3541; }
@@ -233,15 +455,28 @@ const htmlTemplate = `<!DOCTYPE html>
     .status-unknown { color:#d29922; border-color:#6f5a1a; background:#252218; }
     .source-stored { color:#a5d6ff; border-color:#1f4a7a; background:#0d2240; }
     .source-extracted { color:#7ee7c1; border-color:#1a6b4f; background:#0d3026; }
+    .source-olm { color:#ffd8a8; border-color:#7a4f1f; background:#2e1d0d; }
     .source-llm { color:#9fb0c3; border-color:#2b3541; background:#161b22; }
     .source-local { color:#d2a8ff; border-color:#553d7a; background:#1c1336; }
     .muted { color:#9fb0c3; }
-  </style>
+    .skipped { color:#d29922; }
+`
+
+const htmlTemplate = `<!DOCTYPE html>
+<html lang="en">
+<head>
+  <meta charset="UTF-8">
+  <meta name="viewport" content="width=device-width, initial-scale=1.0">
+  <title>kaddons Compatibility Report</title>
+  <style>
+` + htmlReportStyles + `  </style>
 </head>
 <body>
   <h1>kaddons Compatibility Report</h1>
-  <div class="meta">Kubernetes version: {{ .K8sVersion }}</div>
-  <div class="summary">
+  <div class="meta">Kubernetes version: {{ .K8sVersion }}{{ if .Generated }} · {{ .Generated }}{{ end }}</div>
+  {{ if .Support }}<div class="meta">Cluster support: {{ .Support }}</div>{{ end }}
+  {{ range .Skipped }}<div class="meta skipped">Skipped {{ . }}</div>
+  {{ end }}  <div class="summary">
     <span class="pill pill-true">Compatible: {{ .Compatible }}</span>
     <span class="pill pill-false">Incompatible: {{ .Incompatible }}</span>
     <span class="pill pill-unknown">Unknown: {{ .Unknown }}</span>
@@ -264,16 +499,45 @@ const htmlTemplate = `<!DOCTYPE html>
       <tr>
         <td>{{ .Name }}</td>
         <td>{{ .Namespace }}</td>
-        <td>{{ .InstalledVersion }}</td>
+        <td>{{ .InstalledVersion }}{{ if .Upstream }}<br><span class="muted">{{ .Upstream }}</span>{{ end }}</td>
         <td>{{ $.K8sVersion }}</td>
         <td><span class="status-chip {{ .CompatibleClass }}">{{ .CompatibleLabel }}</span></td>
-        <td><span class="status-chip {{ .DataSourceClass }}">{{ .DataSourceLabel }}</span></td>
+        <td><span class="status-chip {{ .DataSourceClass }}"{{ if .DataSourceTitle }} title="{{ .DataSourceTitle }}"{{ end }}>{{ .DataSourceLabel }}</span></td>
         <td>{{ if .LatestCompatibleVersion }}{{ .LatestCompatibleVersion }}{{ else }}<span class="muted">N/A</span>{{ end }}</td>
         <td>{{ if .Note }}{{ .Note }}{{ else }}<span class="muted">No details</span>{{ end }}</td>
       </tr>
       {{ end }}
     </tbody>
   </table>
+  {{ if .NodeRows }}
+  <h2>Node pools</h2>
+  <table>
+    <thead>
+      <tr>
+        <th>Pool</th>
+        <th>Nodes</th>
+        <th>Kubelet</th>
+        <th>Container Runtime</th>
+        <th>Check</th>
+        <th>Status</th>
+        <th>Notes</th>
+      </tr>
+    </thead>
+    <tbody>
+      {{ range .NodeRows }}
+      <tr>
+        <td>{{ .Pool }}</td>
+        <td>{{ .Nodes }}</td>
+        <td>{{ .KubeletVersions }}</td>
+        <td>{{ if .RuntimeVersions }}{{ .RuntimeVersions }}{{ else }}<span class="muted">N/A</span>{{ end }}</td>
+        <td>{{ .Check }}</td>
+        <td><span class="status-chip {{ .CompatibleClass }}">{{ .CompatibleLabel }}</span></td>
+        <td>{{ .Note }}</td>
+      </tr>
+      {{ end }}
+    </tbody>
+  </table>
+  {{ end }}
 </body>
 </html>
 `
@@ -477,11 +477,99 @@ func TestFormatOutput_HTMLContainsLocalSourceColumn(t *testing.T) {
 
 func TestFormatOutput_InvalidFormatReturnsError(t *testing.T) {
 	raw := `[{"name":"a","namespace":"ns","installed_version":"v1","compatible":"true","note":"ok"}]`
-	_, err := FormatOutput(raw, "1.30", "table", "")
+	_, err := FormatOutput(raw, "1.30", "yaml", "")
 	if err == nil {
-		t.Fatalf("FormatOutput(table) expected error")
+		t.Fatalf("FormatOutput(yaml) expected error")
 	}
 	if !strings.Contains(err.Error(), "unsupported output format") {
-		t.Fatalf("FormatOutput(table) error = %v, want unsupported output format", err)
+		t.Fatalf("FormatOutput(yaml) error = %v, want unsupported output format", err)
+	}
+}
+
+func TestFormatOutput_ProvenanceRoundTrip(t *testing.T) {
+	raw := `[{"name":"cert-manager","namespace":"cert-manager","installed_version":"v1.14.0","compatible":"true","data_source":"extracted",` +
+		`"provenance":{"source_url":"https://cert-manager.io/docs/releases/","content_hash":"sha256:abc","table_locator":"html table 1",` +
+		`"strategy":"labeled-column","extracted_at":"2026-10-18T00:00:00Z","confidence":"high"}},` +
+		`{"name":"llm-addon","namespace":"default","installed_version":"v2.0.0","compatible":"unknown","data_source":"llm"}]`
+
+	addons, err := FormatOutput(raw, "1.31", "json", "")
+	if err != nil {
+		t.Fatalf("FormatOutput(json) error = %v", err)
+	}
+	provenance := addons[0].Provenance
+	if provenance == nil {
+		t.Fatal("expected provenance on extracted addon")
+	}
+	if provenance.TableLocator != "html table 1" || provenance.Confidence != "high" || provenance.Strategy != "labeled-column" {
+		t.Errorf("unexpected provenance: %+v", provenance)
+	}
+	if addons[1].Provenance != nil {
+		t.Errorf("expected no provenance on llm addon, got %+v", addons[1].Provenance)
+	}
+
+	data, err := json.Marshal(addons[1])
+	if err != nil {
+		t.Fatalf("marshal error: %v", err)
+	}
+	if strings.Contains(string(data), "provenance") {
+		t.Error("JSON should omit empty provenance field")
+	}
+}
+
+func TestFormatOutput_HTMLShowsProvenanceTooltip(t *testing.T) {
+	tempDir := t.TempDir()
+	reportPath := filepath.Join(tempDir, "provenance-report.html")
+	raw := `[{"name":"cert-manager","namespace":"cert-manager","installed_version":"v1.14.0","compatible":"true","data_source":"extracted",` +
+		`"provenance":{"source_url":"https://cert-manager.io/docs/releases/","table_locator":"markdown table 2",` +
+		`"strategy":"version-header","extracted_at":"2026-10-18T00:00:00Z","confidence":"medium"}}]`
+
+	if _, err := FormatOutput(raw, "1.31", "html", reportPath); err != nil {
+		t.Fatalf("FormatOutput(html) error = %v", err)
+	}
+	data, err := os.ReadFile(reportPath)
+	if err != nil {
+		t.Fatalf("reading report: %v", err)
+	}
+	want := `title="medium confidence · version-header · markdown table 2 · extracted 2026-10-18T00:00:00Z"`
+	if !strings.Contains(string(data), want) {
+		t.Errorf("HTML report missing provenance tooltip %s", want)
+	}
+}
+
+func TestFormatOutput_UpstreamReleaseFields(t *testing.T) {
+	raw := `[{"name":"keda","namespace":"keda","installed_version":"2.12.0","compatible":"unknown","data_source":"local",` +
+		`"latest_upstream_version":"2.15.1","latest_upstream_release_date":"2024-08-01","minor_versions_behind":3},` +
+		`{"name":"current","namespace":"default","installed_version":"1.2.0","compatible":"true","minor_versions_behind":0},` +
+		`{"name":"no-upstream","namespace":"default","installed_version":"1.0.0","compatible":"true"}]`
+
+	tempDir := t.TempDir()
+	reportPath := filepath.Join(tempDir, "upstream-report.html")
+	addons, err := FormatOutput(raw, "1.31", "html", reportPath)
+	if err != nil {
+		t.Fatalf("FormatOutput(html) error = %v", err)
+	}
+	if addons[0].MinorVersionsBehind == nil || *addons[0].MinorVersionsBehind != 3 {
+		t.Errorf("minor_versions_behind = %v, want 3", addons[0].MinorVersionsBehind)
+	}
+	if addons[1].MinorVersionsBehind == nil || *addons[1].MinorVersionsBehind != 0 {
+		t.Error("minor_versions_behind 0 must survive a round trip")
+	}
+
+	data, err := json.Marshal(addons[2])
+	if err != nil {
+		t.Fatalf("marshal error: %v", err)
+	}
+	for _, key := range []string{"latest_upstream_version", "latest_upstream_release_date", "minor_versions_behind"} {
+		if strings.Contains(string(data), key) {
+			t.Errorf("JSON should omit empty %s", key)
+		}
+	}
+
+	html, err := os.ReadFile(reportPath)
+	if err != nil {
+		t.Fatalf("reading report: %v", err)
+	}
+	if want := "upstream 2.15.1 · 2024-08-01 · 3 minors behind"; !strings.Contains(string(html), want) {
+		t.Errorf("HTML report missing %q", want)
 	}
 }
@@ -0,0 +1,132 @@
+package output
+
+import (
+	"io"
+	"strings"
+	"time"
+
+	"github.com/qbandev/kaddons/internal/metrics"
+)
+
+// compatibleGaugeValue maps a status to kaddons_addon_compatible: 1 for
+// compatible, 0 for incompatible and -1 for unknown.
+var compatibleGaugeValue = map[Status]float64{
+	StatusTrue:    1,
+	StatusFalse:   0,
+	StatusUnknown: -1,
+}
+
+// writePrometheus writes the report in the Prometheus text exposition format,
+// for the node exporter textfile collector: one kaddons_addon_compatible
+// series per addon instance, status totals, and run details from the
+// metadata, followed by the process counters in metrics.Default.
+func writePrometheus(w io.Writer, report CompatibilityReport) error {
+	compatible := metrics.Family{
+		Name: "kaddons_addon_compatible",
+		Help: "Whether the installed addon version supports the cluster's Kubernetes version: 1 compatible, 0 incompatible, -1 unknown.",
+		Type: "gauge",
+	}
+	behind := metrics.Family{
+		Name: "kaddons_addon_minor_versions_behind",
+		Help: "Minor releases between the installed addon version and the latest upstream release.",
+		Type: "gauge",
+	}
+	// The collector rejects a file with repeated series, so an addon reported
+	// twice with the same labels is written once.
+	seen := make(map[string]bool)
+	summary := Summarize(report.Addons)
+	for _, a := range report.Addons {
+		labels := []metrics.Label{
+			{Name: "name", Value: a.Name},
+			{Name: "namespace", Value: a.Namespace},
+			{Name: "installed_version", Value: a.InstalledVersion},
+			{Name: "data_source", Value: a.DataSource},
+		}
+		key := strings.Join([]string{a.Name, a.Namespace, a.InstalledVersion, a.DataSource}, "\xff")
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+		value, ok := compatibleGaugeValue[a.Compatible]
+		if !ok {
+			value = compatibleGaugeValue[StatusUnknown]
+		}
+		compatible.Samples = append(compatible.Samples, metrics.Sample{Labels: labels, Value: value})
+		if a.MinorVersionsBehind != nil {
+			behind.Samples = append(behind.Samples, metrics.Sample{Labels: labels, Value: float64(*a.MinorVersionsBehind)})
+		}
+	}
+
+	families := []metrics.Family{
+		compatible,
+		{
+			Name: "kaddons_addons",
+			Help: "Addon instances in the report, by compatibility status.",
+			Type: "gauge",
+			Samples: []metrics.Sample{
+				{Labels: []metrics.Label{{Name: "status", Value: string(StatusTrue)}}, Value: float64(summary.Compatible)},
+				{Labels: []metrics.Label{{Name: "status", Value: string(StatusFalse)}}, Value: float64(summary.Incompatible)},
+				{Labels: []metrics.Label{{Name: "status", Value: string(StatusUnknown)}}, Value: float64(summary.Unknown)},
+			},
+		},
+	}
+	if len(behind.Samples) > 0 {
+		families = append(families, behind)
+	}
+	families = append(families, metrics.Gauge("kaddons_skipped_scopes",
+		"Resource scopes discovery could not read, so addons there are missing from the report.", float64(len(report.SkippedScopes))))
+	families = append(families, metrics.Gauge("kaddons_report_info",
+		"Kubernetes version the report was checked against.", 1, metrics.Label{Name: "k8s_version", Value: report.K8sVersion}))
+	if support := report.Cluster; support != nil {
+		families = append(families, metrics.Gauge("kaddons_cluster_support_info",
+			"Support status of the cluster's Kubernetes version.", 1,
+			metrics.Label{Name: "version", Value: support.Version}, metrics.Label{Name: "product", Value: support.Product},
+			metrics.Label{Name: "status", Value: support.Status}, metrics.Label{Name: "next_supported_version", Value: support.NextSupportedVersion}))
+		if support.DaysRemaining != nil {
+			families = append(families, metrics.Gauge("kaddons_cluster_support_days_remaining",
+				"Days left in the current support phase of the cluster's Kubernetes version.", float64(*support.DaysRemaining),
+				metrics.Label{Name: "version", Value: support.Version}, metrics.Label{Name: "product", Value: support.Product}))
+		}
+	}
+
+	if len(report.NodePools) > 0 {
+		nodes := metrics.Family{
+			Name: "kaddons_node_pool_nodes",
+			Help: "Nodes in each node pool.",
+			Type: "gauge",
+		}
+		checks := metrics.Family{
+			Name: "kaddons_node_pool_check",
+			Help: "Result of a node check on a node pool: 1 compatible, 0 incompatible, -1 unknown.",
+			Type: "gauge",
+		}
+		for _, pool := range report.NodePools {
+			nodes.Samples = append(nodes.Samples, metrics.Sample{Labels: []metrics.Label{{Name: "pool", Value: pool.Name}}, Value: float64(pool.Nodes)})
+			for _, finding := range pool.Findings {
+				value, ok := compatibleGaugeValue[finding.Compatible]
+				if !ok {
+					value = compatibleGaugeValue[StatusUnknown]
+				}
+				checks.Samples = append(checks.Samples, metrics.Sample{
+					Labels: []metrics.Label{{Name: "pool", Value: pool.Name}, {Name: "check", Value: finding.Check}},
+					Value:  value,
+				})
+			}
+		}
+		families = append(families, nodes, checks)
+	}
+
+	if m := report.Metadata; m != nil {
+		families = append(families,
+			metrics.Gauge("kaddons_build_info", "Version of kaddons that wrote the report.", 1,
+				metrics.Label{Name: "version", Value: m.Tool.Version}, metrics.Label{Name: "commit", Value: m.Tool.Commit}),
+			metrics.Gauge("kaddons_database_entries", "Addons in the embedded compatibility database.", float64(m.Database.Entries)),
+		)
+		if generated, err := time.Parse(time.RFC3339, m.GeneratedAt); err == nil {
+			families = append(families, metrics.Gauge("kaddons_report_timestamp_seconds",
+				"Unix time the report was generated.", float64(generated.Unix())))
+		}
+	}
+	families = append(families, metrics.Default.Families()...)
+	return metrics.WriteFamilies(w, families)
+}
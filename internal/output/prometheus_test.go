@@ -0,0 +1,66 @@
+package output
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestWritePrometheus(t *testing.T) {
+	report := metadataTestReport()
+	report.Addons = append(report.Addons,
+		AddonCompatibility{Name: "keda", Namespace: "keda", InstalledVersion: "2.12.0", Compatible: StatusTrue, DataSource: DataSourceStored},
+		AddonCompatibility{Name: "keda", Namespace: "keda", InstalledVersion: "2.12.0", Compatible: StatusTrue, DataSource: DataSourceStored},
+		AddonCompatibility{Name: "odd\"name", Namespace: "", InstalledVersion: "", Compatible: ""},
+	)
+
+	var buf bytes.Buffer
+	if err := renderReport(&buf, "prometheus", report, false); err != nil {
+		t.Fatalf("rendering prometheus: %v", err)
+	}
+	text := buf.String()
+	for _, want := range []string{
+		"# TYPE kaddons_addon_compatible gauge\n",
+		`kaddons_addon_compatible{name="karpenter",namespace="karpenter",installed_version="0.37.0",data_source="extracted"} 0` + "\n",
+		`kaddons_addon_compatible{name="keda",namespace="keda",installed_version="2.12.0",data_source="stored"} 1` + "\n",
+		`kaddons_addon_compatible{name="odd\"name",namespace="",installed_version="",data_source=""} -1` + "\n",
+		`kaddons_addon_minor_versions_behind{name="karpenter",namespace="karpenter",installed_version="0.37.0",data_source="extracted"} 2` + "\n",
+		`kaddons_addons{status="true"} 2` + "\n",
+		`kaddons_addons{status="false"} 1` + "\n",
+		`kaddons_addons{status="unknown"} 1` + "\n",
+		"kaddons_skipped_scopes 2\n",
+		`kaddons_report_info{k8s_version="1.31"} 1` + "\n",
+		`kaddons_cluster_support_info{version="1.31",product="amazon-eks",status="extended",next_supported_version="1.32"} 1` + "\n",
+		`kaddons_cluster_support_days_remaining{version="1.31",product="amazon-eks"} 120` + "\n",
+		`kaddons_node_pool_nodes{pool="default"} 3` + "\n",
+		`kaddons_node_pool_check{pool="default",check="kubelet_skew"} 1` + "\n",
+		`kaddons_node_pool_check{pool="default",check="container_runtime_eol"} -1` + "\n",
+		`kaddons_build_info{version="v1.4.0",commit="abc1234"} 1` + "\n",
+		"kaddons_database_entries 42\n",
+		"kaddons_report_timestamp_seconds ",
+		"# TYPE kaddons_fetch_failures_total counter\n",
+	} {
+		if !strings.Contains(text, want) {
+			t.Errorf("prometheus output missing %q:\n%s", want, text)
+		}
+	}
+	if got := strings.Count(text, `kaddons_addon_compatible{name="keda"`); got != 1 {
+		t.Errorf("keda series written %d times, want once", got)
+	}
+
+	// Every line is a comment or "series value", as the textfile collector expects.
+	for _, line := range strings.Split(strings.TrimSuffix(text, "\n"), "\n") {
+		if strings.HasPrefix(line, "# HELP ") || strings.HasPrefix(line, "# TYPE ") {
+			continue
+		}
+		series, value := line, ""
+		if end := strings.LastIndex(line, "} "); end >= 0 {
+			series, value = line[:end+1], line[end+2:]
+		} else if name, rest, ok := strings.Cut(line, " "); ok {
+			series, value = name, rest
+		}
+		if series == "" || value == "" || strings.Contains(value, " ") {
+			t.Errorf("malformed sample line %q", line)
+		}
+	}
+}
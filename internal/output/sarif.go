@@ -0,0 +1,173 @@
+package output
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/url"
+	"strings"
+)
+
+// SARIF rule IDs. Compatible addons produce no results.
+const (
+	sarifRuleIncompatible = "kaddons/incompatible-addon"
+	sarifRuleUnknown      = "kaddons/unknown-compatibility"
+)
+
+type sarifLog struct {
+	Schema  string     `json:"$schema"`
+	Version string     `json:"version"`
+	Runs    []sarifRun `json:"runs"`
+}
+
+type sarifRun struct {
+	Tool       sarifTool         `json:"tool"`
+	Results    []sarifResult     `json:"results"`
+	Properties map[string]string `json:"properties,omitempty"`
+}
+
+type sarifTool struct {
+	Driver sarifDriver `json:"driver"`
+}
+
+type sarifDriver struct {
+	Name           string      `json:"name"`
+	Version        string      `json:"version,omitempty"`
+	InformationURI string      `json:"informationUri"`
+	Rules          []sarifRule `json:"rules"`
+}
+
+type sarifRule struct {
+	ID                   string             `json:"id"`
+	ShortDescription     sarifMessage       `json:"shortDescription"`
+	DefaultConfiguration sarifConfiguration `json:"defaultConfiguration"`
+}
+
+type sarifConfiguration struct {
+	Level string `json:"level"`
+}
+
+type sarifMessage struct {
+	Text string `json:"text"`
+}
+
+type sarifResult struct {
+	RuleID           string                 `json:"ruleId"`
+	Level            string                 `json:"level"`
+	Message          sarifMessage           `json:"message"`
+	Locations        []sarifLocation        `json:"locations"`
+	LogicalLocations []sarifLogicalLocation `json:"logicalLocations"`
+	Properties       map[string]string      `json:"properties,omitempty"`
+}
+
+type sarifLocation struct {
+	PhysicalLocation sarifPhysicalLocation `json:"physicalLocation"`
+}
+
+type sarifPhysicalLocation struct {
+	ArtifactLocation sarifArtifactLocation `json:"artifactLocation"`
+	Region           sarifRegion           `json:"region"`
+}
+
+type sarifArtifactLocation struct {
+	URI string `json:"uri"`
+}
+
+type sarifRegion struct {
+	StartLine int `json:"startLine"`
+}
+
+type sarifLogicalLocation struct {
+	Name               string `json:"name"`
+	FullyQualifiedName string `json:"fullyQualifiedName"`
+	Kind               string `json:"kind"`
+}
+
+// writeSARIF writes a SARIF 2.1.0 log with one result per incompatible
+// (error) or unknown (warning) addon. Addons have no source file, so results
+// use logical locations named "namespace/name", plus the stable placeholder
+// file sarifArtifactURI that code scanning uploads require.
+func writeSARIF(w io.Writer, report CompatibilityReport) error {
+	addons, k8sVersion := report.Addons, report.K8sVersion
+	run := sarifRun{
+		Tool: sarifTool{Driver: sarifDriver{
+			Name:           "kaddons",
+			InformationURI: "https://github.com/qbandev/kaddons",
+			Rules: []sarifRule{
+				{
+					ID:                   sarifRuleIncompatible,
+					ShortDescription:     sarifMessage{Text: "Installed addon version does not support the cluster's Kubernetes version"},
+					DefaultConfiguration: sarifConfiguration{Level: "error"},
+				},
+				{
+					ID:                   sarifRuleUnknown,
+					ShortDescription:     sarifMessage{Text: "Addon compatibility with the cluster's Kubernetes version could not be determined"},
+					DefaultConfiguration: sarifConfiguration{Level: "warning"},
+				},
+			},
+		}},
+		Results:    []sarifResult{},
+		Properties: map[string]string{"k8sVersion": k8sVersion},
+	}
+
+	if report.Metadata != nil {
+		run.Tool.Driver.Version = report.Metadata.Tool.Version
+	}
+
+	for _, a := range addons {
+		var result sarifResult
+		switch a.Compatible {
+		case StatusTrue:
+			continue
+		case StatusFalse:
+			result = sarifResult{RuleID: sarifRuleIncompatible, Level: "error"}
+			result.Message.Text = fmt.Sprintf("%s %s is not compatible with Kubernetes %s.", a.Name, a.InstalledVersion, k8sVersion)
+		default:
+			result = sarifResult{RuleID: sarifRuleUnknown, Level: "warning"}
+			result.Message.Text = fmt.Sprintf("Compatibility of %s %s with Kubernetes %s is unknown.", a.Name, a.InstalledVersion, k8sVersion)
+		}
+		if a.LatestCompatibleVersion != "" {
+			result.Message.Text += " Latest compatible version: " + a.LatestCompatibleVersion + "."
+		}
+		if note := strings.TrimSpace(a.Note); note != "" {
+			result.Message.Text += " " + note
+		}
+
+		qualifiedName := a.Name
+		if a.Namespace != "" {
+			qualifiedName = a.Namespace + "/" + a.Name
+		}
+		result.Locations = []sarifLocation{{PhysicalLocation: sarifPhysicalLocation{
+			ArtifactLocation: sarifArtifactLocation{URI: sarifArtifactURI(a.Namespace, a.Name)},
+			Region:           sarifRegion{StartLine: 1},
+		}}}
+		result.LogicalLocations = []sarifLogicalLocation{{Name: a.Name, FullyQualifiedName: qualifiedName, Kind: "module"}}
+		result.Properties = map[string]string{
+			"namespace":        a.Namespace,
+			"installedVersion": a.InstalledVersion,
+			"dataSource":       a.DataSource,
+		}
+		run.Results = append(run.Results, result)
+	}
+
+	out, err := json.MarshalIndent(sarifLog{
+		Schema:  "https://json.schemastore.org/sarif-2.1.0.json",
+		Version: "2.1.0",
+		Runs:    []sarifRun{run},
+	}, "", "  ")
+	if err != nil {
+		return fmt.Errorf("marshaling SARIF report: %w", err)
+	}
+	_, err = fmt.Fprintln(w, string(out))
+	return err
+}
+
+// sarifArtifactURI returns the relative URI "kubernetes/<namespace>/<name>"
+// standing in for an addon's manifest, or "kubernetes/<name>" when the addon
+// has no namespace.
+func sarifArtifactURI(namespace string, name string) string {
+	if namespace == "" {
+		return "kubernetes/" + url.PathEscape(name)
+	}
+	return "kubernetes/" + url.PathEscape(namespace) + "/" + url.PathEscape(name)
+}
@@ -0,0 +1,130 @@
+package output
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestWriteSARIF(t *testing.T) {
+	var buf bytes.Buffer
+	if err := writeSARIF(&buf, CompatibilityReport{K8sVersion: "1.31", Addons: textTestAddons()}); err != nil {
+		t.Fatalf("writeSARIF error = %v", err)
+	}
+
+	var log sarifLog
+	if err := json.Unmarshal(buf.Bytes(), &log); err != nil {
+		t.Fatalf("SARIF output is not valid JSON: %v", err)
+	}
+	if log.Version != "2.1.0" || len(log.Runs) != 1 {
+		t.Fatalf("unexpected SARIF envelope: version %q, %d runs", log.Version, len(log.Runs))
+	}
+	run := log.Runs[0]
+	if run.Tool.Driver.Name != "kaddons" || len(run.Tool.Driver.Rules) != 2 {
+		t.Errorf("unexpected driver %+v", run.Tool.Driver)
+	}
+
+	// Compatible addons produce no results.
+	if len(run.Results) != 2 {
+		t.Fatalf("got %d results, want 2: %+v", len(run.Results), run.Results)
+	}
+	tests := []struct {
+		result   sarifResult
+		rule     string
+		level    string
+		location string
+		uri      string
+		message  string
+	}{
+		{run.Results[0], sarifRuleIncompatible, "error", "karpenter/karpenter", "kubernetes/karpenter/karpenter", "karpenter 0.37.0 is not compatible with Kubernetes 1.31. Upgrade to 1.0."},
+		{run.Results[1], sarifRuleUnknown, "warning", "keda/keda", "kubernetes/keda/keda", "Compatibility of keda 2.12.0 with Kubernetes 1.31 is unknown."},
+	}
+	for _, tt := range tests {
+		if tt.result.RuleID != tt.rule || tt.result.Level != tt.level {
+			t.Errorf("result rule/level = %s/%s, want %s/%s", tt.result.RuleID, tt.result.Level, tt.rule, tt.level)
+		}
+		if len(tt.result.LogicalLocations) != 1 || tt.result.LogicalLocations[0].FullyQualifiedName != tt.location {
+			t.Errorf("result locations = %+v, want %s", tt.result.LogicalLocations, tt.location)
+		}
+		if len(tt.result.Locations) != 1 || tt.result.Locations[0].PhysicalLocation.ArtifactLocation.URI != tt.uri {
+			t.Errorf("result physical locations = %+v, want %s", tt.result.Locations, tt.uri)
+		}
+		if !strings.HasPrefix(tt.result.Message.Text, tt.message) {
+			t.Errorf("message = %q, want prefix %q", tt.result.Message.Text, tt.message)
+		}
+	}
+}
+
+// TestWriteSARIF_CodeScanningFields checks the raw output for the fields a
+// GitHub code scanning upload requires of every result.
+func TestWriteSARIF_CodeScanningFields(t *testing.T) {
+	addons := append(textTestAddons(), AddonCompatibility{Name: "cluster-wide", InstalledVersion: "1.0.0", Compatible: StatusUnknown})
+	var buf bytes.Buffer
+	if err := writeSARIF(&buf, CompatibilityReport{K8sVersion: "1.31", Addons: addons}); err != nil {
+		t.Fatalf("writeSARIF error = %v", err)
+	}
+
+	var log struct {
+		Version string `json:"version"`
+		Runs    []struct {
+			Tool struct {
+				Driver struct {
+					Name string `json:"name"`
+				} `json:"driver"`
+			} `json:"tool"`
+			Results []struct {
+				RuleID  string `json:"ruleId"`
+				Message struct {
+					Text string `json:"text"`
+				} `json:"message"`
+				Locations []struct {
+					PhysicalLocation struct {
+						ArtifactLocation struct {
+							URI string `json:"uri"`
+						} `json:"artifactLocation"`
+						Region struct {
+							StartLine int `json:"startLine"`
+						} `json:"region"`
+					} `json:"physicalLocation"`
+				} `json:"locations"`
+			} `json:"results"`
+		} `json:"runs"`
+	}
+	if err := json.Unmarshal(buf.Bytes(), &log); err != nil {
+		t.Fatalf("SARIF output is not valid JSON: %v", err)
+	}
+	if log.Version != "2.1.0" || len(log.Runs) != 1 || log.Runs[0].Tool.Driver.Name == "" {
+		t.Fatalf("unexpected SARIF envelope:\n%s", buf.String())
+	}
+	results := log.Runs[0].Results
+	if len(results) != 3 {
+		t.Fatalf("got %d results, want 3", len(results))
+	}
+	for _, r := range results {
+		if r.RuleID == "" || r.Message.Text == "" || len(r.Locations) == 0 {
+			t.Errorf("result %+v lacks a rule, message or location", r)
+			continue
+		}
+		physical := r.Locations[0].PhysicalLocation
+		if uri := physical.ArtifactLocation.URI; uri == "" || strings.Contains(uri, "://") || strings.HasPrefix(uri, "/") {
+			t.Errorf("artifactLocation.uri = %q, want a relative URI", uri)
+		}
+		if physical.Region.StartLine < 1 {
+			t.Errorf("region.startLine = %d, want at least 1", physical.Region.StartLine)
+		}
+	}
+	if got := results[2].Locations[0].PhysicalLocation.ArtifactLocation.URI; got != "kubernetes/cluster-wide" {
+		t.Errorf("cluster-scoped addon uri = %q, want kubernetes/cluster-wide", got)
+	}
+}
+
+func TestWriteSARIF_NoFindings(t *testing.T) {
+	var buf bytes.Buffer
+	if err := writeSARIF(&buf, CompatibilityReport{K8sVersion: "1.31", Addons: []AddonCompatibility{{Name: "keda", Compatible: StatusTrue}}}); err != nil {
+		t.Fatalf("writeSARIF error = %v", err)
+	}
+	if !strings.Contains(buf.String(), `"results": []`) {
+		t.Errorf("expected an empty results array, got:\n%s", buf.String())
+	}
+}
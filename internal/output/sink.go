@@ -0,0 +1,172 @@
+package output
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// StdoutPath is the sink path that writes to stdout.
+const StdoutPath = "-"
+
+// DefaultHTMLPath is where a bare "html" sink writes when no path is given.
+const DefaultHTMLPath = "./kaddons-report.html"
+
+// Formats lists the supported report formats.
+var Formats = []string{"json", "html", "table", "markdown", "sarif", "csv", "xlsx", "prometheus"}
+
+// formatLabels name each format in "report written to" messages.
+var formatLabels = map[string]string{
+	"json":       "JSON",
+	"html":       "HTML",
+	"table":      "Table",
+	"markdown":   "Markdown",
+	"sarif":      "SARIF",
+	"csv":        "CSV",
+	"xlsx":       "XLSX",
+	"prometheus": "Prometheus",
+}
+
+// Sink is one destination for a rendered report.
+type Sink struct {
+	Format string
+	// Path is a file path, or StdoutPath.
+	Path string
+}
+
+// ParseSinks parses an --output value: either a single format ("json") or a
+// comma-separated list of format=path pairs
+// ("json=report.json,html=report.html,sarif=-"). A format without a path
+// writes to stdout, except html, which writes to htmlPath (DefaultHTMLPath
+// when empty), and xlsx, which writes to DefaultXLSXPath. At most one sink
+// may write to stdout and no two sinks may write to the same file.
+func ParseSinks(spec, htmlPath string) ([]Sink, error) {
+	if htmlPath == "" {
+		htmlPath = DefaultHTMLPath
+	}
+	var sinks []Sink
+	stdoutFormat := ""
+	files := make(map[string]string)
+	for _, item := range strings.Split(spec, ",") {
+		format, path, hasPath := strings.Cut(strings.TrimSpace(item), "=")
+		format = strings.ToLower(strings.TrimSpace(format))
+		path = strings.TrimSpace(path)
+		if _, ok := formatLabels[format]; !ok {
+			return nil, fmt.Errorf("unsupported output format %q (supported: %s)", format, strings.Join(Formats, ", "))
+		}
+		switch {
+		case hasPath && path == "":
+			return nil, fmt.Errorf("output %q: empty path (use %s for stdout)", item, StdoutPath)
+		case !hasPath && format == "html":
+			path = htmlPath
+		case !hasPath && format == "xlsx":
+			path = DefaultXLSXPath
+		case !hasPath:
+			path = StdoutPath
+		}
+
+		if path == StdoutPath {
+			if stdoutFormat != "" {
+				return nil, fmt.Errorf("output formats %s and %s both write to stdout; give one a file path", stdoutFormat, format)
+			}
+			stdoutFormat = format
+		} else {
+			key := filepath.Clean(path)
+			if other, ok := files[key]; ok {
+				return nil, fmt.Errorf("output formats %s and %s both write to %s", other, format, path)
+			}
+			files[key] = format
+		}
+		sinks = append(sinks, Sink{Format: format, Path: path})
+	}
+	return sinks, nil
+}
+
+// writeSink renders the report in the sink's format to stdout or to its file.
+// Color is only used for a table on a terminal.
+func writeSink(sink Sink, report CompatibilityReport) error {
+	if sink.Path == StdoutPath {
+		return renderReport(os.Stdout, sink.Format, report, useColor(os.Stdout))
+	}
+
+	err := writeOutputFile(sink.Path, func(w io.Writer) error {
+		return renderReport(w, sink.Format, report, false)
+	})
+	if err != nil {
+		return fmt.Errorf("writing %s report file: %w", formatLabels[sink.Format], err)
+	}
+	fmt.Fprintf(os.Stderr, "%s report written to %s\n", formatLabels[sink.Format], sink.Path)
+	return nil
+}
+
+func renderReport(w io.Writer, format string, report CompatibilityReport, color bool) error {
+	switch format {
+	case "json":
+		out, err := json.MarshalIndent(report, "", "  ")
+		if err != nil {
+			return fmt.Errorf("marshaling report: %w", err)
+		}
+		_, err = fmt.Fprintln(w, string(out))
+		return err
+	case "html":
+		return writeHTMLReport(w, report)
+	case "table":
+		return writeTable(w, report, color)
+	case "markdown":
+		return writeMarkdown(w, report)
+	case "sarif":
+		return writeSARIF(w, report)
+	case "csv":
+		return writeCSV(w, report.Addons, report.K8sVersion)
+	case "xlsx":
+		return writeXLSX(w, report.Addons, report.K8sVersion)
+	case "prometheus":
+		return writePrometheus(w, report)
+	default:
+		return fmt.Errorf("unsupported output format %q (supported: %s)", format, strings.Join(Formats, ", "))
+	}
+}
+
+// writeOutputFile renders into a temporary file next to path and renames it
+// into place, so readers such as the node exporter textfile collector never
+// see a partial report. The directory is created if needed and the file name
+// cannot escape it.
+func writeOutputFile(path string, render func(io.Writer) error) error {
+	path = filepath.Clean(path)
+	directory := filepath.Dir(path)
+	name := filepath.Base(path)
+	if name == "." || name == string(filepath.Separator) {
+		return fmt.Errorf("invalid output path: %s", path)
+	}
+	if err := os.MkdirAll(directory, 0o750); err != nil {
+		return fmt.Errorf("creating output directory: %w", err)
+	}
+	root, err := os.OpenRoot(directory)
+	if err != nil {
+		return fmt.Errorf("opening output directory root: %w", err)
+	}
+	defer func() { _ = root.Close() }()
+
+	tempName := "." + name + ".tmp"
+	file, err := root.OpenFile(tempName, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0o644)
+	if err != nil {
+		return err
+	}
+	if err := render(file); err != nil {
+		_ = file.Close()
+		_ = root.Remove(tempName)
+		return err
+	}
+	if err := file.Close(); err != nil {
+		_ = root.Remove(tempName)
+		return err
+	}
+	if err := root.Rename(tempName, name); err != nil {
+		_ = root.Remove(tempName)
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,145 @@
+package output
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseSinks(t *testing.T) {
+	tests := []struct {
+		name     string
+		spec     string
+		htmlPath string
+		want     []Sink
+		wantErr  string
+	}{
+		{name: "single json", spec: "json", want: []Sink{{Format: "json", Path: StdoutPath}}},
+		{name: "html uses output path", spec: "html", htmlPath: "out/r.html", want: []Sink{{Format: "html", Path: "out/r.html"}}},
+		{name: "html default path", spec: "html", want: []Sink{{Format: "html", Path: DefaultHTMLPath}}},
+		{
+			name: "multiple sinks",
+			spec: "json=report.json, HTML=report.html,sarif=-",
+			want: []Sink{
+				{Format: "json", Path: "report.json"},
+				{Format: "html", Path: "report.html"},
+				{Format: "sarif", Path: StdoutPath},
+			},
+		},
+		{name: "html to stdout", spec: "html=-", want: []Sink{{Format: "html", Path: StdoutPath}}},
+		{name: "xlsx default path", spec: "csv,xlsx", want: []Sink{{Format: "csv", Path: StdoutPath}, {Format: "xlsx", Path: DefaultXLSXPath}}},
+		{name: "unknown format", spec: "json,yaml=out.yaml", wantErr: `unsupported output format "yaml"`},
+		{name: "empty path", spec: "json=", wantErr: "empty path"},
+		{name: "two stdout sinks", spec: "json,table", wantErr: "both write to stdout"},
+		{name: "same file twice", spec: "json=out/r.txt,markdown=./out/r.txt", wantErr: "both write to ./out/r.txt"},
+		{name: "empty spec", spec: "", wantErr: "unsupported output format"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseSinks(tt.spec, tt.htmlPath)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ParseSinks() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFormatOutput_WritesEverySink(t *testing.T) {
+	dir := t.TempDir()
+	jsonPath := filepath.Join(dir, "report.json")
+	htmlPath := filepath.Join(dir, "nested", "report.html")
+	markdownPath := filepath.Join(dir, "report.md")
+	raw := `[{"name":"karpenter","namespace":"karpenter","installed_version":"0.37.0","compatible":"false"}]`
+
+	spec := "json=" + jsonPath + ",html=" + htmlPath + ",markdown=" + markdownPath
+	addons, err := FormatOutput(raw, "1.31", spec, "")
+	if err != nil {
+		t.Fatalf("FormatOutput(%s) error = %v", spec, err)
+	}
+	if len(addons) != 1 {
+		t.Fatalf("FormatOutput returned %d addons, want 1", len(addons))
+	}
+
+	data, err := os.ReadFile(jsonPath)
+	if err != nil {
+		t.Fatalf("reading JSON sink: %v", err)
+	}
+	var report CompatibilityReport
+	if err := json.Unmarshal(data, &report); err != nil {
+		t.Fatalf("JSON sink is not a report: %v", err)
+	}
+	if report.K8sVersion != "1.31" || len(report.Addons) != 1 || report.Addons[0].Compatible != StatusFalse {
+		t.Errorf("unexpected JSON report %+v", report)
+	}
+
+	for path, want := range map[string]string{
+		htmlPath:     "<td>karpenter</td>",
+		markdownPath: "| karpenter | karpenter | 0.37.0 | ❌ incompatible |",
+	} {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			t.Fatalf("reading sink %s: %v", path, err)
+		}
+		if !strings.Contains(string(data), want) {
+			t.Errorf("%s missing %q", filepath.Base(path), want)
+		}
+	}
+}
+
+func TestFormatOutput_FailedSinkDoesNotStopOthers(t *testing.T) {
+	dir := t.TempDir()
+	blocker := filepath.Join(dir, "blocker")
+	if err := os.WriteFile(blocker, nil, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	jsonPath := filepath.Join(dir, "report.json")
+	raw := `[{"name":"keda","compatible":"true"}]`
+
+	// A regular file used as a directory makes the html sink fail.
+	_, err := FormatOutput(raw, "1.31", "html="+filepath.Join(blocker, "r.html")+",json="+jsonPath, "")
+	if err == nil || !strings.Contains(err.Error(), "HTML report file") {
+		t.Fatalf("error = %v, want HTML report file error", err)
+	}
+	if _, err := os.Stat(jsonPath); err != nil {
+		t.Errorf("JSON sink not written after HTML sink failed: %v", err)
+	}
+}
+
+func TestWriteReport_ReplacesFileWithoutLeftovers(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "kaddons.prom")
+	if err := os.WriteFile(path, []byte("stale\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	report := CompatibilityReport{K8sVersion: "1.31", Addons: []AddonCompatibility{{Name: "keda", Compatible: StatusTrue}}}
+	if err := WriteReport(report, "prometheus="+path, ""); err != nil {
+		t.Fatalf("WriteReport error = %v", err)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(data), `kaddons_addon_compatible{name="keda"`) || strings.Contains(string(data), "stale") {
+		t.Errorf("file not replaced:\n%s", data)
+	}
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 1 {
+		t.Errorf("directory holds %d entries, want only the report", len(entries))
+	}
+}
@@ -0,0 +1,244 @@
+package output
+
+import (
+	"archive/zip"
+	"encoding/csv"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// DefaultXLSXPath is where a bare "xlsx" sink writes when no path is given.
+const DefaultXLSXPath = "./kaddons-report.xlsx"
+
+// spreadsheetColumns is the column order of CSV and XLSX exports. Columns are
+// only ever appended, so spreadsheets built on earlier exports keep working.
+var spreadsheetColumns = []string{
+	"k8s_version",
+	"name",
+	"namespace",
+	"installed_version",
+	"compatible",
+	"latest_compatible_version",
+	"data_source",
+	"source_url",
+	"note",
+	"latest_upstream_version",
+	"latest_upstream_release_date",
+	"minor_versions_behind",
+	"confidence",
+}
+
+// sourceURLColumn and minorsBehindColumn index spreadsheetColumns for cells
+// that XLSX writes as a hyperlink and a number.
+const (
+	sourceURLColumn    = 7
+	minorsBehindColumn = 11
+)
+
+// spreadsheetRows returns one row per addon instance, in spreadsheetColumns order.
+func spreadsheetRows(addons []AddonCompatibility, k8sVersion string) [][]string {
+	rows := make([][]string, 0, len(addons))
+	for _, a := range addons {
+		minorsBehind := ""
+		if a.MinorVersionsBehind != nil {
+			minorsBehind = strconv.Itoa(*a.MinorVersionsBehind)
+		}
+		confidence := ""
+		if a.Provenance != nil {
+			confidence = a.Provenance.Confidence
+		}
+		rows = append(rows, []string{
+			k8sVersion,
+			a.Name,
+			a.Namespace,
+			a.InstalledVersion,
+			string(a.Compatible),
+			a.LatestCompatibleVersion,
+			a.DataSource,
+			noteSourceURL(a.Note),
+			a.Note,
+			a.LatestUpstreamVersion,
+			a.LatestUpstreamReleaseDate,
+			minorsBehind,
+			confidence,
+		})
+	}
+	return rows
+}
+
+// noteSourceURL returns the URL a note cites after "Source: ", or else the
+// first URL in the note, without trailing sentence punctuation.
+func noteSourceURL(note string) string {
+	matches := reportURLPattern.FindAllStringIndex(note, -1)
+	if len(matches) == 0 {
+		return ""
+	}
+	chosen := matches[0]
+	for _, loc := range matches {
+		if strings.HasSuffix(note[:loc[0]], "Source: ") {
+			chosen = loc
+			break
+		}
+	}
+	return strings.TrimRight(note[chosen[0]:chosen[1]], ".,;:)")
+}
+
+// writeCSV writes an RFC 4180 CSV export: a header row, CRLF line endings and
+// quoting as needed. Cells that a spreadsheet would evaluate as a formula are
+// prefixed with a single quote.
+func writeCSV(w io.Writer, addons []AddonCompatibility, k8sVersion string) error {
+	writer := csv.NewWriter(w)
+	writer.UseCRLF = true
+	if err := writer.Write(spreadsheetColumns); err != nil {
+		return fmt.Errorf("writing CSV header: %w", err)
+	}
+	for _, row := range spreadsheetRows(addons, k8sVersion) {
+		for i, cell := range row {
+			row[i] = neutralizeFormula(cell)
+		}
+		if err := writer.Write(row); err != nil {
+			return fmt.Errorf("writing CSV row: %w", err)
+		}
+	}
+	writer.Flush()
+	return writer.Error()
+}
+
+// neutralizeFormula keeps spreadsheets from evaluating text that starts like
+// a formula, such as an LLM-written note beginning with "=".
+func neutralizeFormula(cell string) string {
+	if cell != "" && strings.ContainsRune("=+-@\t\r", rune(cell[0])) {
+		return "'" + cell
+	}
+	return cell
+}
+
+// writeXLSX writes a single-sheet Office Open XML workbook with a bold, frozen
+// header row, an autofilter, numeric minor_versions_behind cells and
+// clickable source URLs. Strings are stored inline, so no shared string
+// table is needed.
+func writeXLSX(w io.Writer, addons []AddonCompatibility, k8sVersion string) error {
+	rows := spreadsheetRows(addons, k8sVersion)
+	lastColumn := xlsxColumnName(len(spreadsheetColumns) - 1)
+	lastRow := strconv.Itoa(len(rows) + 1)
+
+	var sheet strings.Builder
+	var sheetRels strings.Builder
+	var hyperlinks strings.Builder
+	sheet.WriteString(xml.Header)
+	sheet.WriteString(`<worksheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main" xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships">`)
+	sheet.WriteString(`<sheetViews><sheetView workbookViewId="0"><pane ySplit="1" topLeftCell="A2" activePane="bottomLeft" state="frozen"/></sheetView></sheetViews>`)
+	sheet.WriteString(`<sheetData>`)
+	writeRow := func(rowIndex int, cells []string, header bool) {
+		fmt.Fprintf(&sheet, `<row r="%d">`, rowIndex+1)
+		for col, value := range cells {
+			if value == "" {
+				continue
+			}
+			ref := xlsxCellRef(col, rowIndex)
+			switch {
+			case header:
+				fmt.Fprintf(&sheet, `<c r="%s" t="inlineStr" s="1"><is><t>%s</t></is></c>`, ref, xmlText(value))
+			case col == minorsBehindColumn:
+				fmt.Fprintf(&sheet, `<c r="%s"><v>%s</v></c>`, ref, xmlText(value))
+			default:
+				fmt.Fprintf(&sheet, `<c r="%s" t="inlineStr"><is><t xml:space="preserve">%s</t></is></c>`, ref, xmlText(value))
+			}
+			if !header && col == sourceURLColumn {
+				id := fmt.Sprintf("rId%d", rowIndex)
+				fmt.Fprintf(&hyperlinks, `<hyperlink ref="%s" r:id="%s"/>`, ref, id)
+				fmt.Fprintf(&sheetRels, `<Relationship Id="%s" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/hyperlink" Target="%s" TargetMode="External"/>`, id, xmlText(value))
+			}
+		}
+		sheet.WriteString(`</row>`)
+	}
+	writeRow(0, spreadsheetColumns, true)
+	for i, row := range rows {
+		writeRow(i+1, row, false)
+	}
+	sheet.WriteString(`</sheetData>`)
+	fmt.Fprintf(&sheet, `<autoFilter ref="A1:%s%s"/>`, lastColumn, lastRow)
+	if hyperlinks.Len() > 0 {
+		fmt.Fprintf(&sheet, `<hyperlinks>%s</hyperlinks>`, hyperlinks.String())
+	}
+	sheet.WriteString(`</worksheet>`)
+
+	parts := []xlsxPart{
+		{"[Content_Types].xml", xml.Header + `<Types xmlns="http://schemas.openxmlformats.org/package/2006/content-types">` +
+			`<Default Extension="rels" ContentType="application/vnd.openxmlformats-package.relationships+xml"/>` +
+			`<Default Extension="xml" ContentType="application/xml"/>` +
+			`<Override PartName="/xl/workbook.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.sheet.main+xml"/>` +
+			`<Override PartName="/xl/worksheets/sheet1.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.worksheet+xml"/>` +
+			`<Override PartName="/xl/styles.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.styles+xml"/>` +
+			`</Types>`},
+		{"_rels/.rels", xml.Header + `<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">` +
+			`<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/officeDocument" Target="xl/workbook.xml"/>` +
+			`</Relationships>`},
+		{"xl/workbook.xml", xml.Header + `<workbook xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main" xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships">` +
+			`<sheets><sheet name="Compatibility" sheetId="1" r:id="rId1"/></sheets>` +
+			`<definedNames><definedName name="_xlnm._FilterDatabase" localSheetId="0" hidden="1">Compatibility!$A$1:$` + lastColumn + `$` + lastRow + `</definedName></definedNames>` +
+			`</workbook>`},
+		{"xl/_rels/workbook.xml.rels", xml.Header + `<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">` +
+			`<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/worksheet" Target="worksheets/sheet1.xml"/>` +
+			`<Relationship Id="rId2" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/styles" Target="styles.xml"/>` +
+			`</Relationships>`},
+		{"xl/styles.xml", xml.Header + `<styleSheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main">` +
+			`<fonts count="2"><font><sz val="11"/><name val="Calibri"/></font><font><b/><sz val="11"/><name val="Calibri"/></font></fonts>` +
+			`<fills count="2"><fill><patternFill patternType="none"/></fill><fill><patternFill patternType="gray125"/></fill></fills>` +
+			`<borders count="1"><border><left/><right/><top/><bottom/><diagonal/></border></borders>` +
+			`<cellStyleXfs count="1"><xf numFmtId="0" fontId="0" fillId="0" borderId="0"/></cellStyleXfs>` +
+			`<cellXfs count="2"><xf numFmtId="0" fontId="0" fillId="0" borderId="0" xfId="0"/><xf numFmtId="0" fontId="1" fillId="0" borderId="0" xfId="0" applyFont="1"/></cellXfs>` +
+			`</styleSheet>`},
+		{"xl/worksheets/sheet1.xml", sheet.String()},
+	}
+	if sheetRels.Len() > 0 {
+		parts = append(parts, xlsxPart{"xl/worksheets/_rels/sheet1.xml.rels", xml.Header + `<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">` + sheetRels.String() + `</Relationships>`})
+	}
+
+	archive := zip.NewWriter(w)
+	for _, part := range parts {
+		file, err := archive.CreateHeader(&zip.FileHeader{Name: part.name, Method: zip.Deflate})
+		if err != nil {
+			return fmt.Errorf("writing XLSX part %s: %w", part.name, err)
+		}
+		if _, err := io.WriteString(file, part.content); err != nil {
+			return fmt.Errorf("writing XLSX part %s: %w", part.name, err)
+		}
+	}
+	if err := archive.Close(); err != nil {
+		return fmt.Errorf("finishing XLSX archive: %w", err)
+	}
+	return nil
+}
+
+// xlsxPart is one file in the XLSX zip package.
+type xlsxPart struct {
+	name    string
+	content string
+}
+
+// xlsxCellRef returns the A1-style reference of a zero-based column and row.
+func xlsxCellRef(col, row int) string {
+	return xlsxColumnName(col) + strconv.Itoa(row+1)
+}
+
+// xlsxColumnName returns the letters of a zero-based column: A, ..., Z, AA, ...
+func xlsxColumnName(col int) string {
+	name := ""
+	for col >= 0 {
+		name = string(rune('A'+col%26)) + name
+		col = col/26 - 1
+	}
+	return name
+}
+
+// xmlText escapes s for XML character data and attributes. Characters XML
+// cannot represent become U+FFFD.
+func xmlText(s string) string {
+	var b strings.Builder
+	_ = xml.EscapeText(&b, []byte(s))
+	return b.String()
+}
@@ -0,0 +1,188 @@
+package output
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/csv"
+	"encoding/xml"
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/qbandev/kaddons/internal/addon"
+)
+
+func spreadsheetTestAddons() []AddonCompatibility {
+	behind := 3
+	return []AddonCompatibility{
+		{
+			Name: "cert-manager", Namespace: "cert-manager", InstalledVersion: "v1.14.2", Compatible: StatusTrue,
+			LatestCompatibleVersion: "v1.15.0", DataSource: DataSourceStored,
+			Note:                "Supported, see release notes, \"1.14\" line. Source: https://cert-manager.io/docs/releases/.",
+			MinorVersionsBehind: &behind, Provenance: &addon.Provenance{Confidence: addon.ConfidenceHigh},
+		},
+		{Name: "keda", Namespace: "keda", InstalledVersion: "2.12.0", Compatible: StatusUnknown, DataSource: DataSourceLocal, Note: "=HYPERLINK(\"x\")\nsecond line"},
+	}
+}
+
+func TestNoteSourceURL(t *testing.T) {
+	tests := []struct {
+		note string
+		want string
+	}{
+		{note: "See https://a.example/x and Source: https://b.example/y.", want: "https://b.example/y"},
+		{note: "Upgrade guide (https://a.example/upgrade).", want: "https://a.example/upgrade"},
+		{note: "No link here", want: ""},
+	}
+	for _, tt := range tests {
+		if got := noteSourceURL(tt.note); got != tt.want {
+			t.Errorf("noteSourceURL(%q) = %q, want %q", tt.note, got, tt.want)
+		}
+	}
+}
+
+func TestWriteCSV(t *testing.T) {
+	var buf bytes.Buffer
+	if err := writeCSV(&buf, spreadsheetTestAddons(), "1.31"); err != nil {
+		t.Fatalf("writeCSV error = %v", err)
+	}
+	if !strings.HasSuffix(buf.String(), "\r\n") || !strings.Contains(buf.String(), "minor_versions_behind,confidence\r\n") {
+		t.Errorf("expected CRLF line endings:\n%q", buf.String())
+	}
+
+	records, err := csv.NewReader(&buf).ReadAll()
+	if err != nil {
+		t.Fatalf("CSV does not parse back: %v", err)
+	}
+	if len(records) != 3 {
+		t.Fatalf("got %d records, want header and 2 rows", len(records))
+	}
+	if !reflect.DeepEqual(records[0], spreadsheetColumns) {
+		t.Errorf("header = %v, want %v", records[0], spreadsheetColumns)
+	}
+	want := []string{
+		"1.31", "cert-manager", "cert-manager", "v1.14.2", "true", "v1.15.0", "stored",
+		"https://cert-manager.io/docs/releases/",
+		"Supported, see release notes, \"1.14\" line. Source: https://cert-manager.io/docs/releases/.",
+		"", "", "3", "high",
+	}
+	if !reflect.DeepEqual(records[1], want) {
+		t.Errorf("row 1 = %q\nwant    %q", records[1], want)
+	}
+	if got := records[2][8]; got != "'=HYPERLINK(\"x\")\nsecond line" {
+		t.Errorf("formula-like note = %q, want it prefixed with a quote", got)
+	}
+}
+
+func TestWriteXLSX(t *testing.T) {
+	var buf bytes.Buffer
+	if err := writeXLSX(&buf, spreadsheetTestAddons(), "1.31"); err != nil {
+		t.Fatalf("writeXLSX error = %v", err)
+	}
+	archive, err := zip.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	if err != nil {
+		t.Fatalf("XLSX is not a zip archive: %v", err)
+	}
+
+	parts := make(map[string]string)
+	for _, file := range archive.File {
+		reader, err := file.Open()
+		if err != nil {
+			t.Fatalf("opening %s: %v", file.Name, err)
+		}
+		data, err := io.ReadAll(reader)
+		_ = reader.Close()
+		if err != nil {
+			t.Fatalf("reading %s: %v", file.Name, err)
+		}
+		parts[file.Name] = string(data)
+
+		decoder := xml.NewDecoder(bytes.NewReader(data))
+		for {
+			if _, err := decoder.Token(); err == io.EOF {
+				break
+			} else if err != nil {
+				t.Fatalf("%s is not well-formed XML: %v", file.Name, err)
+			}
+		}
+	}
+	for _, name := range []string{"[Content_Types].xml", "_rels/.rels", "xl/workbook.xml", "xl/_rels/workbook.xml.rels", "xl/styles.xml", "xl/worksheets/sheet1.xml", "xl/worksheets/_rels/sheet1.xml.rels"} {
+		if _, ok := parts[name]; !ok {
+			t.Errorf("XLSX is missing part %s", name)
+		}
+	}
+
+	var sheet struct {
+		Rows []struct {
+			Ref   string `xml:"r,attr"`
+			Cells []struct {
+				Ref    string `xml:"r,attr"`
+				Type   string `xml:"t,attr"`
+				Inline string `xml:"is>t"`
+				Value  string `xml:"v"`
+			} `xml:"c"`
+		} `xml:"sheetData>row"`
+		AutoFilter struct {
+			Ref string `xml:"ref,attr"`
+		} `xml:"autoFilter"`
+		Hyperlinks []struct {
+			Ref string `xml:"ref,attr"`
+		} `xml:"hyperlinks>hyperlink"`
+	}
+	if err := xml.Unmarshal([]byte(parts["xl/worksheets/sheet1.xml"]), &sheet); err != nil {
+		t.Fatalf("parsing sheet: %v", err)
+	}
+	if len(sheet.Rows) != 3 {
+		t.Fatalf("got %d rows, want 3", len(sheet.Rows))
+	}
+	if sheet.AutoFilter.Ref != "A1:M3" {
+		t.Errorf("autoFilter ref = %q, want A1:M3", sheet.AutoFilter.Ref)
+	}
+	if len(sheet.Hyperlinks) != 1 || sheet.Hyperlinks[0].Ref != "H2" {
+		t.Errorf("hyperlinks = %+v, want one on H2", sheet.Hyperlinks)
+	}
+
+	cells := make(map[string]string)
+	for _, row := range sheet.Rows {
+		for _, cell := range row.Cells {
+			value := cell.Inline
+			if cell.Type != "inlineStr" {
+				value = "number:" + cell.Value
+			}
+			cells[cell.Ref] = value
+		}
+	}
+	for ref, want := range map[string]string{
+		"A1": "k8s_version",
+		"B2": "cert-manager",
+		"H2": "https://cert-manager.io/docs/releases/",
+		"L2": "number:3",
+		"I3": "=HYPERLINK(\"x\")\nsecond line",
+	} {
+		if cells[ref] != want {
+			t.Errorf("cell %s = %q, want %q", ref, cells[ref], want)
+		}
+	}
+	if !strings.Contains(parts["xl/worksheets/_rels/sheet1.xml.rels"], `Target="https://cert-manager.io/docs/releases/" TargetMode="External"`) {
+		t.Errorf("hyperlink relationship missing:\n%s", parts["xl/worksheets/_rels/sheet1.xml.rels"])
+	}
+}
+
+func TestXLSXCellRef(t *testing.T) {
+	tests := []struct {
+		col, row int
+		want     string
+	}{
+		{0, 0, "A1"},
+		{25, 9, "Z10"},
+		{26, 0, "AA1"},
+		{701, 0, "ZZ1"},
+		{702, 0, "AAA1"},
+	}
+	for _, tt := range tests {
+		if got := xlsxCellRef(tt.col, tt.row); got != tt.want {
+			t.Errorf("xlsxCellRef(%d, %d) = %q, want %q", tt.col, tt.row, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,223 @@
+package output
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"text/tabwriter"
+)
+
+// ANSI colors for status group headings in table output.
+const (
+	ansiReset  = "\x1b[0m"
+	ansiBold   = "\x1b[1m"
+	ansiRed    = "\x1b[31m"
+	ansiGreen  = "\x1b[32m"
+	ansiYellow = "\x1b[33m"
+)
+
+// maxTableNoteRunes keeps table rows on one line in a typical terminal; the
+// full note is in the JSON, HTML and Markdown formats.
+const maxTableNoteRunes = 80
+
+// statusGroup is one section of table output. Incompatible addons come first
+// because they are what the reader has to act on.
+type statusGroup struct {
+	status Status
+	title  string
+	color  string
+}
+
+var tableGroups = []statusGroup{
+	{status: StatusFalse, title: "Incompatible", color: ansiRed},
+	{status: StatusUnknown, title: "Unknown", color: ansiYellow},
+	{status: StatusTrue, title: "Compatible", color: ansiGreen},
+}
+
+// useColor reports whether f is a terminal and NO_COLOR is unset.
+func useColor(f *os.File) bool {
+	if os.Getenv("NO_COLOR") != "" {
+		return false
+	}
+	info, err := f.Stat()
+	if err != nil {
+		return false
+	}
+	return info.Mode()&os.ModeCharDevice != 0
+}
+
+// writeTable writes an aligned plain-text report grouped by status. Color is
+// only applied to headings so column alignment is unaffected.
+func writeTable(w io.Writer, report CompatibilityReport, color bool) error {
+	addons, k8sVersion := report.Addons, report.K8sVersion
+	paint := func(code, text string) string {
+		if !color {
+			return text
+		}
+		return code + text + ansiReset
+	}
+
+	summary := Summarize(addons)
+	if _, err := fmt.Fprintf(w, "%s — %d compatible, %d incompatible, %d unknown\n",
+		paint(ansiBold, "Kubernetes "+k8sVersion), summary.Compatible, summary.Incompatible, summary.Unknown); err != nil {
+		return err
+	}
+	if support := describeClusterSupport(report.Cluster); support != "" {
+		if _, err := fmt.Fprintf(w, "Cluster support: %s\n", support); err != nil {
+			return err
+		}
+	}
+	for _, scope := range report.SkippedScopes {
+		if _, err := fmt.Fprintf(w, "%s %s\n", paint(ansiYellow, "Skipped"), DescribeSkippedScope(scope)); err != nil {
+			return err
+		}
+	}
+
+	for _, group := range tableGroups {
+		var members []AddonCompatibility
+		for _, a := range addons {
+			if a.Compatible == group.status || (group.status == StatusUnknown && a.Compatible != StatusTrue && a.Compatible != StatusFalse) {
+				members = append(members, a)
+			}
+		}
+		if len(members) == 0 {
+			continue
+		}
+		if _, err := fmt.Fprintf(w, "\n%s\n", paint(group.color+ansiBold, fmt.Sprintf("%s (%d)", group.title, len(members)))); err != nil {
+			return err
+		}
+		tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+		_, _ = fmt.Fprintln(tw, "  NAME\tNAMESPACE\tINSTALLED\tLATEST COMPATIBLE\tSOURCE\tNOTE")
+		for _, a := range members {
+			_, _ = fmt.Fprintf(tw, "  %s\t%s\t%s\t%s\t%s\t%s\n",
+				tableCell(a.Name), tableCell(a.Namespace), tableCell(a.InstalledVersion),
+				tableCell(a.LatestCompatibleVersion), tableCell(a.DataSource), truncateRunes(a.Note, maxTableNoteRunes))
+		}
+		if err := tw.Flush(); err != nil {
+			return err
+		}
+	}
+
+	if len(report.NodePools) == 0 {
+		return nil
+	}
+	if _, err := fmt.Fprintf(w, "\n%s\n", paint(ansiBold, fmt.Sprintf("Node pools (%d)", len(report.NodePools)))); err != nil {
+		return err
+	}
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	_, _ = fmt.Fprintln(tw, "  POOL\tNODES\tKUBELET\tCHECK\tSTATUS\tNOTE")
+	for _, pool := range report.NodePools {
+		for _, finding := range pool.Findings {
+			_, _ = fmt.Fprintf(tw, "  %s\t%d\t%s\t%s\t%s\t%s\n",
+				tableCell(pool.Name), pool.Nodes, tableCell(strings.Join(pool.KubeletVersions, ",")),
+				finding.Check, statusLabel(finding.Compatible), truncateRunes(finding.Note, maxTableNoteRunes))
+		}
+	}
+	return tw.Flush()
+}
+
+// statusLabel names a status in table output, as the HTML report does.
+func statusLabel(status Status) string {
+	switch status {
+	case StatusTrue:
+		return "compatible"
+	case StatusFalse:
+		return "incompatible"
+	default:
+		return "unknown"
+	}
+}
+
+func tableCell(value string) string {
+	value = strings.Join(strings.Fields(value), " ")
+	if value == "" {
+		return "-"
+	}
+	return value
+}
+
+func truncateRunes(value string, maxRunes int) string {
+	value = strings.Join(strings.Fields(value), " ")
+	runes := []rune(value)
+	if len(runes) <= maxRunes {
+		return value
+	}
+	return string(runes[:maxRunes-1]) + "…"
+}
+
+// writeMarkdown writes a GitHub-flavored Markdown report suitable for PR
+// comments. URLs in notes become links, found with the same pattern the HTML
+// report uses.
+func writeMarkdown(w io.Writer, report CompatibilityReport) error {
+	addons, k8sVersion := report.Addons, report.K8sVersion
+	summary := Summarize(addons)
+	var b strings.Builder
+	b.WriteString("## kaddons compatibility report\n\n")
+	fmt.Fprintf(&b, "Kubernetes version: %s\n\n", k8sVersion)
+	if support := describeClusterSupport(report.Cluster); support != "" {
+		fmt.Fprintf(&b, "Cluster support: %s\n\n", markdownCell(support))
+	}
+	if len(report.SkippedScopes) > 0 {
+		b.WriteString("**Skipped scopes** (addons there are not in this report):\n\n")
+		for _, scope := range report.SkippedScopes {
+			fmt.Fprintf(&b, "- %s\n", markdownCell(DescribeSkippedScope(scope)))
+		}
+		b.WriteString("\n")
+	}
+	fmt.Fprintf(&b, "**Compatible:** %d · **Incompatible:** %d · **Unknown:** %d\n\n",
+		summary.Compatible, summary.Incompatible, summary.Unknown)
+	b.WriteString("| Name | Namespace | Installed | Compatibility | Source | Latest Compatible | Notes |\n")
+	b.WriteString("|------|-----------|-----------|---------------|--------|-------------------|-------|\n")
+	for _, a := range addons {
+		fmt.Fprintf(&b, "| %s | %s | %s | %s | %s | %s | %s |\n",
+			markdownCell(a.Name), markdownCell(a.Namespace), markdownCell(a.InstalledVersion),
+			markdownStatus(a.Compatible), markdownCell(a.DataSource),
+			markdownCell(a.LatestCompatibleVersion), linkifyMarkdownNote(a.Note))
+	}
+	if len(report.NodePools) > 0 {
+		b.WriteString("\n### Node pools\n\n")
+		b.WriteString("| Pool | Nodes | Kubelet | Container Runtime | Check | Status | Notes |\n")
+		b.WriteString("|------|-------|---------|-------------------|-------|--------|-------|\n")
+		for _, pool := range report.NodePools {
+			for _, finding := range pool.Findings {
+				fmt.Fprintf(&b, "| %s | %d | %s | %s | %s | %s | %s |\n",
+					markdownCell(pool.Name), pool.Nodes, markdownCell(strings.Join(pool.KubeletVersions, ", ")),
+					markdownCell(strings.Join(pool.ContainerRuntimeVersions, ", ")), markdownCell(finding.Check),
+					markdownStatus(finding.Compatible), linkifyMarkdownNote(finding.Note))
+			}
+		}
+	}
+	if generated := describeGeneration(report.Metadata); generated != "" {
+		fmt.Fprintf(&b, "\n<sub>%s</sub>\n", markdownCell(generated))
+	}
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+func markdownStatus(status Status) string {
+	switch status {
+	case StatusTrue:
+		return "✅ compatible"
+	case StatusFalse:
+		return "❌ incompatible"
+	default:
+		return "❔ unknown"
+	}
+}
+
+// linkifyMarkdownNote turns URLs in a note into Markdown links labeled with
+// the URL minus its scheme, after escaping the rest for a table cell.
+func linkifyMarkdownNote(note string) string {
+	var b strings.Builder
+	last := 0
+	for _, loc := range reportURLPattern.FindAllStringIndex(note, -1) {
+		b.WriteString(markdownCell(note[last:loc[0]]))
+		url := note[loc[0]:loc[1]]
+		label := strings.TrimPrefix(strings.TrimPrefix(url, "https://"), "http://")
+		fmt.Fprintf(&b, "[%s](%s)", markdownCell(label), strings.ReplaceAll(url, "|", "%7C"))
+		last = loc[1]
+	}
+	b.WriteString(markdownCell(note[last:]))
+	return b.String()
+}
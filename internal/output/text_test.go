@@ -0,0 +1,236 @@
+package output
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func textTestAddons() []AddonCompatibility {
+	return []AddonCompatibility{
+		{Name: "cert-manager", Namespace: "cert-manager", InstalledVersion: "v1.14.2", Compatible: StatusTrue, DataSource: DataSourceStored, LatestCompatibleVersion: "v1.15.0"},
+		{Name: "karpenter", Namespace: "karpenter", InstalledVersion: "0.37.0", Compatible: StatusFalse, DataSource: DataSourceStored, Note: "Upgrade to 1.0. See https://karpenter.sh/docs/upgrading/compatibility/"},
+		{Name: "keda", Namespace: "keda", InstalledVersion: "2.12.0", Compatible: StatusUnknown, DataSource: DataSourceLocal, Note: "a|b"},
+		{Name: "metrics-server", Namespace: "kube-system", InstalledVersion: "v0.7.1", Compatible: StatusTrue, DataSource: DataSourceExtracted},
+	}
+}
+
+func TestSummarize(t *testing.T) {
+	got := Summarize(textTestAddons())
+	want := Summary{Compatible: 2, Incompatible: 1, Unknown: 1}
+	if got != want {
+		t.Errorf("Summarize() = %+v, want %+v", got, want)
+	}
+}
+
+func TestWriteTable(t *testing.T) {
+	tests := []struct {
+		name      string
+		color     bool
+		wantANSI  bool
+		wantLines []string
+	}{
+		{
+			name: "plain",
+			wantLines: []string{
+				"Kubernetes 1.31 — 2 compatible, 1 incompatible, 1 unknown",
+				"Incompatible (1)",
+				"Unknown (1)",
+				"Compatible (2)",
+			},
+		},
+		{
+			name:      "color",
+			color:     true,
+			wantANSI:  true,
+			wantLines: []string{ansiRed + ansiBold + "Incompatible (1)" + ansiReset},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			if err := writeTable(&buf, CompatibilityReport{K8sVersion: "1.31", Addons: textTestAddons()}, tt.color); err != nil {
+				t.Fatalf("writeTable error = %v", err)
+			}
+			out := buf.String()
+			for _, want := range tt.wantLines {
+				if !strings.Contains(out, want) {
+					t.Errorf("table missing %q:\n%s", want, out)
+				}
+			}
+			if got := strings.Contains(out, "\x1b["); got != tt.wantANSI {
+				t.Errorf("ANSI escapes present = %v, want %v", got, tt.wantANSI)
+			}
+
+			incompatible := strings.Index(out, "karpenter")
+			unknown := strings.Index(out, "keda")
+			compatible := strings.Index(out, "cert-manager")
+			if incompatible >= unknown || unknown >= compatible {
+				t.Errorf("groups out of order (incompatible, unknown, compatible):\n%s", out)
+			}
+		})
+	}
+}
+
+func TestWriteTable_AlignsColumns(t *testing.T) {
+	var buf bytes.Buffer
+	if err := writeTable(&buf, CompatibilityReport{K8sVersion: "1.31", Addons: textTestAddons()}, false); err != nil {
+		t.Fatalf("writeTable error = %v", err)
+	}
+	lines := strings.Split(buf.String(), "\n")
+	column := func(prefix, value string) int {
+		for _, line := range lines {
+			if strings.HasPrefix(line, prefix) {
+				return strings.Index(line, value)
+			}
+		}
+		return -1
+	}
+	certManager := column("  cert-manager", "v1.14.2")
+	metricsServer := column("  metrics-server", "v0.7.1")
+	if certManager < 0 || certManager != metricsServer {
+		t.Errorf("installed versions not aligned (%d vs %d):\n%s", certManager, metricsServer, buf.String())
+	}
+}
+
+func TestWriteMarkdown(t *testing.T) {
+	var buf bytes.Buffer
+	if err := writeMarkdown(&buf, CompatibilityReport{K8sVersion: "1.31", Addons: textTestAddons()}); err != nil {
+		t.Fatalf("writeMarkdown error = %v", err)
+	}
+	out := buf.String()
+	for _, want := range []string{
+		"Kubernetes version: 1.31",
+		"**Compatible:** 2 · **Incompatible:** 1 · **Unknown:** 1",
+		"| karpenter | karpenter | 0.37.0 | ❌ incompatible | stored |  | Upgrade to 1.0. See [karpenter.sh/docs/upgrading/compatibility/](https://karpenter.sh/docs/upgrading/compatibility/) |",
+		`| keda | keda | 2.12.0 | ❔ unknown | local |  | a\|b |`,
+		"| cert-manager | cert-manager | v1.14.2 | ✅ compatible | stored | v1.15.0 |  |",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("markdown missing %q:\n%s", want, out)
+		}
+	}
+}
+
+func TestDescribeClusterSupport(t *testing.T) {
+	days := func(n int) *int { return &n }
+	tests := []struct {
+		name    string
+		support *ClusterSupport
+		want    string
+	}{
+		{name: "none", want: ""},
+		{
+			name:    "standard",
+			support: &ClusterSupport{Version: "1.33", Product: "kubernetes", Status: SupportStandard, StandardSupportEnds: "2026-06-28", DaysRemaining: days(30), NextSupportedVersion: "1.34"},
+			want:    "kubernetes 1.33: standard support, 30 days left (until 2026-06-28); upgrade to 1.34",
+		},
+		{
+			name:    "extended",
+			support: &ClusterSupport{Version: "1.30", Product: "amazon-eks", Status: SupportExtended, StandardSupportEnds: "2025-07-23", ExtendedSupportEnds: "2026-07-23", DaysRemaining: days(144), NextSupportedVersion: "1.32"},
+			want:    "amazon-eks 1.30: extended support, 144 days left (until 2026-07-23); upgrade to 1.32",
+		},
+		{
+			name:    "ended",
+			support: &ClusterSupport{Version: "1.29", Product: "kubernetes", Status: SupportEnded, StandardSupportEnds: "2025-02-28", NextSupportedVersion: "1.33"},
+			want:    "kubernetes 1.29: end of life since 2025-02-28; upgrade to 1.33",
+		},
+		{
+			name:    "unknown",
+			support: &ClusterSupport{Version: "1.40", Product: "kubernetes", Status: SupportUnknown},
+			want:    "kubernetes 1.40: support status unknown",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := describeClusterSupport(tt.support); got != tt.want {
+				t.Errorf("describeClusterSupport() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+
+	var table bytes.Buffer
+	if err := writeTable(&table, metadataTestReport(), false); err != nil {
+		t.Fatalf("writeTable() error = %v", err)
+	}
+	if !strings.Contains(table.String(), "Cluster support: amazon-eks 1.31: extended support, 120 days left (until 2026-11-26); upgrade to 1.32\n") {
+		t.Errorf("table output missing cluster support line:\n%s", table.String())
+	}
+}
+
+func TestWriteNodePools(t *testing.T) {
+	report := metadataTestReport()
+
+	var table bytes.Buffer
+	if err := writeTable(&table, report, false); err != nil {
+		t.Fatalf("writeTable() error = %v", err)
+	}
+	for _, want := range []string{"Node pools (1)\n", "POOL", "kubelet_skew", "container_runtime_eol"} {
+		if !strings.Contains(table.String(), want) {
+			t.Errorf("table output missing %q:\n%s", want, table.String())
+		}
+	}
+
+	var markdown bytes.Buffer
+	if err := writeMarkdown(&markdown, report); err != nil {
+		t.Fatalf("writeMarkdown() error = %v", err)
+	}
+	if want := "| default | 3 | v1.28.15-eks-1552ad0, v1.31.2-eks-7f9249a | containerd://1.7.22 | kubelet_skew | ✅ compatible |"; !strings.Contains(markdown.String(), want) {
+		t.Errorf("markdown output missing %q:\n%s", want, markdown.String())
+	}
+
+	var html bytes.Buffer
+	if err := writeHTMLReport(&html, report); err != nil {
+		t.Fatalf("writeHTMLReport() error = %v", err)
+	}
+	for _, want := range []string{"<h2>Node pools</h2>", `<span class="status-chip status-unknown">unknown</span>`} {
+		if !strings.Contains(html.String(), want) {
+			t.Errorf("HTML output missing %q", want)
+		}
+	}
+
+	report.NodePools = nil
+	table.Reset()
+	if err := writeTable(&table, report, false); err != nil {
+		t.Fatalf("writeTable() error = %v", err)
+	}
+	if strings.Contains(table.String(), "Node pools") {
+		t.Errorf("table output without node pools has a node pools section:\n%s", table.String())
+	}
+}
+
+func TestDescribeSkippedScope(t *testing.T) {
+	tests := []struct {
+		scope SkippedScope
+		want  string
+	}{
+		{SkippedScope{Resource: "nodes", Reason: "list is forbidden"}, "nodes cluster-wide: list is forbidden"},
+		{SkippedScope{Resource: "deployments.apps", Namespace: "team-a", Reason: "list is forbidden"}, "deployments.apps in team-a: list is forbidden"},
+	}
+	for _, tt := range tests {
+		if got := DescribeSkippedScope(tt.scope); got != tt.want {
+			t.Errorf("DescribeSkippedScope() = %q, want %q", got, tt.want)
+		}
+	}
+
+	report := metadataTestReport()
+	var table, markdown, html bytes.Buffer
+	if err := writeTable(&table, report, false); err != nil {
+		t.Fatalf("writeTable() error = %v", err)
+	}
+	if err := writeMarkdown(&markdown, report); err != nil {
+		t.Fatalf("writeMarkdown() error = %v", err)
+	}
+	if err := writeHTMLReport(&html, report); err != nil {
+		t.Fatalf("writeHTMLReport() error = %v", err)
+	}
+	for name, out := range map[string]string{
+		"table":    table.String(),
+		"markdown": markdown.String(),
+		"html":     html.String(),
+	} {
+		if !strings.Contains(out, "subscriptions.operators.coreos.com in karpenter: list is forbidden") {
+			t.Errorf("%s output missing the skipped scope:\n%s", name, out)
+		}
+	}
+}
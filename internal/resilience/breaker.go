@@ -0,0 +1,117 @@
+package resilience
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// ErrCircuitOpen is returned for requests to a host whose circuit is open.
+var ErrCircuitOpen = errors.New("circuit open")
+
+// CircuitBreaker tracks consecutive failures per host (host:port as in URL.Host) and, once a host reaches
+// FailureThreshold, rejects requests to it for Cooldown. After the cooldown a
+// single probe request is let through: success closes the circuit, failure
+// opens it for another cooldown. A nil *CircuitBreaker allows everything, so
+// callers can hold an optional breaker without nil checks.
+//
+// One breaker is meant to be shared by every request of a process, so a host
+// that is down costs a handful of failed requests rather than one per URL.
+type CircuitBreaker struct {
+	FailureThreshold int
+	Cooldown         time.Duration
+	// Clock is used for cooldowns. Nil uses the system clock.
+	Clock Clock
+
+	mu    sync.Mutex
+	hosts map[string]*hostCircuit
+}
+
+type hostCircuit struct {
+	failures    int
+	openedAt    time.Time // zero while closed
+	probeSentAt time.Time // non-zero while a half-open probe is in flight
+}
+
+// NewCircuitBreaker returns a breaker that opens after failureThreshold
+// consecutive failures to a host and stays open for cooldown.
+func NewCircuitBreaker(failureThreshold int, cooldown time.Duration) *CircuitBreaker {
+	return &CircuitBreaker{FailureThreshold: failureThreshold, Cooldown: cooldown}
+}
+
+// Allow returns ErrCircuitOpen (wrapped with the host name) if requests to
+// host should not be attempted now.
+func (b *CircuitBreaker) Allow(host string) error {
+	if b == nil {
+		return nil
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	circuit := b.hosts[host]
+	if circuit == nil || circuit.openedAt.IsZero() {
+		return nil
+	}
+	now := clockOrSystem(b.Clock).Now()
+	// A probe whose outcome was never recorded (e.g. its caller was cancelled)
+	// stops blocking the host after one cooldown.
+	probeInFlight := !circuit.probeSentAt.IsZero() && now.Sub(circuit.probeSentAt) < b.Cooldown
+	if probeInFlight || now.Sub(circuit.openedAt) < b.Cooldown {
+		return fmt.Errorf("%w for host %s", ErrCircuitOpen, host)
+	}
+	circuit.probeSentAt = now
+	return nil
+}
+
+// Record reports the outcome of a request to host. Success resets the host's
+// failure count and closes its circuit.
+func (b *CircuitBreaker) Record(host string, success bool) {
+	if b == nil {
+		return
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.hosts == nil {
+		b.hosts = make(map[string]*hostCircuit)
+	}
+	circuit := b.hosts[host]
+	if circuit == nil {
+		circuit = &hostCircuit{}
+		b.hosts[host] = circuit
+	}
+
+	if success {
+		*circuit = hostCircuit{}
+		return
+	}
+	circuit.failures++
+	threshold := b.FailureThreshold
+	if threshold <= 0 {
+		threshold = 1
+	}
+	if !circuit.probeSentAt.IsZero() || circuit.failures >= threshold {
+		circuit.openedAt = clockOrSystem(b.Clock).Now()
+		circuit.probeSentAt = time.Time{}
+	}
+}
+
+// State reports "closed", "open" or "half-open" for host, for logging.
+func (b *CircuitBreaker) State(host string) string {
+	if b == nil {
+		return "closed"
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	circuit := b.hosts[host]
+	switch {
+	case circuit == nil || circuit.openedAt.IsZero():
+		return "closed"
+	case !circuit.probeSentAt.IsZero() || clockOrSystem(b.Clock).Now().Sub(circuit.openedAt) >= b.Cooldown:
+		return "half-open"
+	default:
+		return "open"
+	}
+}
@@ -0,0 +1,110 @@
+package resilience
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestCircuitBreaker_OpensAfterThresholdAndProbesAfterCooldown(t *testing.T) {
+	clock := newFakeClock()
+	breaker := &CircuitBreaker{FailureThreshold: 2, Cooldown: time.Minute, Clock: clock}
+
+	breaker.Record("github.com", false)
+	if err := breaker.Allow("github.com"); err != nil {
+		t.Fatalf("Allow() after 1 failure = %v, want nil", err)
+	}
+	breaker.Record("github.com", false)
+	if err := breaker.Allow("github.com"); !errors.Is(err, ErrCircuitOpen) {
+		t.Fatalf("Allow() after threshold = %v, want ErrCircuitOpen", err)
+	}
+	if err := breaker.Allow("example.com"); err != nil {
+		t.Fatalf("other hosts must be unaffected, got %v", err)
+	}
+
+	clock.Advance(time.Minute)
+	if got := breaker.State("github.com"); got != "half-open" {
+		t.Fatalf("State() after cooldown = %q, want half-open", got)
+	}
+	if err := breaker.Allow("github.com"); err != nil {
+		t.Fatalf("probe Allow() = %v, want nil", err)
+	}
+	if err := breaker.Allow("github.com"); !errors.Is(err, ErrCircuitOpen) {
+		t.Fatalf("second Allow() during probe = %v, want ErrCircuitOpen", err)
+	}
+
+	// A failed probe re-opens immediately, without waiting for the threshold.
+	breaker.Record("github.com", false)
+	if got := breaker.State("github.com"); got != "open" {
+		t.Fatalf("State() after failed probe = %q, want open", got)
+	}
+
+	clock.Advance(time.Minute)
+	if err := breaker.Allow("github.com"); err != nil {
+		t.Fatalf("probe Allow() = %v, want nil", err)
+	}
+	breaker.Record("github.com", true)
+	if got := breaker.State("github.com"); got != "closed" {
+		t.Fatalf("State() after successful probe = %q, want closed", got)
+	}
+}
+
+func TestCircuitBreaker_UnrecordedProbeExpires(t *testing.T) {
+	clock := newFakeClock()
+	breaker := &CircuitBreaker{FailureThreshold: 1, Cooldown: time.Minute, Clock: clock}
+	breaker.Record("github.com", false)
+
+	clock.Advance(time.Minute)
+	if err := breaker.Allow("github.com"); err != nil {
+		t.Fatalf("probe Allow() = %v, want nil", err)
+	}
+	clock.Advance(time.Minute)
+	if err := breaker.Allow("github.com"); err != nil {
+		t.Fatalf("Allow() after abandoned probe = %v, want nil", err)
+	}
+}
+
+func TestCircuitBreaker_NilAllowsEverything(t *testing.T) {
+	var breaker *CircuitBreaker
+	breaker.Record("github.com", false)
+	if err := breaker.Allow("github.com"); err != nil {
+		t.Fatalf("nil breaker Allow() = %v, want nil", err)
+	}
+}
+
+func TestDoHTTPRequestWithRetry_SharedBreakerStopsHammeringHost(t *testing.T) {
+	requestCount := 0
+	testServer := httptest.NewServer(http.HandlerFunc(func(responseWriter http.ResponseWriter, request *http.Request) {
+		requestCount++
+		responseWriter.WriteHeader(http.StatusBadGateway)
+	}))
+	defer testServer.Close()
+
+	clock := newFakeClock()
+	policy := RetryPolicy{
+		Attempts: 3,
+		Breaker:  &CircuitBreaker{FailureThreshold: 2, Cooldown: time.Minute, Clock: clock},
+		Clock:    clock,
+	}
+
+	request, _ := http.NewRequestWithContext(context.Background(), http.MethodGet, testServer.URL, nil)
+	response, err := DoHTTPRequestWithRetry(context.Background(), testServer.Client(), request, policy)
+	if err != nil {
+		t.Fatalf("first call error = %v, want last 502 response", err)
+	}
+	_ = response.Body.Close()
+	if response.StatusCode != http.StatusBadGateway || requestCount != 2 {
+		t.Fatalf("status = %d, requests = %d; want 502 after 2 requests", response.StatusCode, requestCount)
+	}
+
+	_, err = DoHTTPRequestWithRetry(context.Background(), testServer.Client(), request, policy)
+	if !errors.Is(err, ErrCircuitOpen) {
+		t.Fatalf("second call error = %v, want ErrCircuitOpen", err)
+	}
+	if requestCount != 2 {
+		t.Fatalf("requests = %d, want no request while the circuit is open", requestCount)
+	}
+}
@@ -6,21 +6,85 @@ import (
 	"fmt"
 	"io"
 	"math"
+	"math/rand/v2"
 	"net"
 	"net/http"
+	"strconv"
 	"strings"
+	"sync"
 	"time"
 )
 
-// RetryPolicy defines deterministic retry behavior (no jitter).
+// defaultMaxRetryAfter bounds how long a Retry-After header may make a caller
+// wait when the policy sets no MaxRetryAfter.
+const defaultMaxRetryAfter = 30 * time.Second
+
+// RetryPolicy defines retry behavior. The zero values of the optional fields
+// keep backoff deterministic with no jitter, no time budget and no breaker.
 type RetryPolicy struct {
 	Attempts     int
 	InitialDelay time.Duration
 	MaxDelay     time.Duration
 	Multiplier   float64
+
+	// Jitter shortens each backoff delay by a random fraction of up to Jitter
+	// (0.2 turns a 1s delay into 0.8s–1s), so concurrent callers spread out.
+	// Retry-After delays are never jittered.
+	Jitter float64
+	// Rand returns values in [0, 1) for jitter. Nil uses math/rand/v2; tests
+	// and reproducible runs pass SeededRand.
+	Rand func() float64
+	// MaxElapsed is the total time budget across all attempts and waits. A
+	// retry whose delay would exceed the remaining budget is not attempted.
+	MaxElapsed time.Duration
+	// MaxRetryAfter is the longest Retry-After the policy will honor; longer
+	// requests end retries. Zero means 30s.
+	MaxRetryAfter time.Duration
+	// Breaker, when set, is consulted per request host by DoHTTPRequestWithRetry.
+	Breaker *CircuitBreaker
+	// Clock is used for waits and budgets. Nil uses the system clock.
+	Clock Clock
+}
+
+// Clock abstracts time so retry waits and breaker cooldowns can be tested
+// without sleeping.
+type Clock interface {
+	Now() time.Time
+	After(d time.Duration) <-chan time.Time
+}
+
+type systemClock struct{}
+
+func (systemClock) Now() time.Time                         { return time.Now() }
+func (systemClock) After(d time.Duration) <-chan time.Time { return time.After(d) }
+
+func clockOrSystem(clock Clock) Clock {
+	if clock == nil {
+		return systemClock{}
+	}
+	return clock
+}
+
+// SeededRand returns a concurrency-safe source of values in [0, 1) that yields
+// the same sequence for the same seed.
+func SeededRand(seed uint64) func() float64 {
+	var mu sync.Mutex
+	source := rand.New(rand.NewPCG(seed, seed)) // #nosec G404 -- jitter does not need a cryptographic source
+	return func() float64 {
+		mu.Lock()
+		defer mu.Unlock()
+		return source.Float64()
+	}
 }
 
-// Retry executes fn with deterministic backoff until success, context cancellation,
+// RetryAfterError is implemented by errors that carry a server-requested
+// delay before the next attempt.
+type RetryAfterError interface {
+	error
+	RetryAfter() time.Duration
+}
+
+// Retry executes fn with backoff until success, context cancellation,
 // or retry budget exhaustion.
 func Retry(ctx context.Context, policy RetryPolicy, isRetryable func(error) bool, fn func(context.Context) error) error {
 	_, err := RetryWithResult(ctx, policy, isRetryable, func(callCtx context.Context) (struct{}, error) {
@@ -29,7 +93,8 @@ func Retry(ctx context.Context, policy RetryPolicy, isRetryable func(error) bool
 	return err
 }
 
-// RetryWithResult executes fn with deterministic backoff and returns fn result.
+// RetryWithResult executes fn with backoff and returns fn result. Errors that
+// implement RetryAfterError replace the computed backoff with the server's delay.
 func RetryWithResult[T any](
 	ctx context.Context,
 	policy RetryPolicy,
@@ -51,6 +116,9 @@ func RetryWithResult[T any](
 		policy.MaxDelay = 0
 	}
 
+	clock := clockOrSystem(policy.Clock)
+	start := clock.Now()
+
 	var lastErr error
 	for attempt := 1; attempt <= attempts; attempt++ {
 		result, err := fn(ctx)
@@ -62,26 +130,78 @@ func RetryWithResult[T any](
 			return zero, err
 		}
 
-		delay := policy.backoff(attempt)
+		delay, ok := policy.delay(attempt, err)
+		if !ok {
+			return zero, err
+		}
+		if policy.MaxElapsed > 0 && clock.Now().Sub(start)+delay > policy.MaxElapsed {
+			return zero, err
+		}
 		if delay <= 0 {
 			continue
 		}
-		timer := time.NewTimer(delay)
 		select {
 		case <-ctx.Done():
-			if !timer.Stop() {
-				select {
-				case <-timer.C:
-				default:
-				}
-			}
 			return zero, ctx.Err()
-		case <-timer.C:
+		case <-clock.After(delay):
 		}
 	}
 	return zero, lastErr
 }
 
+// delay returns the wait before the attempt after a failed one. ok is false
+// when the server asked for a longer wait than the policy honors.
+func (policy RetryPolicy) delay(attempt int, err error) (time.Duration, bool) {
+	var retryAfterErr RetryAfterError
+	if errors.As(err, &retryAfterErr) && retryAfterErr.RetryAfter() > 0 {
+		maxRetryAfter := policy.MaxRetryAfter
+		if maxRetryAfter <= 0 {
+			maxRetryAfter = defaultMaxRetryAfter
+		}
+		if retryAfterErr.RetryAfter() > maxRetryAfter {
+			return 0, false
+		}
+		return retryAfterErr.RetryAfter(), true
+	}
+	return policy.jitter(policy.backoff(attempt)), true
+}
+
+// jitter shortens delay by up to policy.Jitter of its length.
+func (policy RetryPolicy) jitter(delay time.Duration) time.Duration {
+	if policy.Jitter <= 0 || delay <= 0 {
+		return delay
+	}
+	fraction := math.Min(policy.Jitter, 1)
+	random := policy.Rand
+	if random == nil {
+		random = rand.Float64 // #nosec G404 -- jitter does not need a cryptographic source
+	}
+	return delay - time.Duration(float64(delay)*fraction*random())
+}
+
+// ParseRetryAfter parses a Retry-After header value, either delay-seconds or
+// an HTTP-date relative to now. ok is false for empty or malformed values.
+func ParseRetryAfter(value string, now time.Time) (time.Duration, bool) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return 0, false
+	}
+	if seconds, err := strconv.Atoi(value); err == nil {
+		if seconds < 0 {
+			return 0, false
+		}
+		return time.Duration(seconds) * time.Second, true
+	}
+	at, err := http.ParseTime(value)
+	if err != nil {
+		return 0, false
+	}
+	if delay := at.Sub(now); delay > 0 {
+		return delay, true
+	}
+	return 0, true
+}
+
 func (policy RetryPolicy) backoff(attempt int) time.Duration {
 	if attempt <= 0 || policy.InitialDelay <= 0 {
 		return 0
@@ -123,34 +243,63 @@ func IsRetryableNetworkError(err error) bool {
 }
 
 // DoHTTPRequestWithRetry performs an HTTP request with the given retry policy.
-// Retryable HTTP statuses (429/5xx) are retried until the attempt budget is exhausted.
+// Retryable HTTP statuses (429/5xx) are retried, honoring Retry-After, until the
+// attempt or time budget is exhausted; the last such response is then returned
+// to the caller. With policy.Breaker set, requests to a host whose circuit is
+// open fail immediately with ErrCircuitOpen.
 func DoHTTPRequestWithRetry(
 	ctx context.Context,
 	client *http.Client,
 	request *http.Request,
 	policy RetryPolicy,
 ) (*http.Response, error) {
-	effectiveAttempts := policy.Attempts
-	if effectiveAttempts <= 0 {
-		effectiveAttempts = 1
+	clock := clockOrSystem(policy.Clock)
+	host := request.URL.Host
+	// pending is the last retryable-status response; its body stays open in case
+	// no further attempt is made and it has to be returned.
+	var pending *http.Response
+	closePending := func() {
+		if pending != nil {
+			_ = pending.Body.Close()
+			pending = nil
+		}
 	}
-	attemptCounter := 0
-	return RetryWithResult(ctx, policy, IsRetryableHTTPRequestError, func(callCtx context.Context) (*http.Response, error) {
-		attemptCounter++
+
+	response, err := RetryWithResult(ctx, policy, IsRetryableHTTPRequestError, func(callCtx context.Context) (*http.Response, error) {
+		if err := policy.Breaker.Allow(host); err != nil {
+			return nil, err
+		}
+		closePending()
 		requestForAttempt := request.Clone(callCtx)
 		response, err := client.Do(requestForAttempt) // #nosec G704 -- caller controls URL validation and request construction
 		if err != nil {
+			// Only transient failures say the host is struggling; DNS or TLS
+			// errors are permanent per URL and are reported as such by callers.
+			if IsRetryableNetworkError(err) {
+				policy.Breaker.Record(host, false)
+			}
 			return nil, err
 		}
+		policy.Breaker.Record(host, !IsRetryableHTTPStatus(response.StatusCode))
 		if IsRetryableHTTPStatus(response.StatusCode) {
-			if attemptCounter >= effectiveAttempts {
-				return response, nil
-			}
-			_ = response.Body.Close()
-			return nil, retryableHTTPStatusError{statusCode: response.StatusCode}
+			pending = response
+			statusErr := retryableHTTPStatusError{statusCode: response.StatusCode}
+			statusErr.retryAfter, _ = ParseRetryAfter(response.Header.Get("Retry-After"), clock.Now())
+			return nil, statusErr
 		}
 		return response, nil
 	})
+	if err != nil {
+		// Out of attempts, budget or breaker allowance: hand back the last
+		// 429/5xx response so callers see the real status.
+		var statusErr retryableHTTPStatusError
+		if pending != nil && (errors.As(err, &statusErr) || errors.Is(err, ErrCircuitOpen)) {
+			return pending, nil
+		}
+		closePending()
+		return nil, err
+	}
+	return response, nil
 }
 
 // IsRetryableHTTPRequestError classifies retryable transport/status errors for HTTP wrappers.
@@ -164,8 +313,13 @@ func IsRetryableHTTPRequestError(err error) bool {
 
 type retryableHTTPStatusError struct {
 	statusCode int
+	retryAfter time.Duration
 }
 
 func (err retryableHTTPStatusError) Error() string {
 	return fmt.Sprintf("retryable HTTP status %d", err.statusCode)
 }
+
+func (err retryableHTTPStatusError) RetryAfter() time.Duration {
+	return err.retryAfter
+}
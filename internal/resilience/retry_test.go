@@ -5,6 +5,8 @@ import (
 	"errors"
 	"net/http"
 	"net/http/httptest"
+	"reflect"
+	"sync"
 	"testing"
 	"time"
 )
@@ -140,3 +142,177 @@ var (
 	errRetryable = errors.New("retryable")
 	errTerminal  = errors.New("terminal")
 )
+
+// fakeClock advances instantly on After and records every wait.
+type fakeClock struct {
+	mu    sync.Mutex
+	now   time.Time
+	waits []time.Duration
+}
+
+func newFakeClock() *fakeClock {
+	return &fakeClock{now: time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)}
+}
+
+func (c *fakeClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+func (c *fakeClock) After(d time.Duration) <-chan time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = c.now.Add(d)
+	c.waits = append(c.waits, d)
+	fired := make(chan time.Time, 1)
+	fired <- c.now
+	return fired
+}
+
+func (c *fakeClock) Advance(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = c.now.Add(d)
+}
+
+func TestParseRetryAfter(t *testing.T) {
+	now := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name   string
+		value  string
+		want   time.Duration
+		wantOK bool
+	}{
+		{"delay seconds", "7", 7 * time.Second, true},
+		{"http date", "Thu, 01 Jan 2026 12:00:30 GMT", 30 * time.Second, true},
+		{"date in the past", "Thu, 01 Jan 2026 11:00:00 GMT", 0, true},
+		{"empty", "", 0, false},
+		{"negative", "-5", 0, false},
+		{"garbage", "soon", 0, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := ParseRetryAfter(tt.value, now)
+			if got != tt.want || ok != tt.wantOK {
+				t.Errorf("ParseRetryAfter(%q) = %v, %v; want %v, %v", tt.value, got, ok, tt.want, tt.wantOK)
+			}
+		})
+	}
+}
+
+func TestDoHTTPRequestWithRetry_HonorsRetryAfter(t *testing.T) {
+	requestCount := 0
+	testServer := httptest.NewServer(http.HandlerFunc(func(responseWriter http.ResponseWriter, request *http.Request) {
+		requestCount++
+		if requestCount == 1 {
+			responseWriter.Header().Set("Retry-After", "7")
+			responseWriter.WriteHeader(http.StatusTooManyRequests)
+			return
+		}
+		responseWriter.WriteHeader(http.StatusOK)
+	}))
+	defer testServer.Close()
+
+	clock := newFakeClock()
+	request, _ := http.NewRequestWithContext(context.Background(), http.MethodGet, testServer.URL, nil)
+	response, err := DoHTTPRequestWithRetry(context.Background(), testServer.Client(), request, RetryPolicy{
+		Attempts:     3,
+		InitialDelay: 100 * time.Millisecond,
+		Clock:        clock,
+	})
+	if err != nil {
+		t.Fatalf("DoHTTPRequestWithRetry() error = %v", err)
+	}
+	_ = response.Body.Close()
+
+	if response.StatusCode != http.StatusOK {
+		t.Fatalf("status = %d, want 200", response.StatusCode)
+	}
+	if len(clock.waits) != 1 || clock.waits[0] != 7*time.Second {
+		t.Fatalf("waits = %v, want [7s] from Retry-After", clock.waits)
+	}
+}
+
+func TestDoHTTPRequestWithRetry_RetryAfterBeyondLimitReturnsResponse(t *testing.T) {
+	requestCount := 0
+	testServer := httptest.NewServer(http.HandlerFunc(func(responseWriter http.ResponseWriter, request *http.Request) {
+		requestCount++
+		responseWriter.Header().Set("Retry-After", "3600")
+		responseWriter.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer testServer.Close()
+
+	clock := newFakeClock()
+	request, _ := http.NewRequestWithContext(context.Background(), http.MethodGet, testServer.URL, nil)
+	response, err := DoHTTPRequestWithRetry(context.Background(), testServer.Client(), request, RetryPolicy{
+		Attempts: 3,
+		Clock:    clock,
+	})
+	if err != nil {
+		t.Fatalf("DoHTTPRequestWithRetry() error = %v", err)
+	}
+	_ = response.Body.Close()
+
+	if requestCount != 1 || len(clock.waits) != 0 {
+		t.Fatalf("requests = %d, waits = %v; want a single request and no wait", requestCount, clock.waits)
+	}
+	if response.StatusCode != http.StatusServiceUnavailable {
+		t.Fatalf("status = %d, want 503", response.StatusCode)
+	}
+}
+
+func TestRetryWithResult_SeededJitterIsReproducible(t *testing.T) {
+	run := func() []time.Duration {
+		clock := newFakeClock()
+		policy := RetryPolicy{
+			Attempts:     4,
+			InitialDelay: time.Second,
+			Multiplier:   2,
+			Jitter:       0.5,
+			Rand:         SeededRand(42),
+			Clock:        clock,
+		}
+		_, _ = RetryWithResult(context.Background(), policy, func(err error) bool { return true },
+			func(ctx context.Context) (string, error) { return "", errRetryable })
+		return clock.waits
+	}
+
+	first, second := run(), run()
+	if !reflect.DeepEqual(first, second) {
+		t.Fatalf("same seed produced different waits: %v vs %v", first, second)
+	}
+	if len(first) != 3 {
+		t.Fatalf("waits = %v, want 3", first)
+	}
+	for i, wait := range first {
+		base := time.Second << i
+		if wait < base/2 || wait > base {
+			t.Errorf("wait %d = %v, want within [%v, %v]", i, wait, base/2, base)
+		}
+	}
+}
+
+func TestRetryWithResult_StopsWhenBudgetExhausted(t *testing.T) {
+	clock := newFakeClock()
+	policy := RetryPolicy{
+		Attempts:     5,
+		InitialDelay: time.Second,
+		Multiplier:   2,
+		MaxElapsed:   2500 * time.Millisecond,
+		Clock:        clock,
+	}
+	attempts := 0
+	_, err := RetryWithResult(context.Background(), policy, func(err error) bool { return true },
+		func(ctx context.Context) (string, error) {
+			attempts++
+			return "", errRetryable
+		})
+	if !errors.Is(err, errRetryable) {
+		t.Fatalf("error = %v, want last attempt error", err)
+	}
+	// 1s wait fits, the next 2s wait would end at 3s > 2.5s.
+	if attempts != 2 || !reflect.DeepEqual(clock.waits, []time.Duration{time.Second}) {
+		t.Fatalf("attempts = %d, waits = %v; want 2 attempts and [1s]", attempts, clock.waits)
+	}
+}
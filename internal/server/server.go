@@ -0,0 +1,238 @@
+package server
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/qbandev/kaddons/internal/addon"
+	"github.com/qbandev/kaddons/internal/agent"
+	"github.com/qbandev/kaddons/internal/cluster"
+	"github.com/qbandev/kaddons/internal/metrics"
+	"github.com/qbandev/kaddons/internal/output"
+)
+
+const (
+	// maxRequestBytes bounds POST /v1/check bodies; a few hundred addons fit easily.
+	maxRequestBytes = 1 << 20
+	// maxCheckAddons bounds the addons per check so one request cannot queue
+	// hundreds of page fetches.
+	maxCheckAddons = 200
+	// maxConcurrentChecks follows the semaphore size used for other
+	// concurrent fetch work in the repo.
+	maxConcurrentChecks = 10
+)
+
+var k8sVersionRe = regexp.MustCompile(`^v?\d+\.\d+(?:\.\d+)?$`)
+
+// Server answers compatibility checks over HTTP. Verdicts come from stored
+// data and deterministic extraction only; addons neither can resolve are
+// reported as unknown, so requests never wait on an LLM.
+type Server struct {
+	matcher *addon.Matcher
+	options agent.Options
+	addons  int
+	checks  chan struct{}
+	mux     *http.ServeMux
+
+	registry *metrics.Registry
+	requests *metrics.CounterVec
+	verdicts *metrics.CounterVec
+}
+
+// CheckRequest is the body of POST /v1/check.
+type CheckRequest struct {
+	K8sVersion string       `json:"k8s_version"`
+	Addons     []CheckAddon `json:"addons"`
+}
+
+// CheckAddon is one addon version to check.
+type CheckAddon struct {
+	Name      string `json:"name"`
+	Version   string `json:"version"`
+	Namespace string `json:"namespace,omitempty"`
+}
+
+type errorResponse struct {
+	Error string `json:"error"`
+}
+
+type healthResponse struct {
+	Status string `json:"status"`
+	Addons int    `json:"addons"`
+}
+
+// New returns a server over the given addon database. opts supplies the page
+// and upstream release caches shared across requests; its API key is ignored
+// and EOL lookups are skipped, as both only matter for LLM analysis.
+func New(addons []addon.Addon, opts agent.Options) *Server {
+	opts.APIKey = ""
+	opts.SkipEOL = true
+	s := &Server{
+		matcher: addon.NewMatcher(addons),
+		options: opts,
+		addons:  len(addons),
+		checks:  make(chan struct{}, maxConcurrentChecks),
+		mux:     http.NewServeMux(),
+
+		registry: metrics.NewRegistry(),
+	}
+	s.requests = s.registry.NewCounterVec("kaddons_http_requests_total",
+		"HTTP requests served, by route pattern and status code.", "route", "code")
+	s.verdicts = s.registry.NewCounterVec("kaddons_server_verdicts_total",
+		"Addon verdicts returned by /v1/check, by compatibility status.", "status")
+	for _, status := range []output.Status{output.StatusTrue, output.StatusFalse, output.StatusUnknown} {
+		s.verdicts.Add(0, string(status))
+	}
+	s.mux.HandleFunc("POST /v1/check", s.handleCheck)
+	s.mux.HandleFunc("GET /v1/addons/{name}", s.handleAddon)
+	s.mux.HandleFunc("GET /v1/healthz", s.handleHealth)
+	s.mux.HandleFunc("GET /metrics", s.handleMetrics)
+	return s
+}
+
+// ServeHTTP implements http.Handler. Every request is counted by the route
+// pattern it matched, which keeps the label set bounded.
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	recorder := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+	s.mux.ServeHTTP(recorder, r)
+	route := r.Pattern
+	if route == "" {
+		route = "unmatched"
+	}
+	s.requests.Inc(route, strconv.Itoa(recorder.status))
+}
+
+// statusRecorder remembers the status code a handler wrote.
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+}
+
+func (r *statusRecorder) WriteHeader(status int) {
+	r.status = status
+	r.ResponseWriter.WriteHeader(status)
+}
+
+// handleMetrics serves the Prometheus text exposition format: database and
+// build details, request and verdict counters, and the pipeline counters
+// shared with the CLI.
+func (s *Server) handleMetrics(w http.ResponseWriter, _ *http.Request) {
+	tool := s.options.Tool
+	families := []metrics.Family{
+		metrics.Gauge("kaddons_build_info", "Version of the running kaddons server.", 1,
+			metrics.Label{Name: "version", Value: tool.Version}, metrics.Label{Name: "commit", Value: tool.Commit}),
+		metrics.Gauge("kaddons_database_entries", "Addons in the embedded compatibility database.", float64(s.addons)),
+	}
+	families = append(families, s.registry.Families()...)
+	families = append(families, metrics.Default.Families()...)
+	w.Header().Set("Content-Type", metrics.ContentType)
+	_ = metrics.WriteFamilies(w, families)
+}
+
+func (s *Server) handleHealth(w http.ResponseWriter, _ *http.Request) {
+	writeJSON(w, http.StatusOK, healthResponse{Status: "ok", Addons: s.addons})
+}
+
+func (s *Server) handleAddon(w http.ResponseWriter, r *http.Request) {
+	name := strings.TrimSpace(r.PathValue("name"))
+	// A database lookup by name: fuzzy matching would answer with a
+	// different addon for a partial or misspelt name.
+	match, ok := s.matcher.Lookup(name)
+	if !ok {
+		writeError(w, http.StatusNotFound, fmt.Sprintf("addon %q not found", name))
+		return
+	}
+	writeJSON(w, http.StatusOK, match)
+}
+
+func (s *Server) handleCheck(w http.ResponseWriter, r *http.Request) {
+	var req CheckRequest
+	decoder := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxRequestBytes))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&req); err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			writeError(w, http.StatusRequestEntityTooLarge, "request body too large")
+			return
+		}
+		writeError(w, http.StatusBadRequest, fmt.Sprintf("invalid request body: %v", err))
+		return
+	}
+	detected, err := req.validate()
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	select {
+	case s.checks <- struct{}{}:
+		defer func() { <-s.checks }()
+	case <-r.Context().Done():
+		writeError(w, http.StatusServiceUnavailable, "request cancelled while waiting for a check slot")
+		return
+	}
+
+	k8sVersion := strings.TrimPrefix(req.K8sVersion, "v")
+	start := time.Now()
+	results, err := agent.Check(r.Context(), s.options, s.matcher, k8sVersion, detected)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	metrics.ObservePhase(agent.PhaseResolution, start)
+	for _, result := range results {
+		status := result.Compatible
+		if status != output.StatusTrue && status != output.StatusFalse {
+			status = output.StatusUnknown
+		}
+		s.verdicts.Inc(string(status))
+	}
+	writeJSON(w, http.StatusOK, output.CompatibilityReport{K8sVersion: k8sVersion, Addons: results})
+}
+
+// validate checks the request and converts it to the detected-addon form the
+// agent pipeline takes.
+func (req CheckRequest) validate() ([]cluster.DetectedAddon, error) {
+	req.K8sVersion = strings.TrimSpace(req.K8sVersion)
+	if !k8sVersionRe.MatchString(req.K8sVersion) {
+		return nil, fmt.Errorf("k8s_version must look like 1.31, got %q", req.K8sVersion)
+	}
+	if len(req.Addons) == 0 {
+		return nil, errors.New("addons must list at least one addon")
+	}
+	if len(req.Addons) > maxCheckAddons {
+		return nil, fmt.Errorf("addons lists %d entries; the limit is %d", len(req.Addons), maxCheckAddons)
+	}
+	detected := make([]cluster.DetectedAddon, 0, len(req.Addons))
+	for i, a := range req.Addons {
+		name := strings.TrimSpace(a.Name)
+		if name == "" {
+			return nil, fmt.Errorf("addons[%d].name is required", i)
+		}
+		detected = append(detected, cluster.DetectedAddon{
+			Name:      name,
+			Namespace: strings.TrimSpace(a.Namespace),
+			Version:   strings.TrimSpace(a.Version),
+			Source:    "api",
+		})
+	}
+	return detected, nil
+}
+
+func writeJSON(w http.ResponseWriter, status int, value any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	_ = encoder.Encode(value)
+}
+
+func writeError(w http.ResponseWriter, status int, message string) {
+	writeJSON(w, status, errorResponse{Error: message})
+}
@@ -0,0 +1,220 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/qbandev/kaddons/internal/addon"
+	"github.com/qbandev/kaddons/internal/agent"
+	"github.com/qbandev/kaddons/internal/fetch"
+	"github.com/qbandev/kaddons/internal/output"
+)
+
+const widgetPage = `# Widget
+
+## Compatibility
+
+| Widget | 1.29 | 1.30 | 1.31 |
+|--------|------|------|------|
+| 2.1    | ✓    | ✓    | ✓    |
+| 2.0    | ✓    | ✓    |      |
+`
+
+// newTestServer builds a server over a small database. Neither addon has a
+// repository, and the widget page comes from an in-memory fetcher, so no test
+// touches the network.
+func newTestServer(t *testing.T) *Server {
+	t.Helper()
+	addons := []addon.Addon{
+		{
+			Name:                    "cert-manager",
+			ProjectURL:              "https://cert-manager.io",
+			CompatibilityMatrixURL:  "https://cert-manager.io/docs/releases/",
+			KubernetesCompatibility: map[string][]string{"v1.14": {"1.29", "1.30"}, "v1.15": {"1.29", "1.30", "1.31"}},
+		},
+		{
+			Name:                   "widget",
+			CompatibilityMatrixURL: "https://example.com/widget/README.md",
+		},
+	}
+	pages := &fetch.PageCache{
+		Fetch: func(ctx context.Context, pageURL string) (fetch.FetchedPage, error) {
+			if pageURL != "https://example.com/widget/README.md" {
+				return fetch.FetchedPage{}, fmt.Errorf("unexpected fetch of %s", pageURL)
+			}
+			return fetch.FetchedPage{Text: widgetPage, Raw: widgetPage, IsRaw: true}, nil
+		},
+	}
+	return New(addons, agent.Options{APIKey: "ignored", Pages: pages})
+}
+
+func TestServer_Check(t *testing.T) {
+	body := `{"k8s_version":"v1.31","addons":[
+		{"name":"cert-manager","version":"v1.14.2","namespace":"cert-manager"},
+		{"name":"widget","version":"2.1.0"},
+		{"name":"not-in-db","version":"0.1.0"}
+	]}`
+	rec := httptest.NewRecorder()
+	newTestServer(t).ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/v1/check", strings.NewReader(body)))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d, body = %s", rec.Code, rec.Body.String())
+	}
+
+	var report output.CompatibilityReport
+	if err := json.Unmarshal(rec.Body.Bytes(), &report); err != nil {
+		t.Fatalf("decoding report: %v", err)
+	}
+	if report.K8sVersion != "1.31" {
+		t.Errorf("k8s_version = %q, want 1.31", report.K8sVersion)
+	}
+	byName := make(map[string]output.AddonCompatibility, len(report.Addons))
+	for _, a := range report.Addons {
+		byName[a.Name] = a
+	}
+	tests := []struct {
+		name       string
+		wantStatus output.Status
+		wantSource string
+	}{
+		{name: "cert-manager", wantStatus: output.StatusFalse, wantSource: output.DataSourceStored},
+		{name: "widget", wantStatus: output.StatusTrue, wantSource: output.DataSourceExtracted},
+		{name: "not-in-db", wantStatus: output.StatusUnknown, wantSource: output.DataSourceLocal},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := byName[tt.name]
+			if !ok {
+				t.Fatalf("addon missing from report: %+v", report.Addons)
+			}
+			if got.Compatible != tt.wantStatus || got.DataSource != tt.wantSource {
+				t.Errorf("got %s/%s, want %s/%s (note: %s)", got.Compatible, got.DataSource, tt.wantStatus, tt.wantSource, got.Note)
+			}
+		})
+	}
+	if got := byName["cert-manager"].LatestCompatibleVersion; got != "v1.15" {
+		t.Errorf("latest_compatible_version = %q, want v1.15", got)
+	}
+}
+
+func TestServer_CheckRejectsBadRequests(t *testing.T) {
+	tooMany := make([]string, maxCheckAddons+1)
+	for i := range tooMany {
+		tooMany[i] = fmt.Sprintf(`{"name":"addon-%d"}`, i)
+	}
+	tests := []struct {
+		name     string
+		body     string
+		wantCode int
+		wantErr  string
+	}{
+		{name: "malformed JSON", body: `{"k8s_version":`, wantCode: http.StatusBadRequest, wantErr: "invalid request body"},
+		{name: "unknown field", body: `{"k8s_version":"1.31","addons":[{"name":"x"}],"model":"gemini"}`, wantCode: http.StatusBadRequest, wantErr: "unknown field"},
+		{name: "missing K8s version", body: `{"addons":[{"name":"x"}]}`, wantCode: http.StatusBadRequest, wantErr: "k8s_version"},
+		{name: "invalid K8s version", body: `{"k8s_version":"latest","addons":[{"name":"x"}]}`, wantCode: http.StatusBadRequest, wantErr: "k8s_version"},
+		{name: "no addons", body: `{"k8s_version":"1.31","addons":[]}`, wantCode: http.StatusBadRequest, wantErr: "at least one addon"},
+		{name: "blank addon name", body: `{"k8s_version":"1.31","addons":[{"name":" "}]}`, wantCode: http.StatusBadRequest, wantErr: "addons[0].name"},
+		{name: "too many addons", body: `{"k8s_version":"1.31","addons":[` + strings.Join(tooMany, ",") + `]}`, wantCode: http.StatusBadRequest, wantErr: "limit"},
+		{name: "body too large", body: `{"k8s_version":"1.31","addons":[{"name":"` + strings.Repeat("a", maxRequestBytes) + `"}]}`, wantCode: http.StatusRequestEntityTooLarge, wantErr: "too large"},
+	}
+	server := newTestServer(t)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			server.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/v1/check", strings.NewReader(tt.body)))
+			if rec.Code != tt.wantCode {
+				t.Fatalf("status = %d, want %d (body %s)", rec.Code, tt.wantCode, rec.Body.String())
+			}
+			var resp errorResponse
+			if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+				t.Fatalf("decoding error body: %v", err)
+			}
+			if !strings.Contains(resp.Error, tt.wantErr) {
+				t.Errorf("error = %q, want it to contain %q", resp.Error, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestServer_Addon(t *testing.T) {
+	tests := []struct {
+		name     string
+		path     string
+		wantCode int
+		wantName string
+	}{
+		{name: "exact name", path: "/v1/addons/cert-manager", wantCode: http.StatusOK, wantName: "cert-manager"},
+		{name: "case-insensitive", path: "/v1/addons/Cert-Manager", wantCode: http.StatusOK, wantName: "cert-manager"},
+		{name: "unknown addon", path: "/v1/addons/not-in-db", wantCode: http.StatusNotFound},
+		{name: "workload name is not fuzzy matched", path: "/v1/addons/cert-manager-webhook", wantCode: http.StatusNotFound},
+	}
+	server := newTestServer(t)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			server.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, tt.path, nil))
+			if rec.Code != tt.wantCode {
+				t.Fatalf("status = %d, want %d", rec.Code, tt.wantCode)
+			}
+			if tt.wantName == "" {
+				return
+			}
+			var got addon.Addon
+			if err := json.Unmarshal(rec.Body.Bytes(), &got); err != nil {
+				t.Fatalf("decoding addon: %v", err)
+			}
+			if got.Name != tt.wantName || len(got.KubernetesCompatibility) == 0 {
+				t.Errorf("unexpected addon %+v", got)
+			}
+		})
+	}
+}
+
+func TestServer_HealthzAndMethods(t *testing.T) {
+	server := newTestServer(t)
+
+	rec := httptest.NewRecorder()
+	server.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/v1/healthz", nil))
+	if rec.Code != http.StatusOK || !strings.Contains(rec.Body.String(), `"addons": 2`) {
+		t.Errorf("healthz = %d %s", rec.Code, rec.Body.String())
+	}
+
+	rec = httptest.NewRecorder()
+	server.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/v1/check", nil))
+	if rec.Code != http.StatusMethodNotAllowed {
+		t.Errorf("GET /v1/check status = %d, want 405", rec.Code)
+	}
+}
+
+func TestServer_Metrics(t *testing.T) {
+	server := New(nil, agent.Options{Tool: output.ToolInfo{Name: "kaddons", Version: "v1.4.0", Commit: "abc1234"}})
+	server.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/v1/healthz", nil))
+	server.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/v1/addons/nope", nil))
+	server.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/nope", nil))
+
+	rec := httptest.NewRecorder()
+	server.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d", rec.Code)
+	}
+	if got := rec.Header().Get("Content-Type"); !strings.HasPrefix(got, "text/plain; version=0.0.4") {
+		t.Errorf("Content-Type = %q", got)
+	}
+	for _, want := range []string{
+		`kaddons_build_info{version="v1.4.0",commit="abc1234"} 1`,
+		"kaddons_database_entries 0",
+		`kaddons_http_requests_total{route="GET /v1/healthz",code="200"} 1`,
+		`kaddons_http_requests_total{route="GET /v1/addons/{name}",code="404"} 1`,
+		`kaddons_http_requests_total{route="unmatched",code="404"} 1`,
+		`kaddons_server_verdicts_total{status="unknown"} 0`,
+		"# TYPE kaddons_fetch_failures_total counter",
+	} {
+		if !strings.Contains(rec.Body.String(), want) {
+			t.Errorf("metrics missing %q:\n%s", want, rec.Body.String())
+		}
+	}
+}
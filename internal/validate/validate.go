@@ -15,6 +15,7 @@ import (
 	"time"
 
 	"github.com/qbandev/kaddons/internal/addon"
+	"github.com/qbandev/kaddons/internal/extract"
 	"github.com/qbandev/kaddons/internal/fetch"
 	"github.com/qbandev/kaddons/internal/resilience"
 )
@@ -49,7 +50,8 @@ type consumer struct {
 
 type urlTask struct {
 	url          string
-	needsContent bool // true if any consumer uses this as compatibility_matrix_url
+	needsContent bool   // true if any consumer uses this as compatibility_matrix_url
+	sourceType   string // compatibility_source_type declared by the first matrix consumer, if any
 	consumers    []consumer
 }
 
@@ -90,6 +92,9 @@ func harvest(addons []addon.Addon) map[string]*urlTask {
 
 			if pair.field == "compatibility_matrix_url" {
 				t.needsContent = true
+				if t.sourceType == "" {
+					t.sourceType = a.CompatibilitySourceType
+				}
 			}
 		}
 	}
@@ -130,6 +135,15 @@ func ValidateStoredData(addons []addon.Addon) []StoredDataProblem {
 	var problems []StoredDataProblem
 
 	for _, a := range addons {
+		if a.CompatibilitySourceType != "" && !extract.IsStructuredSourceType(a.CompatibilitySourceType) {
+			problems = append(problems, StoredDataProblem{
+				AddonName: a.Name,
+				Field:     "compatibility_source_type",
+				Value:     a.CompatibilitySourceType,
+				Reason:    "must be one of yaml, json, helm-chart",
+			})
+		}
+
 		if a.KubernetesMinVersion != "" && !k8sVersionFormat.MatchString(a.KubernetesMinVersion) {
 			problems = append(problems, StoredDataProblem{
 				AddonName: a.Name,
@@ -204,11 +218,50 @@ func ValidateStoredData(addons []addon.Addon) []StoredDataProblem {
 				Reason:    "matrix must contain at least one key format supported by stored resolver",
 			})
 		}
+
+		problems = append(problems, validateProvenance(a)...)
 	}
 
 	return problems
 }
 
+// validateProvenance checks the compatibility_provenance block written by
+// kaddons-extract --sync, if present.
+func validateProvenance(a addon.Addon) []StoredDataProblem {
+	p := a.CompatibilityProvenance
+	if p == nil {
+		return nil
+	}
+	var problems []StoredDataProblem
+	if p.SourceURL == "" {
+		problems = append(problems, StoredDataProblem{
+			AddonName: a.Name,
+			Field:     "compatibility_provenance.source_url",
+			Value:     "(empty)",
+			Reason:    "provenance must name the page the data was extracted from",
+		})
+	}
+	switch p.Confidence {
+	case addon.ConfidenceHigh, addon.ConfidenceMedium, addon.ConfidenceLow:
+	default:
+		problems = append(problems, StoredDataProblem{
+			AddonName: a.Name,
+			Field:     "compatibility_provenance.confidence",
+			Value:     p.Confidence,
+			Reason:    "must be one of high, medium, low",
+		})
+	}
+	if _, err := time.Parse(time.RFC3339, p.ExtractedAt); err != nil {
+		problems = append(problems, StoredDataProblem{
+			AddonName: a.Name,
+			Field:     "compatibility_provenance.extracted_at",
+			Value:     p.ExtractedAt,
+			Reason:    "must be an RFC 3339 timestamp",
+		})
+	}
+	return problems
+}
+
 func isResolverSupportedCompatibilityKey(rawKey string) bool {
 	normalizedKey := strings.ToLower(strings.TrimSpace(rawKey))
 	if normalizedKey == "" {
@@ -407,6 +460,9 @@ func Run(linksOnly, matrixOnly, storedOnly bool) error {
 
 // executeTask processes a single URL task.
 func executeTask(ctx context.Context, client *http.Client, task *urlTask, githubToken string) *urlResult {
+	if task.needsContent && extract.IsStructuredSourceType(task.sourceType) {
+		return executeStructuredTask(ctx, client, task)
+	}
 	if task.needsContent {
 		// Auth token not passed here: the --links workflow sets needsContent=false
 		// for all tasks, so this path is never hit during linkcheck runs.
@@ -427,6 +483,25 @@ func executeTask(ctx context.Context, client *http.Client, task *urlTask, github
 	return &urlResult{reachable: true}
 }
 
+// executeStructuredTask classifies a machine-readable compatibility source by
+// parsing it, since YAML/JSON rarely contain the prose keywords ClassifyK8sMatrix
+// looks for. Unparseable documents fall back to keyword classification.
+func executeStructuredTask(ctx context.Context, client *http.Client, task *urlTask) *urlResult {
+	page, err := fetch.CompatibilityPageFullWithClient(ctx, client, task.url)
+	if err != nil {
+		return &urlResult{reachable: false, reachError: fmt.Sprintf("error: %v", err)}
+	}
+	structured, err := extract.ExtractStructured(task.sourceType, page.Raw)
+	if err == nil && structured != nil {
+		tier := matrixTierPartial
+		if len(structured.Matrix) > 0 {
+			tier = matrixTierStrict
+		}
+		return &urlResult{reachable: true, matrixTier: tier}
+	}
+	return &urlResult{reachable: true, matrixTier: ClassifyK8sMatrix(page.Text)}
+}
+
 // checkURL performs an HTTP HEAD request with GET fallback on 405/403.
 func checkURL(ctx context.Context, client *http.Client, rawURL string, githubToken string) string {
 	if err := fetch.ValidatePublicHTTPSURL(rawURL); err != nil {
@@ -483,12 +558,20 @@ func checkURL(ctx context.Context, client *http.Client, rawURL string, githubTok
 	return "ok"
 }
 
+// hostBreaker is shared by every check in a run, so a host that is down (or
+// rate limiting hundreds of GitHub URLs) fails fast instead of costing three
+// attempts per URL.
+var hostBreaker = resilience.NewCircuitBreaker(5, 30*time.Second)
+
 func doRequestWithRetry(ctx context.Context, client *http.Client, request *http.Request) (*http.Response, error) {
 	policy := resilience.RetryPolicy{
 		Attempts:     3,
 		InitialDelay: 500 * time.Millisecond,
 		MaxDelay:     time.Second,
 		Multiplier:   2,
+		Jitter:       0.2,
+		MaxElapsed:   20 * time.Second,
+		Breaker:      hostBreaker,
 	}
 	return resilience.DoHTTPRequestWithRetry(ctx, client, request, policy)
 }
@@ -533,7 +616,7 @@ func classifyError(reachError string) string {
 
 	lower := strings.ToLower(reachError)
 
-	for _, keyword := range []string{"timeout", "deadline exceeded", "connection refused", "connection reset"} {
+	for _, keyword := range []string{"timeout", "deadline exceeded", "connection refused", "connection reset", "circuit open"} {
 		if strings.Contains(lower, keyword) {
 			return "transient"
 		}
@@ -381,6 +381,22 @@ func TestReporting_MissingResultHandledAsFailure(t *testing.T) {
 	}
 }
 
+func TestHarvest_StructuredSourceType(t *testing.T) {
+	addons := []addon.Addon{
+		{Name: "addon-a", Repository: "https://example.com/Chart.yaml"},
+		{Name: "addon-b", CompatibilityMatrixURL: "https://example.com/Chart.yaml", CompatibilitySourceType: "helm-chart"},
+	}
+
+	tasks := harvest(addons)
+	task := tasks["https://example.com/Chart.yaml"]
+	if task == nil {
+		t.Fatal("expected task for shared URL")
+	}
+	if task.sourceType != "helm-chart" {
+		t.Errorf("expected sourceType=helm-chart from the matrix consumer, got %q", task.sourceType)
+	}
+}
+
 func TestLinksOnlyFlag(t *testing.T) {
 	addons := []addon.Addon{
 		{Name: "addon-a", CompatibilityMatrixURL: "https://example.com/matrix"},
@@ -768,6 +784,7 @@ func TestClassifyError(t *testing.T) {
 		{"timeout error", "error: context deadline exceeded", "transient"},
 		{"connection refused", "error: dial tcp: connection refused", "transient"},
 		{"connection reset", "error: read: connection reset by peer", "transient"},
+		{"circuit open", "error: circuit open for host github.com", "transient"},
 		{"HTTP 404", "HTTP 404", "permanent"},
 		{"HTTP 403", "HTTP 403", "permanent"},
 		{"DNS error", "error: no such host", "permanent"},
@@ -826,3 +843,66 @@ func TestReport_AllTransient(t *testing.T) {
 		t.Fatalf("expected ErrValidationFailed, got %v", err)
 	}
 }
+
+func TestValidateStoredData_CompatibilitySourceType(t *testing.T) {
+	tests := []struct {
+		sourceType   string
+		wantProblems int
+	}{
+		{"", 0},
+		{"yaml", 0},
+		{"json", 0},
+		{"helm-chart", 0},
+		{"toml", 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.sourceType, func(t *testing.T) {
+			problems := ValidateStoredData([]addon.Addon{{Name: "addon", CompatibilitySourceType: tt.sourceType}})
+			if len(problems) != tt.wantProblems {
+				t.Fatalf("expected %d problems, got %d: %+v", tt.wantProblems, len(problems), problems)
+			}
+			if tt.wantProblems > 0 && problems[0].Field != "compatibility_source_type" {
+				t.Errorf("expected field compatibility_source_type, got %q", problems[0].Field)
+			}
+		})
+	}
+}
+
+func TestValidateStoredData_Provenance(t *testing.T) {
+	valid := addon.Provenance{
+		SourceURL:   "https://example.com/compat",
+		Strategy:    "labeled-column",
+		ExtractedAt: "2026-10-18T00:00:00Z",
+		Confidence:  "high",
+	}
+	tests := []struct {
+		name      string
+		mutate    func(p *addon.Provenance)
+		wantField string
+	}{
+		{"valid", func(p *addon.Provenance) {}, ""},
+		{"missing source url", func(p *addon.Provenance) { p.SourceURL = "" }, "compatibility_provenance.source_url"},
+		{"unknown confidence", func(p *addon.Provenance) { p.Confidence = "certain" }, "compatibility_provenance.confidence"},
+		{"bad timestamp", func(p *addon.Provenance) { p.ExtractedAt = "yesterday" }, "compatibility_provenance.extracted_at"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			provenance := valid
+			tt.mutate(&provenance)
+			problems := ValidateStoredData([]addon.Addon{{
+				Name:                    "addon",
+				KubernetesMinVersion:    "1.28",
+				CompatibilityProvenance: &provenance,
+			}})
+			if tt.wantField == "" {
+				if len(problems) != 0 {
+					t.Fatalf("expected no problems, got %+v", problems)
+				}
+				return
+			}
+			if len(problems) != 1 || problems[0].Field != tt.wantField {
+				t.Fatalf("expected one %s problem, got %+v", tt.wantField, problems)
+			}
+		})
+	}
+}